@@ -0,0 +1,63 @@
+/*
+Copyright (c) 2020 Loadsmart, Inc.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package coveralls
+
+import (
+	"context"
+)
+
+// TokenProvider provides the API token used to authenticate requests.
+//
+// Token is called once per request, so implementations can fetch tokens
+// from a secret store and rotate them without recreating the Client.
+// Implementations must be safe for concurrent use.
+type TokenProvider interface {
+	Token(ctx context.Context) (string, error)
+}
+
+// StaticToken is a TokenProvider that always returns the same token
+type StaticToken string
+
+// Token returns the token itself
+func (t StaticToken) Token(context.Context) (string, error) {
+	return string(t), nil
+}
+
+// TokenProviderFunc adapts a function to TokenProvider
+type TokenProviderFunc func(ctx context.Context) (string, error)
+
+// Token calls f(ctx)
+func (f TokenProviderFunc) Token(ctx context.Context) (string, error) {
+	return f(ctx)
+}
+
+// WithTokenProvider makes the Client ask p for the API token before each
+// request, ignoring the token passed to NewClient. If p returns an error,
+// the request is not sent and the error is returned by the service method.
+//
+// Calls made with WithRepoToken do not ask p for a token.
+func WithTokenProvider(p TokenProvider) Option {
+	return func(o *options) {
+		o.tokenProvider = p
+	}
+}
@@ -0,0 +1,123 @@
+/*
+Copyright (c) 2020 Loadsmart, Inc.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package coveralls
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithTokenProvider(t *testing.T) {
+	fakeUrl := "https://coveralls.io/api/repos/github/user/fakerepo"
+	var received []string
+	httpmock.RegisterResponder("GET", fakeUrl, func(req *http.Request) (*http.Response, error) {
+		received = append(received, req.Header.Get("Authorization"))
+		return httpmock.NewJsonResponse(http.StatusOK, &Repository{ID: 123})
+	})
+
+	calls := 0
+	provider := TokenProviderFunc(func(ctx context.Context) (string, error) {
+		calls++
+		return fmt.Sprintf("rotated-%d", calls), nil
+	})
+	client := NewClient("ignored token", WithTokenProvider(provider))
+	httpmock.ActivateNonDefault(client.client)
+	defer httpmock.DeactivateAndReset()
+
+	for i := 0; i < 2; i++ {
+		_, err := client.Repositories.Get(context.Background(), "github", "user/fakerepo")
+		assert.Nil(t, err)
+	}
+
+	assert.Equal(t, []string{"token rotated-1", "token rotated-2"}, received)
+}
+
+func TestWithTokenProviderError(t *testing.T) {
+	fakeUrl := "https://coveralls.io/api/repos/github/user/fakerepo"
+	httpmock.RegisterResponder("GET", fakeUrl, httpmock.NewStringResponder(http.StatusOK, "{}"))
+
+	errVault := errors.New("vault is sealed")
+	provider := TokenProviderFunc(func(ctx context.Context) (string, error) {
+		return "", errVault
+	})
+	client := NewClient("ignored token", WithTokenProvider(provider))
+	httpmock.ActivateNonDefault(client.client)
+	defer httpmock.DeactivateAndReset()
+
+	repo, err := client.Repositories.Get(context.Background(), "github", "user/fakerepo")
+
+	assert.True(t, errors.Is(err, errVault))
+	assert.Nil(t, repo)
+	assert.Equal(t, 0, httpmock.GetTotalCallCount())
+}
+
+func TestWithTokenProviderRedactsToken(t *testing.T) {
+	fakeUrl := "https://coveralls.io/api/repos/github/user/fakerepo"
+	httpmock.RegisterResponder("GET", fakeUrl, httpmock.NewStringResponder(http.StatusBadRequest, `{"error": "bad token provided-secret"}`))
+
+	provider := TokenProviderFunc(func(ctx context.Context) (string, error) {
+		return "provided-secret", nil
+	})
+	client := NewClient("ignored token", WithTokenProvider(provider))
+	httpmock.ActivateNonDefault(client.client)
+	defer httpmock.DeactivateAndReset()
+
+	_, err := client.Repositories.Get(context.Background(), "github", "user/fakerepo")
+
+	var apiErr *APIError
+	assert.True(t, errors.As(err, &apiErr))
+	assert.NotContains(t, apiErr.Body, "provided-secret")
+	assert.NotContains(t, err.Error(), "provided-secret")
+}
+
+func TestStaticToken(t *testing.T) {
+	token, err := StaticToken("my-token").Token(context.Background())
+
+	assert.Nil(t, err)
+	assert.Equal(t, "my-token", token)
+}
+
+func TestWithTokenProviderAndRepoToken(t *testing.T) {
+	fakeUrl := "https://coveralls.io/api/repos/github/user/fakerepo"
+	httpmock.RegisterResponder("GET", fakeUrl, func(req *http.Request) (*http.Response, error) {
+		assert.Equal(t, "token repo token", req.Header.Get("Authorization"))
+		return httpmock.NewJsonResponse(http.StatusOK, &Repository{ID: 123})
+	})
+
+	provider := TokenProviderFunc(func(ctx context.Context) (string, error) {
+		return "", errors.New("should not be called")
+	})
+	client := NewClient("ignored token", WithTokenProvider(provider))
+	httpmock.ActivateNonDefault(client.client)
+	defer httpmock.DeactivateAndReset()
+
+	_, err := client.Repositories.Get(context.Background(), "github", "user/fakerepo", WithRepoToken("repo token"))
+
+	assert.Nil(t, err)
+}
@@ -0,0 +1,117 @@
+/*
+Copyright (c) 2020 Loadsmart, Inc.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package coveralls
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/url"
+)
+
+// BadgesService holds information to build coverage badges
+type BadgesService interface {
+	URL(svc string, repo string, branch string) string
+	Markdown(svc string, repo string, branch string) string
+	Shield(ctx context.Context, svc string, repo string, branch string, opts ...CallOption) (*Shield, error)
+}
+
+// BadgesServiceImpl holds information to build coverage badges
+type BadgesServiceImpl service
+
+// Shield is the JSON document expected by shields.io endpoint badges.
+// See https://shields.io/endpoint for details.
+type Shield struct {
+	SchemaVersion int    `json:"schemaVersion"`
+	Label         string `json:"label"`
+	Message       string `json:"message"`
+	Color         string `json:"color"`
+}
+
+// URL returns the address of the badge SVG image of a repository.
+//
+// Branch may be empty to use the default branch of the repository.
+func (s BadgesServiceImpl) URL(svc string, repo string, branch string) string {
+	return fmt.Sprintf("%s/repos/%s/%s/badge.svg%s", s.client.hostURL(), svc, repo, branchQuery(branch))
+}
+
+// Markdown returns a markdown snippet of the badge linking to the repository page in Coveralls
+func (s BadgesServiceImpl) Markdown(svc string, repo string, branch string) string {
+	link := fmt.Sprintf("%s/%s/%s%s", s.client.hostURL(), svc, repo, branchQuery(branch))
+	return fmt.Sprintf("[![Coverage Status](%s)](%s)", s.URL(svc, repo, branch), link)
+}
+
+// Shield returns the shields.io-compatible badge for the latest build of a branch.
+//
+// Branch may be empty to use the latest build of any branch.
+//
+// It may return errors ErrRepoNotFound, ErrBuildNotFound (when the branch
+// has no builds) or ErrUnexpectedStatusCode
+func (s BadgesServiceImpl) Shield(ctx context.Context, svc string, repo string, branch string, opts ...CallOption) (*Shield, error) {
+	build, err := s.client.Builds.LatestForBranch(ctx, svc, repo, branch, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	if build.CoveredPercent == nil {
+		return nil, ErrBuildNotFound
+	}
+	return newShield(*build.CoveredPercent), nil
+}
+
+func newShield(coverage float64) *Shield {
+	// Round once so the color matches the percentage in the message
+	coverage = math.Round(coverage)
+	return &Shield{
+		SchemaVersion: 1,
+		Label:         "coverage",
+		Message:       fmt.Sprintf("%.0f%%", coverage),
+		Color:         shieldColor(coverage),
+	}
+}
+
+// shieldColor returns the badge color for a coverage percentage, using the
+// same ranges as the badges served by Coveralls
+func shieldColor(coverage float64) string {
+	switch {
+	case coverage >= 90:
+		return "brightgreen"
+	case coverage >= 80:
+		return "green"
+	case coverage >= 70:
+		return "yellowgreen"
+	case coverage >= 60:
+		return "yellow"
+	case coverage >= 50:
+		return "orange"
+	default:
+		return "red"
+	}
+}
+
+func branchQuery(branch string) string {
+	if branch == "" {
+		return ""
+	}
+	return "?branch=" + url.QueryEscape(branch)
+}
@@ -0,0 +1,86 @@
+/*
+Copyright (c) 2020 Loadsmart, Inc.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package coveralls
+
+import (
+	"bytes"
+	"errors"
+)
+
+// Markers around the badge in a README, as written by InjectBadge
+const (
+	BadgeStartMarker = "<!-- coveralls-badge -->"
+	BadgeEndMarker   = "<!-- /coveralls-badge -->"
+)
+
+// ErrBadgeMarkers is returned by InjectBadge when a README has a start
+// marker without an end marker after it, or the other way around
+var ErrBadgeMarkers = errors.New("unbalanced coveralls-badge markers")
+
+// InjectBadge returns readme with badge, e.g. returned by
+// BadgesService.Markdown, between the markers BadgeStartMarker and
+// BadgeEndMarker, replacing whatever was there. If readme has no markers,
+// they are added with the badge after its title, or at the top if it
+// doesn't start with one. Running it again with the same badge changes
+// nothing, so callers can compare its result with readme.
+func InjectBadge(readme []byte, badge string) ([]byte, error) {
+	block := []byte(BadgeStartMarker + "\n" + badge + "\n" + BadgeEndMarker)
+
+	start := bytes.Index(readme, []byte(BadgeStartMarker))
+	end := bytes.Index(readme, []byte(BadgeEndMarker))
+	switch {
+	case start >= 0 && end > start:
+		var b bytes.Buffer
+		b.Write(readme[:start])
+		b.Write(block)
+		b.Write(readme[end+len(BadgeEndMarker):])
+		return b.Bytes(), nil
+	case start >= 0 || end >= 0:
+		return nil, ErrBadgeMarkers
+	}
+
+	var b bytes.Buffer
+	if bytes.HasPrefix(readme, []byte("# ")) {
+		title := readme
+		rest := []byte(nil)
+		if i := bytes.IndexByte(readme, '\n'); i >= 0 {
+			title, rest = readme[:i], readme[i+1:]
+		}
+		b.Write(title)
+		b.WriteString("\n\n")
+		b.Write(block)
+		b.WriteString("\n")
+		if len(rest) > 0 && !bytes.HasPrefix(rest, []byte("\n")) {
+			b.WriteString("\n")
+		}
+		b.Write(rest)
+		return b.Bytes(), nil
+	}
+	b.Write(block)
+	b.WriteString("\n")
+	if len(readme) > 0 {
+		b.WriteString("\n")
+	}
+	b.Write(readme)
+	return b.Bytes(), nil
+}
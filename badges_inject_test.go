@@ -0,0 +1,104 @@
+/*
+Copyright (c) 2020 Loadsmart, Inc.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package coveralls
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInjectBadge(t *testing.T) {
+	badge := "[![Coverage Status](badge.svg)](page)"
+	block := BadgeStartMarker + "\n" + badge + "\n" + BadgeEndMarker
+
+	var testCases = []struct {
+		name   string
+		readme string
+		want   string
+	}{
+		{
+			name:   "after title",
+			readme: "# Project\n\nSome text.\n",
+			want:   "# Project\n\n" + block + "\n\nSome text.\n",
+		},
+		{
+			name:   "after title without blank line",
+			readme: "# Project\nSome text.\n",
+			want:   "# Project\n\n" + block + "\n\nSome text.\n",
+		},
+		{
+			name:   "title only",
+			readme: "# Project",
+			want:   "# Project\n\n" + block + "\n",
+		},
+		{
+			name:   "no title",
+			readme: "Some text.\n",
+			want:   block + "\n\nSome text.\n",
+		},
+		{
+			name:   "empty",
+			readme: "",
+			want:   block + "\n",
+		},
+		{
+			name:   "update",
+			readme: "# Project\n\n" + BadgeStartMarker + "\n[![Coverage Status](old.svg)](page)\n" + BadgeEndMarker + "\n\nSome text.\n",
+			want:   "# Project\n\n" + block + "\n\nSome text.\n",
+		},
+		{
+			name:   "update inline",
+			readme: "Badges: " + BadgeStartMarker + "old" + BadgeEndMarker + " [![CI](ci.svg)](ci)\n",
+			want:   "Badges: " + block + " [![CI](ci.svg)](ci)\n",
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := InjectBadge([]byte(tt.readme), badge)
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, string(got))
+
+			again, err := InjectBadge(got, badge)
+			require.NoError(t, err)
+			assert.Equal(t, string(got), string(again), "injecting again changes nothing")
+		})
+	}
+}
+
+func TestInjectBadgeUnbalancedMarkers(t *testing.T) {
+	for _, readme := range []string{
+		"# Project\n" + BadgeStartMarker + "\n",
+		"# Project\n" + BadgeEndMarker + "\n",
+		BadgeEndMarker + "\n" + BadgeStartMarker + "\n",
+	} {
+		got, err := InjectBadge([]byte(readme), "badge")
+
+		assert.Nil(t, got)
+		assert.True(t, errors.Is(err, ErrBadgeMarkers), readme)
+	}
+}
@@ -0,0 +1,117 @@
+/*
+Copyright (c) 2020 Loadsmart, Inc.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package coveralls
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBadgesServiceURL(t *testing.T) {
+	client := NewClient("fake token")
+
+	assert.Equal(t, "https://coveralls.io/repos/github/user/fakerepo/badge.svg?branch=release%2F1.0", client.Badges.URL("github", "user/fakerepo", "release/1.0"))
+	assert.Equal(t, "https://coveralls.io/repos/github/user/fakerepo/badge.svg", client.Badges.URL("github", "user/fakerepo", ""))
+}
+
+func TestBadgesServiceMarkdown(t *testing.T) {
+	client := NewClient("fake token")
+
+	assert.Equal(t,
+		"[![Coverage Status](https://coveralls.io/repos/github/user/fakerepo/badge.svg?branch=master)](https://coveralls.io/github/user/fakerepo?branch=master)",
+		client.Badges.Markdown("github", "user/fakerepo", "master"))
+}
+
+func TestBadgesServiceShield(t *testing.T) {
+	var testCases = []struct {
+		name   string
+		code   int
+		page   *buildPage
+		shield *Shield
+		err    error
+	}{
+		{
+			name:   "existing",
+			code:   http.StatusOK,
+			page:   &buildPage{Page: 1, Pages: 1, Total: 1, Builds: []*Build{{CoveredPercent: pfloat64(83.4)}}},
+			shield: &Shield{SchemaVersion: 1, Label: "coverage", Message: "83%", Color: "green"},
+			err:    nil,
+		},
+		{
+			name:   "nobuilds",
+			code:   http.StatusOK,
+			page:   &buildPage{Page: 1},
+			shield: nil,
+			err:    ErrBuildNotFound,
+		},
+		{
+			name:   "notfound",
+			code:   http.StatusNotFound,
+			page:   nil,
+			shield: nil,
+			err:    ErrRepoNotFound,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			fakeUrl := "https://coveralls.io/github/user/fakerepo.json"
+			httpmock.RegisterResponder("GET", fakeUrl, func(req *http.Request) (*http.Response, error) {
+				assert.Equal(t, "master", req.URL.Query().Get("branch"))
+				return httpmock.NewJsonResponse(tt.code, tt.page)
+			})
+
+			client := NewClient("fake token")
+			httpmock.ActivateNonDefault(client.client)
+			defer httpmock.DeactivateAndReset()
+
+			result, err := client.Badges.Shield(context.Background(), "github", "user/fakerepo", "master")
+
+			if !errors.Is(err, tt.err) {
+				t.Errorf("Errors do not match.\n\texpected: '%v'\n\tgot: '%v'", tt.err, err)
+			}
+			assert.Equal(t, tt.shield, result)
+		})
+	}
+}
+
+func TestShieldColor(t *testing.T) {
+	assert.Equal(t, "brightgreen", shieldColor(100))
+	assert.Equal(t, "yellowgreen", shieldColor(70))
+	assert.Equal(t, "red", shieldColor(12.5))
+}
+
+func TestNewShieldRounding(t *testing.T) {
+	shield := newShield(79.6)
+	assert.Equal(t, "80%", shield.Message)
+	assert.Equal(t, "green", shield.Color)
+
+	shield = newShield(79.4)
+	assert.Equal(t, "79%", shield.Message)
+	assert.Equal(t, "yellowgreen", shield.Color)
+}
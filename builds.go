@@ -0,0 +1,367 @@
+/*
+Copyright (c) 2020 Loadsmart, Inc.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package coveralls
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrBuildNotFound is returned when we receive a 404 Not Found status code while
+	// querying a build, or when the build found does not belong to the given repo
+	ErrBuildNotFound = fmt.Errorf("build was not found (status code %d)", http.StatusNotFound)
+)
+
+// BuildsService holds information to access build-related endpoints
+type BuildsService interface {
+	Get(ctx context.Context, svc string, repo string, sha string, opts ...CallOption) (*Build, error)
+	Close(ctx context.Context, repoToken string, buildNum string, opts ...CallOption) error
+	List(ctx context.Context, svc string, repo string, opts *BuildListOptions, callOpts ...CallOption) ([]*Build, error)
+	Rerun(ctx context.Context, svc string, repo string, sha string, opts ...CallOption) error
+	LatestForBranch(ctx context.Context, svc string, repo string, branch string, opts ...CallOption) (*Build, error)
+	ForPullRequest(ctx context.Context, svc string, repo string, prNumber int, opts ...CallOption) (*Build, error)
+	Trend(ctx context.Context, svc string, repo string, from time.Time, to time.Time, opts ...CallOption) ([]*CoveragePoint, error)
+	Compare(ctx context.Context, svc string, repo string, baseSHA string, headSHA string, paths []string, opts ...CallOption) (*BuildComparison, error)
+	Wait(ctx context.Context, svc string, repo string, sha string, opts *WaitOptions, callOpts ...CallOption) (*Build, error)
+}
+
+// BuildsServiceImpl holds information to access build-related endpoints
+type BuildsServiceImpl service
+
+// Build holds information about one specific build
+type Build struct {
+	CreatedAt      string   `json:"created_at,omitempty"`
+	URL            string   `json:"url,omitempty"`
+	CommitMessage  string   `json:"commit_message,omitempty"`
+	Branch         string   `json:"branch,omitempty"`
+	CommitterName  string   `json:"committer_name,omitempty"`
+	CommitterEmail string   `json:"committer_email,omitempty"`
+	CommitSHA      string   `json:"commit_sha,omitempty"`
+	RepoName       string   `json:"repo_name,omitempty"`
+	PullRequest    int      `json:"pull_request,omitempty"` // Number of the pull request built, if any
+	BadgeURL       string   `json:"badge_url,omitempty"`
+	CoverageChange *float64 `json:"coverage_change,omitempty"` // Change in coverage relative to the previous build, in percentage points
+	CoveredPercent *float64 `json:"covered_percent,omitempty"` // Coverage of this build, between 0 and 100
+}
+
+// CoveragePoint is the coverage of a repository at some point in time
+type CoveragePoint struct {
+	Time           time.Time
+	CoveredPercent float64
+	CommitSHA      string
+	Branch         string
+}
+
+// BuildListOptions specifies the options of BuildsService.List
+type BuildListOptions struct {
+	ListOptions
+	Branch      string // Only list builds of this branch. Empty means all branches
+	PullRequest int    // Only list builds of this pull request. Zero means builds of any pull request or none
+	Limit       int    // Maximum number of builds returned. Zero means no limit
+}
+
+// buildPage is one page of results returned when listing builds of a repository
+type buildPage struct {
+	Page   int      `json:"page"`
+	Pages  int      `json:"pages"`
+	Total  int      `json:"total"`
+	Builds []*Build `json:"builds"`
+}
+
+// Get information about a build of the given commit.
+//
+// Svc and repo have the same meaning as in RepositoryService.Get. Sha is
+// the full commit SHA the build was made for.
+//
+// Coveralls looks builds up by commit SHA only, so the same commit may be
+// found in a fork. When the build found belongs to another repository we
+// treat it as not found.
+//
+// It may return errors ErrBuildNotFound or ErrUnexpectedStatusCode
+func (s BuildsServiceImpl) Get(ctx context.Context, svc string, repo string, sha string, opts ...CallOption) (*Build, error) {
+	endpoint := fmt.Sprintf("/builds/%s.json", sha)
+
+	resp, err := s.client.newRequest(ctx, opts).
+		SetResult(&Build{}).
+		Get(endpoint)
+
+	if err != nil {
+		return nil, err
+	}
+
+	switch resp.StatusCode() {
+	case http.StatusOK:
+		build, ok := resp.Result().(*Build)
+		if !ok {
+			return nil, resp.decodeFailure(errNoResult)
+		}
+		if build.RepoName != "" && build.RepoName != repo {
+			return nil, resp.apiError(ErrBuildNotFound)
+		}
+		return build, nil
+	case http.StatusNotFound:
+		return nil, resp.apiError(ErrBuildNotFound)
+	default:
+		return nil, newErrFromResponse(resp)
+	}
+}
+
+// webhookBody is the body expected by the parallel build webhook
+type webhookBody struct {
+	Carryforward string          `json:"carryforward,omitempty"` // Comma-separated flags
+	Payload      *webhookPayload `json:"payload"`
+}
+
+// webhookPayload identifies the build closed by the parallel build webhook
+type webhookPayload struct {
+	BuildNum string `json:"build_num"`
+	Status   string `json:"status"`
+}
+
+// Close marks a parallel build as done, so Coveralls can merge the coverage of
+// all jobs sent with Job.Parallel set to true.
+//
+// RepoToken is the secret token of the repository (not your personal access
+// token). BuildNum is the build number in the CI service, the same sent as
+// Job.ServiceNumber. See Carryforward to carry forward the coverage of flags
+// with no job in the build.
+//
+// It may return errors ErrBuildNotFound, ErrUnprocessableEntity or ErrUnexpectedStatusCode
+func (s BuildsServiceImpl) Close(ctx context.Context, repoToken string, buildNum string, opts ...CallOption) error {
+	endpoint := "/webhook"
+
+	body := &webhookBody{
+		Carryforward: strings.Join(newCallSettings(ctx, opts).carryforward, ","),
+		Payload:      &webhookPayload{BuildNum: buildNum, Status: "done"},
+	}
+
+	resp, err := s.client.newRequest(ctx, opts).
+		SetQueryParam("repo_token", repoToken).
+		SetBody(body).
+		Post(endpoint)
+
+	if err != nil {
+		return err
+	}
+
+	switch resp.StatusCode() {
+	case http.StatusOK:
+		return nil
+	case http.StatusNotFound:
+		return resp.apiError(ErrBuildNotFound)
+	case http.StatusUnprocessableEntity:
+		return resp.apiError(newErrUnprocessableEntity(resp.errorBody()))
+	default:
+		return newErrFromResponse(resp)
+	}
+}
+
+// List the builds of a repository, most recent first.
+//
+// Results are paginated by the API; List walks through the pages until
+// opts.Limit builds are found or there are no more pages. Opts may be nil
+// to list all builds of all branches.
+//
+// It may return errors ErrRepoNotFound or ErrUnexpectedStatusCode
+func (s BuildsServiceImpl) List(ctx context.Context, svc string, repo string, opts *BuildListOptions, callOpts ...CallOption) ([]*Build, error) {
+	if opts == nil {
+		opts = &BuildListOptions{}
+	}
+
+	var builds []*Build
+	err := s.walk(ctx, svc, repo, opts, callOpts, func(b *Build) bool {
+		builds = append(builds, b)
+		return opts.Limit <= 0 || len(builds) < opts.Limit
+	})
+	if err != nil {
+		return nil, err
+	}
+	return builds, nil
+}
+
+// walk calls fn for each build of a repository, most recent first, fetching
+// pages as needed. It stops when fn returns false or there are no more builds.
+func (s BuildsServiceImpl) walk(ctx context.Context, svc string, repo string, opts *BuildListOptions, callOpts []CallOption, fn func(*Build) bool) error {
+	for page := 1; ; page++ {
+		_, result, err := s.page(ctx, svc, repo, opts, page, callOpts)
+		if err != nil {
+			return err
+		}
+		for _, b := range result.Builds {
+			if !fn(b) {
+				return nil
+			}
+		}
+		if len(result.Builds) == 0 || page >= result.Pages {
+			return nil
+		}
+	}
+}
+
+// page fetches one page of the builds of a repository
+func (s BuildsServiceImpl) page(ctx context.Context, svc string, repo string, opts *BuildListOptions, page int, callOpts []CallOption) (*response, *buildPage, error) {
+	endpoint := fmt.Sprintf("/%s/%s.json", svc, repo)
+
+	params := opts.queryParams(page)
+	if opts.Branch != "" {
+		params["branch"] = opts.Branch
+	}
+	if opts.PullRequest > 0 {
+		params["pull_request"] = strconv.Itoa(opts.PullRequest)
+	}
+
+	resp, err := s.client.newRequest(ctx, callOpts).
+		SetQueryParams(params).
+		SetResult(&buildPage{}).
+		Get(endpoint)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	switch resp.StatusCode() {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return nil, nil, resp.apiError(ErrRepoNotFound)
+	default:
+		return nil, nil, newErrFromResponse(resp)
+	}
+
+	result, ok := resp.Result().(*buildPage)
+	if !ok {
+		return nil, nil, resp.decodeFailure(errNoResult)
+	}
+	return resp, result, nil
+}
+
+// latest returns the most recent build matching opts, or an *APIError
+// wrapping ErrBuildNotFound when there is none
+func (s BuildsServiceImpl) latest(ctx context.Context, svc string, repo string, opts *BuildListOptions, callOpts []CallOption) (*Build, error) {
+	resp, result, err := s.page(ctx, svc, repo, opts, 1, callOpts)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(result.Builds) == 0 {
+		return nil, resp.apiError(ErrBuildNotFound)
+	}
+	return result.Builds[0], nil
+}
+
+// Rerun asks Coveralls to calculate again the coverage of the build of the
+// given commit. This is the same as the "rerun" button in the build page.
+//
+// The calculation happens asynchronously; poll Get to find out the new coverage.
+//
+// It may return errors ErrBuildNotFound or ErrUnexpectedStatusCode
+func (s BuildsServiceImpl) Rerun(ctx context.Context, svc string, repo string, sha string, opts ...CallOption) error {
+	endpoint := fmt.Sprintf("/builds/%s/rerun", sha)
+
+	resp, err := s.client.newRequest(ctx, opts).
+		SetQueryParams(map[string]string{
+			"service":   svc,
+			"repo_name": repo,
+		}).
+		Post(endpoint)
+
+	if err != nil {
+		return err
+	}
+
+	switch resp.StatusCode() {
+	case http.StatusOK, http.StatusAccepted:
+		return nil
+	case http.StatusNotFound:
+		return resp.apiError(ErrBuildNotFound)
+	default:
+		return newErrFromResponse(resp)
+	}
+}
+
+// LatestForBranch returns the most recent build of a branch.
+//
+// Branch may be empty to get the most recent build of any branch.
+//
+// It may return errors ErrRepoNotFound, ErrBuildNotFound (when the branch
+// has no builds) or ErrUnexpectedStatusCode
+func (s BuildsServiceImpl) LatestForBranch(ctx context.Context, svc string, repo string, branch string, opts ...CallOption) (*Build, error) {
+	return s.latest(ctx, svc, repo, &BuildListOptions{ListOptions: ListOptions{PerPage: 1}, Branch: branch}, opts)
+}
+
+// ForPullRequest returns the most recent build of a pull request. Its
+// CoveredPercent and CoverageChange are the same reported by Coveralls in
+// the pull request comment.
+//
+// PrNumber must be positive; otherwise ForPullRequest returns an error
+// without calling the API.
+//
+// It may return errors ErrRepoNotFound, ErrBuildNotFound (when the pull
+// request has no builds) or ErrUnexpectedStatusCode
+func (s BuildsServiceImpl) ForPullRequest(ctx context.Context, svc string, repo string, prNumber int, opts ...CallOption) (*Build, error) {
+	if prNumber <= 0 {
+		return nil, fmt.Errorf("invalid pull request number %d: must be positive", prNumber)
+	}
+	return s.latest(ctx, svc, repo, &BuildListOptions{ListOptions: ListOptions{PerPage: 1}, PullRequest: prNumber}, opts)
+}
+
+// Trend returns the coverage of the builds created between from and to
+// (both inclusive), oldest first. Builds without coverage information are
+// left out.
+//
+// It may return errors ErrRepoNotFound or ErrUnexpectedStatusCode
+func (s BuildsServiceImpl) Trend(ctx context.Context, svc string, repo string, from time.Time, to time.Time, opts ...CallOption) ([]*CoveragePoint, error) {
+	var points []*CoveragePoint
+	err := s.walk(ctx, svc, repo, &BuildListOptions{}, opts, func(b *Build) bool {
+		createdAt, err := time.Parse(time.RFC3339, b.CreatedAt)
+		if err != nil {
+			return true
+		}
+		if createdAt.Before(from) {
+			return false
+		}
+		if createdAt.After(to) || b.CoveredPercent == nil {
+			return true
+		}
+
+		points = append(points, &CoveragePoint{
+			Time:           createdAt,
+			CoveredPercent: *b.CoveredPercent,
+			CommitSHA:      b.CommitSHA,
+			Branch:         b.Branch,
+		})
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for i, j := 0, len(points)-1; i < j; i, j = i+1, j-1 {
+		points[i], points[j] = points[j], points[i]
+	}
+	return points, nil
+}
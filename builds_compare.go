@@ -0,0 +1,152 @@
+/*
+Copyright (c) 2020 Loadsmart, Inc.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package coveralls
+
+import (
+	"context"
+	"errors"
+)
+
+// BuildComparison holds the coverage changes between two builds
+type BuildComparison struct {
+	Base           *Build
+	Head           *Build
+	CoverageChange *float64          // Change in coverage from base to head, in percentage points (nil if either build has no coverage)
+	Files          []*FileComparison // Changes of the files compared, in the order given
+}
+
+// FileComparison holds the coverage changes of a file between two builds
+type FileComparison struct {
+	Name           string
+	BaseCoverage   *float64 // Coverage of the file in the base build, between 0 and 100 (nil if it's not in the build or has no relevant lines)
+	HeadCoverage   *float64 // Coverage of the file in the head build, between 0 and 100 (nil if it's not in the build or has no relevant lines)
+	CoverageChange *float64 // Change in coverage from base to head, in percentage points (nil if either coverage is nil)
+	NewlyUncovered []int    // Lines (starting at 1) uncovered in head that weren't uncovered in base
+}
+
+// Compare the coverage of the builds of two commits of a repository,
+// e.g. the base and head commits of a pull request.
+//
+// Coveralls doesn't list the files of a build, so per-file changes are
+// computed for the given paths only, usually the files changed between
+// the commits. Lines are matched by number: lines moved by the changes
+// may show up in NewlyUncovered even if their coverage didn't change.
+//
+// It may return errors ErrBuildNotFound or ErrUnexpectedStatusCode
+func (s BuildsServiceImpl) Compare(ctx context.Context, svc string, repo string, baseSHA string, headSHA string, paths []string, opts ...CallOption) (*BuildComparison, error) {
+	base, err := s.Get(ctx, svc, repo, baseSHA, opts...)
+	if err != nil {
+		return nil, err
+	}
+	head, err := s.Get(ctx, svc, repo, headSHA, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	comparison := &BuildComparison{
+		Base:           base,
+		Head:           head,
+		CoverageChange: change(base.CoveredPercent, head.CoveredPercent),
+	}
+	for _, path := range paths {
+		baseFile, err := s.sourceFile(ctx, svc, repo, baseSHA, path, opts)
+		if err != nil {
+			return nil, err
+		}
+		headFile, err := s.sourceFile(ctx, svc, repo, headSHA, path, opts)
+		if err != nil {
+			return nil, err
+		}
+		comparison.Files = append(comparison.Files, compareFiles(path, baseFile, headFile))
+	}
+	return comparison, nil
+}
+
+// sourceFile returns the coverage of a file in a build, or nil if the build
+// doesn't have it
+func (s BuildsServiceImpl) sourceFile(ctx context.Context, svc string, repo string, sha string, path string, opts []CallOption) (*SourceFile, error) {
+	file, err := s.client.SourceFiles.Get(ctx, svc, repo, sha, path, opts...)
+	if errors.Is(err, ErrSourceFileNotFound) {
+		return nil, nil
+	}
+	return file, err
+}
+
+// compareFiles compares the coverage of a file in two builds. Either file
+// may be nil if the build doesn't have it.
+func compareFiles(name string, base *SourceFile, head *SourceFile) *FileComparison {
+	comparison := &FileComparison{
+		Name:         name,
+		BaseCoverage: base.coveredPercent(),
+		HeadCoverage: head.coveredPercent(),
+	}
+	comparison.CoverageChange = change(comparison.BaseCoverage, comparison.HeadCoverage)
+
+	if head == nil {
+		return comparison
+	}
+	uncovered := make(map[int]bool)
+	if base != nil {
+		for _, line := range base.UncoveredLines() {
+			uncovered[line] = true
+		}
+	}
+	for _, line := range head.UncoveredLines() {
+		if !uncovered[line] {
+			comparison.NewlyUncovered = append(comparison.NewlyUncovered, line)
+		}
+	}
+	return comparison
+}
+
+// coveredPercent returns the percentage of relevant lines of the file with
+// hits, or nil if f is nil or has no relevant lines
+func (f *SourceFile) coveredPercent() *float64 {
+	if f == nil {
+		return nil
+	}
+	var covered, relevant int
+	for _, hits := range f.Coverage {
+		if hits == nil {
+			continue
+		}
+		relevant++
+		if *hits > 0 {
+			covered++
+		}
+	}
+	if relevant == 0 {
+		return nil
+	}
+	percent := float64(covered) * 100 / float64(relevant)
+	return &percent
+}
+
+// change returns the difference from base to head, or nil if either is nil
+func change(base *float64, head *float64) *float64 {
+	if base == nil || head == nil {
+		return nil
+	}
+	diff := *head - *base
+	return &diff
+}
@@ -0,0 +1,105 @@
+/*
+Copyright (c) 2020 Loadsmart, Inc.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package coveralls
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuildsServiceCompare(t *testing.T) {
+	builds := map[string]*Build{
+		"base": {CommitSHA: "base", CoveredPercent: pfloat64(80)},
+		"head": {CommitSHA: "head", CoveredPercent: pfloat64(78.5)},
+	}
+	files := map[string]map[string]*SourceFile{
+		"base": {
+			"main.go": {Name: "main.go", Coverage: []*int{nil, pint(1), pint(0), pint(1), pint(1)}},
+		},
+		"head": {
+			"main.go": {Name: "main.go", Coverage: []*int{nil, pint(1), pint(0), pint(0), pint(1)}},
+			"new.go":  {Name: "new.go", Coverage: []*int{pint(0), pint(2)}},
+		},
+	}
+	for sha := range builds {
+		sha := sha
+		httpmock.RegisterResponder("GET", "https://coveralls.io/builds/"+sha+".json", func(req *http.Request) (*http.Response, error) {
+			return httpmock.NewJsonResponse(http.StatusOK, builds[sha])
+		})
+		httpmock.RegisterResponder("GET", "https://coveralls.io/builds/"+sha+"/source.json", func(req *http.Request) (*http.Response, error) {
+			file, ok := files[sha][req.URL.Query().Get("filename")]
+			if !ok {
+				return httpmock.NewStringResponse(http.StatusNotFound, ""), nil
+			}
+			return httpmock.NewJsonResponse(http.StatusOK, file)
+		})
+	}
+
+	client := NewClient("fake token")
+	httpmock.ActivateNonDefault(client.client)
+	defer httpmock.DeactivateAndReset()
+
+	result, err := client.Builds.Compare(context.Background(), "github", "user/fakerepo", "base", "head", []string{"main.go", "new.go", "gone.go"})
+
+	assert.Nil(t, err)
+	assert.Equal(t, &BuildComparison{
+		Base:           builds["base"],
+		Head:           builds["head"],
+		CoverageChange: pfloat64(-1.5),
+		Files: []*FileComparison{
+			{
+				Name:           "main.go",
+				BaseCoverage:   pfloat64(75),
+				HeadCoverage:   pfloat64(50),
+				CoverageChange: pfloat64(-25),
+				NewlyUncovered: []int{4},
+			},
+			{
+				Name:           "new.go",
+				HeadCoverage:   pfloat64(50),
+				NewlyUncovered: []int{1},
+			},
+			{
+				Name: "gone.go",
+			},
+		},
+	}, result)
+}
+
+func TestBuildsServiceCompareNotFound(t *testing.T) {
+	httpmock.RegisterResponder("GET", "https://coveralls.io/builds/base.json", httpmock.NewStringResponder(http.StatusNotFound, ""))
+
+	client := NewClient("fake token")
+	httpmock.ActivateNonDefault(client.client)
+	defer httpmock.DeactivateAndReset()
+
+	result, err := client.Builds.Compare(context.Background(), "github", "user/fakerepo", "base", "head", []string{"main.go"})
+
+	assert.Nil(t, result)
+	assert.True(t, errors.Is(err, ErrBuildNotFound))
+}
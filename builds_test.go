@@ -0,0 +1,414 @@
+/*
+Copyright (c) 2020 Loadsmart, Inc.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package coveralls
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuildsServiceGet(t *testing.T) {
+	var testCases = []struct {
+		name  string
+		code  int
+		body  *Build
+		build *Build
+		err   error
+	}{
+		{
+			name: "existing",
+			code: http.StatusOK,
+			body: &Build{
+				CreatedAt:      "2022-03-15T21:47:57Z",
+				URL:            "https://coveralls.io/builds/123",
+				CommitMessage:  "Fix everything",
+				Branch:         "master",
+				CommitterName:  "John Doe",
+				CommitterEmail: "john@example.com",
+				CommitSHA:      "abc123",
+				RepoName:       "user/fakerepo",
+				CoverageChange: pfloat64(-0.4),
+				CoveredPercent: pfloat64(87.2),
+			},
+			build: &Build{
+				CreatedAt:      "2022-03-15T21:47:57Z",
+				URL:            "https://coveralls.io/builds/123",
+				CommitMessage:  "Fix everything",
+				Branch:         "master",
+				CommitterName:  "John Doe",
+				CommitterEmail: "john@example.com",
+				CommitSHA:      "abc123",
+				RepoName:       "user/fakerepo",
+				CoverageChange: pfloat64(-0.4),
+				CoveredPercent: pfloat64(87.2),
+			},
+			err: nil,
+		},
+		{
+			name:  "otherrepo",
+			code:  http.StatusOK,
+			body:  &Build{CommitSHA: "abc123", RepoName: "fork/fakerepo"},
+			build: nil,
+			err:   ErrBuildNotFound,
+		},
+		{
+			name:  "notfound",
+			code:  http.StatusNotFound,
+			body:  nil,
+			build: nil,
+			err:   ErrBuildNotFound,
+		},
+		{
+			name:  "unexpected",
+			code:  http.StatusUseProxy,
+			body:  nil,
+			build: nil,
+			err: ErrUnexpectedStatusCode{
+				StatusCode: http.StatusUseProxy,
+				ErrorBody:  "null",
+			},
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			fakeUrl := "https://coveralls.io/builds/abc123.json"
+			responder, _ := httpmock.NewJsonResponder(tt.code, tt.body)
+			httpmock.RegisterResponder("GET", fakeUrl, responder)
+
+			client := NewClient("fake token")
+			httpmock.ActivateNonDefault(client.client)
+			defer httpmock.DeactivateAndReset()
+
+			result, err := client.Builds.Get(context.Background(), "github", "user/fakerepo", "abc123")
+
+			if !errors.Is(err, tt.err) {
+				t.Errorf("Errors do not match.\n\texpected: '%v'\n\tgot: '%v'", tt.err, err)
+			}
+			assert.Equal(t, tt.build, result)
+		})
+	}
+}
+
+func TestBuildsServiceClose(t *testing.T) {
+	var testCases = []struct {
+		name string
+		code int
+		err  error
+	}{
+		{name: "done", code: http.StatusOK, err: nil},
+		{name: "notfound", code: http.StatusNotFound, err: ErrBuildNotFound},
+		{name: "unprocessable", code: http.StatusUnprocessableEntity, err: ErrUnprocessableEntity{ErrorBody: `{"error":"invalid"}`}},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			fakeUrl := "https://coveralls.io/webhook"
+			httpmock.RegisterResponder("POST", fakeUrl, func(req *http.Request) (*http.Response, error) {
+				assert.Equal(t, "fake-repo-token", req.URL.Query().Get("repo_token"))
+
+				body := make(map[string]*webhookPayload)
+				if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+					return httpmock.NewStringResponse(400, ""), nil
+				}
+				assert.Equal(t, &webhookPayload{BuildNum: "42", Status: "done"}, body["payload"])
+
+				if tt.code == http.StatusOK {
+					return httpmock.NewStringResponse(tt.code, `{"done":true}`), nil
+				}
+				return httpmock.NewStringResponse(tt.code, `{"error":"invalid"}`), nil
+			})
+
+			client := NewClient("fake token")
+			httpmock.ActivateNonDefault(client.client)
+			defer httpmock.DeactivateAndReset()
+
+			err := client.Builds.Close(context.Background(), "fake-repo-token", "42")
+
+			if !errors.Is(err, tt.err) {
+				t.Errorf("Errors do not match.\n\texpected: '%v'\n\tgot: '%v'", tt.err, err)
+			}
+		})
+	}
+}
+
+func TestBuildsServiceList(t *testing.T) {
+	pages := []*buildPage{
+		{Page: 1, Pages: 3, Total: 5, Builds: []*Build{{CommitSHA: "sha5"}, {CommitSHA: "sha4"}}},
+		{Page: 2, Pages: 3, Total: 5, Builds: []*Build{{CommitSHA: "sha3"}, {CommitSHA: "sha2"}}},
+		{Page: 3, Pages: 3, Total: 5, Builds: []*Build{{CommitSHA: "sha1"}}},
+	}
+
+	var testCases = []struct {
+		name     string
+		opts     *BuildListOptions
+		expected []string
+	}{
+		{name: "all", opts: nil, expected: []string{"sha5", "sha4", "sha3", "sha2", "sha1"}},
+		{name: "limit", opts: &BuildListOptions{Branch: "master", Limit: 3}, expected: []string{"sha5", "sha4", "sha3"}},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			requests := 0
+			fakeUrl := "https://coveralls.io/github/user/fakerepo.json"
+			httpmock.RegisterResponder("GET", fakeUrl, func(req *http.Request) (*http.Response, error) {
+				requests++
+				if tt.opts != nil {
+					assert.Equal(t, tt.opts.Branch, req.URL.Query().Get("branch"))
+				}
+
+				var page int
+				fmt.Sscan(req.URL.Query().Get("page"), &page)
+				if page < 1 || page > len(pages) {
+					return httpmock.NewStringResponse(400, ""), nil
+				}
+				return httpmock.NewJsonResponse(200, pages[page-1])
+			})
+
+			client := NewClient("fake token")
+			httpmock.ActivateNonDefault(client.client)
+			defer httpmock.DeactivateAndReset()
+
+			result, err := client.Builds.List(context.Background(), "github", "user/fakerepo", tt.opts)
+
+			assert.Nil(t, err)
+			var shas []string
+			for _, b := range result {
+				shas = append(shas, b.CommitSHA)
+			}
+			assert.Equal(t, tt.expected, shas)
+			assert.Equal(t, (len(tt.expected)+1)/2, requests)
+		})
+	}
+}
+
+func TestBuildsServiceListNotFound(t *testing.T) {
+	fakeUrl := "https://coveralls.io/github/user/fakerepo.json"
+	httpmock.RegisterResponder("GET", fakeUrl, httpmock.NewStringResponder(404, ""))
+
+	client := NewClient("fake token")
+	httpmock.ActivateNonDefault(client.client)
+	defer httpmock.DeactivateAndReset()
+
+	result, err := client.Builds.List(context.Background(), "github", "user/fakerepo", nil)
+
+	assert.True(t, errors.Is(err, ErrRepoNotFound))
+	assert.Nil(t, result)
+}
+
+func TestBuildsServiceRerun(t *testing.T) {
+	var testCases = []struct {
+		name string
+		code int
+		err  error
+	}{
+		{name: "accepted", code: http.StatusAccepted, err: nil},
+		{name: "notfound", code: http.StatusNotFound, err: ErrBuildNotFound},
+		{name: "unexpected", code: http.StatusUseProxy, err: ErrUnexpectedStatusCode{StatusCode: http.StatusUseProxy, ErrorBody: ""}},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			fakeUrl := "https://coveralls.io/builds/abc123/rerun"
+			httpmock.RegisterResponder("POST", fakeUrl, func(req *http.Request) (*http.Response, error) {
+				assert.Equal(t, "user/fakerepo", req.URL.Query().Get("repo_name"))
+				return httpmock.NewStringResponse(tt.code, ""), nil
+			})
+
+			client := NewClient("fake token")
+			httpmock.ActivateNonDefault(client.client)
+			defer httpmock.DeactivateAndReset()
+
+			err := client.Builds.Rerun(context.Background(), "github", "user/fakerepo", "abc123")
+
+			if !errors.Is(err, tt.err) {
+				t.Errorf("Errors do not match.\n\texpected: '%v'\n\tgot: '%v'", tt.err, err)
+			}
+		})
+	}
+}
+
+func TestBuildsServiceLatestForBranch(t *testing.T) {
+	var testCases = []struct {
+		name  string
+		page  *buildPage
+		build *Build
+		err   error
+	}{
+		{
+			name:  "existing",
+			page:  &buildPage{Page: 1, Pages: 10, Total: 10, Builds: []*Build{{CommitSHA: "abc123", Branch: "release/1.0"}}},
+			build: &Build{CommitSHA: "abc123", Branch: "release/1.0"},
+			err:   nil,
+		},
+		{
+			name:  "nobuilds",
+			page:  &buildPage{Page: 1},
+			build: nil,
+			err:   ErrBuildNotFound,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			fakeUrl := "https://coveralls.io/github/user/fakerepo.json"
+			httpmock.RegisterResponder("GET", fakeUrl, func(req *http.Request) (*http.Response, error) {
+				assert.Equal(t, "release/1.0", req.URL.Query().Get("branch"))
+				assert.Equal(t, "1", req.URL.Query().Get("per_page"))
+				return httpmock.NewJsonResponse(200, tt.page)
+			})
+
+			client := NewClient("fake token")
+			httpmock.ActivateNonDefault(client.client)
+			defer httpmock.DeactivateAndReset()
+
+			result, err := client.Builds.LatestForBranch(context.Background(), "github", "user/fakerepo", "release/1.0")
+
+			if !errors.Is(err, tt.err) {
+				t.Errorf("Errors do not match.\n\texpected: '%v'\n\tgot: '%v'", tt.err, err)
+			}
+			if tt.err != nil {
+				var apiErr *APIError
+				assert.True(t, errors.As(err, &apiErr))
+				assert.Equal(t, "GET", apiErr.Method)
+				assert.Contains(t, apiErr.URL, "branch=release%2F1.0")
+			}
+			assert.Equal(t, tt.build, result)
+		})
+	}
+}
+
+func TestBuildsServiceForPullRequest(t *testing.T) {
+	fakeUrl := "https://coveralls.io/github/user/fakerepo.json"
+	httpmock.RegisterResponder("GET", fakeUrl, func(req *http.Request) (*http.Response, error) {
+		assert.Equal(t, "17", req.URL.Query().Get("pull_request"))
+		page := &buildPage{Page: 1, Pages: 1, Total: 1, Builds: []*Build{
+			{CommitSHA: "abc123", PullRequest: 17, CoveredPercent: pfloat64(80.1), CoverageChange: pfloat64(1.2)},
+		}}
+		return httpmock.NewJsonResponse(200, page)
+	})
+
+	client := NewClient("fake token")
+	httpmock.ActivateNonDefault(client.client)
+	defer httpmock.DeactivateAndReset()
+
+	result, err := client.Builds.ForPullRequest(context.Background(), "github", "user/fakerepo", 17)
+
+	assert.Nil(t, err)
+	assert.Equal(t, &Build{CommitSHA: "abc123", PullRequest: 17, CoveredPercent: pfloat64(80.1), CoverageChange: pfloat64(1.2)}, result)
+}
+
+func TestBuildsServiceForPullRequestNoBuilds(t *testing.T) {
+	fakeUrl := "https://coveralls.io/github/user/fakerepo.json"
+	httpmock.RegisterResponder("GET", fakeUrl, httpmock.NewJsonResponderOrPanic(200, &buildPage{Page: 1}))
+
+	client := NewClient("fake token")
+	httpmock.ActivateNonDefault(client.client)
+	defer httpmock.DeactivateAndReset()
+
+	result, err := client.Builds.ForPullRequest(context.Background(), "github", "user/fakerepo", 17)
+
+	assert.Nil(t, result)
+	assert.True(t, errors.Is(err, ErrBuildNotFound))
+	code, ok := StatusCode(err)
+	assert.True(t, ok)
+	assert.Equal(t, http.StatusOK, code)
+}
+
+func TestBuildsServiceForPullRequestInvalidNumber(t *testing.T) {
+	for _, prNumber := range []int{0, -1} {
+		t.Run(strconv.Itoa(prNumber), func(t *testing.T) {
+			client := NewClient("fake token")
+			httpmock.ActivateNonDefault(client.client)
+			defer httpmock.DeactivateAndReset()
+
+			result, err := client.Builds.ForPullRequest(context.Background(), "github", "user/fakerepo", prNumber)
+
+			assert.Nil(t, result)
+			assert.Error(t, err)
+			assert.Equal(t, 0, httpmock.GetTotalCallCount())
+		})
+	}
+}
+
+func TestBuildsServiceTrend(t *testing.T) {
+	pages := []*buildPage{
+		{Page: 1, Pages: 3, Total: 6, Builds: []*Build{
+			{CommitSHA: "sha6", CreatedAt: "2022-03-20T10:00:00Z", CoveredPercent: pfloat64(86)},
+			{CommitSHA: "sha5", CreatedAt: "2022-03-18T10:00:00Z", CoveredPercent: pfloat64(85)},
+		}},
+		{Page: 2, Pages: 3, Total: 6, Builds: []*Build{
+			{CommitSHA: "sha4", CreatedAt: "2022-03-17T10:00:00Z"},
+			{CommitSHA: "sha3", CreatedAt: "2022-03-16T10:00:00Z", CoveredPercent: pfloat64(84)},
+		}},
+		{Page: 3, Pages: 3, Total: 6, Builds: []*Build{
+			{CommitSHA: "sha2", CreatedAt: "2022-03-14T10:00:00Z", CoveredPercent: pfloat64(83)},
+			{CommitSHA: "sha1", CreatedAt: "2022-03-13T10:00:00Z", CoveredPercent: pfloat64(82)},
+		}},
+	}
+	requests := 0
+	fakeUrl := "https://coveralls.io/github/user/fakerepo.json"
+	httpmock.RegisterResponder("GET", fakeUrl, func(req *http.Request) (*http.Response, error) {
+		requests++
+		var page int
+		fmt.Sscan(req.URL.Query().Get("page"), &page)
+		return httpmock.NewJsonResponse(200, pages[page-1])
+	})
+
+	client := NewClient("fake token")
+	httpmock.ActivateNonDefault(client.client)
+	defer httpmock.DeactivateAndReset()
+
+	from := time.Date(2022, 3, 15, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2022, 3, 19, 0, 0, 0, 0, time.UTC)
+	result, err := client.Builds.Trend(context.Background(), "github", "user/fakerepo", from, to)
+
+	assert.Nil(t, err)
+	assert.Equal(t, []*CoveragePoint{
+		{Time: time.Date(2022, 3, 16, 10, 0, 0, 0, time.UTC), CoveredPercent: 84, CommitSHA: "sha3"},
+		{Time: time.Date(2022, 3, 18, 10, 0, 0, 0, time.UTC), CoveredPercent: 85, CommitSHA: "sha5"},
+	}, result)
+	assert.Equal(t, 3, requests)
+}
+
+func ExampleBuildsService_Get() {
+	client := NewClient("your-personal-access-token")
+	// This returns information about the build of a specific commit
+	build, err := client.Builds.Get(context.Background(), "github", "user/repository", "9ab6b4d1c8d2f0e2d5f0d1e3b8a9d0c4e5f6a7b8")
+	if err != nil {
+		log.Fatalf("Error querying Coveralls API: %s\n", err)
+	}
+
+	fmt.Printf("Build has %.2f%% coverage", *build.CoveredPercent)
+}
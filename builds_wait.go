@@ -0,0 +1,115 @@
+/*
+Copyright (c) 2020 Loadsmart, Inc.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package coveralls
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Default delays between the checks of BuildsService.Wait
+const (
+	defaultWaitInterval    = 5 * time.Second
+	defaultWaitMaxInterval = time.Minute
+)
+
+// WaitOptions specifies how often BuildsService.Wait checks the build
+type WaitOptions struct {
+	Interval    time.Duration // Delay before the second check, doubled for the next ones. Zero means 5 seconds
+	MaxInterval time.Duration // Maximum delay between checks. Zero means a minute
+}
+
+// Wait polls the build of the commit sha until Coveralls finishes
+// processing it, and returns it with its final coverage. Builds that
+// Coveralls doesn't know yet, e.g. because CI is still uploading jobs, are
+// waited for too.
+//
+// Checks back off as set by opts, which may be nil. Ctx bounds the wait:
+// when it is done, Wait fails with an error that matches the context error,
+// e.g. context.DeadlineExceeded. When the next delay would pass the deadline
+// of ctx, the build is checked a last time just before it.
+//
+// It may return errors ErrUnexpectedStatusCode
+func (s BuildsServiceImpl) Wait(ctx context.Context, svc string, repo string, sha string, opts *WaitOptions, callOpts ...CallOption) (*Build, error) {
+	if opts == nil {
+		opts = &WaitOptions{}
+	}
+	policy := retryPolicy{baseDelay: opts.Interval, maxDelay: opts.MaxInterval}
+	if policy.baseDelay <= 0 {
+		policy.baseDelay = defaultWaitInterval
+	}
+	if policy.maxDelay <= 0 {
+		policy.maxDelay = defaultWaitMaxInterval
+	}
+
+	final := false
+	for attempt := 0; ; attempt++ {
+		start := time.Now()
+		build, err := s.Get(ctx, svc, repo, sha, callOpts...)
+		switch {
+		case err == nil && build.CoveredPercent != nil:
+			return build, nil
+		case err != nil && ctx.Err() != nil:
+			return nil, fmt.Errorf("waiting for the build of %s: %w", sha, err)
+		case err != nil && !errors.Is(err, ErrBuildNotFound):
+			return nil, err
+		case final:
+			return nil, fmt.Errorf("waiting for the build of %s: %w", sha, context.DeadlineExceeded)
+		}
+
+		d := policy.delay(attempt)
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) <= d {
+			// Check a last time before the deadline instead of giving up a
+			// whole delay early. Leave it as much time as this check took,
+			// and at least a tenth of the time left.
+			left := time.Until(deadline)
+			reserve := time.Since(start)
+			if reserve < left/10 {
+				reserve = left / 10
+			}
+			d = left - reserve
+			final = true
+		}
+		if err := sleep(ctx, d); err != nil {
+			return nil, fmt.Errorf("waiting for the build of %s: %w", sha, err)
+		}
+	}
+}
+
+// sleep waits for d, or until ctx is done
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
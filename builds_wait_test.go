@@ -0,0 +1,113 @@
+/*
+Copyright (c) 2020 Loadsmart, Inc.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package coveralls
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildsServiceWait(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		assert.Equal(t, "/builds/abc123.json", req.URL.Path)
+		w.Header().Set("Content-Type", "application/json")
+		switch atomic.AddInt32(&calls, 1) {
+		case 1:
+			w.WriteHeader(http.StatusNotFound)
+		case 2:
+			w.Write([]byte(`{"commit_sha": "abc123", "repo_name": "user/repository"}`))
+		default:
+			w.Write([]byte(`{"commit_sha": "abc123", "repo_name": "user/repository", "covered_percent": 87.5}`))
+		}
+	}))
+	defer server.Close()
+	client, _ := NewEnterpriseClient(server.URL, "fake token")
+
+	build, err := client.Builds.Wait(context.Background(), "github", "user/repository", "abc123", &WaitOptions{Interval: time.Millisecond})
+
+	require.NoError(t, err)
+	assert.Equal(t, 87.5, *build.CoveredPercent)
+	assert.Equal(t, int32(3), atomic.LoadInt32(&calls))
+}
+
+func TestBuildsServiceWaitTimeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+	client, _ := NewEnterpriseClient(server.URL, "fake token")
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	build, err := client.Builds.Wait(ctx, "github", "user/repository", "abc123", &WaitOptions{Interval: time.Millisecond, MaxInterval: 5 * time.Millisecond})
+
+	assert.Nil(t, build)
+	assert.True(t, errors.Is(err, context.DeadlineExceeded), err)
+	assert.Contains(t, err.Error(), "waiting for the build of abc123")
+}
+
+func TestBuildsServiceWaitFinalCheck(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if atomic.AddInt32(&calls, 1) == 1 {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(`{"commit_sha": "abc123", "repo_name": "user/repository", "covered_percent": 87.5}`))
+	}))
+	defer server.Close()
+	client, _ := NewEnterpriseClient(server.URL, "fake token")
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	build, err := client.Builds.Wait(ctx, "github", "user/repository", "abc123", &WaitOptions{Interval: time.Hour})
+
+	require.NoError(t, err)
+	assert.Equal(t, 87.5, *build.CoveredPercent)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+	assert.True(t, time.Since(start) >= 100*time.Millisecond, "the last check should wait until close to the deadline")
+}
+
+func TestBuildsServiceWaitError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer server.Close()
+	client, _ := NewEnterpriseClient(server.URL, "fake token")
+
+	build, err := client.Builds.Wait(context.Background(), "github", "user/repository", "abc123", nil)
+
+	assert.Nil(t, build)
+	assert.True(t, errors.Is(err, ErrUnauthorized))
+}
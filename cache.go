@@ -0,0 +1,210 @@
+/*
+Copyright (c) 2020 Loadsmart, Inc.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package coveralls
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Cache stores the responses to GET requests for WithCache. Implementations
+// must be safe for concurrent use.
+type Cache interface {
+	Get(key string) (*CachedResponse, bool)
+	Set(key string, resp *CachedResponse)
+}
+
+// CachedResponse is a response stored in a Cache
+type CachedResponse struct {
+	StatusCode int
+	Header     http.Header
+	Body       []byte
+	StoredAt   time.Time // When the response was received
+}
+
+// WithCache makes the Client store the responses to GET requests that have
+// an ETag or Last-Modified header in cache, and revalidate them with
+// If-None-Match and If-Modified-Since when requested again. When the API
+// answers 304 Not Modified, the call returns the cached data, sparing the
+// API from sending it again.
+//
+// The cache is keyed by URL and the credential sent in the request, so
+// clients and calls with different tokens, including the tokens of a
+// TokenProvider, may share it without seeing each other's responses. See NewMemoryCache for a simple implementation.
+func WithCache(cache Cache) Option {
+	return func(o *options) {
+		o.cache = cache
+	}
+}
+
+// WithStaleIfError makes GET calls return the last response stored in the
+// cache set with WithCache when the API is unreachable: on network errors,
+// timeouts, ErrCircuitOpen and 5xx status codes. Calls then succeed with
+// the cached data, and CaptureMetadata reports it as stale. Responses older
+// than maxAge aren't used; zero means no limit.
+//
+// With it, the cache also stores responses without ETag or Last-Modified.
+func WithStaleIfError(maxAge time.Duration) Option {
+	return func(o *options) {
+		o.staleIfError = &maxAge
+	}
+}
+
+// memoryCache is a Cache that keeps responses in memory
+type memoryCache struct {
+	mu        sync.RWMutex
+	responses map[string]*CachedResponse
+}
+
+// NewMemoryCache returns a Cache that keeps responses in memory, without
+// ever evicting them
+func NewMemoryCache() Cache {
+	return &memoryCache{responses: make(map[string]*CachedResponse)}
+}
+
+func (c *memoryCache) Get(key string) (*CachedResponse, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	resp, ok := c.responses[key]
+	return resp, ok
+}
+
+func (c *memoryCache) Set(key string, resp *CachedResponse) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.responses[key] = resp
+}
+
+// response returns a copy of raw, the 304 Not Modified response to a
+// revalidation, with the status code and headers of the cached response.
+// Raw may be nil when there was no response.
+func (c *CachedResponse) response(raw *http.Response) *http.Response {
+	if raw == nil {
+		raw = &http.Response{Proto: "HTTP/1.1", ProtoMajor: 1, ProtoMinor: 1, Header: make(http.Header)}
+	}
+	resp := *raw
+	resp.StatusCode = c.StatusCode
+	resp.Status = http.StatusText(c.StatusCode)
+	resp.Header = c.Header.Clone()
+	if resp.Header == nil {
+		resp.Header = make(http.Header)
+	}
+	for name, values := range raw.Header {
+		resp.Header[name] = values
+	}
+	resp.ContentLength = int64(len(c.Body))
+	return &resp
+}
+
+// revalidate sets the headers to revalidate the cached response to req,
+// if any, and returns it
+func (r *request) revalidate(req *http.Request) *CachedResponse {
+	if r.client.cache == nil || req.Method != http.MethodGet {
+		return nil
+	}
+	r.cacheKey = cacheKey(req)
+	cached, ok := r.client.cache.Get(r.cacheKey)
+	if !ok {
+		return nil
+	}
+	if etag := cached.Header.Get("ETag"); etag != "" {
+		req.Header.Set("If-None-Match", etag)
+	}
+	if modified := cached.Header.Get("Last-Modified"); modified != "" {
+		req.Header.Set("If-Modified-Since", modified)
+	}
+	return cached
+}
+
+// store adds a successful response to the cache, if it can be revalidated
+func (r *request) store(req *http.Request, raw *http.Response, body []byte) {
+	if r.client.cache == nil || req.Method != http.MethodGet || raw.StatusCode != http.StatusOK {
+		return
+	}
+	if r.client.staleIfError == nil && raw.Header.Get("ETag") == "" && raw.Header.Get("Last-Modified") == "" {
+		return
+	}
+	r.client.cache.Set(r.cacheKey, &CachedResponse{
+		StatusCode: raw.StatusCode,
+		Header:     raw.Header.Clone(),
+		Body:       body,
+		StoredAt:   time.Now(),
+	})
+}
+
+// stale returns the cached response to a GET request that failed because
+// the API was unreachable, if WithStaleIfError is set and there is a
+// response recent enough for the credential the request was sent with
+func (r *request) stale(method string, resp *response, err error) *response {
+	maxAge := r.client.staleIfError
+	if maxAge == nil || r.client.cache == nil || method != http.MethodGet || r.cacheKey == "" || !outage(resp, err) {
+		return nil
+	}
+	cached, ok := r.client.cache.Get(r.cacheKey)
+	if !ok || (*maxAge > 0 && time.Since(cached.StoredAt) > *maxAge) {
+		return nil
+	}
+	if r.result != nil {
+		if json.Unmarshal(cached.Body, r.result) != nil {
+			return nil
+		}
+	}
+
+	raw := cached.response(nil)
+	if r.settings.response != nil {
+		captured := *raw
+		captured.Body = ioutil.NopCloser(bytes.NewReader(cached.Body))
+		*r.settings.response = &captured
+	}
+	if r.settings.metadata != nil {
+		r.settings.metadata.StatusCode = cached.StatusCode
+		r.settings.metadata.RequestID = requestID(cached.Header)
+		r.settings.metadata.Stale = true
+	}
+	return &response{RawResponse: raw, body: cached.Body, result: r.result}
+}
+
+// cacheKey identifies the responses to req in the cache: its URL and a hash
+// of the credential it was sent with, so the cache doesn't keep tokens
+func cacheKey(req *http.Request) string {
+	sum := sha256.Sum256([]byte(req.Header.Get("Authorization")))
+	return hex.EncodeToString(sum[:]) + " " + req.URL.String()
+}
+
+// outage reports whether a call failed because the API was unreachable
+func outage(resp *response, err error) bool {
+	if err == nil {
+		return resp != nil && resp.StatusCode() >= http.StatusInternalServerError
+	}
+	if code, ok := StatusCode(err); ok {
+		return code >= http.StatusInternalServerError
+	}
+	// Timeouts, network errors and ErrCircuitOpen
+	return IsRetryable(err)
+}
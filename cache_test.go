@@ -0,0 +1,228 @@
+/*
+Copyright (c) 2020 Loadsmart, Inc.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package coveralls
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithCache(t *testing.T) {
+	const lastModified = "Wed, 21 Oct 2015 07:28:00 GMT"
+
+	var testCases = []struct {
+		name            string
+		header          string
+		value           string
+		conditional     string
+		wantRequests    int
+		wantNotModified int
+	}{
+		{name: "etag", header: "ETag", value: `"v1"`, conditional: "If-None-Match", wantRequests: 2, wantNotModified: 1},
+		{name: "last modified", header: "Last-Modified", value: lastModified, conditional: "If-Modified-Since", wantRequests: 2, wantNotModified: 1},
+		{name: "no validators", wantRequests: 2},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			requests, notModified := 0, 0
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				requests++
+				if tt.header != "" {
+					w.Header().Set(tt.header, tt.value)
+					if req.Header.Get(tt.conditional) == tt.value {
+						notModified++
+						w.WriteHeader(http.StatusNotModified)
+						return
+					}
+				}
+				w.Header().Set("Content-Type", "application/json")
+				_, _ = w.Write([]byte(`{"name": "user/fakerepo", "service": "github"}`))
+			}))
+			defer server.Close()
+
+			client, _ := NewEnterpriseClient(server.URL, "fake token", WithCache(NewMemoryCache()))
+
+			for i := 0; i < 2; i++ {
+				var metadata ResponseMetadata
+				repo, err := client.Repositories.Get(context.Background(), "github", "user/fakerepo", CaptureMetadata(&metadata))
+
+				assert.Nil(t, err)
+				assert.Equal(t, "user/fakerepo", repo.Name)
+				assert.Equal(t, http.StatusOK, metadata.StatusCode)
+			}
+			assert.Equal(t, tt.wantRequests, requests)
+			assert.Equal(t, tt.wantNotModified, notModified)
+		})
+	}
+}
+
+func TestWithCacheKeyedByToken(t *testing.T) {
+	var conditional []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		conditional = append(conditional, req.Header.Get("If-None-Match"))
+		w.Header().Set("ETag", `"v1"`)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"name": "user/fakerepo", "service": "github"}`))
+	}))
+	defer server.Close()
+
+	cache := NewMemoryCache()
+	client, _ := NewEnterpriseClient(server.URL, "fake token", WithCache(cache))
+	other := client.Clone(WithToken("other token"))
+
+	_, _ = client.Repositories.Get(context.Background(), "github", "user/fakerepo")
+	_, _ = other.Repositories.Get(context.Background(), "github", "user/fakerepo")
+	_, _ = client.Repositories.Get(context.Background(), "github", "user/fakerepo")
+
+	assert.Equal(t, []string{"", "", `"v1"`}, conditional)
+}
+
+func TestWithCacheKeyedBySentToken(t *testing.T) {
+	down := false
+	var conditional []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if down {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		conditional = append(conditional, req.Header.Get("If-None-Match"))
+		w.Header().Set("ETag", `"v1"`)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"name": "user/fakerepo", "service": "github"}`))
+	}))
+	defer server.Close()
+
+	tokens := []string{"tenant a", "tenant b"}
+	calls := 0
+	provider := TokenProviderFunc(func(ctx context.Context) (string, error) {
+		calls++
+		return tokens[(calls-1)%2], nil
+	})
+	client, _ := NewEnterpriseClient(server.URL, "fake token", WithCache(NewMemoryCache()), WithStaleIfError(0), WithTokenProvider(provider))
+
+	_, _ = client.Repositories.Get(context.Background(), "github", "user/fakerepo")
+	_, _ = client.Repositories.Get(context.Background(), "github", "user/fakerepo")
+	_, _ = client.Repositories.Get(context.Background(), "github", "user/fakerepo", WithRepoToken("tenant c"))
+	_, _ = client.Repositories.Get(context.Background(), "github", "user/fakerepo", WithRepoToken("tenant c"))
+
+	assert.Equal(t, []string{"", "", "", `"v1"`}, conditional)
+
+	down = true
+	_, err := client.Repositories.Get(context.Background(), "github", "user/fakerepo", WithRepoToken("tenant d"))
+	assert.True(t, errors.As(err, &ErrServiceUnavailable{}), "nothing cached for another token")
+	repo, err := client.Repositories.Get(context.Background(), "github", "user/fakerepo", WithRepoToken("tenant c"))
+	assert.Nil(t, err)
+	assert.Equal(t, "user/fakerepo", repo.Name)
+}
+
+func TestWithStaleIfError(t *testing.T) {
+	down := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if down {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("X-Request-Id", "abc")
+		_, _ = w.Write([]byte(`{"name": "user/fakerepo", "service": "github"}`))
+	}))
+	defer server.Close()
+
+	client, _ := NewEnterpriseClient(server.URL, "fake token", WithCache(NewMemoryCache()), WithStaleIfError(time.Hour))
+
+	var metadata ResponseMetadata
+	repo, err := client.Repositories.Get(context.Background(), "github", "user/fakerepo", CaptureMetadata(&metadata))
+	assert.Nil(t, err)
+	assert.Equal(t, "user/fakerepo", repo.Name)
+	assert.False(t, metadata.Stale)
+
+	down = true
+	metadata = ResponseMetadata{}
+	repo, err = client.Repositories.Get(context.Background(), "github", "user/fakerepo", CaptureMetadata(&metadata))
+	assert.Nil(t, err)
+	assert.Equal(t, "user/fakerepo", repo.Name)
+	assert.Equal(t, ResponseMetadata{StatusCode: http.StatusOK, RequestID: "abc", Stale: true}, metadata)
+
+	_, err = client.Repositories.Get(context.Background(), "github", "user/otherrepo")
+	assert.True(t, errors.As(err, &ErrServiceUnavailable{}), "nothing cached for another repo")
+
+	server.Close()
+	repo, err = client.Repositories.Get(context.Background(), "github", "user/fakerepo")
+	assert.Nil(t, err, "unreachable")
+	assert.Equal(t, "user/fakerepo", repo.Name)
+}
+
+func TestWithStaleIfErrorMaxAge(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		requests++
+		if requests > 1 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"name": "user/fakerepo", "service": "github"}`))
+	}))
+	defer server.Close()
+
+	cache := NewMemoryCache()
+	client, _ := NewEnterpriseClient(server.URL, "fake token", WithCache(cache), WithStaleIfError(time.Minute))
+
+	_, err := client.Repositories.Get(context.Background(), "github", "user/fakerepo")
+	assert.Nil(t, err)
+
+	for _, cached := range cache.(*memoryCache).responses {
+		cached.StoredAt = cached.StoredAt.Add(-time.Hour)
+	}
+	_, err = client.Repositories.Get(context.Background(), "github", "user/fakerepo")
+	code, _ := StatusCode(err)
+	assert.Equal(t, http.StatusBadGateway, code)
+}
+
+func TestWithStaleIfErrorNotFound(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		requests++
+		if requests > 1 {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"name": "user/fakerepo", "service": "github"}`))
+	}))
+	defer server.Close()
+
+	client, _ := NewEnterpriseClient(server.URL, "fake token", WithCache(NewMemoryCache()), WithStaleIfError(0))
+
+	_, _ = client.Repositories.Get(context.Background(), "github", "user/fakerepo")
+	_, err := client.Repositories.Get(context.Background(), "github", "user/fakerepo")
+
+	assert.True(t, errors.Is(err, ErrRepoNotFound))
+}
@@ -0,0 +1,193 @@
+/*
+Copyright (c) 2020 Loadsmart, Inc.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package coveralls
+
+import (
+	"os"
+	"path"
+	"strings"
+)
+
+// CIBuild identifies the CI build the process runs in
+type CIBuild struct {
+	ServiceName        string // Name of the CI service in Coveralls, e.g. github or travis-ci
+	ServiceNumber      string // Build number in the CI service
+	ServiceJobID       string // Job ID in the CI service
+	ServicePullRequest string // Number of the pull request being built, if any
+	CommitSHA          string // Commit being built, if the CI service tells it
+}
+
+// ciService tells how to detect a CI service from its environment variables
+type ciService struct {
+	detect      func() bool
+	name        string
+	number      string // Variable of the build number
+	jobID       string // Variable of the job ID
+	pullRequest func() string
+	commitSHA   string // Variable of the commit SHA
+}
+
+// ciServices are the CI services DetectCI knows
+var ciServices = []ciService{
+	{
+		detect:      envIs("GITHUB_ACTIONS", "true"),
+		name:        "github",
+		number:      "GITHUB_RUN_ID",
+		jobID:       "GITHUB_JOB",
+		pullRequest: githubPullRequest,
+		commitSHA:   "GITHUB_SHA",
+	},
+	{
+		detect:      envIs("TRAVIS", "true"),
+		name:        "travis-ci",
+		number:      "TRAVIS_BUILD_NUMBER",
+		jobID:       "TRAVIS_JOB_ID",
+		pullRequest: envUnless("TRAVIS_PULL_REQUEST", "false"),
+		commitSHA:   "TRAVIS_COMMIT",
+	},
+	{
+		detect:      envIs("CIRCLECI", "true"),
+		name:        "circleci",
+		number:      "CIRCLE_WORKFLOW_ID",
+		jobID:       "CIRCLE_BUILD_NUM",
+		pullRequest: circlePullRequest,
+		commitSHA:   "CIRCLE_SHA1",
+	},
+	{
+		detect:      envIs("GITLAB_CI", "true"),
+		name:        "gitlab-ci",
+		number:      "CI_PIPELINE_IID",
+		jobID:       "CI_JOB_ID",
+		pullRequest: envUnless("CI_MERGE_REQUEST_IID", ""),
+		commitSHA:   "CI_COMMIT_SHA",
+	},
+	{
+		detect:      envIs("BUILDKITE", "true"),
+		name:        "buildkite",
+		number:      "BUILDKITE_BUILD_NUMBER",
+		jobID:       "BUILDKITE_JOB_ID",
+		pullRequest: envUnless("BUILDKITE_PULL_REQUEST", "false"),
+		commitSHA:   "BUILDKITE_COMMIT",
+	},
+	{
+		detect:      func() bool { return os.Getenv("JENKINS_URL") != "" },
+		name:        "jenkins",
+		number:      "BUILD_NUMBER",
+		jobID:       "BUILD_ID",
+		pullRequest: envUnless("CHANGE_ID", ""),
+		commitSHA:   "GIT_COMMIT",
+	},
+}
+
+// DetectCI returns the CI build the process runs in, detected from the
+// environment variables set by GitHub Actions, Travis CI, CircleCI, GitLab
+// CI, Buildkite and Jenkins. It returns nil outside of them.
+//
+// As in other Coveralls tools, the variables COVERALLS_SERVICE_NAME,
+// COVERALLS_SERVICE_NUMBER, COVERALLS_SERVICE_JOB_ID and
+// COVERALLS_PULL_REQUEST override what is detected, and describe builds of
+// other CI services.
+func DetectCI() *CIBuild {
+	build := &CIBuild{}
+	for _, s := range ciServices {
+		if !s.detect() {
+			continue
+		}
+		build = &CIBuild{
+			ServiceName:        s.name,
+			ServiceNumber:      os.Getenv(s.number),
+			ServiceJobID:       os.Getenv(s.jobID),
+			ServicePullRequest: s.pullRequest(),
+			CommitSHA:          os.Getenv(s.commitSHA),
+		}
+		break
+	}
+
+	override := func(field *string, name string) {
+		if value := os.Getenv(name); value != "" {
+			*field = value
+		}
+	}
+	override(&build.ServiceName, "COVERALLS_SERVICE_NAME")
+	override(&build.ServiceNumber, "COVERALLS_SERVICE_NUMBER")
+	override(&build.ServiceJobID, "COVERALLS_SERVICE_JOB_ID")
+	override(&build.ServicePullRequest, "COVERALLS_PULL_REQUEST")
+
+	if build.ServiceName == "" {
+		return nil
+	}
+	return build
+}
+
+// Apply fills in the fields of job that identify the CI build, unless job
+// already sets them
+func (b *CIBuild) Apply(job *Job) {
+	fill := func(field *string, value string) {
+		if *field == "" {
+			*field = value
+		}
+	}
+	fill(&job.ServiceName, b.ServiceName)
+	fill(&job.ServiceNumber, b.ServiceNumber)
+	fill(&job.ServiceJobID, b.ServiceJobID)
+	fill(&job.ServicePullRequest, b.ServicePullRequest)
+	fill(&job.CommitSHA, b.CommitSHA)
+}
+
+// envIs returns whether the environment variable name is set to value
+func envIs(name string, value string) func() bool {
+	return func() bool {
+		return os.Getenv(name) == value
+	}
+}
+
+// envUnless returns the value of the environment variable name, or ""
+// when it is set to none, the value that means it doesn't apply
+func envUnless(name string, none string) func() string {
+	return func() string {
+		if value := os.Getenv(name); value != none {
+			return value
+		}
+		return ""
+	}
+}
+
+// githubPullRequest returns the number of the pull request being built in
+// GitHub Actions, taken from a ref like refs/pull/17/merge
+func githubPullRequest() string {
+	ref := os.Getenv("GITHUB_REF")
+	if !strings.HasPrefix(ref, "refs/pull/") {
+		return ""
+	}
+	return strings.SplitN(strings.TrimPrefix(ref, "refs/pull/"), "/", 2)[0]
+}
+
+// circlePullRequest returns the number of the pull request being built in
+// CircleCI, taken from its URL
+func circlePullRequest() string {
+	url := os.Getenv("CIRCLE_PULL_REQUEST")
+	if url == "" {
+		return ""
+	}
+	return path.Base(url)
+}
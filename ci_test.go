@@ -0,0 +1,129 @@
+/*
+Copyright (c) 2020 Loadsmart, Inc.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package coveralls
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// clearCIEnv unsets the environment variables read by DetectCI
+func clearCIEnv(t *testing.T) {
+	for _, name := range []string{
+		"GITHUB_ACTIONS", "GITHUB_REF", "TRAVIS", "TRAVIS_PULL_REQUEST", "CIRCLECI", "CIRCLE_PULL_REQUEST",
+		"GITLAB_CI", "CI_MERGE_REQUEST_IID", "BUILDKITE", "BUILDKITE_PULL_REQUEST", "JENKINS_URL", "CHANGE_ID",
+		"COVERALLS_SERVICE_NAME", "COVERALLS_SERVICE_NUMBER", "COVERALLS_SERVICE_JOB_ID", "COVERALLS_PULL_REQUEST",
+	} {
+		t.Setenv(name, "")
+	}
+	for _, s := range ciServices {
+		t.Setenv(s.number, "")
+		t.Setenv(s.jobID, "")
+		t.Setenv(s.commitSHA, "")
+	}
+}
+
+func TestDetectCI(t *testing.T) {
+	cases := []struct {
+		name string
+		env  map[string]string
+		want *CIBuild
+	}{
+		{
+			name: "none",
+		},
+		{
+			name: "github pull request",
+			env: map[string]string{
+				"GITHUB_ACTIONS": "true", "GITHUB_RUN_ID": "123", "GITHUB_JOB": "test",
+				"GITHUB_REF": "refs/pull/17/merge", "GITHUB_SHA": "abc123",
+			},
+			want: &CIBuild{ServiceName: "github", ServiceNumber: "123", ServiceJobID: "test", ServicePullRequest: "17", CommitSHA: "abc123"},
+		},
+		{
+			name: "github branch",
+			env:  map[string]string{"GITHUB_ACTIONS": "true", "GITHUB_RUN_ID": "123", "GITHUB_REF": "refs/heads/main"},
+			want: &CIBuild{ServiceName: "github", ServiceNumber: "123"},
+		},
+		{
+			name: "travis",
+			env: map[string]string{
+				"TRAVIS": "true", "TRAVIS_BUILD_NUMBER": "42", "TRAVIS_JOB_ID": "4242",
+				"TRAVIS_PULL_REQUEST": "false", "TRAVIS_COMMIT": "abc123",
+			},
+			want: &CIBuild{ServiceName: "travis-ci", ServiceNumber: "42", ServiceJobID: "4242", CommitSHA: "abc123"},
+		},
+		{
+			name: "circleci",
+			env: map[string]string{
+				"CIRCLECI": "true", "CIRCLE_WORKFLOW_ID": "wf", "CIRCLE_BUILD_NUM": "7",
+				"CIRCLE_PULL_REQUEST": "https://github.com/user/repo/pull/17",
+			},
+			want: &CIBuild{ServiceName: "circleci", ServiceNumber: "wf", ServiceJobID: "7", ServicePullRequest: "17"},
+		},
+		{
+			name: "gitlab",
+			env:  map[string]string{"GITLAB_CI": "true", "CI_PIPELINE_IID": "9", "CI_JOB_ID": "99", "CI_MERGE_REQUEST_IID": "3"},
+			want: &CIBuild{ServiceName: "gitlab-ci", ServiceNumber: "9", ServiceJobID: "99", ServicePullRequest: "3"},
+		},
+		{
+			name: "jenkins",
+			env:  map[string]string{"JENKINS_URL": "https://jenkins.example.com", "BUILD_NUMBER": "5", "BUILD_ID": "5"},
+			want: &CIBuild{ServiceName: "jenkins", ServiceNumber: "5", ServiceJobID: "5"},
+		},
+		{
+			name: "overrides",
+			env: map[string]string{
+				"TRAVIS": "true", "TRAVIS_BUILD_NUMBER": "42", "TRAVIS_PULL_REQUEST": "false",
+				"COVERALLS_SERVICE_NAME": "travis-pro", "COVERALLS_PULL_REQUEST": "8",
+			},
+			want: &CIBuild{ServiceName: "travis-pro", ServiceNumber: "42", ServicePullRequest: "8"},
+		},
+		{
+			name: "other service",
+			env:  map[string]string{"COVERALLS_SERVICE_NAME": "drone", "COVERALLS_SERVICE_JOB_ID": "1"},
+			want: &CIBuild{ServiceName: "drone", ServiceJobID: "1"},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			clearCIEnv(t)
+			for name, value := range tc.env {
+				t.Setenv(name, value)
+			}
+
+			assert.Equal(t, tc.want, DetectCI())
+		})
+	}
+}
+
+func TestCIBuildApply(t *testing.T) {
+	build := &CIBuild{ServiceName: "github", ServiceNumber: "123", ServiceJobID: "test", CommitSHA: "abc123"}
+	job := &Job{ServiceJobID: "custom"}
+
+	build.Apply(job)
+
+	assert.Equal(t, &Job{ServiceName: "github", ServiceNumber: "123", ServiceJobID: "custom", CommitSHA: "abc123"}, job)
+}
@@ -0,0 +1,116 @@
+/*
+Copyright (c) 2020 Loadsmart, Inc.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package coveralls
+
+import (
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned without sending the request when the circuit
+// breaker set with WithCircuitBreaker is open
+var ErrCircuitOpen = errors.New("circuit breaker is open: too many consecutive failures from the API")
+
+// WithCircuitBreaker makes the Client stop sending requests for coolDown
+// after threshold consecutive requests fail with network errors, timeouts
+// or 5xx status codes, so it doesn't hammer the API during an outage.
+// Meanwhile calls fail fast with ErrCircuitOpen.
+//
+// After coolDown, a single request is let through: the breaker closes if
+// it succeeds and stays open for another coolDown if it fails. Retries
+// count as separate requests. Clients made with Client.Clone have a breaker
+// of their own.
+func WithCircuitBreaker(threshold int, coolDown time.Duration) Option {
+	return func(o *options) {
+		o.circuitBreaker = &circuitBreakerSettings{threshold: threshold, coolDown: coolDown}
+	}
+}
+
+// circuitBreakerSettings holds the settings of WithCircuitBreaker
+type circuitBreakerSettings struct {
+	threshold int
+	coolDown  time.Duration
+}
+
+// circuitBreaker tracks the failures of requests and tells whether new ones may be sent
+type circuitBreaker struct {
+	settings circuitBreakerSettings
+	now      func() time.Time
+
+	mu       sync.Mutex
+	failures int       // Consecutive failures
+	openedAt time.Time // When the breaker opened. Zero if closed
+	probing  bool      // Whether a request is being let through after the cool-down
+}
+
+func newCircuitBreaker(settings circuitBreakerSettings) *circuitBreaker {
+	return &circuitBreaker{settings: settings, now: time.Now}
+}
+
+// allow returns ErrCircuitOpen if a request may not be sent now
+func (b *circuitBreaker) allow() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.openedAt.IsZero() {
+		return nil
+	}
+	if b.probing || b.now().Sub(b.openedAt) < b.settings.coolDown {
+		return ErrCircuitOpen
+	}
+	b.probing = true
+	return nil
+}
+
+// record updates the breaker with the outcome of a request
+func (b *circuitBreaker) record(failed bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.probing = false
+	if !failed {
+		b.failures = 0
+		b.openedAt = time.Time{}
+		return
+	}
+
+	b.failures++
+	if !b.openedAt.IsZero() || b.failures >= b.settings.threshold {
+		b.openedAt = b.now()
+	}
+}
+
+// release lets another request through after the cool-down, for when the
+// one let through ended without telling whether the API recovered
+func (b *circuitBreaker) release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.probing = false
+}
+
+// breakerFailure reports whether a response with status code counts as a failure
+func breakerFailure(code int) bool {
+	return code >= http.StatusInternalServerError
+}
@@ -0,0 +1,88 @@
+/*
+Copyright (c) 2020 Loadsmart, Inc.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package coveralls
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	b := newCircuitBreaker(circuitBreakerSettings{threshold: 2, coolDown: time.Minute})
+	b.now = func() time.Time { return now }
+
+	assert.Nil(t, b.allow())
+	b.record(true)
+	assert.Nil(t, b.allow())
+	b.record(true)
+	assert.Equal(t, ErrCircuitOpen, b.allow())
+
+	now = now.Add(time.Minute)
+	assert.Nil(t, b.allow(), "one request is let through after the cool-down")
+	assert.Equal(t, ErrCircuitOpen, b.allow(), "only one request is let through")
+	b.record(true)
+	assert.Equal(t, ErrCircuitOpen, b.allow(), "failing after the cool-down opens the breaker again")
+
+	now = now.Add(time.Minute)
+	assert.Nil(t, b.allow())
+	b.release()
+	assert.Nil(t, b.allow())
+	b.record(false)
+	assert.Nil(t, b.allow())
+	assert.Nil(t, b.allow())
+}
+
+func TestCircuitBreakerResetsOnSuccess(t *testing.T) {
+	b := newCircuitBreaker(circuitBreakerSettings{threshold: 2, coolDown: time.Minute})
+
+	b.record(true)
+	b.record(false)
+	b.record(true)
+
+	assert.Nil(t, b.allow())
+}
+
+func TestWithCircuitBreaker(t *testing.T) {
+	requests := 0
+	transport := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		requests++
+		return &http.Response{StatusCode: http.StatusBadGateway, Body: http.NoBody, Header: http.Header{}}, nil
+	})
+	client := NewClient("fake token", WithTransport(transport), WithCircuitBreaker(3, time.Hour))
+
+	for i := 0; i < 5; i++ {
+		_, err := client.Repositories.Get(context.Background(), "github", "user/fakerepo")
+		if i < 3 {
+			assert.True(t, errors.Is(err, ErrUnexpectedStatusCode{StatusCode: http.StatusBadGateway}))
+		} else {
+			assert.Equal(t, ErrCircuitOpen, err)
+		}
+	}
+	assert.Equal(t, 3, requests)
+}
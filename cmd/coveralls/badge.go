@@ -0,0 +1,127 @@
+/*
+Copyright (c) 2020 Loadsmart, Inc.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+
+	coveralls "github.com/stone-payments/go-coveralls-api"
+)
+
+// badge is the result of coveralls badge
+type badge struct {
+	URL      string `json:"url"`
+	Markdown string `json:"markdown"`
+}
+
+// badgeFlags are the flags of coveralls badge
+type badgeFlags struct {
+	global   globalFlags
+	branch   string
+	markdown bool
+	url      bool
+	inject   string
+	check    bool
+}
+
+// register defines the flags in fs
+func (f *badgeFlags) register(fs *flag.FlagSet) {
+	f.global.register(fs)
+	fs.StringVar(&f.branch, "branch", "", "branch whose coverage the badge shows (default the default branch)")
+	fs.BoolVar(&f.markdown, "markdown", true, "print the badge as Markdown, linking to the repository page; --markdown=false is like --url")
+	fs.BoolVar(&f.url, "url", false, "print the address of the badge image instead of Markdown")
+	fs.StringVar(&f.inject, "inject", "", "README file to add or update the badge in, between <!-- coveralls-badge --> markers")
+	fs.BoolVar(&f.check, "check", false, "with --inject, fail instead of changing the file if the badge is missing or outdated")
+}
+
+// badgeCommand runs coveralls badge, which prints the coverage badge of a
+// repository or injects it into a README
+func badgeCommand(ctx context.Context, args []string, stdout io.Writer, stderr io.Writer) error {
+	fs := flag.NewFlagSet("coveralls badge", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	var f badgeFlags
+	f.register(fs)
+	global := &f.global
+	positional, err := global.parse(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 {
+		return usageError{"badge: expected one repository, as SERVICE/NAME"}
+	}
+	svc, name, err := parseRepo(positional[0], global.service)
+	if err != nil {
+		return err
+	}
+
+	// Badges are public, so no token is needed
+	client, err := global.clientWithToken(global.token)
+	if err != nil {
+		return err
+	}
+	b := badge{
+		URL:      client.Badges.URL(svc, name, f.branch),
+		Markdown: client.Badges.Markdown(svc, name, f.branch),
+	}
+	if f.inject != "" {
+		return injectBadge(f.inject, b.Markdown, f.check)
+	}
+	switch {
+	case global.output.name != "":
+		return global.print(stdout, b)
+	case f.url || !f.markdown:
+		_, err = fmt.Fprintln(stdout, b.URL)
+	default:
+		_, err = fmt.Fprintln(stdout, b.Markdown)
+	}
+	return err
+}
+
+// injectBadge adds or updates markdown in the README file name. With
+// check, it returns an error instead of changing the file.
+func injectBadge(name string, markdown string, check bool) error {
+	readme, err := ioutil.ReadFile(name)
+	if err != nil {
+		return err
+	}
+	updated, err := coveralls.InjectBadge(readme, markdown)
+	if err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	if string(updated) == string(readme) {
+		return nil
+	}
+	if check {
+		return fmt.Errorf("%s: the coverage badge is missing or outdated", name)
+	}
+	info, err := os.Stat(name)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(name, updated, info.Mode())
+}
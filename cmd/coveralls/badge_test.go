@@ -0,0 +1,82 @@
+/*
+Copyright (c) 2020 Loadsmart, Inc.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	coveralls "github.com/stone-payments/go-coveralls-api"
+)
+
+func TestBadge(t *testing.T) {
+	markdown := "[![Coverage Status](https://coveralls.io/repos/github/user/repository/badge.svg?branch=main)](https://coveralls.io/github/user/repository?branch=main)\n"
+
+	var testCases = []struct {
+		name   string
+		args   []string
+		stdout string
+	}{
+		{name: "markdown", args: []string{"--markdown"}, stdout: markdown},
+		{name: "url", args: []string{"--url"}, stdout: "https://coveralls.io/repos/github/user/repository/badge.svg?branch=main\n"},
+		{name: "no markdown", args: []string{"--markdown=false"}, stdout: "https://coveralls.io/repos/github/user/repository/badge.svg?branch=main\n"},
+		{name: "template", args: []string{"--output", "go-template={{.url}}"}, stdout: "https://coveralls.io/repos/github/user/repository/badge.svg?branch=main"},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(envEndpoint, "")
+			code, stdout, stderr := runCommand(t, nil, append([]string{"badge", "github/user/repository", "--branch", "main"}, tt.args...)...)
+
+			assert.Equal(t, exitOK, code, stderr)
+			assert.Equal(t, tt.stdout, stdout)
+		})
+	}
+}
+
+func TestBadgeInject(t *testing.T) {
+	t.Setenv(envEndpoint, "")
+	readme := filepath.Join(t.TempDir(), "README.md")
+	require.NoError(t, os.WriteFile(readme, []byte("# Project\n\nSome text.\n"), 0o644))
+
+	code, _, stderr := runCommand(t, nil, "badge", "github/user/repository", "--inject", readme, "--check")
+
+	assert.Equal(t, exitError, code)
+	assert.Contains(t, stderr, "missing or outdated")
+
+	code, stdout, stderr := runCommand(t, nil, "badge", "github/user/repository", "--inject", readme)
+
+	assert.Equal(t, exitOK, code, stderr)
+	assert.Empty(t, stdout)
+	b, err := os.ReadFile(readme)
+	require.NoError(t, err)
+	assert.Equal(t, "# Project\n\n"+coveralls.BadgeStartMarker+"\n[![Coverage Status](https://coveralls.io/repos/github/user/repository/badge.svg)](https://coveralls.io/github/user/repository)\n"+coveralls.BadgeEndMarker+"\n\nSome text.\n", string(b))
+
+	code, _, stderr = runCommand(t, nil, "badge", "github/user/repository", "--inject", readme, "--check")
+
+	assert.Equal(t, exitOK, code, stderr)
+}
@@ -0,0 +1,153 @@
+/*
+Copyright (c) 2020 Loadsmart, Inc.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+
+	coveralls "github.com/stone-payments/go-coveralls-api"
+	"github.com/stone-payments/go-coveralls-api/gate"
+	"github.com/stone-payments/go-coveralls-api/report"
+)
+
+// errViolations is returned by coveralls check when the report breaks a threshold
+var errViolations = errors.New("coverage below thresholds")
+
+// checkResult is the result of coveralls check
+type checkResult struct {
+	Coverage   float64          `json:"coverage"`
+	Baseline   *float64         `json:"baseline,omitempty"` // Coverage of the latest build of the base branch
+	Violations []gate.Violation `json:"violations"`
+}
+
+func (r *checkResult) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "coverage: %.2f%%", r.Coverage)
+	if r.Baseline != nil {
+		fmt.Fprintf(&b, " (base %.2f%%)", *r.Baseline)
+	}
+	b.WriteString("\n")
+	for _, v := range r.Violations {
+		fmt.Fprintf(&b, "%s\n", v)
+	}
+	return b.String()
+}
+
+// checkFlags are the flags of coveralls check
+type checkFlags struct {
+	reports    reportFlags
+	global     globalFlags
+	exclude    stringsFlag
+	root       string
+	minTotal   optionalFloat
+	maxDrop    optionalFloat
+	perFileMin optionalFloat
+	base       string
+}
+
+// register defines the flags in fs
+func (f *checkFlags) register(fs *flag.FlagSet) {
+	f.reports.register(fs)
+	f.global.register(fs)
+	fs.Var(&f.exclude, "exclude", "glob of files to leave out, e.g. '**/*_test.go' (repeatable)")
+	fs.StringVar(&f.root, "root", ".", "root of the repository, where source files are read from")
+	fs.Var(&f.minTotal, "min", "minimum coverage of the report")
+	fs.Var(&f.maxDrop, "max-drop", "maximum decrease of coverage from the base branch, in percentage points")
+	fs.Var(&f.perFileMin, "per-file-min", "minimum coverage of each file")
+	fs.StringVar(&f.base, "base", "", "branch whose latest build in Coveralls is the baseline of --max-drop, e.g. main")
+}
+
+// checkCommand runs coveralls check, which checks local coverage reports
+// against thresholds
+func checkCommand(ctx context.Context, args []string, stdout io.Writer, stderr io.Writer) error {
+	fs := flag.NewFlagSet("coveralls check", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	var f checkFlags
+	f.register(fs)
+	global := &f.global
+	positional, err := global.parse(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) > 1 {
+		return usageError{fmt.Sprintf("check: unexpected argument %q", positional[1])}
+	}
+	if (f.base != "" || f.maxDrop.value != nil) && (len(positional) == 0 || f.base == "") {
+		return usageError{"check: --max-drop needs --base and the repository, as SERVICE/NAME"}
+	}
+
+	merged, err := f.reports.parse(f.root)
+	if err != nil {
+		return err
+	}
+	merged = merged.Filter(report.Exclude(f.exclude...))
+	var opts []gate.Option
+	if f.minTotal.value != nil {
+		opts = append(opts, gate.MinTotal(*f.minTotal.value))
+	}
+	if f.maxDrop.value != nil {
+		opts = append(opts, gate.MaxDrop(*f.maxDrop.value))
+	}
+	if f.perFileMin.value != nil {
+		opts = append(opts, gate.PerFileMin(*f.perFileMin.value))
+	}
+
+	result := &checkResult{Coverage: merged.Coverage()}
+	if f.base != "" {
+		svc, name, err := parseRepo(positional[0], global.service)
+		if err != nil {
+			return err
+		}
+		// Builds of public repositories can be read without a token
+		client, err := global.clientWithToken(global.token)
+		if err != nil {
+			return err
+		}
+		build, err := client.Builds.LatestForBranch(ctx, svc, name, f.base)
+		if err != nil {
+			return fmt.Errorf("getting the coverage of %s: %w", f.base, err)
+		}
+		if build.CoveredPercent == nil {
+			return fmt.Errorf("getting the coverage of %s: %w", f.base, coveralls.ErrBuildNotFound)
+		}
+		result.Baseline = build.CoveredPercent
+		opts = append(opts, gate.Baseline(*build.CoveredPercent))
+	}
+	result.Violations = gate.Check(merged, opts...)
+	if result.Violations == nil {
+		result.Violations = []gate.Violation{}
+	}
+
+	if err := global.print(stdout, result); err != nil {
+		return err
+	}
+	if len(result.Violations) > 0 {
+		return fmt.Errorf("%w: %d violations", errViolations, len(result.Violations))
+	}
+	return nil
+}
@@ -0,0 +1,126 @@
+/*
+Copyright (c) 2020 Loadsmart, Inc.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// checkLCOV is a report with 75% coverage: 50% in main.go and 100% in util.go
+const checkLCOV = `SF:main.go
+DA:1,1
+DA:2,1
+DA:3,0
+DA:4,0
+end_of_record
+SF:util.go
+DA:1,1
+DA:2,1
+DA:3,1
+DA:4,1
+end_of_record
+`
+
+func TestCheck(t *testing.T) {
+	lcov := filepath.Join(t.TempDir(), "lcov.info")
+	require.NoError(t, os.WriteFile(lcov, []byte(checkLCOV), 0o600))
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/github/user/repository.json", r.URL.Path)
+		assert.Equal(t, "main", r.URL.Query().Get("branch"))
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"page": 1, "pages": 1, "total": 1, "builds": [{"branch": "main", "covered_percent": 76}]}`))
+	}))
+	defer server.Close()
+
+	var testCases = []struct {
+		name   string
+		args   []string
+		code   int
+		stdout string
+	}{
+		{
+			name:   "pass",
+			args:   []string{"--min", "70"},
+			code:   exitOK,
+			stdout: "coverage: 75.00%\n",
+		},
+		{
+			name:   "below minimum",
+			args:   []string{"--min", "80", "--per-file-min", "60"},
+			code:   exitError,
+			stdout: "coverage: 75.00%\ncoverage 75.00% is below the minimum of 80.00%\ncoverage of main.go 50.00% is below the minimum of 60.00%\n",
+		},
+		{
+			name:   "drop within maximum",
+			args:   []string{"--max-drop", "1", "--base", "main", "github/user/repository"},
+			code:   exitOK,
+			stdout: "coverage: 75.00% (base 76.00%)\n",
+		},
+		{
+			name:   "drop above maximum",
+			args:   []string{"--max-drop", "0.5", "--base", "main", "github/user/repository"},
+			code:   exitError,
+			stdout: "coverage: 75.00% (base 76.00%)\ncoverage dropped 1.00 points, more than the maximum of 0.50\n",
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			code, stdout, stderr := runCommand(t, server, append([]string{"check", "--lcov", lcov}, tt.args...)...)
+
+			assert.Equal(t, tt.code, code, stderr)
+			assert.Equal(t, tt.stdout, stdout)
+		})
+	}
+}
+
+func TestCheckJSON(t *testing.T) {
+	lcov := filepath.Join(t.TempDir(), "lcov.info")
+	require.NoError(t, os.WriteFile(lcov, []byte(checkLCOV), 0o600))
+
+	code, stdout, stderr := runCommand(t, nil, "check", "--lcov", lcov, "--per-file-min", "60", "--exclude", "util.go", "--json")
+
+	assert.Equal(t, exitError, code)
+	assert.Contains(t, stderr, "coverage below thresholds: 1 violations")
+	assert.JSONEq(t, `{"coverage": 50, "violations": [{"rule": "per_file_min", "file": "main.go", "actual": 50, "threshold": 60}]}`, stdout)
+}
+
+func TestCheckUsage(t *testing.T) {
+	for _, args := range [][]string{
+		{"--max-drop", "1"},
+		{"--max-drop", "1", "github/user/repository"},
+		{"--base", "main"},
+		{"a", "b"},
+	} {
+		code, _, _ := runCommand(t, nil, append([]string{"check", "--lcov", "lcov.info"}, args...)...)
+
+		assert.Equal(t, exitUsage, code, args)
+	}
+}
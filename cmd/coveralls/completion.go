@@ -0,0 +1,279 @@
+/*
+Copyright (c) 2020 Loadsmart, Inc.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// Commands that describe the other commands are added at init, to break the
+// initialization cycle of commands
+func init() {
+	commands["completion"] = command{run: completionCommand, args: shells}
+	commands["man"] = command{run: manCommand}
+}
+
+// shells are the shells coveralls completion writes scripts for
+var shells = []string{"bash", "zsh", "fish", "powershell"}
+
+// commandSpec describes a command for shell completion and the man page
+type commandSpec struct {
+	Name        string
+	Args        []string      // Values of the first argument, e.g. the shells of coveralls completion
+	Flags       []*flag.Flag  // Flags, sorted by name
+	Subcommands []commandSpec // Subcommands, e.g. of coveralls repo, which have the flags instead
+}
+
+// choices returns the words completed after the command: its subcommands
+// or the values of its first argument
+func (c commandSpec) choices() []string {
+	if len(c.Subcommands) == 0 {
+		return c.Args
+	}
+	names := make([]string, len(c.Subcommands))
+	for i, sub := range c.Subcommands {
+		names[i] = sub.Name
+	}
+	return names
+}
+
+// commandTree describes the commands, sorted by name, with the flags they
+// define
+func commandTree() []commandSpec {
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	specs := make([]commandSpec, len(names))
+	for i, name := range names {
+		c := commands[name]
+		specs[i] = commandSpec{Name: name, Args: c.args}
+		if len(c.subcommands) == 0 {
+			specs[i].Flags = definedFlags(c.flags)
+			continue
+		}
+		for _, sub := range c.subcommands {
+			specs[i].Subcommands = append(specs[i].Subcommands, commandSpec{Name: sub.name, Flags: definedFlags(sub.flags)})
+		}
+	}
+	return specs
+}
+
+// definedFlags returns the flags defined by define, which may be nil,
+// sorted by name
+func definedFlags(define func(fs *flag.FlagSet)) []*flag.Flag {
+	fs := flag.NewFlagSet("", flag.ContinueOnError)
+	if define != nil {
+		define(fs)
+	}
+	var flags []*flag.Flag
+	fs.VisitAll(func(f *flag.Flag) {
+		flags = append(flags, f)
+	})
+	return flags
+}
+
+// isBoolFlag reports whether f is given without a value
+func isBoolFlag(f *flag.Flag) bool {
+	b, ok := f.Value.(interface{ IsBoolFlag() bool })
+	return ok && b.IsBoolFlag()
+}
+
+// flagWords returns the names of flags as given in the command line
+func flagWords(flags []*flag.Flag) string {
+	words := make([]string, len(flags))
+	for i, f := range flags {
+		words[i] = "--" + f.Name
+	}
+	return strings.Join(words, " ")
+}
+
+// completionCommand runs coveralls completion, which writes the completion
+// script of a shell
+func completionCommand(ctx context.Context, args []string, stdout io.Writer, stderr io.Writer) error {
+	fs := flag.NewFlagSet("coveralls completion", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	positional, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 {
+		return usageError{"completion: expected one shell: " + strings.Join(shells, ", ")}
+	}
+
+	specs := commandTree()
+	switch positional[0] {
+	case "bash":
+		writeBashCompletion(stdout, specs)
+	case "zsh":
+		fmt.Fprint(stdout, "autoload -U +X bashcompinit && bashcompinit\n\n")
+		writeBashCompletion(stdout, specs)
+	case "fish":
+		writeFishCompletion(stdout, specs)
+	case "powershell":
+		writePowerShellCompletion(stdout, specs)
+	default:
+		return usageError{fmt.Sprintf("completion: unknown shell %q", positional[0])}
+	}
+	return nil
+}
+
+// writeBashCompletion writes the completion script of bash, which zsh
+// also runs with bashcompinit
+func writeBashCompletion(w io.Writer, specs []commandSpec) {
+	names := make([]string, len(specs))
+	for i, spec := range specs {
+		names[i] = spec.Name
+	}
+
+	fmt.Fprint(w, "# Completion of coveralls, written by coveralls completion\n")
+	fmt.Fprint(w, "_coveralls() {\n")
+	fmt.Fprint(w, "\tlocal cur=\"${COMP_WORDS[COMP_CWORD]}\" choices=\"\" flags=\"\"\n")
+	fmt.Fprint(w, "\tif [ \"$COMP_CWORD\" -eq 1 ]; then\n")
+	fmt.Fprintf(w, "\t\tchoices=%q\n", strings.Join(names, " "))
+	fmt.Fprint(w, "\telse\n")
+	fmt.Fprint(w, "\t\tcase \"${COMP_WORDS[1]}\" in\n")
+	for _, spec := range specs {
+		fmt.Fprintf(w, "\t\t%s)\n", spec.Name)
+		if len(spec.Subcommands) == 0 {
+			fmt.Fprintf(w, "\t\t\tflags=%q\n", flagWords(spec.Flags))
+			if len(spec.Args) > 0 {
+				fmt.Fprintf(w, "\t\t\t[ \"$COMP_CWORD\" -eq 2 ] && choices=%q\n", strings.Join(spec.Args, " "))
+			}
+			fmt.Fprint(w, "\t\t\t;;\n")
+			continue
+		}
+		fmt.Fprint(w, "\t\t\tif [ \"$COMP_CWORD\" -eq 2 ]; then\n")
+		fmt.Fprintf(w, "\t\t\t\tchoices=%q\n", strings.Join(spec.choices(), " "))
+		fmt.Fprint(w, "\t\t\telse\n")
+		fmt.Fprint(w, "\t\t\t\tcase \"${COMP_WORDS[2]}\" in\n")
+		for _, sub := range spec.Subcommands {
+			fmt.Fprintf(w, "\t\t\t\t%s) flags=%q ;;\n", sub.Name, flagWords(sub.Flags))
+		}
+		fmt.Fprint(w, "\t\t\t\tesac\n")
+		fmt.Fprint(w, "\t\t\tfi\n")
+		fmt.Fprint(w, "\t\t\t;;\n")
+	}
+	fmt.Fprint(w, "\t\tesac\n")
+	fmt.Fprint(w, "\tfi\n\n")
+	fmt.Fprint(w, "\tif [[ \"$cur\" == -* ]]; then\n")
+	fmt.Fprint(w, "\t\tCOMPREPLY=($(compgen -W \"$flags\" -- \"$cur\"))\n")
+	fmt.Fprint(w, "\telif [ -n \"$choices\" ]; then\n")
+	fmt.Fprint(w, "\t\tCOMPREPLY=($(compgen -W \"$choices\" -- \"$cur\"))\n")
+	fmt.Fprint(w, "\telse\n")
+	fmt.Fprint(w, "\t\tCOMPREPLY=($(compgen -f -- \"$cur\"))\n")
+	fmt.Fprint(w, "\tfi\n")
+	fmt.Fprint(w, "}\n")
+	fmt.Fprint(w, "complete -o filenames -F _coveralls coveralls\n")
+}
+
+// writeFishCompletion writes the completion script of fish
+func writeFishCompletion(w io.Writer, specs []commandSpec) {
+	names := make([]string, len(specs))
+	for i, spec := range specs {
+		names[i] = spec.Name
+	}
+
+	fmt.Fprint(w, "# Completion of coveralls, written by coveralls completion\n")
+	fmt.Fprintf(w, "complete -c coveralls -n __fish_use_subcommand -f -a %s\n", fishQuote(strings.Join(names, " ")))
+	for _, spec := range specs {
+		seen := "__fish_seen_subcommand_from " + spec.Name
+		if choices := spec.choices(); len(choices) > 0 {
+			words := strings.Join(choices, " ")
+			fmt.Fprintf(w, "complete -c coveralls -n %s -f -a %s\n", fishQuote(seen+"; and not __fish_seen_subcommand_from "+words), fishQuote(words))
+		}
+		writeFishFlags(w, seen, spec.Flags)
+		for _, sub := range spec.Subcommands {
+			writeFishFlags(w, seen+"; and __fish_seen_subcommand_from "+sub.Name, sub.Flags)
+		}
+	}
+}
+
+// writeFishFlags writes the completion of flags when condition holds
+func writeFishFlags(w io.Writer, condition string, flags []*flag.Flag) {
+	for _, f := range flags {
+		_, usage := flag.UnquoteUsage(f)
+		required := " -r"
+		if isBoolFlag(f) {
+			required = ""
+		}
+		fmt.Fprintf(w, "complete -c coveralls -n %s -l %s%s -d %s\n", fishQuote(condition), f.Name, required, fishQuote(usage))
+	}
+}
+
+// fishQuote quotes s for fish
+func fishQuote(s string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(s) + "'"
+}
+
+// writePowerShellCompletion writes the completion script of PowerShell
+func writePowerShellCompletion(w io.Writer, specs []commandSpec) {
+	words := map[string][]string{}
+	var names []string
+	for _, spec := range specs {
+		names = append(names, spec.Name)
+		var specWords []string
+		specWords = append(specWords, spec.choices()...)
+		words[spec.Name] = append(specWords, strings.Fields(flagWords(spec.Flags))...)
+		for _, sub := range spec.Subcommands {
+			words[spec.Name+" "+sub.Name] = strings.Fields(flagWords(sub.Flags))
+		}
+	}
+	words[""] = names
+	keys := make([]string, 0, len(words))
+	for key := range words {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	fmt.Fprint(w, "# Completion of coveralls, written by coveralls completion\n")
+	fmt.Fprint(w, "Register-ArgumentCompleter -Native -CommandName coveralls -ScriptBlock {\n")
+	fmt.Fprint(w, "\tparam($wordToComplete, $commandAst, $cursorPosition)\n")
+	fmt.Fprint(w, "\t$completions = @{\n")
+	for _, key := range keys {
+		quoted := make([]string, len(words[key]))
+		for i, word := range words[key] {
+			quoted[i] = "'" + word + "'"
+		}
+		fmt.Fprintf(w, "\t\t'%s' = @(%s)\n", key, strings.Join(quoted, ", "))
+	}
+	fmt.Fprint(w, "\t}\n")
+	fmt.Fprint(w, "\t$words = @($commandAst.CommandElements | Select-Object -Skip 1 | ForEach-Object { $_.ToString() })\n")
+	fmt.Fprint(w, "\tif ($wordToComplete) { $words = @($words | Select-Object -SkipLast 1) }\n")
+	fmt.Fprint(w, "\t$words = @($words | Where-Object { -not $_.StartsWith('-') })\n")
+	fmt.Fprint(w, "\t$key = ''\n")
+	fmt.Fprint(w, "\tif ($words.Count -ge 2 -and $completions.ContainsKey(\"$($words[0]) $($words[1])\")) { $key = \"$($words[0]) $($words[1])\" }\n")
+	fmt.Fprint(w, "\telseif ($words.Count -ge 1) { $key = $words[0] }\n")
+	fmt.Fprint(w, "\tif (-not $completions.ContainsKey($key)) { return }\n")
+	fmt.Fprint(w, "\t$completions[$key] | Where-Object { $_ -like \"$wordToComplete*\" } | ForEach-Object {\n")
+	fmt.Fprint(w, "\t\t[System.Management.Automation.CompletionResult]::new($_, $_, 'ParameterValue', $_)\n")
+	fmt.Fprint(w, "\t}\n")
+	fmt.Fprint(w, "}\n")
+}
@@ -0,0 +1,121 @@
+/*
+Copyright (c) 2020 Loadsmart, Inc.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompletion(t *testing.T) {
+	var testCases = []struct {
+		shell    string
+		expected []string
+	}{
+		{
+			shell: "bash",
+			expected: []string{
+				`choices="badge check completion done drift man repo submit sync ui wait"`,
+				`choices="get add update ensure"`,
+				`get) flags="--endpoint --json --output --profile --token --yaml" ;;`,
+				"complete -o filenames -F _coveralls coveralls\n",
+			},
+		},
+		{
+			shell: "zsh",
+			expected: []string{
+				"autoload -U +X bashcompinit && bashcompinit\n",
+				`[ "$COMP_CWORD" -eq 2 ] && choices="bash zsh fish powershell"`,
+			},
+		},
+		{
+			shell: "fish",
+			expected: []string{
+				"complete -c coveralls -n '__fish_seen_subcommand_from repo; and not __fish_seen_subcommand_from get add update ensure' -f -a 'get add update ensure'\n",
+				"complete -c coveralls -n '__fish_seen_subcommand_from wait' -l timeout -r -d 'how long to wait'\n",
+				"complete -c coveralls -n '__fish_seen_subcommand_from sync' -l dry-run -d",
+			},
+		},
+		{
+			shell: "powershell",
+			expected: []string{
+				"Register-ArgumentCompleter -Native -CommandName coveralls",
+				"'repo update' = @('--comment-on-pull-requests', '--endpoint',",
+			},
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.shell, func(t *testing.T) {
+			code, stdout, stderr := runCommand(t, nil, "completion", tt.shell)
+
+			assert.Equal(t, exitOK, code, stderr)
+			for _, s := range tt.expected {
+				assert.Contains(t, stdout, s)
+			}
+		})
+	}
+
+	code, _, stderr := runCommand(t, nil, "completion", "tcsh")
+
+	assert.Equal(t, exitUsage, code)
+	assert.Contains(t, stderr, `unknown shell "tcsh"`)
+}
+
+func TestMan(t *testing.T) {
+	code, stdout, stderr := runCommand(t, nil, "man")
+
+	assert.Equal(t, exitOK, code, stderr)
+	assert.True(t, strings.HasPrefix(stdout, ".TH COVERALLS 1 "))
+	assert.Contains(t, stdout, ".SS coveralls repo ensure\n")
+	assert.Contains(t, stdout, ".SS coveralls completion bash|zsh|fish|powershell\n")
+	assert.Contains(t, stdout, ".BI \\-\\-timeout \" duration\"\nhow long to wait (default 10m0s)\n")
+	assert.Contains(t, stdout, ".B \\-\\-dry\\-run\n")
+	assert.NotContains(t, stdout, "fake-token")
+}
+
+func TestCommandTreeFlags(t *testing.T) {
+	for _, spec := range commandTree() {
+		cases := []commandSpec{spec}
+		if len(spec.Subcommands) > 0 {
+			cases = spec.Subcommands
+		}
+		for _, c := range cases {
+			args := []string{spec.Name, "-h"}
+			if c.Name != spec.Name {
+				args = []string{spec.Name, c.Name, "-h"}
+			}
+			t.Run(strings.Join(args[:len(args)-1], " "), func(t *testing.T) {
+				code, _, stderr := runCommand(t, nil, args...)
+
+				assert.Equal(t, exitOK, code)
+				assert.Equal(t, len(c.Flags), strings.Count(stderr, "\n  -"))
+				for _, f := range c.Flags {
+					assert.Contains(t, stderr, "  -"+f.Name)
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,106 @@
+/*
+Copyright (c) 2020 Loadsmart, Inc.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+// config is the configuration file of the command, by default
+// ~/.config/coveralls/config.yaml:
+//
+//	default_profile: cloud
+//	profiles:
+//	  cloud:
+//	    token: your-personal-access-token
+//	    service: github
+//	  enterprise:
+//	    token: another-token
+//	    endpoint: https://coveralls.example.com
+type config struct {
+	DefaultProfile string              `yaml:"default_profile"` // Profile used without --profile
+	Profiles       map[string]*profile `yaml:"profiles"`
+}
+
+// profile holds the settings to reach a Coveralls server
+type profile struct {
+	Token    string `yaml:"token"`    // Personal access token
+	Endpoint string `yaml:"endpoint"` // Address of a self-hosted server, empty for coveralls.io
+	Service  string `yaml:"service"`  // Service of repositories given without one, e.g. github
+}
+
+// configPath returns the path of the configuration file: $COVERALLS_CONFIG,
+// or coveralls/config.yaml in $XDG_CONFIG_HOME or ~/.config
+func configPath() (string, error) {
+	if path := os.Getenv(envConfig); path != "" {
+		return path, nil
+	}
+	dir := os.Getenv("XDG_CONFIG_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		dir = filepath.Join(home, ".config")
+	}
+	return filepath.Join(dir, "coveralls", "config.yaml"), nil
+}
+
+// readConfig reads the configuration file at path. A missing file is an
+// empty configuration.
+func readConfig(path string) (*config, error) {
+	b, err := ioutil.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return &config{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var c config
+	if err := yaml.UnmarshalStrict(b, &c); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return &c, nil
+}
+
+// profile returns the profile called name, or the default profile if name
+// is empty. It returns nil if there is no default profile.
+func (c *config) profile(name string) (*profile, error) {
+	if name == "" {
+		name = c.DefaultProfile
+	}
+	if name == "" {
+		return nil, nil
+	}
+	p, ok := c.Profiles[name]
+	if !ok || p == nil {
+		return nil, usageError{fmt.Sprintf("unknown profile %q", name)}
+	}
+	return p, nil
+}
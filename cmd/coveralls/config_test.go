@@ -0,0 +1,150 @@
+/*
+Copyright (c) 2020 Loadsmart, Inc.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadConfig(t *testing.T) {
+	dir := t.TempDir()
+
+	c, err := readConfig(filepath.Join(dir, "missing.yaml"))
+	require.NoError(t, err)
+	assert.Equal(t, &config{}, c)
+
+	path := filepath.Join(dir, "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("profiles:\n  cloud:\n    tokn: typo\n"), 0o600))
+	_, err = readConfig(path)
+	assert.Error(t, err, "unknown fields are errors")
+}
+
+func TestConfigProfile(t *testing.T) {
+	c := &config{DefaultProfile: "cloud", Profiles: map[string]*profile{
+		"cloud":      {Token: "cloud-token"},
+		"enterprise": {Token: "enterprise-token"},
+	}}
+
+	p, err := c.profile("")
+	require.NoError(t, err)
+	assert.Equal(t, "cloud-token", p.Token)
+
+	p, err = c.profile("enterprise")
+	require.NoError(t, err)
+	assert.Equal(t, "enterprise-token", p.Token)
+
+	_, err = c.profile("staging")
+	assert.Equal(t, usageError{`unknown profile "staging"`}, err)
+
+	p, err = (&config{}).profile("")
+	assert.NoError(t, err)
+	assert.Nil(t, p)
+}
+
+func TestProfiles(t *testing.T) {
+	var token, path string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token = r.Header.Get("Authorization")
+		path = r.URL.Path
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"id": 42}`))
+	}))
+	defer server.Close()
+
+	var testCases = []struct {
+		name     string
+		args     []string
+		envToken string
+		token    string
+		path     string
+		code     int
+	}{
+		{name: "default profile", args: []string{"user/repository"}, token: "token default-token", path: "/api/repos/gitlab/user/repository"},
+		{name: "environment over default profile", args: []string{"github/user/repository"}, envToken: "env-token", token: "token env-token", path: "/api/repos/github/user/repository"},
+		{name: "chosen profile over environment", args: []string{"--profile", "other", "user/repository"}, envToken: "env-token", token: "token other-token", path: "/api/repos/github/user/repository"},
+		{name: "flag over chosen profile", args: []string{"--profile", "other", "--token", "flag-token", "user/repository"}, token: "token flag-token", path: "/api/repos/github/user/repository"},
+		{name: "unknown profile", args: []string{"--profile", "staging", "user/repository"}, code: exitUsage},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			config := filepath.Join(t.TempDir(), "config.yaml")
+			require.NoError(t, os.WriteFile(config, []byte(fmt.Sprintf(`
+default_profile: default
+profiles:
+  default:
+    token: default-token
+    endpoint: %s
+    service: gitlab
+  other:
+    token: other-token
+    endpoint: %s
+    service: github
+`, server.URL, server.URL)), 0o600))
+			t.Setenv(envConfig, config)
+			t.Setenv(envProfile, "")
+			t.Setenv(envAPIToken, tt.envToken)
+			t.Setenv(envEndpoint, "")
+			token, path = "", ""
+
+			var stdout, stderr bytes.Buffer
+			code := run(context.Background(), append([]string{"repo", "get"}, tt.args...), &stdout, &stderr)
+
+			assert.Equal(t, tt.code, code, stderr.String())
+			assert.Equal(t, tt.token, token)
+			assert.Equal(t, tt.path, path)
+		})
+	}
+}
+
+func TestParseRepoDefaultService(t *testing.T) {
+	var testCases = []struct {
+		arg  string
+		svc  string
+		name string
+	}{
+		{arg: "user/repository", svc: "github", name: "user/repository"},
+		{arg: "gitlab/user/repository", svc: "gitlab", name: "user/repository"},
+		{arg: "repository", svc: "github", name: "repository"},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.arg, func(t *testing.T) {
+			svc, name, err := parseRepo(tt.arg, "github")
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.svc, svc)
+			assert.Equal(t, tt.name, name)
+		})
+	}
+}
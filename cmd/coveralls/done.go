@@ -0,0 +1,104 @@
+/*
+Copyright (c) 2020 Loadsmart, Inc.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+
+	coveralls "github.com/stone-payments/go-coveralls-api"
+)
+
+// doneResult is the result of coveralls done
+type doneResult struct {
+	BuildNum     string   `json:"build_num"`
+	Carryforward []string `json:"carryforward,omitempty"`
+}
+
+// doneFlags are the flags of coveralls done
+type doneFlags struct {
+	global       globalFlags
+	repoToken    string
+	buildNumber  string
+	carryforward stringsFlag
+}
+
+// register defines the flags in fs
+func (f *doneFlags) register(fs *flag.FlagSet) {
+	f.global.register(fs)
+	fs.StringVar(&f.repoToken, "repo-token", "", "repository token (default $"+coveralls.EnvRepoToken+" or $"+coveralls.EnvToken+")")
+	fs.StringVar(&f.buildNumber, "build-number", "", "build number in the CI service (default detected from the CI environment)")
+	fs.Var(&f.carryforward, "carryforward", "flags with no job in the build whose coverage to carry forward, e.g. unit,integration (repeatable)")
+}
+
+// doneCommand runs coveralls done, which closes a parallel build once all
+// of its jobs are submitted with coveralls submit --parallel
+func doneCommand(ctx context.Context, args []string, stdout io.Writer, stderr io.Writer) error {
+	fs := flag.NewFlagSet("coveralls done", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	var f doneFlags
+	f.register(fs)
+	global := &f.global
+	positional, err := global.parse(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) > 0 {
+		return usageError{fmt.Sprintf("done: unexpected argument %q", positional[0])}
+	}
+	if f.repoToken == "" {
+		// Not a flag default, so usage doesn't print it
+		f.repoToken = repoTokenFromEnv()
+	}
+	if f.repoToken == "" {
+		return usageError{"done: missing repository token: set --repo-token or " + coveralls.EnvRepoToken}
+	}
+	if f.buildNumber == "" {
+		if build := coveralls.DetectCI(); build != nil {
+			f.buildNumber = build.ServiceNumber
+		}
+	}
+	if f.buildNumber == "" {
+		return usageError{"done: missing build number: set --build-number or COVERALLS_SERVICE_NUMBER"}
+	}
+	var carryforward []string
+	for _, flags := range f.carryforward {
+		for _, flag := range strings.Split(flags, ",") {
+			if flag = strings.TrimSpace(flag); flag != "" {
+				carryforward = append(carryforward, flag)
+			}
+		}
+	}
+
+	client, err := global.clientWithToken(f.repoToken)
+	if err != nil {
+		return err
+	}
+	if err := client.Builds.Close(ctx, f.repoToken, f.buildNumber, coveralls.Carryforward(carryforward...)); err != nil {
+		return err
+	}
+	return global.print(stdout, &doneResult{BuildNum: f.buildNumber, Carryforward: carryforward})
+}
@@ -0,0 +1,105 @@
+/*
+Copyright (c) 2020 Loadsmart, Inc.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	coveralls "github.com/stone-payments/go-coveralls-api"
+)
+
+func TestDone(t *testing.T) {
+	var testCases = []struct {
+		name         string
+		args         []string
+		buildNum     string
+		carryforward string
+	}{
+		{name: "detected build", args: nil, buildNum: "42"},
+		{name: "build number", args: []string{"--build-number", "43"}, buildNum: "43"},
+		{name: "carryforward", args: []string{"--carryforward", "unit,integration", "--carryforward", "e2e"}, buildNum: "42", carryforward: "unit,integration,e2e"},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			setSubmitEnv(t)
+			var body map[string]interface{}
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, "/webhook", r.URL.Path)
+				assert.Equal(t, "repo-token", r.URL.Query().Get("repo_token"))
+				require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+				_, _ = w.Write([]byte(`{"done": true}`))
+			}))
+			defer server.Close()
+
+			code, stdout, stderr := runCommand(t, server, append([]string{"done"}, tt.args...)...)
+
+			assert.Equal(t, exitOK, code, stderr)
+			assert.Contains(t, stdout, "build_num: "+tt.buildNum)
+			assert.Equal(t, map[string]interface{}{"build_num": tt.buildNum, "status": "done"}, body["payload"])
+			if tt.carryforward == "" {
+				assert.NotContains(t, body, "carryforward")
+			} else {
+				assert.Equal(t, tt.carryforward, body["carryforward"])
+			}
+		})
+	}
+}
+
+func TestDoneErrors(t *testing.T) {
+	var testCases = []struct {
+		name        string
+		args        []string
+		repoToken   string
+		buildNumber string
+		stderr      string
+	}{
+		{name: "unexpected argument", args: []string{"extra"}, repoToken: "repo-token", buildNumber: "42", stderr: `unexpected argument "extra"`},
+		{name: "missing repo token", buildNumber: "42", stderr: "missing repository token"},
+		{name: "missing build number", repoToken: "repo-token", stderr: "missing build number"},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			setSubmitEnv(t)
+			t.Setenv(coveralls.EnvRepoToken, tt.repoToken)
+			t.Setenv(coveralls.EnvToken, "")
+			t.Setenv("COVERALLS_SERVICE_NUMBER", tt.buildNumber)
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				t.Errorf("unexpected request %s %s", r.Method, r.URL)
+			}))
+			defer server.Close()
+
+			code, _, stderr := runCommand(t, server, append([]string{"done"}, tt.args...)...)
+
+			assert.Equal(t, exitUsage, code)
+			assert.Contains(t, stderr, tt.stderr)
+		})
+	}
+}
@@ -0,0 +1,91 @@
+/*
+Copyright (c) 2020 Loadsmart, Inc.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+
+	"github.com/stone-payments/go-coveralls-api/reposync"
+)
+
+// errDrift is returned by coveralls drift --exit-code when settings drifted
+var errDrift = errors.New("settings drifted from the manifest")
+
+// driftFlags are the flags of coveralls drift
+type driftFlags struct {
+	global   globalFlags
+	exitCode bool
+}
+
+// register defines the flags in fs
+func (f *driftFlags) register(fs *flag.FlagSet) {
+	f.global.register(fs)
+	fs.BoolVar(&f.exitCode, "exit-code", false, "exit with 1 if any setting drifted, like git diff --exit-code")
+}
+
+// driftCommand runs coveralls drift, which reports the settings of
+// repositories that differ from a manifest
+func driftCommand(ctx context.Context, args []string, stdout io.Writer, stderr io.Writer) error {
+	fs := flag.NewFlagSet("coveralls drift", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	var f driftFlags
+	f.register(fs)
+	global := &f.global
+	positional, err := global.parse(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 {
+		return usageError{"drift: expected one manifest file"}
+	}
+
+	manifest, err := reposync.ReadFile(positional[0])
+	if err != nil {
+		return err
+	}
+	client, err := global.client()
+	if err != nil {
+		return err
+	}
+	drift, err := reposync.Diff(ctx, client.Repositories, manifest)
+	if err != nil {
+		return err
+	}
+	switch global.output.name {
+	case "", "table":
+		err = reposync.WriteTable(stdout, drift)
+	default:
+		err = global.print(stdout, drift)
+	}
+	if err != nil {
+		return err
+	}
+	if f.exitCode && len(drift) > 0 {
+		return fmt.Errorf("%w: %d differences", errDrift, len(drift))
+	}
+	return nil
+}
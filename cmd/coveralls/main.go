@@ -0,0 +1,366 @@
+/*
+Copyright (c) 2020 Loadsmart, Inc.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+// Command coveralls is a command line client of the Coveralls API, for
+// scripts that manage repositories in Coveralls.
+//
+// Usage:
+//
+//	coveralls repo get SERVICE/NAME
+//	coveralls repo add|update|ensure [flags] SERVICE/NAME
+//	coveralls submit [flags]
+//	coveralls done [--build-number N] [--carryforward FLAGS]
+//	coveralls check [flags] [SERVICE/NAME]
+//	coveralls wait [--sha SHA] [--timeout 10m] SERVICE/NAME
+//	coveralls sync [--dry-run] MANIFEST
+//	coveralls drift [--exit-code] MANIFEST
+//	coveralls badge [--url] [--inject README.md] SERVICE/NAME
+//	coveralls ui [SERVICE/NAME]
+//	coveralls completion bash|zsh|fish|powershell
+//	coveralls man
+//
+// Submit uploads coverage reports, e.g. coveralls submit --profile
+// coverage.out. The CI build is detected from the environment, and the
+// repository token read from --repo-token, COVERALLS_REPO_TOKEN or
+// COVERALLS_TOKEN.
+//
+// Done closes a parallel build, whose jobs were submitted with --parallel,
+// so Coveralls merges their coverage. The build number is detected from
+// the environment unless set with --build-number, and --carryforward
+// carries forward the coverage of flags with no job in the build.
+//
+// Check reads coverage reports, with the same flags as submit, and fails if
+// they break the thresholds set by --min, --per-file-min and --max-drop, the
+// last one compared with the latest build of the --base branch in
+// Coveralls, e.g. coveralls check --profile coverage.out --min 80
+// --max-drop 0.5 --base main github/user/repository.
+//
+// Wait polls Coveralls until it finishes processing the build of a commit,
+// HEAD unless set with --sha, and prints it with its final coverage. It
+// fails when --timeout passes first.
+//
+// Sync adds and updates repositories to match a YAML or JSON manifest, see
+// package reposync. With --dry-run, it prints the changes without applying
+// them. Drift prints, as a table or in the format set by --output, the
+// settings that differ from the manifest without changing them.
+//
+// Badge prints the Markdown of the coverage badge of a repository, or with
+// --inject adds it to a README between <!-- coveralls-badge --> markers,
+// updating the badge already there.
+//
+// Ui browses the repositories interactively: it lists them, shows the
+// settings and recent builds of the one chosen, and edits its thresholds,
+// e.g. with threshold 80 or comments off.
+//
+// Completion writes the completion script of a shell, e.g. source <(coveralls
+// completion bash), and man the man page in roff, e.g. coveralls man >
+// coveralls.1. Both are generated from the commands and their flags.
+//
+// SERVICE/NAME identifies a repository as in its Coveralls URL, e.g.
+// github/user/repository. The personal access token is read from --token or
+// the COVERALLS_API_TOKEN environment variable, and the address of a
+// self-hosted server from --endpoint or COVERALLS_ENDPOINT.
+//
+// Both may instead come from a profile of the configuration file,
+// ~/.config/coveralls/config.yaml or $COVERALLS_CONFIG, chosen with
+// --profile or COVERALLS_PROFILE:
+//
+//	default_profile: cloud
+//	profiles:
+//	  cloud:
+//	    token: your-personal-access-token
+//	    service: github
+//	  enterprise:
+//	    token: another-token
+//	    endpoint: https://coveralls.example.com
+//
+// Coveralls submit, whose --profile names a Go cover profile, reads the
+// profile only from COVERALLS_PROFILE.
+//
+// Flags take precedence over the profile chosen with --profile, which takes
+// precedence over the environment and then the default profile. The
+// service of a profile lets repositories be given without one, e.g.
+// user/repository instead of github/user/repository.
+//
+// Results are printed as text, or in the format set by --output: table,
+// json, yaml or go-template=TEMPLATE, e.g. --output 'go-template={{.id}}'.
+// Fields have the names of the Coveralls API in every format, so scripts
+// can rely on them. --json and --yaml are short for --output json and
+// --output yaml.
+//
+// The exit code tells why a command failed:
+//
+//	0  success
+//	1  unexpected error
+//	2  invalid usage, e.g. a job without repository token
+//	3  not found, e.g. the repository is not in Coveralls
+//	4  rejected by the API, e.g. the repository name is taken
+//	5  unauthorized or forbidden: check the token
+//	6  rate limited or Coveralls unavailable: try again later
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/signal"
+
+	coveralls "github.com/stone-payments/go-coveralls-api"
+)
+
+// Exit codes
+const (
+	exitOK = iota
+	exitError
+	exitUsage
+	exitNotFound
+	exitRejected
+	exitUnauthorized
+	exitUnavailable
+)
+
+// Environment variables read by the command
+const (
+	envAPIToken = "COVERALLS_API_TOKEN"
+	envEndpoint = coveralls.EnvEndpoint
+	envProfile  = "COVERALLS_PROFILE"
+	envConfig   = "COVERALLS_CONFIG"
+)
+
+// command is a subcommand of coveralls
+type command struct {
+	run         func(ctx context.Context, args []string, stdout io.Writer, stderr io.Writer) error
+	flags       func(fs *flag.FlagSet) // Defines the flags of the command, as run does
+	args        []string               // Values of the first argument, e.g. the shells of coveralls completion
+	subcommands []subcommand           // Subcommands, e.g. of coveralls repo, which have the flags instead
+}
+
+// subcommand is a subcommand of a command, e.g. coveralls repo get
+type subcommand struct {
+	name  string
+	flags func(fs *flag.FlagSet) // Defines the flags of the subcommand
+}
+
+// commands are the subcommands, by name
+var commands = map[string]command{
+	"badge":  {run: badgeCommand, flags: func(fs *flag.FlagSet) { new(badgeFlags).register(fs) }},
+	"check":  {run: checkCommand, flags: func(fs *flag.FlagSet) { new(checkFlags).register(fs) }},
+	"done":   {run: doneCommand, flags: func(fs *flag.FlagSet) { new(doneFlags).register(fs) }},
+	"drift":  {run: driftCommand, flags: func(fs *flag.FlagSet) { new(driftFlags).register(fs) }},
+	"repo":   {run: repoCommand, subcommands: repoSubcommands("get", "add", "update", "ensure")},
+	"submit": {run: submitCommand, flags: func(fs *flag.FlagSet) { new(submitFlags).register(fs) }},
+	"sync":   {run: syncCommand, flags: func(fs *flag.FlagSet) { new(syncFlags).register(fs) }},
+	"ui":     {run: uiCommand, flags: func(fs *flag.FlagSet) { new(uiFlags).register(fs) }},
+	"wait":   {run: waitCommand, flags: func(fs *flag.FlagSet) { new(waitFlags).register(fs) }},
+}
+
+const usage = `Usage:
+  coveralls repo get SERVICE/NAME
+  coveralls repo add|update|ensure [flags] SERVICE/NAME
+  coveralls submit [flags]
+  coveralls done [--build-number N] [--carryforward FLAGS]
+  coveralls check [flags] [SERVICE/NAME]
+  coveralls wait [--sha SHA] [--timeout 10m] SERVICE/NAME
+  coveralls sync [--dry-run] MANIFEST
+  coveralls drift [--exit-code] MANIFEST
+  coveralls badge [--url] [--inject README.md] SERVICE/NAME
+  coveralls ui [SERVICE/NAME]
+  coveralls completion bash|zsh|fish|powershell
+  coveralls man
+
+Run a command with -h to list its flags.
+`
+
+// usageError is an error in the arguments of a command
+type usageError struct {
+	msg string
+}
+
+func (e usageError) Error() string {
+	return e.msg
+}
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	code := run(ctx, os.Args[1:], os.Stdout, os.Stderr)
+	stop()
+	os.Exit(code)
+}
+
+// run runs the command line args and returns the exit code
+func run(ctx context.Context, args []string, stdout io.Writer, stderr io.Writer) int {
+	if len(args) == 0 {
+		fmt.Fprint(stderr, usage)
+		return exitUsage
+	}
+	cmd, ok := commands[args[0]]
+	if !ok {
+		fmt.Fprintf(stderr, "coveralls: unknown command %q\n\n%s", args[0], usage)
+		return exitUsage
+	}
+
+	err := cmd.run(ctx, args[1:], stdout, stderr)
+	if errors.Is(err, flag.ErrHelp) {
+		return exitOK
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "coveralls: %s\n", err)
+		return exitCode(err)
+	}
+	return exitOK
+}
+
+// exitCode returns the exit code of a command that failed with err
+func exitCode(err error) int {
+	var usage usageError
+	var invalidJob coveralls.ErrInvalidJob
+	if errors.As(err, &usage) || errors.As(err, &invalidJob) {
+		return exitUsage
+	}
+
+	switch code, _ := coveralls.StatusCode(err); {
+	case code == http.StatusNotFound,
+		errors.Is(err, coveralls.ErrBuildNotFound):
+		return exitNotFound
+	case code == http.StatusUnprocessableEntity:
+		return exitRejected
+	case code == http.StatusUnauthorized, code == http.StatusForbidden:
+		return exitUnauthorized
+	case code == http.StatusTooManyRequests, code == http.StatusServiceUnavailable,
+		errors.Is(err, coveralls.ErrCircuitOpen):
+		return exitUnavailable
+	default:
+		return exitError
+	}
+}
+
+// globalFlags are the flags shared by all commands
+type globalFlags struct {
+	token    string
+	endpoint string
+	profile  string
+	service  string // Service of repositories given without one, from the profile
+	output   outputFormat
+}
+
+// register defines the flags in fs, with defaults from the environment.
+// Commands with flags of the same names define them first. The token is
+// read from the environment by parse instead, so usage never prints it.
+func (g *globalFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&g.token, "token", "", "personal access token (default $"+envAPIToken+")")
+	fs.StringVar(&g.endpoint, "endpoint", os.Getenv(envEndpoint), "address of a self-hosted Coveralls server (default $"+envEndpoint+")")
+	g.profile = os.Getenv(envProfile)
+	if fs.Lookup("profile") == nil {
+		// coveralls submit has its own --profile, of coverage
+		fs.StringVar(&g.profile, "profile", g.profile, "profile of the configuration file to use (default $"+envProfile+")")
+	}
+	fs.Var(&g.output, "output", "format of results: text, table, json, yaml or go-template=TEMPLATE")
+	fs.BoolFunc("json", "print results as JSON, like --output json", func(string) error { return g.output.Set("json") })
+	fs.BoolFunc("yaml", "print results as YAML, like --output yaml", func(string) error { return g.output.Set("yaml") })
+}
+
+// parse parses args with fs, as parseFlags does, and fills in the settings
+// not given as flags from the profile of the configuration file. The
+// profile chosen with --profile takes precedence over the environment;
+// the default profile doesn't.
+func (g *globalFlags) parse(fs *flag.FlagSet, args []string) ([]string, error) {
+	positional, err := parseFlags(fs, args)
+	if err != nil {
+		return nil, err
+	}
+	given := make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) {
+		given[f.Name] = true
+	})
+	if !given["token"] {
+		g.token = os.Getenv(envAPIToken)
+	}
+
+	path, err := configPath()
+	if err != nil {
+		return nil, err
+	}
+	c, err := readConfig(path)
+	if err != nil {
+		return nil, err
+	}
+	p, err := c.profile(g.profile)
+	if err != nil || p == nil {
+		return positional, err
+	}
+
+	explicit := g.profile != ""
+	if !given["token"] && (explicit || g.token == "") {
+		g.token = p.Token
+	}
+	if !given["endpoint"] && (explicit || g.endpoint == "") {
+		g.endpoint = p.Endpoint
+	}
+	g.service = p.Service
+	return positional, nil
+}
+
+// client returns a Coveralls client configured by the flags
+func (g *globalFlags) client() (*coveralls.Client, error) {
+	if g.token == "" {
+		return nil, usageError{"missing API token: set --token or " + envAPIToken}
+	}
+	return g.clientWithToken(g.token)
+}
+
+// clientWithToken returns a Coveralls client configured by the flags that
+// authenticates with token, e.g. a repository token
+func (g *globalFlags) clientWithToken(token string) (*coveralls.Client, error) {
+	opts := []coveralls.Option{coveralls.WithUserAgent("coveralls-cli/" + coveralls.Version)}
+	if g.endpoint != "" {
+		return coveralls.NewEnterpriseClient(g.endpoint, token, opts...)
+	}
+	return coveralls.NewClient(token, opts...), nil
+}
+
+// print writes v to w in the format chosen by the flags
+func (g *globalFlags) print(w io.Writer, v interface{}) error {
+	return g.output.write(w, v)
+}
+
+// parseFlags parses args with fs, allowing flags after positional
+// arguments, and returns the positional arguments
+func parseFlags(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			if errors.Is(err, flag.ErrHelp) {
+				return nil, err
+			}
+			return nil, usageError{err.Error()}
+		}
+		if fs.NArg() == 0 {
+			return positional, nil
+		}
+		positional = append(positional, fs.Arg(0))
+		args = fs.Args()[1:]
+	}
+}
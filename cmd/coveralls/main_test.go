@@ -0,0 +1,189 @@
+/*
+Copyright (c) 2020 Loadsmart, Inc.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	coveralls "github.com/stone-payments/go-coveralls-api"
+)
+
+// runCommand runs the command line args against server and returns its
+// exit code and output
+func runCommand(t *testing.T, server *httptest.Server, args ...string) (code int, stdout string, stderr string) {
+	t.Setenv(envAPIToken, "fake-token")
+	t.Setenv(envProfile, "")
+	t.Setenv(envConfig, filepath.Join(t.TempDir(), "config.yaml"))
+	if server != nil {
+		t.Setenv(envEndpoint, server.URL)
+	}
+	var out, errOut bytes.Buffer
+	code = run(context.Background(), args, &out, &errOut)
+	return code, out.String(), errOut.String()
+}
+
+func TestRunUsage(t *testing.T) {
+	var testCases = []struct {
+		name string
+		args []string
+		code int
+	}{
+		{name: "no command", args: nil, code: exitUsage},
+		{name: "unknown command", args: []string{"deploy"}, code: exitUsage},
+		{name: "help", args: []string{"repo", "get", "-h"}, code: exitOK},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			code, stdout, stderr := runCommand(t, nil, tt.args...)
+
+			assert.Equal(t, tt.code, code)
+			assert.Empty(t, stdout)
+			assert.Contains(t, stderr, "Usage")
+			assert.NotContains(t, stderr, "fake-token")
+		})
+	}
+}
+
+func TestExitCode(t *testing.T) {
+	var testCases = []struct {
+		name   string
+		status int
+		code   int
+	}{
+		{name: "not found", status: http.StatusNotFound, code: exitNotFound},
+		{name: "rejected", status: http.StatusUnprocessableEntity, code: exitRejected},
+		{name: "unauthorized", status: http.StatusUnauthorized, code: exitUnauthorized},
+		{name: "forbidden", status: http.StatusForbidden, code: exitUnauthorized},
+		{name: "rate limited", status: http.StatusTooManyRequests, code: exitUnavailable},
+		{name: "unavailable", status: http.StatusServiceUnavailable, code: exitUnavailable},
+		{name: "unexpected", status: http.StatusInternalServerError, code: exitError},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+			}))
+			defer server.Close()
+
+			code, _, stderr := runCommand(t, server, "repo", "get", "github/user/repository")
+
+			assert.Equal(t, tt.code, code)
+			assert.Contains(t, stderr, fmt.Sprint(tt.status))
+		})
+	}
+
+	assert.Equal(t, exitUsage, exitCode(usageError{"invalid"}))
+	assert.Equal(t, exitNotFound, exitCode(coveralls.ErrBuildNotFound))
+	assert.Equal(t, exitError, exitCode(errors.New("unexpected")))
+}
+
+func TestOutput(t *testing.T) {
+	type head struct {
+		ID string `json:"id"`
+	}
+	value := struct {
+		Name    string   `json:"name"`
+		Percent float64  `json:"percent"`
+		Count   int      `json:"count"`
+		Missing *int     `json:"missing"`
+		Head    head     `json:"head"`
+		Flags   []string `json:"flags"`
+	}{Name: "user/repository", Percent: 85.5, Count: 3, Head: head{ID: "abc123"}, Flags: []string{"unit", "e2e"}}
+
+	var testCases = []struct {
+		format string
+		want   string
+	}{
+		{
+			format: "text",
+			want:   "name: user/repository\npercent: 85.5\ncount: 3\nhead.id: abc123\nflags[0]: unit\nflags[1]: e2e\n",
+		},
+		{
+			format: "yaml",
+			want:   "name: user/repository\npercent: 85.5\ncount: 3\nmissing: null\nhead:\n  id: abc123\nflags:\n- unit\n- e2e\n",
+		},
+		{
+			format: "json",
+			want:   "{\n  \"name\": \"user/repository\",\n  \"percent\": 85.5,\n  \"count\": 3,\n  \"missing\": null,\n  \"head\": {\n    \"id\": \"abc123\"\n  },\n  \"flags\": [\n    \"unit\",\n    \"e2e\"\n  ]\n}\n",
+		},
+		{
+			format: "table",
+			want:   "NAME             PERCENT  COUNT  MISSING  HEAD       FLAGS\nuser/repository  85.5     3               id=abc123  unit,e2e\n",
+		},
+		{
+			format: "go-template={{.name}} {{.count}} {{.head.id}}{{range .flags}} {{.}}{{end}}",
+			want:   "user/repository 3 abc123 unit e2e",
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.format, func(t *testing.T) {
+			var o outputFormat
+			assert.Nil(t, o.Set(tt.format))
+			var b bytes.Buffer
+			assert.Nil(t, o.write(&b, value))
+			assert.Equal(t, tt.want, b.String())
+		})
+	}
+}
+
+func TestOutputTable(t *testing.T) {
+	type change struct {
+		Action string   `json:"action"`
+		Name   string   `json:"name"`
+		Fields []string `json:"fields,omitempty"`
+	}
+	plan := struct {
+		Changes []change `json:"changes"`
+	}{Changes: []change{{Action: "update", Name: "user/api", Fields: []string{"threshold"}}, {Action: "none", Name: "user/web"}}}
+
+	var b bytes.Buffer
+	assert.Nil(t, writeTable(&b, plan))
+	assert.Equal(t, "ACTION  NAME      FIELDS\nupdate  user/api  threshold\nnone    user/web  \n", b.String())
+
+	b.Reset()
+	assert.Nil(t, writeTable(&b, []string{"a", "b"}))
+	assert.Equal(t, "VALUE\na\nb\n", b.String())
+}
+
+func TestOutputInvalid(t *testing.T) {
+	for _, format := range []string{"xml", "go-template={{.name", "template={{.name}}"} {
+		var o outputFormat
+		assert.NotNil(t, o.Set(format), format)
+	}
+
+	var o outputFormat
+	assert.Nil(t, o.Set("go-template={{.missing}}"))
+	assert.NotNil(t, o.write(&bytes.Buffer{}, struct{}{}), "missing fields are errors")
+}
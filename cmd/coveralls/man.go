@@ -0,0 +1,164 @@
+/*
+Copyright (c) 2020 Loadsmart, Inc.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+
+	coveralls "github.com/stone-payments/go-coveralls-api"
+)
+
+// manDescription is the description of the man page, in roff
+const manDescription = `coveralls manages repositories in Coveralls, submits coverage reports and
+checks them against thresholds.
+.PP
+SERVICE/NAME identifies a repository as in its Coveralls URL, e.g.
+github/user/repository. Results are printed as text, or in the format set by
+\-\-output: table, json, yaml or go\-template=TEMPLATE.
+.PP
+The personal access token and the address of a self\-hosted server may also
+come from a profile of the configuration file, chosen with \-\-profile.
+Flags take precedence over the profile chosen with \-\-profile, which takes
+precedence over the environment and then the default profile.
+`
+
+// manFooter are the last sections of the man page, in roff
+const manFooter = `.SH ENVIRONMENT
+.TP
+.B COVERALLS_API_TOKEN
+Personal access token.
+.TP
+.B COVERALLS_ENDPOINT
+Address of a self\-hosted Coveralls server.
+.TP
+.B COVERALLS_PROFILE
+Profile of the configuration file to use.
+.TP
+.B COVERALLS_CONFIG
+Path of the configuration file.
+.TP
+.B COVERALLS_REPO_TOKEN
+Repository token of the jobs sent by coveralls submit.
+.SH FILES
+.TP
+.I ~/.config/coveralls/config.yaml
+Configuration file with profiles, in $XDG_CONFIG_HOME if set.
+.SH EXIT STATUS
+.TP
+.B 0
+Success.
+.TP
+.B 1
+Unexpected error.
+.TP
+.B 2
+Invalid usage, e.g. a job without repository token.
+.TP
+.B 3
+Not found, e.g. the repository is not in Coveralls.
+.TP
+.B 4
+Rejected by the API, e.g. the repository name is taken.
+.TP
+.B 5
+Unauthorized or forbidden: check the token.
+.TP
+.B 6
+Rate limited or Coveralls unavailable: try again later.
+`
+
+// manCommand runs coveralls man, which writes the man page of the command
+// in roff, e.g. coveralls man > coveralls.1
+func manCommand(ctx context.Context, args []string, stdout io.Writer, stderr io.Writer) error {
+	fs := flag.NewFlagSet("coveralls man", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	positional, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) > 0 {
+		return usageError{fmt.Sprintf("man: unexpected argument %q", positional[0])}
+	}
+	writeMan(stdout, commandTree())
+	return nil
+}
+
+// writeMan writes the man page of the commands
+func writeMan(w io.Writer, specs []commandSpec) {
+	fmt.Fprintf(w, ".TH COVERALLS 1 \"\" \"coveralls %s\" \"User Commands\"\n", roffEscape(coveralls.Version))
+	fmt.Fprint(w, ".SH NAME\ncoveralls \\- command line client of the Coveralls API\n")
+	fmt.Fprint(w, ".SH SYNOPSIS\n.nf\n")
+	for _, line := range strings.Split(usage, "\n") {
+		if strings.HasPrefix(line, "  coveralls ") {
+			fmt.Fprintln(w, roffEscape(strings.TrimSpace(line)))
+		}
+	}
+	fmt.Fprint(w, ".fi\n.SH DESCRIPTION\n")
+	fmt.Fprint(w, manDescription)
+	fmt.Fprint(w, ".SH COMMANDS\n")
+	for _, spec := range specs {
+		name := "coveralls " + spec.Name
+		if len(spec.Args) > 0 {
+			name += " " + strings.Join(spec.Args, "|")
+		}
+		if len(spec.Subcommands) == 0 {
+			writeManFlags(w, name, spec.Flags)
+		}
+		for _, sub := range spec.Subcommands {
+			writeManFlags(w, name+" "+sub.Name, sub.Flags)
+		}
+	}
+	fmt.Fprint(w, manFooter)
+}
+
+// writeManFlags writes the section of a command with its flags
+func writeManFlags(w io.Writer, name string, flags []*flag.Flag) {
+	fmt.Fprintf(w, ".SS %s\n", roffEscape(name))
+	for _, f := range flags {
+		arg, usage := flag.UnquoteUsage(f)
+		fmt.Fprint(w, ".TP\n")
+		if isBoolFlag(f) {
+			fmt.Fprintf(w, ".B %s\n", roffEscape("--"+f.Name))
+		} else {
+			fmt.Fprintf(w, ".BI %s \" %s\"\n", roffEscape("--"+f.Name), roffEscape(arg))
+		}
+		// Defaults read from the environment are in the usage instead
+		if f.DefValue != "" && f.DefValue != "false" && !strings.Contains(usage, "(default") {
+			usage += fmt.Sprintf(" (default %s)", f.DefValue)
+		}
+		fmt.Fprintln(w, roffEscape(usage))
+	}
+}
+
+// roffEscape escapes s for roff
+func roffEscape(s string) string {
+	s = strings.NewReplacer(`\`, `\e`, "-", `\-`).Replace(s)
+	if strings.HasPrefix(s, ".") || strings.HasPrefix(s, "'") {
+		s = `\&` + s
+	}
+	return s
+}
@@ -0,0 +1,299 @@
+/*
+Copyright (c) 2020 Loadsmart, Inc.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"text/template"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+// outputFormat is the format of the results of a command, set by --output
+type outputFormat struct {
+	name     string             // Name of the format, empty for the default text
+	template *template.Template // Template of the go-template format
+}
+
+func (o *outputFormat) String() string {
+	return o.name
+}
+
+func (o *outputFormat) Set(s string) error {
+	if text, ok := strings.CutPrefix(s, "go-template="); ok {
+		tmpl, err := template.New("output").Option("missingkey=error").Parse(text)
+		if err != nil {
+			return err
+		}
+		o.name, o.template = "go-template", tmpl
+		return nil
+	}
+	switch s {
+	case "text", "table", "json", "yaml":
+		o.name, o.template = s, nil
+		return nil
+	default:
+		return fmt.Errorf("unknown format %q: use text, table, json, yaml or go-template=TEMPLATE", s)
+	}
+}
+
+// write writes v to w in the format
+func (o *outputFormat) write(w io.Writer, v interface{}) error {
+	switch o.name {
+	case "table":
+		return writeTable(w, v)
+	case "json":
+		return writeJSON(w, v)
+	case "yaml":
+		return writeYAML(w, v)
+	case "go-template":
+		return writeTemplate(w, o.template, v)
+	default:
+		return writeText(w, v)
+	}
+}
+
+// writeJSON writes v as indented JSON
+func writeJSON(w io.Writer, v interface{}) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+// writeYAML writes v as YAML, with the same field names as in JSON
+func writeYAML(w io.Writer, v interface{}) error {
+	fields, err := jsonFields(v)
+	if err != nil {
+		return err
+	}
+	b, err := yaml.Marshal(fields)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(b)
+	return err
+}
+
+// writeText writes v as lines of field names and values, with the same
+// field names as in JSON. Nested fields are joined with dots, e.g.
+// git.head.id, and elements of lists numbered, e.g. remotes[0].name.
+// Values that implement fmt.Stringer are written as their String instead.
+func writeText(w io.Writer, v interface{}) error {
+	if s, ok := v.(fmt.Stringer); ok {
+		_, err := io.WriteString(w, s.String())
+		return err
+	}
+	fields, err := jsonFields(v)
+	if err != nil {
+		return err
+	}
+	var b strings.Builder
+	writeTextValue(&b, "", fields)
+	_, err = io.WriteString(w, b.String())
+	return err
+}
+
+// writeTextValue writes value, found at path, to b
+func writeTextValue(b *strings.Builder, path string, value interface{}) {
+	switch v := value.(type) {
+	case yaml.MapSlice:
+		for _, item := range v {
+			key := fmt.Sprint(item.Key)
+			if path != "" {
+				key = path + "." + key
+			}
+			writeTextValue(b, key, item.Value)
+		}
+	case []interface{}:
+		for i, item := range v {
+			writeTextValue(b, fmt.Sprintf("%s[%d]", path, i), item)
+		}
+	case nil:
+	default:
+		if path == "" {
+			fmt.Fprintf(b, "%v\n", v)
+			return
+		}
+		fmt.Fprintf(b, "%s: %v\n", path, v)
+	}
+}
+
+// writeTable writes v as a table with a column per field, named as in JSON
+// in upper case. Lists have a row per element, and so do objects whose only
+// field is a list, such as a sync plan; other values have a single row.
+// Nested values are written in a cell, e.g. field=a,desired=1.
+func writeTable(w io.Writer, v interface{}) error {
+	fields, err := jsonFields(v)
+	if err != nil {
+		return err
+	}
+	if object, ok := fields.(yaml.MapSlice); ok && len(object) == 1 {
+		if list, ok := object[0].Value.([]interface{}); ok {
+			fields = list
+		}
+	}
+	list, ok := fields.([]interface{})
+	if !ok {
+		list = []interface{}{fields}
+	}
+
+	var rows []yaml.MapSlice
+	var columns []string
+	seen := make(map[string]bool)
+	for _, item := range list {
+		row, ok := item.(yaml.MapSlice)
+		if !ok {
+			row = yaml.MapSlice{{Key: "value", Value: item}}
+		}
+		for _, field := range row {
+			if key := fmt.Sprint(field.Key); !seen[key] {
+				seen[key] = true
+				columns = append(columns, key)
+			}
+		}
+		rows = append(rows, row)
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	for i, column := range columns {
+		if i > 0 {
+			fmt.Fprint(tw, "\t")
+		}
+		fmt.Fprint(tw, strings.ToUpper(column))
+	}
+	fmt.Fprintln(tw)
+	for _, row := range rows {
+		cells := make(map[string]string, len(row))
+		for _, field := range row {
+			cells[fmt.Sprint(field.Key)] = tableCell(field.Value)
+		}
+		for i, column := range columns {
+			if i > 0 {
+				fmt.Fprint(tw, "\t")
+			}
+			fmt.Fprint(tw, cells[column])
+		}
+		fmt.Fprintln(tw)
+	}
+	return tw.Flush()
+}
+
+// tableCell formats value for a cell of a table
+func tableCell(value interface{}) string {
+	switch v := value.(type) {
+	case yaml.MapSlice:
+		items := make([]string, 0, len(v))
+		for _, item := range v {
+			items = append(items, fmt.Sprintf("%v=%s", item.Key, tableCell(item.Value)))
+		}
+		return strings.Join(items, ",")
+	case []interface{}:
+		items := make([]string, 0, len(v))
+		for _, item := range v {
+			items = append(items, tableCell(item))
+		}
+		return strings.Join(items, ",")
+	case nil:
+		return ""
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// writeTemplate executes tmpl with v as data. The data has the fields of v
+// as JSON, so templates use the same names as other formats, e.g. {{.id}}.
+func writeTemplate(w io.Writer, tmpl *template.Template, v interface{}) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	var data interface{}
+	if err := dec.Decode(&data); err != nil {
+		return err
+	}
+	return tmpl.Execute(w, data)
+}
+
+// jsonFields converts v into the values it has as JSON: yaml.MapSlice for
+// objects, which keeps the order of their fields, []interface{} for arrays,
+// and strings, numbers, booleans or nil
+func jsonFields(v interface{}) (interface{}, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	return decodeJSONValue(dec)
+}
+
+// decodeJSONValue decodes the next value of dec as jsonFields does
+func decodeJSONValue(dec *json.Decoder) (interface{}, error) {
+	token, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	switch t := token.(type) {
+	case json.Delim:
+		if t == '[' {
+			values := []interface{}{}
+			for dec.More() {
+				value, err := decodeJSONValue(dec)
+				if err != nil {
+					return nil, err
+				}
+				values = append(values, value)
+			}
+			_, err := dec.Token()
+			return values, err
+		}
+		fields := yaml.MapSlice{}
+		for dec.More() {
+			key, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+			value, err := decodeJSONValue(dec)
+			if err != nil {
+				return nil, err
+			}
+			fields = append(fields, yaml.MapItem{Key: key, Value: value})
+		}
+		_, err := dec.Token()
+		return fields, err
+	case json.Number:
+		if n, err := t.Int64(); err == nil {
+			return n, nil
+		}
+		return t.Float64()
+	default:
+		return t, nil
+	}
+}
@@ -0,0 +1,203 @@
+/*
+Copyright (c) 2020 Loadsmart, Inc.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	coveralls "github.com/stone-payments/go-coveralls-api"
+)
+
+// repoFlags are the flags of the subcommands of coveralls repo
+type repoFlags struct {
+	global   globalFlags
+	settings configFlags
+}
+
+// register defines the flags of the subcommand sub in fs. Repo get changes
+// no settings, so it has no flags for them.
+func (f *repoFlags) register(fs *flag.FlagSet, sub string) {
+	f.global.register(fs)
+	if sub != "get" {
+		f.settings.register(fs)
+	}
+}
+
+// repoSubcommands returns the subcommands of coveralls repo named names
+func repoSubcommands(names ...string) []subcommand {
+	subs := make([]subcommand, len(names))
+	for i, name := range names {
+		name := name
+		subs[i] = subcommand{name: name, flags: func(fs *flag.FlagSet) { new(repoFlags).register(fs, name) }}
+	}
+	return subs
+}
+
+// repoCommand runs coveralls repo, which gets and changes repositories
+func repoCommand(ctx context.Context, args []string, stdout io.Writer, stderr io.Writer) error {
+	if len(args) == 0 {
+		return usageError{"repo: missing subcommand: get, add, update or ensure"}
+	}
+	sub := args[0]
+	switch sub {
+	case "get", "add", "update", "ensure":
+	default:
+		return usageError{fmt.Sprintf("repo: unknown subcommand %q", sub)}
+	}
+
+	fs := flag.NewFlagSet("coveralls repo "+sub, flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	var f repoFlags
+	f.register(fs, sub)
+	global, settings := &f.global, &f.settings
+	positional, err := global.parse(fs, args[1:])
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 {
+		return usageError{fmt.Sprintf("repo %s: expected one repository, as SERVICE/NAME", sub)}
+	}
+	svc, name, err := parseRepo(positional[0], global.service)
+	if err != nil {
+		return err
+	}
+
+	client, err := global.client()
+	if err != nil {
+		return err
+	}
+	var repository *coveralls.Repository
+	switch sub {
+	case "get":
+		repository, err = client.Repositories.Get(ctx, svc, name)
+	case "add":
+		repository, err = client.Repositories.Add(ctx, settings.config(svc, name))
+	case "update":
+		repository, err = client.Repositories.Update(ctx, svc, name, settings.config(svc, name))
+	case "ensure":
+		repository, err = client.Repositories.Ensure(ctx, settings.config(svc, name))
+	}
+	if err != nil {
+		return err
+	}
+	return global.print(stdout, repository)
+}
+
+// services are the git providers of Coveralls, which parseRepo tells apart
+// from the owner of a repository given without service
+var services = map[string]bool{"github": true, "bitbucket": true, "gitlab": true, "stash": true, "manual": true}
+
+// parseRepo splits a repository given as SERVICE/NAME, e.g.
+// github/user/repository. If defaultService is set, the service may be left
+// out, e.g. user/repository.
+func parseRepo(arg string, defaultService string) (svc string, name string, err error) {
+	svc, name, ok := strings.Cut(arg, "/")
+	if defaultService != "" && !services[svc] {
+		return defaultService, arg, nil
+	}
+	if !ok || svc == "" || name == "" {
+		return "", "", usageError{fmt.Sprintf("invalid repository %q: expected SERVICE/NAME, e.g. github/user/repository", arg)}
+	}
+	return svc, name, nil
+}
+
+// configFlags are the flags that set the configuration of a repository.
+// Settings whose flags are not given are left unchanged.
+type configFlags struct {
+	commentOnPullRequests           optionalBool
+	sendBuildStatus                 optionalBool
+	commitStatusFailThreshold       optionalFloat
+	commitStatusFailChangeThreshold optionalFloat
+}
+
+// register defines the flags in fs
+func (c *configFlags) register(fs *flag.FlagSet) {
+	fs.Var(&c.commentOnPullRequests, "comment-on-pull-requests", "whether to comment on pull requests")
+	fs.Var(&c.sendBuildStatus, "send-build-status", "whether to send the build status to the git provider")
+	fs.Var(&c.commitStatusFailThreshold, "fail-threshold", "minimum coverage for builds to pass")
+	fs.Var(&c.commitStatusFailChangeThreshold, "fail-change-threshold", "maximum decrease of coverage for builds to pass")
+}
+
+// config returns the configuration of the repository svc/name set by the flags
+func (c *configFlags) config(svc string, name string) *coveralls.RepositoryConfig {
+	return &coveralls.RepositoryConfig{
+		Service:                         svc,
+		Name:                            name,
+		CommentOnPullRequests:           c.commentOnPullRequests.value,
+		SendBuildStatus:                 c.sendBuildStatus.value,
+		CommitStatusFailThreshold:       c.commitStatusFailThreshold.value,
+		CommitStatusFailChangeThreshold: c.commitStatusFailChangeThreshold.value,
+	}
+}
+
+// optionalBool is a boolean flag that is nil unless given
+type optionalBool struct {
+	value *bool
+}
+
+func (b *optionalBool) String() string {
+	if b.value == nil {
+		return ""
+	}
+	return strconv.FormatBool(*b.value)
+}
+
+func (b *optionalBool) Set(s string) error {
+	v, err := strconv.ParseBool(s)
+	if err != nil {
+		return err
+	}
+	b.value = &v
+	return nil
+}
+
+// IsBoolFlag allows the flag to be given without a value, meaning true
+func (b *optionalBool) IsBoolFlag() bool {
+	return true
+}
+
+// optionalFloat is a number flag that is nil unless given
+type optionalFloat struct {
+	value *float64
+}
+
+func (f *optionalFloat) String() string {
+	if f.value == nil {
+		return ""
+	}
+	return strconv.FormatFloat(*f.value, 'f', -1, 64)
+}
+
+func (f *optionalFloat) Set(s string) error {
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return err
+	}
+	f.value = &v
+	return nil
+}
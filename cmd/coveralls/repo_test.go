@@ -0,0 +1,135 @@
+/*
+Copyright (c) 2020 Loadsmart, Inc.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRepoGet(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/repos/github/user/repository", r.URL.Path)
+		assert.Equal(t, "token fake-token", r.Header.Get("Authorization"))
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"id": 42, "name": "user/repository", "service": "github", "commit_status_fail_threshold": 80}`))
+	}))
+	defer server.Close()
+
+	code, stdout, stderr := runCommand(t, server, "repo", "get", "github/user/repository", "--json")
+
+	assert.Equal(t, exitOK, code, stderr)
+	assert.JSONEq(t, `{"id": 42, "name": "user/repository", "service": "github", "commit_status_fail_threshold": 80}`, stdout)
+
+	code, stdout, _ = runCommand(t, server, "repo", "get", "github/user/repository")
+
+	assert.Equal(t, exitOK, code)
+	assert.Equal(t, "id: 42\nname: user/repository\nservice: github\ncommit_status_fail_threshold: 80\n", stdout)
+
+	code, stdout, _ = runCommand(t, server, "repo", "get", "github/user/repository", "--output", "go-template={{.id}} {{.commit_status_fail_threshold}}")
+
+	assert.Equal(t, exitOK, code)
+	assert.Equal(t, "42 80", stdout)
+
+	code, _, stderr = runCommand(t, server, "repo", "get", "github/user/repository", "--output", "xml")
+
+	assert.Equal(t, exitUsage, code)
+	assert.Contains(t, stderr, `unknown format "xml"`)
+}
+
+func TestRepoChange(t *testing.T) {
+	var testCases = []struct {
+		sub    string
+		method string
+		path   string
+		status int
+	}{
+		{sub: "add", method: http.MethodPost, path: "/api/repos", status: http.StatusCreated},
+		{sub: "update", method: http.MethodPut, path: "/api/repos/github/user/repository", status: http.StatusOK},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.sub, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, tt.method, r.Method)
+				assert.Equal(t, tt.path, r.URL.Path)
+				body, _ := ioutil.ReadAll(r.Body)
+				var received map[string]interface{}
+				assert.Nil(t, json.Unmarshal(body, &received))
+				assert.Equal(t, map[string]interface{}{
+					"repo": map[string]interface{}{
+						"service":                      "github",
+						"name":                         "user/repository",
+						"comment_on_pull_requests":     false,
+						"commit_status_fail_threshold": 80.5,
+					},
+				}, received)
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(tt.status)
+				w.Write([]byte(`{"id": 42, "name": "user/repository"}`))
+			}))
+			defer server.Close()
+
+			code, stdout, stderr := runCommand(t, server, "repo", tt.sub, "--comment-on-pull-requests=false", "--fail-threshold", "80.5", "github/user/repository", "--yaml")
+
+			assert.Equal(t, exitOK, code, stderr)
+			assert.Equal(t, "id: 42\nname: user/repository\n", stdout)
+		})
+	}
+}
+
+func TestRepoUsage(t *testing.T) {
+	var testCases = []struct {
+		name string
+		args []string
+	}{
+		{name: "no subcommand", args: []string{"repo"}},
+		{name: "unknown subcommand", args: []string{"repo", "delete", "github/user/repository"}},
+		{name: "no repository", args: []string{"repo", "get"}},
+		{name: "invalid repository", args: []string{"repo", "get", "repository"}},
+		{name: "two repositories", args: []string{"repo", "get", "github/a", "github/b"}},
+		{name: "invalid flag", args: []string{"repo", "add", "--fail-threshold", "high", "github/user/repository"}},
+		{name: "flag of another subcommand", args: []string{"repo", "get", "--fail-threshold", "80", "github/user/repository"}},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			code, stdout, _ := runCommand(t, nil, tt.args...)
+
+			assert.Equal(t, exitUsage, code)
+			assert.Empty(t, stdout)
+		})
+	}
+}
+
+func TestRepoMissingToken(t *testing.T) {
+	code, _, stderr := runCommand(t, nil, "repo", "get", "github/user/repository", "--token", "")
+
+	assert.Equal(t, exitUsage, code)
+	assert.Contains(t, stderr, envAPIToken)
+}
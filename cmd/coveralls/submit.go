@@ -0,0 +1,235 @@
+/*
+Copyright (c) 2020 Loadsmart, Inc.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	coveralls "github.com/stone-payments/go-coveralls-api"
+	"github.com/stone-payments/go-coveralls-api/gitinfo"
+	"github.com/stone-payments/go-coveralls-api/report"
+)
+
+// reportFlags are the flags that name coverage reports, each of a format
+type reportFlags struct {
+	profile   stringsFlag
+	lcov      stringsFlag
+	istanbul  stringsFlag
+	simplecov stringsFlag
+	cobertura stringsFlag
+	clover    stringsFlag
+	jacoco    stringsFlag
+	saved     stringsFlag
+
+	jacocoSourceDirs stringsFlag
+}
+
+// defaultJaCoCoSourceDir is where Maven and Gradle keep Java sources
+const defaultJaCoCoSourceDir = "src/main/java"
+
+// register defines the flags in fs
+func (r *reportFlags) register(fs *flag.FlagSet) {
+	fs.Var(&r.profile, "profile", "Go cover profile, as written by go test -coverprofile (repeatable)")
+	fs.Var(&r.lcov, "lcov", "LCOV tracefile (repeatable)")
+	fs.Var(&r.istanbul, "istanbul", "Istanbul coverage-final.json (repeatable)")
+	fs.Var(&r.simplecov, "simplecov", "SimpleCov .resultset.json (repeatable)")
+	fs.Var(&r.cobertura, "cobertura", "Cobertura or coverage.py XML report (repeatable)")
+	fs.Var(&r.clover, "clover", "Clover XML report (repeatable)")
+	fs.Var(&r.jacoco, "jacoco", "JaCoCo XML report (repeatable)")
+	fs.Var(&r.jacocoSourceDirs, "jacoco-source-dir", "directory of the sources of JaCoCo reports, relative to the root (repeatable, default "+defaultJaCoCoSourceDir+")")
+	fs.Var(&r.saved, "report", "report saved with report.Save (repeatable)")
+}
+
+// parse reads the reports named by the flags, resolving file names against
+// root, and merges them
+func (r *reportFlags) parse(root string) (*report.Report, error) {
+	var modulePath string
+	if len(r.profile) > 0 {
+		var err error
+		if modulePath, err = report.GoModulePath(root); err != nil {
+			return nil, err
+		}
+	}
+	sourceDirs := []string(r.jacocoSourceDirs)
+	if len(sourceDirs) == 0 {
+		sourceDirs = []string{defaultJaCoCoSourceDir}
+	}
+	parsers := []struct {
+		names []string
+		parse func(r io.Reader) (*report.Report, error)
+	}{
+		{r.profile, func(r io.Reader) (*report.Report, error) { return report.ParseGoCoverProfile(r, modulePath) }},
+		{r.lcov, func(r io.Reader) (*report.Report, error) { return report.ParseLCOV(r, root) }},
+		{r.istanbul, func(r io.Reader) (*report.Report, error) { return report.ParseIstanbul(r, root) }},
+		{r.simplecov, func(r io.Reader) (*report.Report, error) { return report.ParseSimpleCov(r, root) }},
+		{r.cobertura, func(r io.Reader) (*report.Report, error) { return report.ParseCobertura(r, root) }},
+		{r.clover, func(r io.Reader) (*report.Report, error) { return report.ParseClover(r, root) }},
+		{r.jacoco, func(r io.Reader) (*report.Report, error) { return report.ParseJaCoCo(r, root, sourceDirs...) }},
+		{r.saved, report.Load},
+	}
+
+	var reports []*report.Report
+	for _, p := range parsers {
+		for _, name := range p.names {
+			parsed, err := parseFile(name, p.parse)
+			if err != nil {
+				return nil, err
+			}
+			reports = append(reports, parsed)
+		}
+	}
+	if len(reports) == 0 {
+		return nil, usageError{"no coverage reports: use --profile, --lcov or another report flag"}
+	}
+	return report.Merge(reports...), nil
+}
+
+// parseFile parses the report in the file name
+func parseFile(name string, parse func(r io.Reader) (*report.Report, error)) (*report.Report, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	parsed, err := parse(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	return parsed, nil
+}
+
+// submitFlags are the flags of coveralls submit
+type submitFlags struct {
+	reports          reportFlags
+	global           globalFlags
+	exclude          stringsFlag
+	root             string
+	excludeGenerated bool
+	repoToken        string
+	flagName         string
+	parallel         bool
+	dryRun           bool
+}
+
+// register defines the flags in fs
+func (f *submitFlags) register(fs *flag.FlagSet) {
+	f.reports.register(fs)
+	f.global.register(fs)
+	fs.Var(&f.exclude, "exclude", "glob of files to leave out, e.g. '**/*_test.go' (repeatable)")
+	fs.StringVar(&f.root, "root", ".", "root of the repository, where source files are read from")
+	fs.BoolVar(&f.excludeGenerated, "exclude-generated", false, "leave out generated Go files")
+	fs.StringVar(&f.repoToken, "repo-token", "", "repository token (default $"+coveralls.EnvRepoToken+" or $"+coveralls.EnvToken+")")
+	fs.StringVar(&f.flagName, "flag", "", "name of the job in a build with many jobs, e.g. unit")
+	fs.BoolVar(&f.parallel, "parallel", false, "whether more jobs will be sent for the same build, closed then with coveralls done")
+	fs.BoolVar(&f.dryRun, "dry-run", false, "print the job instead of sending it")
+}
+
+// submitCommand runs coveralls submit, which uploads coverage reports
+func submitCommand(ctx context.Context, args []string, stdout io.Writer, stderr io.Writer) error {
+	fs := flag.NewFlagSet("coveralls submit", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	var f submitFlags
+	f.register(fs)
+	global := &f.global
+	positional, err := global.parse(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) > 0 {
+		return usageError{fmt.Sprintf("submit: unexpected argument %q", positional[0])}
+	}
+	if f.repoToken == "" {
+		// Not a flag default, so usage doesn't print it
+		f.repoToken = repoTokenFromEnv()
+	}
+
+	merged, err := f.reports.parse(f.root)
+	if err != nil {
+		return err
+	}
+	opts := []report.Option{report.Exclude(f.exclude...)}
+	if f.excludeGenerated {
+		opts = append(opts, report.ExcludeGenerated())
+	}
+	job, err := merged.Job(f.root, opts...)
+	if err != nil {
+		return err
+	}
+	job.RepoToken = f.repoToken
+	job.FlagName = f.flagName
+	job.Parallel = f.parallel
+	if build := coveralls.DetectCI(); build != nil {
+		build.Apply(job)
+	}
+	if job.Git, err = gitinfo.Collect(ctx, f.root); err != nil {
+		fmt.Fprintf(stderr, "coveralls: warning: leaving out git information: %s\n", err)
+	}
+
+	client, err := global.clientWithToken(f.repoToken)
+	if err != nil {
+		return err
+	}
+	if f.dryRun {
+		var preview coveralls.Preview
+		if _, err := client.Jobs.Submit(ctx, job, coveralls.DryRun(&preview)); err != nil {
+			return err
+		}
+		_, err := fmt.Fprintf(stdout, "%s\n", preview.Body)
+		return err
+	}
+
+	if err := job.Validate(); err != nil {
+		return err
+	}
+	result, err := client.Jobs.SubmitMultipart(ctx, job, &coveralls.MultipartOptions{Gzip: true})
+	if err != nil {
+		return err
+	}
+	return global.print(stdout, result)
+}
+
+// repoTokenFromEnv returns the repository token set in the environment, as
+// coveralls.NewClientFromEnv reads it
+func repoTokenFromEnv() string {
+	if token := os.Getenv(coveralls.EnvRepoToken); token != "" {
+		return token
+	}
+	return os.Getenv(coveralls.EnvToken)
+}
+
+// stringsFlag is a flag that may be given many times
+type stringsFlag []string
+
+func (s *stringsFlag) String() string {
+	return strings.Join(*s, ",")
+}
+
+func (s *stringsFlag) Set(value string) error {
+	*s = append(*s, value)
+	return nil
+}
@@ -0,0 +1,201 @@
+/*
+Copyright (c) 2020 Loadsmart, Inc.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package main
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	coveralls "github.com/stone-payments/go-coveralls-api"
+)
+
+// project is a Go module with coverage reports, shared with the report package
+var project = filepath.Join("..", "..", "report", "testdata", "project")
+
+// setSubmitEnv sets the environment read by coveralls submit, so the tests
+// do not depend on the CI they run on
+func setSubmitEnv(t *testing.T) {
+	for _, name := range []string{"GITHUB_ACTIONS", "TRAVIS", "CIRCLECI", "GITLAB_CI", "BUILDKITE", "JENKINS_URL"} {
+		t.Setenv(name, "")
+	}
+	t.Setenv(coveralls.EnvRepoToken, "repo-token")
+	t.Setenv("COVERALLS_SERVICE_NAME", "test-ci")
+	t.Setenv("COVERALLS_SERVICE_NUMBER", "42")
+	t.Setenv("COVERALLS_SERVICE_JOB_ID", "7")
+	t.Setenv("COVERALLS_PULL_REQUEST", "")
+}
+
+func TestSubmit(t *testing.T) {
+	setSubmitEnv(t)
+	var job coveralls.Job
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/v1/jobs", r.URL.Path)
+		f, _, err := r.FormFile("json_file")
+		require.NoError(t, err)
+		defer f.Close()
+		gr, err := gzip.NewReader(f)
+		require.NoError(t, err)
+		require.NoError(t, json.NewDecoder(gr).Decode(&job))
+		_, _ = w.Write([]byte(`{"message":"Job #42.1","url":"https://coveralls.io/jobs/1"}`))
+	}))
+	defer server.Close()
+
+	code, stdout, stderr := runCommand(t, server, "submit",
+		"--profile", filepath.Join(project, "coverage.out"), "--lcov", filepath.Join(project, "lcov.info"),
+		"--root", project, "--flag", "unit", "--parallel", "--json")
+
+	assert.Equal(t, exitOK, code, stderr)
+	assert.Contains(t, stdout, `"url": "https://coveralls.io/jobs/1"`)
+	assert.Equal(t, "repo-token", job.RepoToken)
+	assert.Equal(t, "test-ci", job.ServiceName)
+	assert.Equal(t, "42", job.ServiceNumber)
+	assert.Equal(t, "7", job.ServiceJobID)
+	assert.Equal(t, "unit", job.FlagName)
+	assert.True(t, job.Parallel)
+	require.Len(t, job.SourceFiles, 2)
+	assert.Equal(t, "main.go", job.SourceFiles[0].Name)
+	assert.Equal(t, "pkg/even.go", job.SourceFiles[1].Name)
+	assert.NotEmpty(t, job.SourceFiles[1].Branches)
+}
+
+func TestSubmitDryRun(t *testing.T) {
+	setSubmitEnv(t)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected request %s %s", r.Method, r.URL)
+	}))
+	defer server.Close()
+
+	code, stdout, stderr := runCommand(t, server, "submit", "--dry-run",
+		"--lcov", filepath.Join(project, "lcov.info"), "--root", project, "--exclude", "main.go")
+
+	assert.Equal(t, exitOK, code, stderr)
+	var job coveralls.Job
+	require.NoError(t, json.Unmarshal([]byte(stdout), &job))
+	assert.Equal(t, "[REDACTED]", job.RepoToken)
+	assert.NotContains(t, stdout, "repo-token")
+	require.Len(t, job.SourceFiles, 1)
+	assert.Equal(t, "pkg/even.go", job.SourceFiles[0].Name)
+}
+
+// jacocoXML is a JaCoCo report of the Java sources in project
+const jacocoXML = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<report name="example">
+	<package name="com/example">
+		<sourcefile name="Even.java">
+			<line nr="3" mi="0" ci="3" mb="0" cb="0"/>
+			<line nr="8" mi="2" ci="0" mb="0" cb="0"/>
+		</sourcefile>
+	</package>
+</report>`
+
+func TestSubmitJaCoCo(t *testing.T) {
+	source, err := ioutil.ReadFile(filepath.Join(project, "src", "main", "java", "com", "example", "Even.java"))
+	require.NoError(t, err)
+	// A Gradle module, with its sources out of the default directory
+	module := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(module, "app", "src", "java", "com", "example"), 0o755))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(module, "app", "src", "java", "com", "example", "Even.java"), source, 0o644))
+	jacoco := filepath.Join(t.TempDir(), "jacoco.xml")
+	require.NoError(t, ioutil.WriteFile(jacoco, []byte(jacocoXML), 0o644))
+
+	var testCases = []struct {
+		name string
+		args []string
+		want string
+	}{
+		{name: "default source dir", args: []string{"--root", project}, want: "src/main/java/com/example/Even.java"},
+		{name: "source dirs", args: []string{"--root", module, "--jacoco-source-dir", "src/main/java", "--jacoco-source-dir", "app/src/java"}, want: "app/src/java/com/example/Even.java"},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			setSubmitEnv(t)
+
+			args := append([]string{"submit", "--dry-run", "--jacoco", jacoco}, tt.args...)
+			code, stdout, stderr := runCommand(t, nil, args...)
+
+			assert.Equal(t, exitOK, code, stderr)
+			var job coveralls.Job
+			require.NoError(t, json.Unmarshal([]byte(stdout), &job))
+			require.Len(t, job.SourceFiles, 1)
+			assert.Equal(t, tt.want, job.SourceFiles[0].Name)
+		})
+	}
+}
+
+func TestSubmitHelp(t *testing.T) {
+	setSubmitEnv(t)
+
+	code, _, stderr := runCommand(t, nil, "submit", "-h")
+
+	assert.Equal(t, exitOK, code)
+	assert.Contains(t, stderr, "-repo-token")
+	assert.NotContains(t, stderr, "repo-token\"")
+}
+
+func TestSubmitErrors(t *testing.T) {
+	lcov := filepath.Join(project, "lcov.info")
+	var testCases = []struct {
+		name      string
+		args      []string
+		repoToken string
+		code      int
+		stderr    string
+	}{
+		{name: "no reports", args: []string{"--root", project}, repoToken: "repo-token", code: exitUsage, stderr: "no coverage reports"},
+		{name: "unexpected argument", args: []string{"--lcov", lcov, "extra"}, repoToken: "repo-token", code: exitUsage, stderr: `unexpected argument "extra"`},
+		{name: "missing report", args: []string{"--lcov", "missing.info"}, repoToken: "repo-token", code: exitError, stderr: "missing.info"},
+		{name: "missing source file", args: []string{"--lcov", lcov}, repoToken: "repo-token", code: exitError, stderr: "reading source file"},
+		{name: "invalid job", args: []string{"--lcov", lcov, "--root", project}, code: exitUsage, stderr: "repo_token"},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			setSubmitEnv(t)
+			t.Setenv(coveralls.EnvRepoToken, tt.repoToken)
+			t.Setenv(coveralls.EnvToken, "")
+			t.Setenv("COVERALLS_SERVICE_NAME", "")
+			t.Setenv("COVERALLS_SERVICE_JOB_ID", "")
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, _ := ioutil.ReadAll(r.Body)
+				t.Errorf("unexpected request %s %s: %s", r.Method, r.URL, body)
+			}))
+			defer server.Close()
+
+			code, stdout, stderr := runCommand(t, server, append([]string{"submit"}, tt.args...)...)
+
+			assert.Equal(t, tt.code, code)
+			assert.Empty(t, stdout)
+			assert.Contains(t, stderr, tt.stderr)
+		})
+	}
+}
@@ -0,0 +1,83 @@
+/*
+Copyright (c) 2020 Loadsmart, Inc.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+
+	"github.com/stone-payments/go-coveralls-api/reposync"
+)
+
+// syncFlags are the flags of coveralls sync
+type syncFlags struct {
+	global globalFlags
+	dryRun bool
+}
+
+// register defines the flags in fs
+func (f *syncFlags) register(fs *flag.FlagSet) {
+	f.global.register(fs)
+	fs.BoolVar(&f.dryRun, "dry-run", false, "print the changes instead of applying them")
+}
+
+// syncCommand runs coveralls sync, which applies a manifest of repositories
+func syncCommand(ctx context.Context, args []string, stdout io.Writer, stderr io.Writer) error {
+	fs := flag.NewFlagSet("coveralls sync", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	var f syncFlags
+	f.register(fs)
+	global := &f.global
+	positional, err := global.parse(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 {
+		return usageError{"sync: expected one manifest file"}
+	}
+
+	manifest, err := reposync.ReadFile(positional[0])
+	if err != nil {
+		return err
+	}
+	client, err := global.client()
+	if err != nil {
+		return err
+	}
+	plan, err := reposync.NewPlan(ctx, client.Repositories, manifest)
+	if err != nil {
+		return err
+	}
+	if err := global.print(stdout, plan); err != nil {
+		return err
+	}
+	if f.dryRun {
+		return nil
+	}
+	if err := plan.Apply(ctx, client.Repositories); err != nil {
+		return fmt.Errorf("sync: %w", err)
+	}
+	return nil
+}
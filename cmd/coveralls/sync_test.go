@@ -0,0 +1,151 @@
+/*
+Copyright (c) 2020 Loadsmart, Inc.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const syncManifest = `
+defaults:
+  commit_status_fail_threshold: 80
+repositories:
+  - service: github
+    name: user/api
+  - service: github
+    name: user/new
+`
+
+// syncServer serves user/api with a threshold of 70 and no user/new,
+// recording the changes it receives
+func syncServer(t *testing.T, changes *[]string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/api/repos/github/user/api":
+			w.Write([]byte(`{"id": 1, "service": "github", "name": "user/api", "commit_status_fail_threshold": 70}`))
+		case r.Method == http.MethodGet:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"message": "Couldn't find a repository matching this request"}`))
+		case r.Method == http.MethodPost:
+			*changes = append(*changes, r.Method+" "+r.URL.Path)
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(`{"id": 2, "service": "github", "name": "user/new"}`))
+		default:
+			*changes = append(*changes, r.Method+" "+r.URL.Path)
+			w.Write([]byte(`{"id": 1, "service": "github", "name": "user/api"}`))
+		}
+	}))
+}
+
+func TestSync(t *testing.T) {
+	manifest := filepath.Join(t.TempDir(), "repos.yaml")
+	require.NoError(t, os.WriteFile(manifest, []byte(syncManifest), 0o600))
+	plan := "update github/user/api\n  commit_status_fail_threshold: 70 -> 80\ncreate github/user/new\n  commit_status_fail_threshold: 80\n"
+
+	var testCases = []struct {
+		name    string
+		args    []string
+		changes []string
+	}{
+		{name: "dry run", args: []string{"--dry-run"}},
+		{name: "apply", changes: []string{"PUT /api/repos/github/user/api", "POST /api/repos"}},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			var changes []string
+			server := syncServer(t, &changes)
+			defer server.Close()
+
+			code, stdout, stderr := runCommand(t, server, append([]string{"sync", manifest}, tt.args...)...)
+
+			assert.Equal(t, exitOK, code, stderr)
+			assert.Equal(t, plan, stdout)
+			assert.Equal(t, tt.changes, changes)
+		})
+	}
+}
+
+func TestSyncInvalidManifest(t *testing.T) {
+	manifest := filepath.Join(t.TempDir(), "repos.yaml")
+	require.NoError(t, os.WriteFile(manifest, []byte("repositories:\n  - service: github\n"), 0o600))
+
+	code, stdout, stderr := runCommand(t, nil, "sync", manifest)
+
+	assert.Equal(t, exitError, code)
+	assert.Empty(t, stdout)
+	assert.Contains(t, stderr, "missing service or name")
+}
+
+func TestDrift(t *testing.T) {
+	manifest := filepath.Join(t.TempDir(), "repos.yaml")
+	require.NoError(t, os.WriteFile(manifest, []byte(syncManifest), 0o600))
+
+	var testCases = []struct {
+		name   string
+		args   []string
+		json   bool
+		code   int
+		stdout string
+	}{
+		{
+			name:   "table",
+			code:   exitOK,
+			stdout: "REPO             FIELD                         EXPECTED  ACTUAL\ngithub/user/api  commit_status_fail_threshold  80        70\ngithub/user/new  exists                        true      false\n",
+		},
+		{
+			name:   "json",
+			args:   []string{"--json", "--exit-code"},
+			json:   true,
+			code:   exitError,
+			stdout: `[{"repo": "github/user/api", "field": "commit_status_fail_threshold", "expected": 80, "actual": 70}, {"repo": "github/user/new", "field": "exists", "expected": true, "actual": false}]`,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			var changes []string
+			server := syncServer(t, &changes)
+			defer server.Close()
+
+			code, stdout, stderr := runCommand(t, server, append([]string{"drift", manifest}, tt.args...)...)
+
+			assert.Equal(t, tt.code, code, stderr)
+			if tt.json {
+				assert.JSONEq(t, tt.stdout, stdout)
+				assert.Contains(t, stderr, "2 differences")
+			} else {
+				assert.Equal(t, tt.stdout, stdout)
+			}
+			assert.Empty(t, changes)
+		})
+	}
+}
@@ -0,0 +1,309 @@
+/*
+Copyright (c) 2020 Loadsmart, Inc.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	coveralls "github.com/stone-payments/go-coveralls-api"
+)
+
+// stdin is read by coveralls ui, replaced in tests
+var stdin io.Reader = os.Stdin
+
+// errQuit ends coveralls ui
+var errQuit = errors.New("quit")
+
+// browser is the state of coveralls ui
+type browser struct {
+	client *coveralls.Client
+	in     *bufio.Scanner
+	out    io.Writer
+	builds int                     // Number of recent builds shown of a repository
+	repos  []*coveralls.Repository // Repositories listed, numbered from 1
+}
+
+// uiFlags are the flags of coveralls ui
+type uiFlags struct {
+	global globalFlags
+	builds int
+}
+
+// register defines the flags in fs
+func (f *uiFlags) register(fs *flag.FlagSet) {
+	f.global.register(fs)
+	fs.IntVar(&f.builds, "builds", 10, "number of recent builds shown of a repository")
+}
+
+// uiCommand runs coveralls ui, an interactive browser of repositories,
+// their recent builds and settings
+func uiCommand(ctx context.Context, args []string, stdout io.Writer, stderr io.Writer) error {
+	fs := flag.NewFlagSet("coveralls ui", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	var f uiFlags
+	f.register(fs)
+	global := &f.global
+	positional, err := global.parse(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) > 1 {
+		return usageError{fmt.Sprintf("ui: unexpected argument %q", positional[1])}
+	}
+	var svc, name string
+	if len(positional) == 1 {
+		if svc, name, err = parseRepo(positional[0], global.service); err != nil {
+			return err
+		}
+	}
+	client, err := global.client()
+	if err != nil {
+		return err
+	}
+
+	b := &browser{client: client, in: bufio.NewScanner(stdin), out: stdout, builds: f.builds}
+	if name != "" {
+		err = b.repository(ctx, svc, name)
+	} else {
+		err = b.list(ctx)
+	}
+	if errors.Is(err, errQuit) {
+		return nil
+	}
+	return err
+}
+
+// prompt prints p and reads the next command, returning errQuit at the
+// end of the input
+func (b *browser) prompt(p string) (string, error) {
+	fmt.Fprintf(b.out, "%s> ", p)
+	if !b.in.Scan() {
+		fmt.Fprintln(b.out)
+		if err := b.in.Err(); err != nil {
+			return "", err
+		}
+		return "", errQuit
+	}
+	return strings.TrimSpace(b.in.Text()), nil
+}
+
+// list shows the repositories the token has access to and opens the one
+// chosen by number
+func (b *browser) list(ctx context.Context) error {
+	refresh := true
+	for {
+		if refresh {
+			repos, err := b.client.Repositories.List(ctx, nil)
+			if err != nil {
+				return err
+			}
+			b.repos = repos
+			b.writeRepos()
+			refresh = false
+		}
+
+		cmd, err := b.prompt("number to open, r to refresh, q to quit")
+		if err != nil {
+			return err
+		}
+		switch cmd {
+		case "":
+		case "q":
+			return errQuit
+		case "r":
+			refresh = true
+		default:
+			i, err := strconv.Atoi(cmd)
+			if err != nil || i < 1 || i > len(b.repos) {
+				fmt.Fprintf(b.out, "no repository %q\n", cmd)
+				continue
+			}
+			r := b.repos[i-1]
+			if err := b.repository(ctx, r.Service, r.Name); err != nil {
+				return err
+			}
+			b.writeRepos()
+		}
+	}
+}
+
+// writeRepos prints the numbered list of repositories
+func (b *browser) writeRepos() {
+	tw := tabwriter.NewWriter(b.out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "#\tREPOSITORY\tCOVERAGE\tLAST BUILD")
+	for i, r := range b.repos {
+		fmt.Fprintf(tw, "%d\t%s/%s\t%s\t%s\n", i+1, r.Service, r.Name, percent(r.CoveredPercent), r.LastBuildAt)
+	}
+	tw.Flush()
+}
+
+// repository shows a repository with its recent builds and edits its
+// settings until the user goes back
+func (b *browser) repository(ctx context.Context, svc string, name string) error {
+	refresh := true
+	for {
+		if refresh {
+			if err := b.writeRepository(ctx, svc, name); err != nil {
+				return err
+			}
+			refresh = false
+		}
+
+		cmd, err := b.prompt("threshold N, change N, comments on|off, status on|off, r to refresh, b to go back, q to quit")
+		if err != nil {
+			return err
+		}
+		fields := strings.Fields(cmd)
+		if len(fields) == 0 {
+			continue
+		}
+		switch fields[0] {
+		case "q":
+			return errQuit
+		case "b":
+			return nil
+		case "r":
+			refresh = true
+			continue
+		}
+
+		config, err := settingChange(svc, name, fields)
+		if err != nil {
+			fmt.Fprintln(b.out, err)
+			continue
+		}
+		if _, err := b.client.Repositories.Update(ctx, svc, name, config); err != nil {
+			fmt.Fprintf(b.out, "updating %s/%s: %s\n", svc, name, err)
+			continue
+		}
+		refresh = true
+	}
+}
+
+// writeRepository prints the settings and recent builds of a repository
+func (b *browser) writeRepository(ctx context.Context, svc string, name string) error {
+	repo, err := b.client.Repositories.Get(ctx, svc, name)
+	if err != nil {
+		return err
+	}
+	builds, err := b.client.Builds.List(ctx, svc, name, &coveralls.BuildListOptions{Limit: b.builds})
+	if err != nil && !errors.Is(err, coveralls.ErrRepoNotFound) {
+		return err
+	}
+
+	fmt.Fprintf(b.out, "\n%s/%s\n", repo.Service, repo.Name)
+	tw := tabwriter.NewWriter(b.out, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(tw, "  coverage\t%s\n", percent(repo.CoveredPercent))
+	fmt.Fprintf(tw, "  fail threshold\t%s\n", percent(repo.CommitStatusFailThreshold))
+	fmt.Fprintf(tw, "  fail change threshold\t%s\n", percent(repo.CommitStatusFailChangeThreshold))
+	fmt.Fprintf(tw, "  comment on pull requests\t%s\n", onOff(repo.CommentOnPullRequests))
+	fmt.Fprintf(tw, "  send build status\t%s\n", onOff(repo.SendBuildStatus))
+	tw.Flush()
+
+	if len(builds) == 0 {
+		fmt.Fprintln(b.out, "\nno builds")
+		return nil
+	}
+	fmt.Fprintln(b.out)
+	tw = tabwriter.NewWriter(b.out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "COMMIT\tBRANCH\tCOVERAGE\tCHANGE\tCREATED")
+	for _, build := range builds {
+		sha := build.CommitSHA
+		if len(sha) > 7 {
+			sha = sha[:7]
+		}
+		change := "-"
+		if build.CoverageChange != nil {
+			change = fmt.Sprintf("%+.2f", *build.CoverageChange)
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", sha, build.Branch, percent(build.CoveredPercent), change, build.CreatedAt)
+	}
+	return tw.Flush()
+}
+
+// settingChange parses a command that changes a setting of a repository
+// into the configuration sent to update it
+func settingChange(svc string, name string, fields []string) (*coveralls.RepositoryConfig, error) {
+	if len(fields) != 2 {
+		return nil, fmt.Errorf("unknown command %q", strings.Join(fields, " "))
+	}
+	config := &coveralls.RepositoryConfig{Service: svc, Name: name}
+	switch fields[0] {
+	case "threshold", "change":
+		var v optionalFloat
+		if err := v.Set(fields[1]); err != nil {
+			return nil, fmt.Errorf("invalid %s %q", fields[0], fields[1])
+		}
+		if fields[0] == "threshold" {
+			config.CommitStatusFailThreshold = v.value
+		} else {
+			config.CommitStatusFailChangeThreshold = v.value
+		}
+	case "comments", "status":
+		var on bool
+		switch fields[1] {
+		case "on":
+			on = true
+		case "off":
+		default:
+			return nil, fmt.Errorf("%s must be on or off", fields[0])
+		}
+		if fields[0] == "comments" {
+			config.CommentOnPullRequests = &on
+		} else {
+			config.SendBuildStatus = &on
+		}
+	default:
+		return nil, fmt.Errorf("unknown command %q", strings.Join(fields, " "))
+	}
+	return config, nil
+}
+
+// percent formats a coverage percentage, or - if unset
+func percent(v *float64) string {
+	if v == nil {
+		return "-"
+	}
+	return fmt.Sprintf("%.2f%%", *v)
+}
+
+// onOff formats a boolean setting, or - if unset
+func onOff(v *bool) string {
+	switch {
+	case v == nil:
+		return "-"
+	case *v:
+		return "on"
+	default:
+		return "off"
+	}
+}
@@ -0,0 +1,121 @@
+/*
+Copyright (c) 2020 Loadsmart, Inc.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	coveralls "github.com/stone-payments/go-coveralls-api"
+)
+
+// uiServer serves a single repository, whose fail threshold is changed by updates
+func uiServer(t *testing.T) *httptest.Server {
+	repo := map[string]interface{}{"id": 42, "service": "github", "name": "user/repository", "covered_percent": 87.5}
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.Method + " " + r.URL.Path {
+		case "GET /api/repos":
+			json.NewEncoder(w).Encode(map[string]interface{}{"page": 1, "pages": 1, "total": 1, "repos": []interface{}{repo}})
+		case "GET /api/repos/github/user/repository":
+			json.NewEncoder(w).Encode(repo)
+		case "PUT /api/repos/github/user/repository":
+			var body struct {
+				Repo map[string]interface{} `json:"repo"`
+			}
+			assert.Nil(t, json.NewDecoder(r.Body).Decode(&body))
+			assert.Equal(t, map[string]interface{}{"service": "github", "name": "user/repository", "commit_status_fail_threshold": 80.0}, body.Repo)
+			repo["commit_status_fail_threshold"] = body.Repo["commit_status_fail_threshold"]
+			json.NewEncoder(w).Encode(repo)
+		case "GET /github/user/repository.json":
+			w.Write([]byte(`{"page": 1, "pages": 1, "builds": [{"commit_sha": "abc1234567", "branch": "main", "covered_percent": 87.5, "coverage_change": -0.5}]}`))
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+}
+
+func TestUI(t *testing.T) {
+	server := uiServer(t)
+	defer server.Close()
+	stdin = strings.NewReader("2\n1\nthreshold 80\nthreshold high\nb\nq\n")
+	defer func() { stdin = os.Stdin }()
+
+	code, stdout, stderr := runCommand(t, server, "ui")
+
+	assert.Equal(t, exitOK, code, stderr)
+	assert.Contains(t, stdout, "1  github/user/repository  87.50%")
+	assert.Contains(t, stdout, `no repository "2"`)
+	assert.Contains(t, stdout, "abc1234  main    87.50%    -0.50")
+	assert.Contains(t, stdout, "fail threshold            80.00%")
+	assert.Contains(t, stdout, `invalid threshold "high"`)
+}
+
+func TestUIRepository(t *testing.T) {
+	server := uiServer(t)
+	defer server.Close()
+	stdin = strings.NewReader("comments maybe\n")
+	defer func() { stdin = os.Stdin }()
+
+	code, stdout, stderr := runCommand(t, server, "ui", "github/user/repository")
+
+	assert.Equal(t, exitOK, code, stderr)
+	assert.Contains(t, stdout, "github/user/repository\n")
+	assert.Contains(t, stdout, "comments must be on or off")
+}
+
+func TestSettingChange(t *testing.T) {
+	on, off, eighty := true, false, 80.0
+	var testCases = []struct {
+		cmd      string
+		expected *coveralls.RepositoryConfig
+		err      string
+	}{
+		{cmd: "threshold 80", expected: &coveralls.RepositoryConfig{Service: "github", Name: "user/repository", CommitStatusFailThreshold: &eighty}},
+		{cmd: "change 80", expected: &coveralls.RepositoryConfig{Service: "github", Name: "user/repository", CommitStatusFailChangeThreshold: &eighty}},
+		{cmd: "comments on", expected: &coveralls.RepositoryConfig{Service: "github", Name: "user/repository", CommentOnPullRequests: &on}},
+		{cmd: "status off", expected: &coveralls.RepositoryConfig{Service: "github", Name: "user/repository", SendBuildStatus: &off}},
+		{cmd: "status", err: `unknown command "status"`},
+		{cmd: "delete it", err: `unknown command "delete it"`},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.cmd, func(t *testing.T) {
+			config, err := settingChange("github", "user/repository", strings.Fields(tt.cmd))
+
+			if tt.err != "" {
+				assert.EqualError(t, err, tt.err)
+				return
+			}
+			assert.Nil(t, err)
+			assert.Equal(t, tt.expected, config)
+		})
+	}
+}
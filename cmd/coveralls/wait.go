@@ -0,0 +1,91 @@
+/*
+Copyright (c) 2020 Loadsmart, Inc.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"time"
+
+	coveralls "github.com/stone-payments/go-coveralls-api"
+	"github.com/stone-payments/go-coveralls-api/gitinfo"
+)
+
+// waitFlags are the flags of coveralls wait
+type waitFlags struct {
+	global   globalFlags
+	sha      string
+	timeout  time.Duration
+	interval time.Duration
+}
+
+// register defines the flags in fs
+func (f *waitFlags) register(fs *flag.FlagSet) {
+	f.global.register(fs)
+	fs.StringVar(&f.sha, "sha", "", "commit whose build to wait for (default HEAD of the working tree)")
+	fs.DurationVar(&f.timeout, "timeout", 10*time.Minute, "how long to wait")
+	fs.DurationVar(&f.interval, "interval", 5*time.Second, "delay between checks, doubled up to a minute")
+}
+
+// waitCommand runs coveralls wait, which waits until Coveralls finishes
+// processing the build of a commit
+func waitCommand(ctx context.Context, args []string, stdout io.Writer, stderr io.Writer) error {
+	fs := flag.NewFlagSet("coveralls wait", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	var f waitFlags
+	f.register(fs)
+	global := &f.global
+	positional, err := global.parse(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 {
+		return usageError{"wait: expected one repository, as SERVICE/NAME"}
+	}
+	svc, name, err := parseRepo(positional[0], global.service)
+	if err != nil {
+		return err
+	}
+	if f.sha == "" {
+		git, err := gitinfo.Collect(ctx, ".")
+		if err != nil {
+			return fmt.Errorf("wait: finding the commit, set --sha: %w", err)
+		}
+		f.sha = git.Head.ID
+	}
+
+	// Builds of public repositories can be read without a token
+	client, err := global.clientWithToken(global.token)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(ctx, f.timeout)
+	defer cancel()
+	build, err := client.Builds.Wait(ctx, svc, name, f.sha, &coveralls.WaitOptions{Interval: f.interval})
+	if err != nil {
+		return err
+	}
+	return global.print(stdout, build)
+}
@@ -0,0 +1,88 @@
+/*
+Copyright (c) 2020 Loadsmart, Inc.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package coveralls
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+)
+
+// WithGzipRequests makes the Client compress request bodies of at least
+// minSize bytes with gzip, e.g. large job payloads. Zero or less disables it,
+// which is the default.
+//
+// Streamed bodies, such as the ones sent by SubmitMultipart, are never
+// compressed; use MultipartOptions.Gzip for those.
+//
+// Responses are always requested and decompressed with gzip, regardless of
+// this option.
+func WithGzipRequests(minSize int) Option {
+	return func(o *options) {
+		o.gzipMinSize = minSize
+	}
+}
+
+// gzipBytes compresses b with gzip
+func gzipBytes(b []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(b); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// readBody reads the body of resp, decompressing it if needed
+func readBody(resp *http.Response) ([]byte, error) {
+	if !strings.EqualFold(resp.Header.Get("Content-Encoding"), "gzip") {
+		return ioutil.ReadAll(resp.Body)
+	}
+
+	zr, err := gzip.NewReader(resp.Body)
+	if err == io.EOF {
+		// Empty body, e.g. 204 No Content
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+
+	body, err := ioutil.ReadAll(zr)
+	if err != nil {
+		return nil, err
+	}
+	// The body is no longer encoded
+	resp.Header.Del("Content-Encoding")
+	resp.Header.Del("Content-Length")
+	resp.ContentLength = -1
+	resp.Uncompressed = true
+	return body, nil
+}
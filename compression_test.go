@@ -0,0 +1,131 @@
+/*
+Copyright (c) 2020 Loadsmart, Inc.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package coveralls
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithGzipRequests(t *testing.T) {
+	cases := []struct {
+		name         string
+		minSize      int
+		wantEncoding string
+	}{
+		{"disabled", 0, ""},
+		{"body smaller than minimum", 1 << 20, ""},
+		{"body larger than minimum", 10, "gzip"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			var encoding, body string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				encoding = req.Header.Get("Content-Encoding")
+				reader := req.Body
+				if encoding == "gzip" {
+					reader, _ = gzip.NewReader(req.Body)
+				}
+				b, _ := ioutil.ReadAll(reader)
+				body = string(b)
+				w.Header().Set("Content-Type", "application/json")
+				_, _ = w.Write([]byte(`{"message": "Job #1.1", "url": "https://coveralls.io/jobs/1"}`))
+			}))
+			defer server.Close()
+
+			client := NewClient("fake token", WithGzipRequests(tc.minSize))
+			client.HostURL, _ = url.Parse(server.URL)
+
+			_, err := client.Jobs.Submit(context.Background(), &Job{RepoToken: "repo token"})
+
+			assert.Nil(t, err)
+			assert.Equal(t, tc.wantEncoding, encoding)
+			assert.JSONEq(t, `{"repo_token": "repo token", "source_files": null}`, body)
+		})
+	}
+}
+
+func TestGzipResponse(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		assert.Equal(t, "gzip", req.Header.Get("Accept-Encoding"))
+		var buf bytes.Buffer
+		zw := gzip.NewWriter(&buf)
+		_, _ = zw.Write([]byte(`{"id": 123, "name": "user/fakerepo"}`))
+		_ = zw.Close()
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Content-Encoding", "gzip")
+		_, _ = w.Write(buf.Bytes())
+	}))
+	defer server.Close()
+
+	var out bytes.Buffer
+	client := NewClient("fake token", WithDebug(&out))
+	client.HostURL, _ = url.Parse(server.URL)
+
+	repo, err := client.Repositories.Get(context.Background(), "github", "user/fakerepo")
+
+	assert.Nil(t, err)
+	assert.Equal(t, 123, repo.ID)
+	assert.Contains(t, out.String(), `{"id": 123, "name": "user/fakerepo"}`)
+}
+
+func TestGzipResponseEmptyBody(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Encoding", "gzip")
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	client := NewClient("fake token")
+	client.HostURL, _ = url.Parse(server.URL)
+
+	err := client.Repositories.Delete(context.Background(), "github", "user/fakerepo")
+
+	assert.Nil(t, err)
+}
+
+func TestWithGzipRequestsDebug(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	var out bytes.Buffer
+	client := NewClient("fake token", WithGzipRequests(1), WithDebug(&out))
+	client.HostURL, _ = url.Parse(server.URL)
+
+	_, _ = client.Repositories.Update(context.Background(), "github", "user/fakerepo", &RepositoryConfig{Service: "github", Name: "user/fakerepo"})
+
+	assert.Contains(t, out.String(), "Content-Encoding: gzip\n")
+	assert.Contains(t, out.String(), `{"repo":{"service":"github","name":"user/fakerepo"}}`)
+}
@@ -0,0 +1,210 @@
+//go:build contract
+
+/*
+Copyright (c) 2020 Loadsmart, Inc.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package coveralls
+
+// Contract tests check the assumptions of the client about the status codes
+// and payloads of the real API. They are opt-in, run with
+//
+//	COVERALLS_CONTRACT_TOKEN=... COVERALLS_CONTRACT_REPO=user/disposable go test -tags=contract -run Contract .
+//
+// The repository must be disposable: its settings are changed and jobs are
+// submitted to it. It is added if missing, and then deleted at the end.
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// contractEnv holds the settings of the contract tests, from the environment
+type contractEnv struct {
+	token   string // COVERALLS_CONTRACT_TOKEN, personal access token
+	baseURL string // COVERALLS_CONTRACT_URL, base URL of an enterprise instance, if not coveralls.io
+	service string // COVERALLS_CONTRACT_SERVICE, git provider of the repository, github by default
+	repo    string // COVERALLS_CONTRACT_REPO, name of the disposable repository
+}
+
+// newContractClient returns a client of the API under test, skipping the
+// test if the contract tests are not set up
+func newContractClient(t *testing.T, token string) (*Client, contractEnv) {
+	env := contractEnv{
+		token:   os.Getenv("COVERALLS_CONTRACT_TOKEN"),
+		baseURL: os.Getenv("COVERALLS_CONTRACT_URL"),
+		service: os.Getenv("COVERALLS_CONTRACT_SERVICE"),
+		repo:    os.Getenv("COVERALLS_CONTRACT_REPO"),
+	}
+	if env.token == "" || env.repo == "" {
+		t.Skip("set COVERALLS_CONTRACT_TOKEN and COVERALLS_CONTRACT_REPO to run contract tests")
+	}
+	if env.service == "" {
+		env.service = "github"
+	}
+	if token == "" {
+		token = env.token
+	}
+
+	opts := []Option{WithTimeout(30 * time.Second)}
+	if env.baseURL == "" {
+		return NewClient(token, opts...), env
+	}
+	client, err := NewEnterpriseClient(env.baseURL, token, opts...)
+	require.Nil(t, err)
+	return client, env
+}
+
+// randomSHA returns a commit SHA no build has, so every run makes a new build
+func randomSHA(t *testing.T) string {
+	b := make([]byte, 20)
+	_, err := rand.Read(b)
+	require.Nil(t, err)
+	return hex.EncodeToString(b)
+}
+
+func TestContractUnauthorized(t *testing.T) {
+	client, env := newContractClient(t, "invalid-token")
+
+	_, err := client.Repositories.Get(context.Background(), env.service, env.repo)
+
+	assert.True(t, errors.Is(err, ErrUnauthorized), "got %v", err)
+}
+
+func TestContractRepositories(t *testing.T) {
+	ctx := context.Background()
+	client, env := newContractClient(t, "")
+
+	_, err := client.Repositories.Get(ctx, env.service, env.repo+"-missing-"+randomSHA(t)[:8])
+	assert.True(t, errors.Is(err, ErrRepoNotFound), "got %v", err)
+
+	repo := ensureContractRepo(t, client, env)
+	assert.NotZero(t, repo.ID)
+	assert.NotEmpty(t, repo.Token)
+
+	_, err = client.Repositories.Add(ctx, &RepositoryConfig{Service: env.service, Name: env.repo})
+	assert.True(t, errors.Is(err, ErrNameIsTaken), "got %v", err)
+
+	threshold, change := 42.5, 1.5
+	updated, err := client.Repositories.Update(ctx, env.service, env.repo, &RepositoryConfig{
+		Service:                         env.service,
+		Name:                            env.repo,
+		CommitStatusFailThreshold:       &threshold,
+		CommitStatusFailChangeThreshold: &change,
+	})
+	require.Nil(t, err)
+	require.NotNil(t, updated.CommitStatusFailThreshold)
+	assert.Equal(t, threshold, *updated.CommitStatusFailThreshold)
+	require.NotNil(t, updated.CommitStatusFailChangeThreshold)
+	assert.Equal(t, change, *updated.CommitStatusFailChangeThreshold)
+
+	byID, err := client.Repositories.GetByID(ctx, repo.ID)
+	require.Nil(t, err)
+	assert.Equal(t, env.repo, byID.Name)
+
+	repos, err := client.Repositories.List(ctx, &ListOptions{PerPage: 2})
+	require.Nil(t, err)
+	found := false
+	for _, r := range repos {
+		found = found || (r.Service == env.service && r.Name == env.repo)
+	}
+	assert.True(t, found, "%s/%s not listed", env.service, env.repo)
+}
+
+func TestContractJobs(t *testing.T) {
+	ctx := context.Background()
+	client, env := newContractClient(t, "")
+	repo := ensureContractRepo(t, client, env)
+	sha := randomSHA(t)
+	hits := func(n int) *int { return &n }
+
+	result, err := client.Jobs.Submit(ctx, &Job{
+		RepoToken:   repo.Token,
+		ServiceName: "contract-test",
+		CommitSHA:   sha,
+		Git:         &Git{Head: GitHead{ID: sha, Message: "Contract test"}, Branch: "contract-test"},
+		SourceFiles: []*SourceFile{{
+			Name:     "contract.go",
+			Source:   "package contract\n\nfunc covered() {}\n\nfunc uncovered() {}\n",
+			Coverage: []*int{nil, nil, hits(1), nil, hits(0)},
+		}},
+	})
+	require.Nil(t, err)
+	id, ok := result.JobID()
+	require.True(t, ok, "no job ID in %q", result.URL)
+
+	_, err = client.Jobs.Submit(ctx, &Job{RepoToken: "invalid-token", CommitSHA: sha, SourceFiles: []*SourceFile{}})
+	var unprocessable ErrUnprocessableEntity
+	assert.True(t, errors.As(err, &unprocessable), "got %v", err)
+
+	job, err := client.Jobs.Get(ctx, id)
+	require.Nil(t, err)
+	assert.Equal(t, id, job.ID)
+
+	waitCtx, cancel := context.WithTimeout(ctx, 5*time.Minute)
+	defer cancel()
+	build, err := client.Builds.Wait(waitCtx, env.service, env.repo, sha, nil)
+	require.Nil(t, err)
+	require.NotNil(t, build.CoveredPercent)
+	assert.Equal(t, 50.0, *build.CoveredPercent)
+
+	builds, err := client.Builds.List(ctx, env.service, env.repo, &BuildListOptions{Branch: "contract-test", Limit: 10})
+	require.Nil(t, err)
+	found := false
+	for _, b := range builds {
+		found = found || b.CommitSHA == sha
+	}
+	assert.True(t, found, "build %s not listed", sha)
+
+	file, err := client.SourceFiles.Get(ctx, env.service, env.repo, sha, "contract.go")
+	require.Nil(t, err)
+	assert.Equal(t, []int{5}, file.UncoveredLines())
+
+	_, err = client.Builds.Get(ctx, env.service, env.repo, randomSHA(t))
+	assert.True(t, errors.Is(err, ErrBuildNotFound), "got %v", err)
+}
+
+// ensureContractRepo returns the disposable repository, adding it if
+// missing and deleting it when the test ends
+func ensureContractRepo(t *testing.T, client *Client, env contractEnv) *Repository {
+	ctx := context.Background()
+	repo, err := client.Repositories.Get(ctx, env.service, env.repo)
+	if errors.Is(err, ErrRepoNotFound) {
+		repo, err = client.Repositories.Add(ctx, &RepositoryConfig{Service: env.service, Name: env.repo})
+	}
+	require.Nil(t, err)
+
+	t.Cleanup(func() {
+		err := client.Repositories.Delete(context.Background(), env.service, env.repo)
+		if err != nil && !errors.Is(err, ErrRepoNotFound) {
+			t.Errorf("deleting %s/%s: %v", env.service, env.repo, err)
+		}
+	})
+	return repo
+}
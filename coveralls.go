@@ -24,41 +24,429 @@ SOFTWARE.
 package coveralls
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"log/slog"
+	"net/http"
 	"net/url"
-
-	"github.com/go-resty/resty/v2"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
 )
 
 const (
-	defaultHostURL = "https://coveralls.io"
+	defaultHostURL        = "https://coveralls.io"
+	defaultAPIPath        = "/api"
+	defaultJobsAPIVersion = "v1"
 )
 
 // Client is used to provide a single interface to interact with Coveralls API
 type Client struct {
-	client *resty.Client
-	common service // Share the same client instance among all services
+	mu            sync.RWMutex       // Guards HostURL, token and header
+	options       *options           // Options the client was created with, used by Clone
+	token         string             // API token
+	client        *http.Client       // HTTP client built from the options, used unless doer is replaced
+	doer          Doer               // Sends all requests
+	header        http.Header        // Headers sent in all requests
+	tokenProvider TokenProvider      // Provides the API token when set, overriding the Authorization header
+	timeout       time.Duration      // Maximum duration of each request. Zero means no limit
+	repoToken     string             // Default Job.RepoToken, set by NewClientFromEnv
+	requestHooks  []RequestHook      // Called before each request
+	responseHooks []ResponseHook     // Called after each response
+	debug         *debugWriter       // Writes traces of requests when set
+	logger        *slog.Logger       // Logs every call when set
+	apiPath       string             // Path prefix of the API endpoints, e.g. /api
+	apiVersion    string             // Version of the API endpoints, e.g. v1. Empty means unversioned
+	gzipMinSize   int                // Minimum size of request bodies compressed with gzip. Zero disables it
+	retry         retryPolicy        // How requests that fail with transient errors are retried
+	breaker       *circuitBreaker    // Stops sending requests during outages when set
+	limiter       concurrencyLimiter // Limits the requests in flight when set
+	budget        *retryBudget       // Limits the retries of all calls when set
+	flights       *flightGroup       // Requests in flight that GET calls may share, when set
+	cache         Cache              // Stores responses to GET requests when set
+	staleIfError  *time.Duration     // Maximum age of cached responses returned during outages, when set
+	hedgeDelay    time.Duration      // Delay before hedging GET requests. Zero disables hedging
+	common        service            // Share the same client instance among all services
 
 	// Host URL for Coveralls. Defaults to https://coveralls.io
-	// Change this if you want to use private Coveralls server (untested)
-	HostURL      *url.URL
-	Repositories RepositoryService // Service to interact with repository-related endpoints
+	// Use NewEnterpriseClient to talk to a private Coveralls server.
+	// Use SetHostURL to change it while requests may be in flight
+	HostURL       *url.URL
+	Repositories  RepositoryService    // Service to interact with repository-related endpoints
+	Builds        BuildsService        // Service to interact with build-related endpoints
+	Jobs          JobsService          // Service to submit coverage reports
+	SourceFiles   SourceFilesService   // Service to query per-file coverage
+	Badges        BadgesService        // Service to build coverage badges
+	Organizations OrganizationsService // Service to interact with organization-related endpoints
 }
 
 type service struct {
 	client *Client
 }
 
+// ListOptions specifies the pagination options of List methods
+type ListOptions struct {
+	PerPage int // Number of results per page. Zero means the API default
+}
+
+// queryParams returns the query string parameters to request the given page
+func (o *ListOptions) queryParams(page int) map[string]string {
+	params := map[string]string{"page": strconv.Itoa(page)}
+	if o != nil && o.PerPage > 0 {
+		params["per_page"] = strconv.Itoa(o.PerPage)
+	}
+	return params
+}
+
 // NewClient returns a new Coveralls API Client
 // t is the Coveralls API token
-func NewClient(t string) *Client {
-	cli := resty.New()
-	cli.SetHeader("Accept", "application/json")
-	cli.SetHeader("Authorization", fmt.Sprintf("token %s", t))
+//
+// Opts customize how the client talks to the API; see the With* functions.
+// By default, requests go through the proxy set in the HTTPS_PROXY,
+// HTTP_PROXY and NO_PROXY environment variables.
+func NewClient(t string, opts ...Option) *Client {
+	o := &options{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	if o.token != nil {
+		t = *o.token
+	}
 
 	url, _ := url.Parse(defaultHostURL)
-	c := &Client{client: cli, HostURL: url}
+	return newClient(t, url, o, newHTTPClient(o))
+}
+
+// newClient returns a new Client using hc as HTTP client, configured by o
+func newClient(t string, hostURL *url.URL, o *options, hc *http.Client) *Client {
+	header := make(http.Header)
+	header.Set("Accept", "application/json")
+	header.Set("Authorization", fmt.Sprintf("token %s", t))
+	header.Set("User-Agent", DefaultUserAgent)
+	if o.userAgent != "" {
+		header.Set("User-Agent", o.userAgent)
+	}
+
+	c := &Client{
+		options:       o,
+		client:        hc,
+		token:         t,
+		header:        header,
+		tokenProvider: o.tokenProvider,
+		timeout:       o.timeout,
+		requestHooks:  o.requestHooks,
+		responseHooks: o.responseHooks,
+		debug:         o.debug,
+		logger:        o.logger,
+		apiPath:       defaultAPIPath,
+		apiVersion:    o.apiVersion,
+		gzipMinSize:   o.gzipMinSize,
+		retry:         o.retry,
+		cache:         o.cache,
+		staleIfError:  o.staleIfError,
+		hedgeDelay:    o.hedgeDelay,
+		HostURL:       hostURL,
+	}
+	if o.apiPath != nil {
+		c.apiPath = *o.apiPath
+	}
+	if o.circuitBreaker != nil {
+		c.breaker = newCircuitBreaker(*o.circuitBreaker)
+	}
+	if o.maxConcurrency > 0 {
+		c.limiter = newConcurrencyLimiter(o.maxConcurrency)
+	}
+	if o.retryBudget != nil {
+		c.budget = newRetryBudget(*o.retryBudget)
+	}
+	if o.deduplicate {
+		c.flights = newFlightGroup()
+	}
+	c.doer = c.client
+	if o.doer != nil {
+		c.doer = o.doer
+	}
 	c.common.client = c
 	c.Repositories = (*RepositoryServiceImpl)(&c.common)
+	c.Builds = (*BuildsServiceImpl)(&c.common)
+	c.Jobs = (*JobsServiceImpl)(&c.common)
+	c.SourceFiles = (*SourceFilesServiceImpl)(&c.common)
+	c.Badges = (*BadgesServiceImpl)(&c.common)
+	c.Organizations = (*OrganizationsServiceImpl)(&c.common)
 	return c
 }
+
+// Clone returns a copy of c changed by opts, e.g. WithToken to use another
+// token. The copy shares the HTTP client of c, and so its connection pool,
+// unless opts change it, e.g. with WithTransport or WithTLSConfig.
+//
+// The copy talks to the same host URL as c; use SetHostURL to change it.
+// Options that add hooks add to the hooks of c.
+func (c *Client) Clone(opts ...Option) *Client {
+	c.mu.RLock()
+	token := c.token
+	hostURL := *c.HostURL
+	c.mu.RUnlock()
+
+	o := c.options.clone()
+	changes := &options{}
+	for _, opt := range opts {
+		opt(o)
+		opt(changes)
+	}
+	if changes.token != nil {
+		token = *changes.token
+	}
+
+	hc := c.client
+	if changes.httpClient != nil || changes.transport != nil || changes.changesTransport() {
+		hc = newHTTPClient(o)
+	}
+
+	clone := newClient(token, &hostURL, o, hc)
+	clone.repoToken = c.repoToken
+	if changes.maxConcurrency == 0 {
+		clone.limiter = c.limiter
+	}
+	if changes.retryBudget == nil {
+		clone.budget = c.budget
+	}
+	return clone
+}
+
+// NewEnterpriseClient returns a new Coveralls API Client for a self-hosted
+// Coveralls server.
+//
+// BaseURL is the address of the server, e.g. https://coveralls.example.com.
+// It may include a path prefix when the server is mounted under a subpath,
+// e.g. https://example.com/coveralls. T is the Coveralls API token of that server.
+//
+// Use WithRootCAs if the server certificate is signed by an internal CA.
+func NewEnterpriseClient(baseURL string, t string, opts ...Option) (*Client, error) {
+	u, err := parseBaseURL(baseURL)
+	if err != nil {
+		return nil, err
+	}
+
+	c := NewClient(t, opts...)
+	c.HostURL = u
+	return c, nil
+}
+
+// SetHostURL changes the address of the Coveralls server used by the
+// Client. It is safe to call while requests are in flight; requests
+// already started keep using the previous address.
+//
+// BaseURL follows the same rules as in NewEnterpriseClient.
+func (c *Client) SetHostURL(baseURL string) error {
+	u, err := parseBaseURL(baseURL)
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.HostURL = u
+	return nil
+}
+
+// SetToken changes the Coveralls API token used by the Client. It is safe
+// to call while requests are in flight; requests already started keep
+// using the previous token.
+//
+// It has no effect on calls using WithRepoToken or on clients created with
+// WithTokenProvider.
+func (c *Client) SetToken(t string) {
+	header := c.headers()
+	header.Set("Authorization", fmt.Sprintf("token %s", t))
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.token = t
+	c.header = header
+}
+
+// hostURL returns the address of the Coveralls server
+func (c *Client) hostURL() *url.URL {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.HostURL
+}
+
+// apiEndpoint returns the path of an API endpoint relative to the host URL,
+// formatted as with fmt.Sprintf
+func (c *Client) apiEndpoint(format string, a ...interface{}) string {
+	prefix := c.apiPath
+	if c.apiVersion != "" {
+		prefix += "/" + c.apiVersion
+	}
+	return fmt.Sprintf("%s/%s", prefix, fmt.Sprintf(format, a...))
+}
+
+// jobsEndpoint returns the path of the endpoint that receives coverage
+// reports, which is versioned even when the other API endpoints are not
+func (c *Client) jobsEndpoint() string {
+	version := c.apiVersion
+	if version == "" {
+		version = defaultJobsAPIVersion
+	}
+	return fmt.Sprintf("%s/%s/jobs", c.apiPath, version)
+}
+
+// headers returns a copy of the headers sent in all requests
+func (c *Client) headers() http.Header {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.header.Clone()
+}
+
+// parseBaseURL validates the address of a Coveralls server
+func parseBaseURL(baseURL string) (*url.URL, error) {
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid base URL: %w", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, errors.New("invalid base URL: scheme must be http or https")
+	}
+	if u.Host == "" {
+		return nil, errors.New("invalid base URL: missing host")
+	}
+	if u.RawQuery != "" || u.Fragment != "" {
+		return nil, errors.New("invalid base URL: query and fragment are not allowed")
+	}
+	u.Path = strings.TrimSuffix(u.Path, "/")
+	u.RawPath = ""
+	return u, nil
+}
+
+// CallOption customizes a single call to the API. All service methods
+// accept a variable number of them after their regular arguments.
+// They may also be attached to a context with ContextWithCallOptions.
+type CallOption func(*callSettings)
+
+// callSettings holds the settings of a single call, built from its CallOptions
+type callSettings struct {
+	response **http.Response
+	metadata *ResponseMetadata
+	token    string
+	timeout  *time.Duration
+	hostURL  string
+	retries  *int
+
+	ignoreExisting bool
+	carryforward   []string
+	preview        *Preview
+	previewSecrets bool
+}
+
+// CaptureResponse stores in dst the HTTP response received, so callers can
+// inspect the status code and headers (e.g. rate limits or request IDs).
+// The response body was already consumed; dst gets a copy of it.
+//
+// Methods that walk through paginated results store the last response received.
+func CaptureResponse(dst **http.Response) CallOption {
+	return func(s *callSettings) {
+		s.response = dst
+	}
+}
+
+// ResponseMetadata describes the response to a call, see CaptureMetadata
+type ResponseMetadata struct {
+	StatusCode int    // Status code of the response
+	RequestID  string // ID the API assigned to the request, if any. Coveralls support may ask for it
+	Retries    int    // Number of times the request was retried
+	Stale      bool   // Whether the response came from the cache because the API was unreachable. See WithStaleIfError
+}
+
+// CaptureMetadata stores in dst information about the response received,
+// such as its request ID, whether the call succeeds or not. Failed calls
+// also report the request ID in their *APIError.
+//
+// Methods that walk through paginated results store the information of the
+// last response received.
+func CaptureMetadata(dst *ResponseMetadata) CallOption {
+	return func(s *callSettings) {
+		s.metadata = dst
+	}
+}
+
+// WithRepoToken authenticates a single call with token instead of the
+// token of the Client. Coveralls distinguishes personal API tokens from
+// repository tokens, and some endpoints (e.g. job submission) need the latter.
+//
+// JobsService also uses token as Job.RepoToken when the job has none.
+func WithRepoToken(token string) CallOption {
+	return func(s *callSettings) {
+		s.token = token
+	}
+}
+
+// WithCallTimeout limits how long each request of a single call may take,
+// overriding WithTimeout. Zero means no limit.
+func WithCallTimeout(d time.Duration) CallOption {
+	return func(s *callSettings) {
+		s.timeout = &d
+	}
+}
+
+// WithCallHostURL sends a single call to the Coveralls server at baseURL
+// instead of the host URL of the Client. BaseURL follows the same rules as
+// in NewEnterpriseClient; the call fails if it is invalid.
+func WithCallHostURL(baseURL string) CallOption {
+	return func(s *callSettings) {
+		s.hostURL = baseURL
+	}
+}
+
+// IgnoreExisting makes RepositoryService.Add return the repository that
+// already exists with the same name instead of ErrNameIsTaken. Other
+// methods ignore it.
+func IgnoreExisting() CallOption {
+	return func(s *callSettings) {
+		s.ignoreExisting = true
+	}
+}
+
+// Carryforward makes BuildsService.Close carry forward the coverage of the
+// given flags from the previous build, for flags with no job in the build
+// being closed. Other methods ignore it.
+func Carryforward(flags ...string) CallOption {
+	return func(s *callSettings) {
+		s.carryforward = append(s.carryforward, flags...)
+	}
+}
+
+// callOptionsKey is the context key of the CallOptions attached by ContextWithCallOptions
+type callOptionsKey struct{}
+
+// ContextWithCallOptions returns a copy of ctx carrying opts, which apply
+// to every call made with it. It lets code that can't change the arguments
+// of the calls, e.g. middleware, customize them.
+//
+// Options passed to a call directly take precedence over the ones in its
+// context. Options already attached to ctx are kept.
+func ContextWithCallOptions(ctx context.Context, opts ...CallOption) context.Context {
+	existing, _ := ctx.Value(callOptionsKey{}).([]CallOption)
+	all := make([]CallOption, 0, len(existing)+len(opts))
+	all = append(all, existing...)
+	all = append(all, opts...)
+	return context.WithValue(ctx, callOptionsKey{}, all)
+}
+
+// newCallSettings builds the settings of a call from the options attached
+// to its context and then from its own options
+func newCallSettings(ctx context.Context, opts []CallOption) *callSettings {
+	settings := &callSettings{}
+	if ctxOpts, ok := ctx.Value(callOptionsKey{}).([]CallOption); ok {
+		for _, opt := range ctxOpts {
+			opt(settings)
+		}
+	}
+	for _, opt := range opts {
+		opt(settings)
+	}
+	return settings
+}
@@ -1,7 +1,18 @@
 package coveralls
 
 import (
+	"context"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
 	"testing"
+	"time"
+
+	"github.com/jarcoal/httpmock"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -9,6 +20,250 @@ import (
 func TestNewClientWithAuthorizationHeader(t *testing.T) {
 	client := NewClient("my-personal-token")
 
-	authHeader := client.client.Header.Get("Authorization")
+	authHeader := client.header.Get("Authorization")
 	assert.Equal(t, "token my-personal-token", authHeader)
 }
+
+func TestCaptureResponse(t *testing.T) {
+	fakeUrl := "https://coveralls.io/api/repos/github/user/fakerepo"
+	httpmock.RegisterResponder("GET", fakeUrl, func(req *http.Request) (*http.Response, error) {
+		resp := httpmock.NewStringResponse(http.StatusOK, `{"id": 123}`)
+		resp.Header.Set("X-Request-Id", "req-1")
+		return resp, nil
+	})
+
+	client := NewClient("fake token")
+	httpmock.ActivateNonDefault(client.client)
+	defer httpmock.DeactivateAndReset()
+
+	var resp *http.Response
+	_, err := client.Repositories.Get(context.Background(), "github", "user/fakerepo", CaptureResponse(&resp))
+
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "req-1", resp.Header.Get("X-Request-Id"))
+	body, _ := ioutil.ReadAll(resp.Body)
+	assert.Equal(t, `{"id": 123}`, string(body))
+}
+
+func TestNewEnterpriseClient(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Path != "/coveralls/api/repos/github/user/fakerepo" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		assert.Equal(t, "token enterprise-token", req.Header.Get("Authorization"))
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"id": 123, "name": "user/fakerepo"}`)
+	}))
+	defer server.Close()
+
+	pool := x509.NewCertPool()
+	pool.AddCert(server.Certificate())
+
+	client, err := NewEnterpriseClient(server.URL+"/coveralls/", "enterprise-token", WithRootCAs(pool))
+	assert.Nil(t, err)
+
+	repo, err := client.Repositories.Get(context.Background(), "github", "user/fakerepo")
+
+	assert.Nil(t, err)
+	assert.Equal(t, &Repository{ID: 123, Name: "user/fakerepo"}, repo)
+}
+
+func TestNewEnterpriseClientUntrustedCertificate(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {}))
+	defer server.Close()
+
+	client, err := NewEnterpriseClient(server.URL, "enterprise-token")
+	assert.Nil(t, err)
+
+	_, err = client.Repositories.Get(context.Background(), "github", "user/fakerepo")
+
+	assert.NotNil(t, err)
+}
+
+func TestNewEnterpriseClientInvalidURL(t *testing.T) {
+	for _, baseURL := range []string{"", "coveralls.example.com", "ftp://coveralls.example.com", "https://", "https://coveralls.example.com/?x=1", "://"} {
+		t.Run(baseURL, func(t *testing.T) {
+			client, err := NewEnterpriseClient(baseURL, "enterprise-token")
+
+			assert.NotNil(t, err)
+			assert.Nil(t, client)
+		})
+	}
+}
+
+func TestWithRepoToken(t *testing.T) {
+	fakeUrl := "https://coveralls.io/api/repos/github/user/fakerepo"
+	var received []string
+	httpmock.RegisterResponder("GET", fakeUrl, func(req *http.Request) (*http.Response, error) {
+		received = append(received, req.Header.Get("Authorization"))
+		return httpmock.NewJsonResponse(http.StatusOK, &Repository{ID: 123})
+	})
+
+	client := NewClient("personal token")
+	httpmock.ActivateNonDefault(client.client)
+	defer httpmock.DeactivateAndReset()
+
+	_, err := client.Repositories.Get(context.Background(), "github", "user/fakerepo", WithRepoToken("repo token"))
+	assert.Nil(t, err)
+	_, err = client.Repositories.Get(context.Background(), "github", "user/fakerepo")
+	assert.Nil(t, err)
+
+	assert.Equal(t, []string{"token repo token", "token personal token"}, received)
+}
+
+func TestSetHostURL(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		assert.Equal(t, "/coveralls/api/repos/github/user/fakerepo", req.URL.Path)
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"id": 123, "name": "user/fakerepo"}`)
+	}))
+	defer server.Close()
+
+	client := NewClient("fake token")
+	err := client.SetHostURL(server.URL + "/coveralls/")
+	assert.Nil(t, err)
+
+	repo, err := client.Repositories.Get(context.Background(), "github", "user/fakerepo")
+	assert.Nil(t, err)
+	assert.Equal(t, 123, repo.ID)
+
+	err = client.SetHostURL("ftp://example.com")
+	assert.NotNil(t, err)
+	assert.Equal(t, server.URL+"/coveralls", client.hostURL().String())
+}
+
+func TestSetToken(t *testing.T) {
+	var tokens []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		tokens = append(tokens, req.Header.Get("Authorization"))
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	client := NewClient("old token")
+	_ = client.SetHostURL(server.URL)
+
+	_ = client.Repositories.Delete(context.Background(), "github", "user/fakerepo")
+	client.SetToken("new token")
+	_ = client.Repositories.Delete(context.Background(), "github", "user/fakerepo")
+
+	assert.Equal(t, []string{"token old token", "token new token"}, tokens)
+}
+
+func TestSetHostURLConcurrently(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	client := NewClient("fake token")
+	_ = client.SetHostURL(server.URL)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			assert.Nil(t, client.Repositories.Delete(context.Background(), "github", "user/fakerepo"))
+		}()
+		go func(i int) {
+			defer wg.Done()
+			_ = client.SetHostURL(server.URL)
+			client.SetToken(fmt.Sprintf("token %d", i))
+		}(i)
+	}
+	wg.Wait()
+}
+
+func TestWithCallTimeout(t *testing.T) {
+	server := newHangingServer(t)
+
+	client, _ := NewEnterpriseClient(server.URL, "fake token", WithTimeout(time.Hour))
+
+	_, err := client.Repositories.Get(context.Background(), "github", "user/fakerepo", WithCallTimeout(20*time.Millisecond))
+
+	assert.True(t, errors.Is(err, ErrTimeout))
+}
+
+func TestWithCallHostURL(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		assert.Equal(t, "/tenant/api/repos/github/user/fakerepo", req.URL.Path)
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"id": 123}`)
+	}))
+	defer server.Close()
+
+	client := NewClient("fake token")
+
+	repo, err := client.Repositories.Get(context.Background(), "github", "user/fakerepo", WithCallHostURL(server.URL+"/tenant"))
+	assert.Nil(t, err)
+	assert.Equal(t, 123, repo.ID)
+	assert.Equal(t, "https://coveralls.io", client.hostURL().String())
+
+	_, err = client.Repositories.Get(context.Background(), "github", "user/fakerepo", WithCallHostURL("not a url"))
+	assert.NotNil(t, err)
+}
+
+func TestContextWithCallOptions(t *testing.T) {
+	var received []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		received = append(received, req.Header.Get("Authorization"))
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	client := NewClient("personal token")
+	ctx := ContextWithCallOptions(context.Background(), WithCallHostURL(server.URL))
+	ctx = ContextWithCallOptions(ctx, WithRepoToken("context token"))
+
+	err := client.Repositories.Delete(ctx, "github", "user/fakerepo")
+	assert.Nil(t, err)
+	err = client.Repositories.Delete(ctx, "github", "user/fakerepo", WithRepoToken("call token"))
+	assert.Nil(t, err)
+
+	assert.Equal(t, []string{"token context token", "token call token"}, received)
+}
+
+func TestClone(t *testing.T) {
+	var received []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		received = append(received, req.Header.Get("Authorization")+" "+req.Header.Get("User-Agent"))
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	client, _ := NewEnterpriseClient(server.URL, "tenant 1", WithUserAgent("my-tool"))
+	clone := client.Clone(WithToken("tenant 2"))
+
+	assert.Same(t, client.client, clone.client)
+	assert.Equal(t, client.HostURL.String(), clone.HostURL.String())
+
+	assert.Nil(t, client.Repositories.Delete(context.Background(), "github", "user/fakerepo"))
+	assert.Nil(t, clone.Repositories.Delete(context.Background(), "github", "user/fakerepo"))
+	assert.Equal(t, []string{"token tenant 1 my-tool", "token tenant 2 my-tool"}, received)
+
+	_ = clone.SetHostURL("https://coveralls.example.com")
+	assert.Equal(t, server.URL, client.hostURL().String())
+}
+
+func TestCloneKeepsToken(t *testing.T) {
+	client := NewClient("old token")
+	client.SetToken("new token")
+
+	clone := client.Clone()
+
+	assert.Equal(t, "token new token", clone.header.Get("Authorization"))
+}
+
+func TestCloneWithTransport(t *testing.T) {
+	client := NewClient("fake token", WithTimeout(time.Minute))
+	transport := &http.Transport{}
+
+	clone := client.Clone(WithTransport(transport))
+
+	assert.Same(t, transport, clone.client.Transport)
+	assert.Equal(t, http.DefaultTransport, client.client.Transport)
+	assert.Equal(t, time.Minute, clone.timeout)
+}
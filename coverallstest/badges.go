@@ -0,0 +1,85 @@
+/*
+Copyright (c) 2020 Loadsmart, Inc.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package coverallstest
+
+import (
+	"context"
+	"sync"
+
+	coveralls "github.com/stone-payments/go-coveralls-api"
+)
+
+// Badges is a fake of coveralls.BadgesService. URL and Markdown return the
+// badges of coveralls.io, as the client does; as they can't fail, failures
+// set for them are ignored.
+type Badges struct {
+	Recorder
+	mu      sync.Mutex
+	shields map[string]*coveralls.Shield
+	badges  coveralls.BadgesService
+}
+
+var _ coveralls.BadgesService = (*Badges)(nil)
+
+// NewBadges returns a fake without shields
+func NewBadges() *Badges {
+	return &Badges{
+		shields: make(map[string]*coveralls.Shield),
+		badges:  coveralls.NewClient("").Badges,
+	}
+}
+
+// PutShield sets the shield Shield returns for a branch of a repository
+func (f *Badges) PutShield(svc string, repo string, branch string, shield *coveralls.Shield) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	c := *shield
+	f.shields[key(svc, repo)+"@"+branch] = &c
+}
+
+// URL returns the address of the badge image of a repository
+func (f *Badges) URL(svc string, repo string, branch string) string {
+	f.record("URL", svc, repo, branch)
+	return f.badges.URL(svc, repo, branch)
+}
+
+// Markdown returns the badge of a repository as Markdown
+func (f *Badges) Markdown(svc string, repo string, branch string) string {
+	f.record("Markdown", svc, repo, branch)
+	return f.badges.Markdown(svc, repo, branch)
+}
+
+// Shield returns the shield put with PutShield, or coveralls.ErrRepoNotFound
+func (f *Badges) Shield(ctx context.Context, svc string, repo string, branch string, opts ...coveralls.CallOption) (*coveralls.Shield, error) {
+	if err := f.record("Shield", svc, repo, branch); err != nil {
+		return nil, err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	shield, ok := f.shields[key(svc, repo)+"@"+branch]
+	if !ok {
+		return nil, coveralls.ErrRepoNotFound
+	}
+	c := *shield
+	return &c, nil
+}
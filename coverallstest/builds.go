@@ -0,0 +1,284 @@
+/*
+Copyright (c) 2020 Loadsmart, Inc.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package coverallstest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	coveralls "github.com/stone-payments/go-coveralls-api"
+)
+
+// defaultWaitInterval is how often Builds.Wait checks the build when opts
+// don't set it, shorter than the client's so tests don't wait
+const defaultWaitInterval = 10 * time.Millisecond
+
+// Builds is a fake of coveralls.BuildsService that keeps builds in memory.
+// Repositories without builds put are not found.
+type Builds struct {
+	Recorder
+	mu          sync.Mutex
+	builds      map[string][]*coveralls.Build // Builds of each repository, most recent first
+	comparisons map[string]*coveralls.BuildComparison
+}
+
+var _ coveralls.BuildsService = (*Builds)(nil)
+
+// NewBuilds returns a fake without builds
+func NewBuilds() *Builds {
+	return &Builds{
+		builds:      make(map[string][]*coveralls.Build),
+		comparisons: make(map[string]*coveralls.BuildComparison),
+	}
+}
+
+// Put adds builds of a repository, most recent first, as more recent than
+// the ones it has. Builds of a commit already put are replaced instead,
+// e.g. to set the coverage of a build Wait is waiting for.
+func (f *Builds) Put(svc string, repo string, builds ...*coveralls.Build) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	existing := f.builds[key(svc, repo)]
+	var added []*coveralls.Build
+	for _, b := range builds {
+		b := *b
+		replaced := false
+		for i, e := range existing {
+			if e.CommitSHA == b.CommitSHA {
+				existing[i] = &b
+				replaced = true
+			}
+		}
+		if !replaced {
+			added = append(added, &b)
+		}
+	}
+	f.builds[key(svc, repo)] = append(added, existing...)
+}
+
+// PutComparison sets the comparison Compare returns for the commits
+// baseSHA and headSHA of a repository, whatever the paths
+func (f *Builds) PutComparison(svc string, repo string, baseSHA string, headSHA string, comparison *coveralls.BuildComparison) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.comparisons[comparisonKey(svc, repo, baseSHA, headSHA)] = comparison
+}
+
+// Get returns the build of the commit sha, or coveralls.ErrBuildNotFound
+func (f *Builds) Get(ctx context.Context, svc string, repo string, sha string, opts ...coveralls.CallOption) (*coveralls.Build, error) {
+	if err := f.record("Get", svc, repo, sha); err != nil {
+		return nil, err
+	}
+	return f.get(svc, repo, sha)
+}
+
+// Close records the call only
+func (f *Builds) Close(ctx context.Context, repoToken string, buildNum string, opts ...coveralls.CallOption) error {
+	return f.record("Close", repoToken, buildNum)
+}
+
+// List returns the builds of a repository, most recent first, filtered
+// by branch and pull request and limited as set by opts, which may be nil
+func (f *Builds) List(ctx context.Context, svc string, repo string, opts *coveralls.BuildListOptions, callOpts ...coveralls.CallOption) ([]*coveralls.Build, error) {
+	if err := f.record("List", svc, repo, opts); err != nil {
+		return nil, err
+	}
+	return f.list(svc, repo, opts)
+}
+
+// Rerun returns coveralls.ErrBuildNotFound if the build doesn't exist, and
+// otherwise records the call only
+func (f *Builds) Rerun(ctx context.Context, svc string, repo string, sha string, opts ...coveralls.CallOption) error {
+	if err := f.record("Rerun", svc, repo, sha); err != nil {
+		return err
+	}
+	_, err := f.get(svc, repo, sha)
+	return err
+}
+
+// LatestForBranch returns the most recent build of a branch
+func (f *Builds) LatestForBranch(ctx context.Context, svc string, repo string, branch string, opts ...coveralls.CallOption) (*coveralls.Build, error) {
+	if err := f.record("LatestForBranch", svc, repo, branch); err != nil {
+		return nil, err
+	}
+	return f.latest(svc, repo, &coveralls.BuildListOptions{Branch: branch, Limit: 1})
+}
+
+// ForPullRequest returns the most recent build of a pull request
+func (f *Builds) ForPullRequest(ctx context.Context, svc string, repo string, prNumber int, opts ...coveralls.CallOption) (*coveralls.Build, error) {
+	if err := f.record("ForPullRequest", svc, repo, prNumber); err != nil {
+		return nil, err
+	}
+	return f.latest(svc, repo, &coveralls.BuildListOptions{PullRequest: prNumber, Limit: 1})
+}
+
+// Trend returns the coverage of the builds created between from and to,
+// oldest first, as the client does. Builds whose CreatedAt is not in
+// RFC 3339 format, or without coverage, are left out.
+func (f *Builds) Trend(ctx context.Context, svc string, repo string, from time.Time, to time.Time, opts ...coveralls.CallOption) ([]*coveralls.CoveragePoint, error) {
+	if err := f.record("Trend", svc, repo, from, to); err != nil {
+		return nil, err
+	}
+	builds, err := f.list(svc, repo, nil)
+	if err != nil {
+		return nil, err
+	}
+	var points []*coveralls.CoveragePoint
+	for i := len(builds) - 1; i >= 0; i-- {
+		b := builds[i]
+		createdAt, err := time.Parse(time.RFC3339, b.CreatedAt)
+		if err != nil || createdAt.Before(from) || createdAt.After(to) || b.CoveredPercent == nil {
+			continue
+		}
+		points = append(points, &coveralls.CoveragePoint{
+			Time:           createdAt,
+			CoveredPercent: *b.CoveredPercent,
+			CommitSHA:      b.CommitSHA,
+			Branch:         b.Branch,
+		})
+	}
+	return points, nil
+}
+
+// Compare returns the comparison put with PutComparison, or
+// coveralls.ErrBuildNotFound
+func (f *Builds) Compare(ctx context.Context, svc string, repo string, baseSHA string, headSHA string, paths []string, opts ...coveralls.CallOption) (*coveralls.BuildComparison, error) {
+	if err := f.record("Compare", svc, repo, baseSHA, headSHA, paths); err != nil {
+		return nil, err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	c, ok := f.comparisons[comparisonKey(svc, repo, baseSHA, headSHA)]
+	if !ok {
+		return nil, coveralls.ErrBuildNotFound
+	}
+	return c, nil
+}
+
+// Wait checks the build of the commit sha every opts.Interval, 10ms if
+// unset, until it has coverage, e.g. after another goroutine puts it. It
+// fails as the client does when ctx is done first.
+func (f *Builds) Wait(ctx context.Context, svc string, repo string, sha string, opts *coveralls.WaitOptions, callOpts ...coveralls.CallOption) (*coveralls.Build, error) {
+	if err := f.record("Wait", svc, repo, sha, opts); err != nil {
+		return nil, err
+	}
+	interval := defaultWaitInterval
+	if opts != nil && opts.Interval > 0 {
+		interval = opts.Interval
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		build, err := f.get(svc, repo, sha)
+		if err == nil && build.CoveredPercent != nil {
+			return build, nil
+		}
+		if err != nil && !errors.Is(err, coveralls.ErrBuildNotFound) {
+			return nil, err
+		}
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return nil, fmt.Errorf("waiting for the build of %s: %w", sha, ctx.Err())
+		}
+	}
+}
+
+// repoOf returns the repository of the build of the commit sha, as the
+// API finds builds by commit only
+func (f *Builds) repoOf(sha string) (svc string, repo string, ok bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for k, builds := range f.builds {
+		for _, b := range builds {
+			if b.CommitSHA == sha {
+				parts := strings.SplitN(k, "/", 2)
+				return parts[0], parts[1], true
+			}
+		}
+	}
+	return "", "", false
+}
+
+// get returns a copy of the build of the commit sha
+func (f *Builds) get(svc string, repo string, sha string) (*coveralls.Build, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, b := range f.builds[key(svc, repo)] {
+		if b.CommitSHA == sha {
+			c := *b
+			return &c, nil
+		}
+	}
+	return nil, coveralls.ErrBuildNotFound
+}
+
+// list returns copies of the builds of a repository selected by opts,
+// which may be nil
+func (f *Builds) list(svc string, repo string, opts *coveralls.BuildListOptions) ([]*coveralls.Build, error) {
+	if opts == nil {
+		opts = &coveralls.BuildListOptions{}
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	all, ok := f.builds[key(svc, repo)]
+	if !ok {
+		return nil, coveralls.ErrRepoNotFound
+	}
+	var builds []*coveralls.Build
+	for _, b := range all {
+		if opts.Limit > 0 && len(builds) == opts.Limit {
+			break
+		}
+		if (opts.Branch != "" && b.Branch != opts.Branch) || (opts.PullRequest > 0 && b.PullRequest != opts.PullRequest) {
+			continue
+		}
+		c := *b
+		builds = append(builds, &c)
+	}
+	return builds, nil
+}
+
+// latest returns the first build selected by opts, or
+// coveralls.ErrBuildNotFound
+func (f *Builds) latest(svc string, repo string, opts *coveralls.BuildListOptions) (*coveralls.Build, error) {
+	builds, err := f.list(svc, repo, opts)
+	if err != nil {
+		return nil, err
+	}
+	if len(builds) == 0 {
+		return nil, coveralls.ErrBuildNotFound
+	}
+	return builds[0], nil
+}
+
+// comparisonKey returns the key of a comparison put with PutComparison
+func comparisonKey(svc string, repo string, baseSHA string, headSHA string) string {
+	return key(svc, repo) + "@" + baseSHA + "..." + headSHA
+}
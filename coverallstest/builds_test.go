@@ -0,0 +1,128 @@
+/*
+Copyright (c) 2020 Loadsmart, Inc.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package coverallstest
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	coveralls "github.com/stone-payments/go-coveralls-api"
+)
+
+func newFakeBuilds() *Builds {
+	f := NewBuilds()
+	f.Put("github", "user/api",
+		&coveralls.Build{CommitSHA: "c3", Branch: "feature", PullRequest: 7, CreatedAt: "2020-03-03T00:00:00Z", CoveredPercent: pfloat64(82)},
+		&coveralls.Build{CommitSHA: "c2", Branch: "main", CreatedAt: "2020-03-02T00:00:00Z", CoveredPercent: pfloat64(81)},
+		&coveralls.Build{CommitSHA: "c1", Branch: "main", CreatedAt: "2020-03-01T00:00:00Z", CoveredPercent: pfloat64(80)},
+	)
+	return f
+}
+
+func TestBuilds(t *testing.T) {
+	ctx := context.Background()
+	f := newFakeBuilds()
+
+	build, err := f.Get(ctx, "github", "user/api", "c2")
+
+	require.Nil(t, err)
+	assert.Equal(t, 81.0, *build.CoveredPercent)
+
+	_, err = f.Get(ctx, "github", "user/api", "c9")
+
+	assert.True(t, errors.Is(err, coveralls.ErrBuildNotFound))
+
+	builds, err := f.List(ctx, "github", "user/api", &coveralls.BuildListOptions{Branch: "main"})
+
+	require.Nil(t, err)
+	require.Len(t, builds, 2)
+	assert.Equal(t, "c2", builds[0].CommitSHA)
+
+	_, err = f.List(ctx, "github", "user/web", nil)
+
+	assert.True(t, errors.Is(err, coveralls.ErrRepoNotFound))
+
+	build, err = f.LatestForBranch(ctx, "github", "user/api", "main")
+
+	require.Nil(t, err)
+	assert.Equal(t, "c2", build.CommitSHA)
+
+	build, err = f.ForPullRequest(ctx, "github", "user/api", 7)
+
+	require.Nil(t, err)
+	assert.Equal(t, "c3", build.CommitSHA)
+
+	_, err = f.ForPullRequest(ctx, "github", "user/api", 8)
+
+	assert.True(t, errors.Is(err, coveralls.ErrBuildNotFound))
+
+	points, err := f.Trend(ctx, "github", "user/api", time.Date(2020, 3, 1, 0, 0, 0, 0, time.UTC), time.Date(2020, 3, 2, 0, 0, 0, 0, time.UTC))
+
+	require.Nil(t, err)
+	require.Len(t, points, 2)
+	assert.Equal(t, "c1", points[0].CommitSHA)
+	assert.Equal(t, 81.0, points[1].CoveredPercent)
+
+	assert.Nil(t, f.Rerun(ctx, "github", "user/api", "c1"))
+	assert.True(t, errors.Is(f.Rerun(ctx, "github", "user/api", "c9"), coveralls.ErrBuildNotFound))
+	assert.Nil(t, f.Close(ctx, "repo-token", "42"))
+	assert.Equal(t, []Call{{Method: "Close", Args: []interface{}{"repo-token", "42"}}}, f.CallsTo("Close"))
+
+	comparison := &coveralls.BuildComparison{CoverageChange: pfloat64(1)}
+	f.PutComparison("github", "user/api", "c2", "c3", comparison)
+	found, err := f.Compare(ctx, "github", "user/api", "c2", "c3", nil)
+
+	require.Nil(t, err)
+	assert.Equal(t, comparison, found)
+
+	_, err = f.Compare(ctx, "github", "user/api", "c1", "c3", nil)
+
+	assert.True(t, errors.Is(err, coveralls.ErrBuildNotFound))
+}
+
+func TestBuildsWait(t *testing.T) {
+	f := newFakeBuilds()
+	go func() {
+		time.Sleep(5 * time.Millisecond)
+		f.Put("github", "user/api", &coveralls.Build{CommitSHA: "c4"})
+		time.Sleep(5 * time.Millisecond)
+		f.Put("github", "user/api", &coveralls.Build{CommitSHA: "c4", CoveredPercent: pfloat64(83)})
+	}()
+
+	build, err := f.Wait(context.Background(), "github", "user/api", "c4", &coveralls.WaitOptions{Interval: time.Millisecond})
+
+	require.Nil(t, err)
+	assert.Equal(t, 83.0, *build.CoveredPercent)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	_, err = f.Wait(ctx, "github", "user/api", "c9", nil)
+
+	assert.True(t, errors.Is(err, context.DeadlineExceeded))
+}
@@ -0,0 +1,276 @@
+/*
+Copyright (c) 2020 Loadsmart, Inc.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package coverallstest
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// Redacted replaces secrets in the interactions recorded to cassettes
+const Redacted = "REDACTED"
+
+// ErrNoInteraction is returned by a replaying Cassette for requests it has
+// no recorded interaction left for
+var ErrNoInteraction = errors.New("coverallstest: no recorded interaction matches the request")
+
+// Mode sets whether a Cassette records or replays interactions
+type Mode int
+
+const (
+	// ModeReplay answers requests with the interactions of the cassette,
+	// without sending them
+	ModeReplay Mode = iota
+	// ModeRecord sends requests to the API and records the interactions, to
+	// be written to the cassette by Save
+	ModeRecord
+)
+
+// Interaction is a request and its response, as recorded to a cassette
+type Interaction struct {
+	Request  RecordedRequest  `json:"request"`
+	Response RecordedResponse `json:"response"`
+}
+
+// RecordedRequest is a request recorded to a cassette. The body is kept
+// only when it is JSON, not compressed nor multipart.
+type RecordedRequest struct {
+	Method string      `json:"method"`
+	URL    string      `json:"url"`
+	Header http.Header `json:"header,omitempty"`
+	Body   string      `json:"body,omitempty"`
+}
+
+// RecordedResponse is a response recorded to a cassette
+type RecordedResponse struct {
+	StatusCode int         `json:"status_code"`
+	Header     http.Header `json:"header,omitempty"`
+	Body       string      `json:"body,omitempty"`
+}
+
+// Cassette is an http.RoundTripper that records interactions with the API
+// and replays them, making tests that were written against the live API
+// hermetic. Plug it into a client with coveralls.WithTransport.
+//
+// Secrets are scrubbed before interactions are recorded: the Authorization
+// and cookie headers are dropped, and repository tokens in URLs and JSON
+// bodies, along with any of Secrets, are replaced with Redacted.
+//
+// Requests are matched to interactions by method, path and query, ignoring
+// the host, in the order they were recorded.
+type Cassette struct {
+	Name         string         // Path of the cassette file
+	Secrets      []string       // Other values to scrub, e.g. the personal access token
+	Interactions []*Interaction // Interactions recorded or loaded from Name
+
+	mode      Mode
+	transport http.RoundTripper
+	mu        sync.Mutex
+	used      []bool
+}
+
+// NewCassette returns a cassette stored in the file name. In ModeReplay
+// the file is loaded and must exist; in ModeRecord requests are sent
+// through transport, or http.DefaultTransport if it is nil, and the file
+// is written by Save.
+func NewCassette(name string, mode Mode, transport http.RoundTripper) (*Cassette, error) {
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	c := &Cassette{Name: name, mode: mode, transport: transport}
+	if mode == ModeRecord {
+		return c, nil
+	}
+
+	b, err := os.ReadFile(name)
+	if err != nil {
+		return nil, fmt.Errorf("reading cassette: %w", err)
+	}
+	if err := json.Unmarshal(b, &c.Interactions); err != nil {
+		return nil, fmt.Errorf("decoding cassette %s: %w", name, err)
+	}
+	c.used = make([]bool, len(c.Interactions))
+	return c, nil
+}
+
+// RoundTrip records or replays the interaction of req
+func (c *Cassette) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		req.Body = io.NopCloser(bytes.NewReader(body))
+	}
+	recorded := c.request(req, body)
+
+	if c.mode == ModeReplay {
+		return c.replay(req, recorded)
+	}
+
+	resp, err := c.transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	respBody, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(respBody))
+
+	header := c.header(resp.Header)
+	header.Del("Content-Length")
+	c.mu.Lock()
+	c.Interactions = append(c.Interactions, &Interaction{
+		Request:  recorded,
+		Response: RecordedResponse{StatusCode: resp.StatusCode, Header: header, Body: c.scrub(string(respBody))},
+	})
+	c.mu.Unlock()
+	return resp, nil
+}
+
+// replay returns the response of the first unused interaction that matches
+// recorded
+func (c *Cassette) replay(req *http.Request, recorded RecordedRequest) (*http.Response, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for i, interaction := range c.Interactions {
+		if c.used[i] || interaction.Request.Method != recorded.Method || !sameRequestURI(interaction.Request.URL, recorded.URL) {
+			continue
+		}
+		c.used[i] = true
+		r := interaction.Response
+		header := r.Header.Clone()
+		if header == nil {
+			header = make(http.Header)
+		}
+		return &http.Response{
+			Status:        fmt.Sprintf("%d %s", r.StatusCode, http.StatusText(r.StatusCode)),
+			StatusCode:    r.StatusCode,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        header,
+			Body:          io.NopCloser(strings.NewReader(r.Body)),
+			ContentLength: int64(len(r.Body)),
+			Request:       req,
+		}, nil
+	}
+	return nil, fmt.Errorf("%w: %s %s", ErrNoInteraction, recorded.Method, recorded.URL)
+}
+
+// Save writes the recorded interactions to the cassette file, creating its
+// directory if needed. It does nothing in ModeReplay.
+func (c *Cassette) Save() error {
+	if c.mode != ModeRecord {
+		return nil
+	}
+	c.mu.Lock()
+	b, err := json.MarshalIndent(c.Interactions, "", "  ")
+	c.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(c.Name), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(c.Name, append(b, '\n'), 0644)
+}
+
+// request returns req as recorded, scrubbed of secrets
+func (c *Cassette) request(req *http.Request, body []byte) RecordedRequest {
+	u := *req.URL
+	q := u.Query()
+	if q.Has("repo_token") {
+		q.Set("repo_token", Redacted)
+		u.RawQuery = q.Encode()
+	}
+	recorded := RecordedRequest{
+		Method: req.Method,
+		URL:    c.scrub(u.String()),
+		Header: c.header(req.Header),
+	}
+	mediaType, _, _ := mime.ParseMediaType(req.Header.Get("Content-Type"))
+	if mediaType == "application/json" && req.Header.Get("Content-Encoding") == "" {
+		recorded.Body = c.scrub(string(body))
+	}
+	return recorded
+}
+
+// header returns a copy of h without the headers that carry credentials
+func (c *Cassette) header(h http.Header) http.Header {
+	header := make(http.Header, len(h))
+	for k, values := range h {
+		switch http.CanonicalHeaderKey(k) {
+		case "Authorization", "Cookie", "Set-Cookie":
+			continue
+		}
+		for _, v := range values {
+			header.Add(k, c.scrub(v))
+		}
+	}
+	return header
+}
+
+// tokenField matches the values of the token fields of JSON bodies
+var tokenField = regexp.MustCompile(`("(?:repo_)?token"\s*:\s*)"(?:[^"\\]|\\.)*"`)
+
+// scrub replaces the secrets in s with Redacted
+func (c *Cassette) scrub(s string) string {
+	s = tokenField.ReplaceAllString(s, `$1"`+Redacted+`"`)
+	for _, secret := range c.Secrets {
+		if secret != "" {
+			s = strings.ReplaceAll(s, secret, Redacted)
+		}
+	}
+	return s
+}
+
+// sameRequestURI reports whether the URLs a and b have the same path and
+// query, in any order
+func sameRequestURI(a string, b string) bool {
+	ua, err := url.Parse(a)
+	if err != nil {
+		return false
+	}
+	ub, err := url.Parse(b)
+	if err != nil {
+		return false
+	}
+	return ua.Path == ub.Path && ua.Query().Encode() == ub.Query().Encode()
+}
@@ -0,0 +1,111 @@
+/*
+Copyright (c) 2020 Loadsmart, Inc.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package coverallstest
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	coveralls "github.com/stone-payments/go-coveralls-api"
+)
+
+func TestCassette(t *testing.T) {
+	ctx := context.Background()
+	name := filepath.Join(t.TempDir(), "cassettes", "repos.json")
+	server := NewServer()
+	defer server.Close()
+	server.Token = "personal-token"
+	server.Fake.Repositories.Put(&coveralls.Repository{Service: "github", Name: "user/api", Token: "repo-token"})
+
+	cassette, err := NewCassette(name, ModeRecord, nil)
+	require.Nil(t, err)
+	cassette.Secrets = []string{server.Token}
+	client, err := coveralls.NewEnterpriseClient(server.URL, server.Token, coveralls.WithTransport(cassette))
+	require.Nil(t, err)
+
+	repo, err := client.Repositories.Get(ctx, "github", "user/api")
+	require.Nil(t, err)
+	assert.Equal(t, "repo-token", repo.Token)
+	_, err = client.Repositories.Get(ctx, "github", "user/web")
+	assert.True(t, errors.Is(err, coveralls.ErrRepoNotFound))
+	_, err = client.Jobs.Submit(ctx, &coveralls.Job{RepoToken: "repo-token", CommitSHA: "c1"})
+	require.Nil(t, err)
+	require.Nil(t, cassette.Save())
+
+	b, err := os.ReadFile(name)
+	require.Nil(t, err)
+	assert.NotContains(t, string(b), "repo-token")
+	assert.NotContains(t, string(b), "personal-token")
+	assert.Contains(t, string(b), Redacted)
+
+	server.Close()
+	cassette, err = NewCassette(name, ModeReplay, nil)
+	require.Nil(t, err)
+	client, err = coveralls.NewEnterpriseClient("http://coveralls.invalid", "other-token", coveralls.WithTransport(cassette))
+	require.Nil(t, err)
+
+	repo, err = client.Repositories.Get(ctx, "github", "user/api")
+	require.Nil(t, err)
+	assert.Equal(t, "user/api", repo.Name)
+	assert.Equal(t, Redacted, repo.Token)
+	_, err = client.Repositories.Get(ctx, "github", "user/web")
+	assert.True(t, errors.Is(err, coveralls.ErrRepoNotFound))
+	result, err := client.Jobs.Submit(ctx, &coveralls.Job{RepoToken: "other-repo-token", CommitSHA: "c1"})
+	require.Nil(t, err)
+	assert.Equal(t, "https://coveralls.io/jobs/1", result.URL)
+
+	_, err = client.Repositories.Get(ctx, "github", "user/api")
+	assert.True(t, errors.Is(err, ErrNoInteraction))
+}
+
+func TestCassetteMissing(t *testing.T) {
+	_, err := NewCassette(filepath.Join(t.TempDir(), "missing.json"), ModeReplay, nil)
+
+	assert.True(t, errors.Is(err, os.ErrNotExist))
+}
+
+func TestCassetteScrub(t *testing.T) {
+	cases := []struct {
+		name     string
+		in       string
+		expected string
+	}{
+		{"repo token", `{"repo_token": "secret", "name": "x"}`, `{"repo_token": "REDACTED", "name": "x"}`},
+		{"token", `{"token":"sec\"ret"}`, `{"token":"REDACTED"}`},
+		{"secret", `token personal`, `token REDACTED`},
+		{"no secrets", `{"name": "token"}`, `{"name": "token"}`},
+	}
+
+	c := &Cassette{Secrets: []string{"personal"}}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, c.scrub(tc.in))
+		})
+	}
+}
@@ -0,0 +1,84 @@
+/*
+Copyright (c) 2020 Loadsmart, Inc.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+// Package coverallstest provides in-memory fakes of the services of the
+// Coveralls client for the unit tests of programs that use it, so they
+// don't need to write their own mocks.
+//
+// Fakes behave like the API: they keep the repositories, builds and jobs
+// put in them, and return the same errors as the client, e.g.
+// coveralls.ErrRepoNotFound. Every call is recorded, and calls can be made
+// to fail with Fail:
+//
+//	fake := coverallstest.NewFake()
+//	fake.Repositories.Put(&coveralls.Repository{Service: "github", Name: "user/repository"})
+//	fake.Repositories.Fail("Update", coveralls.ErrServiceUnavailable{})
+//	client := fake.Client()
+//	// ... run the code under test with client
+//	calls := fake.Repositories.CallsTo("Update")
+package coverallstest
+
+import (
+	coveralls "github.com/stone-payments/go-coveralls-api"
+)
+
+// Fake holds a fake of every service of the client
+type Fake struct {
+	Repositories  *Repositories
+	Builds        *Builds
+	Jobs          *Jobs
+	SourceFiles   *SourceFiles
+	Badges        *Badges
+	Organizations *Organizations
+}
+
+// NewFake returns empty fakes. Its Organizations are made of its
+// Repositories.
+func NewFake() *Fake {
+	repos := NewRepositories()
+	return &Fake{
+		Repositories:  repos,
+		Builds:        NewBuilds(),
+		Jobs:          NewJobs(),
+		SourceFiles:   NewSourceFiles(),
+		Badges:        NewBadges(),
+		Organizations: NewOrganizations(repos),
+	}
+}
+
+// Client returns a client whose services are the fakes, so code that takes
+// a *coveralls.Client can be tested without a server
+func (f *Fake) Client() *coveralls.Client {
+	client := coveralls.NewClient("fake-token")
+	client.Repositories = f.Repositories
+	client.Builds = f.Builds
+	client.Jobs = f.Jobs
+	client.SourceFiles = f.SourceFiles
+	client.Badges = f.Badges
+	client.Organizations = f.Organizations
+	return client
+}
+
+// key returns the key of a repository in the maps of the fakes
+func key(svc string, name string) string {
+	return svc + "/" + name
+}
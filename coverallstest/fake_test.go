@@ -0,0 +1,115 @@
+/*
+Copyright (c) 2020 Loadsmart, Inc.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package coverallstest
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	coveralls "github.com/stone-payments/go-coveralls-api"
+)
+
+func TestFakeClient(t *testing.T) {
+	ctx := context.Background()
+	fake := NewFake()
+	fake.Repositories.Put(
+		&coveralls.Repository{Service: "github", Name: "user/api", CoveredPercent: pfloat64(80)},
+		&coveralls.Repository{Service: "github", Name: "user/web", CoveredPercent: pfloat64(90)},
+		&coveralls.Repository{Service: "github", Name: "other/api"},
+	)
+	client := fake.Client()
+
+	repos, err := client.Organizations.ListRepos(ctx, "github", "user", nil)
+
+	require.Nil(t, err)
+	assert.Len(t, repos, 2)
+
+	summary, err := client.Organizations.Summary(ctx, "github", "user")
+
+	require.Nil(t, err)
+	assert.Equal(t, 2, summary.ReposWithBuilds)
+	assert.Equal(t, 85.0, *summary.AverageCoverage)
+
+	_, err = client.Organizations.Summary(ctx, "gitlab", "user")
+
+	assert.True(t, errors.Is(err, coveralls.ErrOrganizationNotFound))
+
+	fake.SourceFiles.Put("github", "user/api", "c1", &coveralls.SourceFile{Name: "main.go"})
+	file, err := client.SourceFiles.Get(ctx, "github", "user/api", "c1", "main.go")
+
+	require.Nil(t, err)
+	assert.Equal(t, "main.go", file.Name)
+
+	_, err = client.SourceFiles.Get(ctx, "github", "user/api", "c1", "other.go")
+
+	assert.True(t, errors.Is(err, coveralls.ErrSourceFileNotFound))
+
+	fake.Badges.PutShield("github", "user/api", "", &coveralls.Shield{Message: "80%"})
+	shield, err := client.Badges.Shield(ctx, "github", "user/api", "")
+
+	require.Nil(t, err)
+	assert.Equal(t, "80%", shield.Message)
+	assert.Equal(t, "https://coveralls.io/repos/github/user/api/badge.svg?branch=main", client.Badges.URL("github", "user/api", "main"))
+	assert.Len(t, fake.Badges.Calls(), 2)
+}
+
+func TestJobs(t *testing.T) {
+	ctx := context.Background()
+	f := NewJobs()
+
+	result, err := f.Submit(ctx, &coveralls.Job{RepoToken: "token", CommitSHA: "c1"})
+
+	require.Nil(t, err)
+	assert.Equal(t, "https://coveralls.io/jobs/1", result.URL)
+	id, _ := result.JobID()
+	info, err := f.Get(ctx, id)
+
+	require.Nil(t, err)
+	assert.Equal(t, "c1", info.CommitSHA)
+	assert.False(t, info.Processed())
+
+	f.Put(&coveralls.JobInfo{ID: id, CoveredPercent: pfloat64(80)})
+	info, err = f.Get(ctx, id)
+
+	require.Nil(t, err)
+	assert.True(t, info.Processed())
+
+	_, err = f.Get(ctx, 2)
+
+	assert.True(t, errors.Is(err, coveralls.ErrJobNotFound))
+
+	name := filepath.Join(t.TempDir(), "coverage.json")
+	require.Nil(t, os.WriteFile(name, []byte(`{"repo_token": "token", "commit_sha": "c2", "source_files": []}`), 0644))
+	result, err = f.SubmitFile(ctx, name, nil)
+
+	require.Nil(t, err)
+	assert.Equal(t, "Job #2.1", result.Message)
+	require.Len(t, f.Submitted(), 2)
+	assert.Equal(t, "c2", f.Submitted()[1].CommitSHA)
+}
@@ -0,0 +1,99 @@
+/*
+Copyright (c) 2020 Loadsmart, Inc.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package coverallstest
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"path"
+	"sort"
+	"strings"
+)
+
+// fixtures holds representative responses of the endpoints of Coveralls
+//
+//go:embed fixtures/*.json
+var fixtures embed.FS
+
+// Fixtures returns the names of the response fixtures, sorted. Each one is
+// the body of a response of Coveralls:
+//
+//	build                  GET /builds/{sha}.json
+//	builds_page            GET /{service}/{repository}.json
+//	job                    GET /jobs/{id}.json
+//	job_rejected           POST /api/v1/jobs, 422
+//	job_result             POST /api/v1/jobs
+//	repositories_page      GET /api/repos and GET /api/orgs/{service}/{org}/repos
+//	repository             GET, POST and PUT /api/repos/{service}/{repository}
+//	repository_name_taken  POST /api/repos, 422
+//	rerun                  POST /builds/{sha}/rerun
+//	source_file            GET /builds/{sha}/source.json
+//	webhook                POST /webhook
+func Fixtures() []string {
+	entries, err := fs.ReadDir(fixtures, "fixtures")
+	if err != nil {
+		panic(err)
+	}
+	names := make([]string, 0, len(entries))
+	for _, e := range entries {
+		names = append(names, strings.TrimSuffix(e.Name(), ".json"))
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Fixture returns the JSON body of the response fixture name, e.g. "build"
+func Fixture(name string) ([]byte, error) {
+	b, err := fixtures.ReadFile(path.Join("fixtures", name+".json"))
+	if err != nil {
+		return nil, fmt.Errorf("coverallstest: unknown fixture %q", name)
+	}
+	return b, nil
+}
+
+// LoadFixture decodes the response fixture name into v, e.g. a
+// *coveralls.Build for "build"
+func LoadFixture(name string, v interface{}) error {
+	b, err := Fixture(name)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, v)
+}
+
+// FixtureHandler returns a handler that responds with status and the
+// response fixture name, for tests with their own httptest.Server
+func FixtureHandler(status int, name string) http.Handler {
+	b, err := Fixture(name)
+	if err != nil {
+		panic(err)
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		w.Write(b)
+	})
+}
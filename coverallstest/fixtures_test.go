@@ -0,0 +1,159 @@
+/*
+Copyright (c) 2020 Loadsmart, Inc.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package coverallstest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	coveralls "github.com/stone-payments/go-coveralls-api"
+)
+
+// errorResponse is the body of error responses
+type errorResponse struct {
+	Message string              `json:"message"`
+	Error   bool                `json:"error,omitempty"`
+	Errors  map[string][]string `json:"errors,omitempty"`
+}
+
+func TestFixturesDecode(t *testing.T) {
+	cases := map[string]interface{}{
+		"build": &coveralls.Build{},
+		"builds_page": &struct {
+			Page   int                `json:"page"`
+			Pages  int                `json:"pages"`
+			Total  int                `json:"total"`
+			Builds []*coveralls.Build `json:"builds"`
+		}{},
+		"job":          &coveralls.JobInfo{},
+		"job_rejected": &errorResponse{},
+		"job_result":   &coveralls.JobResult{},
+		"repositories_page": &struct {
+			Page  int                     `json:"page"`
+			Pages int                     `json:"pages"`
+			Total int                     `json:"total"`
+			Repos []*coveralls.Repository `json:"repos"`
+		}{},
+		"repository":            &coveralls.Repository{},
+		"repository_name_taken": &errorResponse{},
+		"rerun":                 &errorResponse{},
+		"source_file":           &coveralls.SourceFile{},
+		"webhook": &struct {
+			Done bool   `json:"done"`
+			URL  string `json:"url"`
+			Jobs int    `json:"jobs"`
+		}{},
+	}
+
+	for _, name := range Fixtures() {
+		t.Run(name, func(t *testing.T) {
+			v, ok := cases[name]
+			require.True(t, ok, "fixture %s has no decoding test", name)
+			b, err := Fixture(name)
+			require.Nil(t, err)
+
+			// Every field of the fixture must be known, so fixtures and
+			// structs change together
+			d := json.NewDecoder(bytes.NewReader(b))
+			d.DisallowUnknownFields()
+			assert.Nil(t, d.Decode(v))
+		})
+	}
+	assert.Len(t, Fixtures(), len(cases))
+}
+
+func TestFixturesClient(t *testing.T) {
+	ctx := context.Background()
+	mux := http.NewServeMux()
+	mux.Handle("/api/repos/github/user/repository", FixtureHandler(http.StatusOK, "repository"))
+	mux.Handle("/api/repos", FixtureHandler(http.StatusUnprocessableEntity, "repository_name_taken"))
+	mux.Handle("/api/orgs/github/user/repos", FixtureHandler(http.StatusOK, "repositories_page"))
+	mux.Handle("/builds/9a0c3b1e2f7d4c5b8a6e1d0f3c2b7a9e8d4f6c1b.json", FixtureHandler(http.StatusOK, "build"))
+	mux.Handle("/builds/9a0c3b1e2f7d4c5b8a6e1d0f3c2b7a9e8d4f6c1b/source.json", FixtureHandler(http.StatusOK, "source_file"))
+	mux.Handle("/builds/9a0c3b1e2f7d4c5b8a6e1d0f3c2b7a9e8d4f6c1b/rerun", FixtureHandler(http.StatusOK, "rerun"))
+	mux.Handle("/github/user/repository.json", FixtureHandler(http.StatusOK, "builds_page"))
+	mux.Handle("/webhook", FixtureHandler(http.StatusOK, "webhook"))
+	mux.Handle("/api/v1/jobs", FixtureHandler(http.StatusOK, "job_result"))
+	mux.Handle("/jobs/64953715.json", FixtureHandler(http.StatusOK, "job"))
+	server := httptest.NewServer(mux)
+	defer server.Close()
+	client, err := coveralls.NewEnterpriseClient(server.URL, "token")
+	require.Nil(t, err)
+	sha := "9a0c3b1e2f7d4c5b8a6e1d0f3c2b7a9e8d4f6c1b"
+
+	repo, err := client.Repositories.Get(ctx, "github", "user/repository")
+	require.Nil(t, err)
+	assert.Equal(t, 1876552, repo.ID)
+	assert.Equal(t, 80.0, *repo.CommitStatusFailThreshold)
+	assert.Equal(t, 412, *repo.LastBuildNumber)
+
+	_, err = client.Repositories.Add(ctx, &coveralls.RepositoryConfig{Service: "github", Name: "user/repository"})
+	assert.True(t, errors.Is(err, coveralls.ErrNameIsTaken))
+
+	repos, err := client.Organizations.ListRepos(ctx, "github", "user", nil)
+	require.Nil(t, err)
+	require.Len(t, repos, 2)
+	assert.Nil(t, repos[1].CoveredPercent)
+
+	build, err := client.Builds.Get(ctx, "github", "user/repository", sha)
+	require.Nil(t, err)
+	assert.Equal(t, 87.31, *build.CoveredPercent)
+	assert.Equal(t, 0.4, *build.CoverageChange)
+
+	builds, err := client.Builds.List(ctx, "github", "user/repository", &coveralls.BuildListOptions{Limit: 2})
+	require.Nil(t, err)
+	require.Len(t, builds, 2)
+	assert.Equal(t, 57, builds[1].PullRequest)
+
+	file, err := client.SourceFiles.Get(ctx, "github", "user/repository", sha, "client.go")
+	require.Nil(t, err)
+	assert.Equal(t, []int{5, 10}, file.UncoveredLines())
+
+	assert.Nil(t, client.Builds.Rerun(ctx, "github", "user/repository", sha))
+	assert.Nil(t, client.Builds.Close(ctx, "repo-token", "412"))
+
+	result, err := client.Jobs.Submit(ctx, &coveralls.Job{RepoToken: "repo-token"})
+	require.Nil(t, err)
+	id, ok := result.JobID()
+	require.True(t, ok)
+
+	job, err := client.Jobs.Get(ctx, id)
+	require.Nil(t, err)
+	assert.True(t, job.Processed())
+	assert.Equal(t, sha, job.CommitSHA)
+}
+
+func TestFixtureUnknown(t *testing.T) {
+	_, err := Fixture("missing")
+
+	assert.NotNil(t, err)
+	assert.NotNil(t, LoadFixture("missing", &coveralls.Build{}))
+}
@@ -0,0 +1,125 @@
+/*
+Copyright (c) 2020 Loadsmart, Inc.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package coverallstest
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+
+	coveralls "github.com/stone-payments/go-coveralls-api"
+)
+
+// Jobs is a fake of coveralls.JobsService that keeps the jobs submitted
+type Jobs struct {
+	Recorder
+	mu        sync.Mutex
+	submitted []*coveralls.Job
+	infos     map[int]*coveralls.JobInfo
+}
+
+var _ coveralls.JobsService = (*Jobs)(nil)
+
+// NewJobs returns a fake without jobs
+func NewJobs() *Jobs {
+	return &Jobs{infos: make(map[int]*coveralls.JobInfo)}
+}
+
+// Submitted returns the jobs submitted so far, in order. Their IDs, as
+// taken by Get, start at 1.
+func (f *Jobs) Submitted() []*coveralls.Job {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]*coveralls.Job(nil), f.submitted...)
+}
+
+// Put sets the information Get returns for the job info.ID, e.g. to mark
+// a submitted job processed by setting its coverage
+func (f *Jobs) Put(info *coveralls.JobInfo) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	c := *info
+	f.infos[info.ID] = &c
+}
+
+// Submit keeps job and returns the result of a new job
+func (f *Jobs) Submit(ctx context.Context, job *coveralls.Job, opts ...coveralls.CallOption) (*coveralls.JobResult, error) {
+	if err := f.record("Submit", job); err != nil {
+		return nil, err
+	}
+	return f.submit(job), nil
+}
+
+// Get returns the job with the given ID, not yet processed unless set
+// with Put, or coveralls.ErrJobNotFound
+func (f *Jobs) Get(ctx context.Context, jobID int, opts ...coveralls.CallOption) (*coveralls.JobInfo, error) {
+	if err := f.record("Get", jobID); err != nil {
+		return nil, err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	info, ok := f.infos[jobID]
+	if !ok {
+		return nil, coveralls.ErrJobNotFound
+	}
+	c := *info
+	return &c, nil
+}
+
+// SubmitMultipart keeps job as Submit does
+func (f *Jobs) SubmitMultipart(ctx context.Context, job *coveralls.Job, upload *coveralls.MultipartOptions, opts ...coveralls.CallOption) (*coveralls.JobResult, error) {
+	if err := f.record("SubmitMultipart", job, upload); err != nil {
+		return nil, err
+	}
+	return f.submit(job), nil
+}
+
+// SubmitFile reads the job stored as JSON in the file name and keeps it as
+// Submit does
+func (f *Jobs) SubmitFile(ctx context.Context, name string, upload *coveralls.MultipartOptions, opts ...coveralls.CallOption) (*coveralls.JobResult, error) {
+	if err := f.record("SubmitFile", name, upload); err != nil {
+		return nil, err
+	}
+	b, err := os.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+	var job coveralls.Job
+	if err := json.Unmarshal(b, &job); err != nil {
+		return nil, err
+	}
+	return f.submit(&job), nil
+}
+
+// submit keeps job and returns its result
+func (f *Jobs) submit(job *coveralls.Job) *coveralls.JobResult {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.submitted = append(f.submitted, job)
+	id := len(f.submitted)
+	url := fmt.Sprintf("https://coveralls.io/jobs/%d", id)
+	f.infos[id] = &coveralls.JobInfo{ID: id, URL: url, ServiceJobID: job.ServiceJobID, CommitSHA: job.CommitSHA}
+	return &coveralls.JobResult{Message: fmt.Sprintf("Job #%d.1", id), URL: url}
+}
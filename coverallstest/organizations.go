@@ -0,0 +1,98 @@
+/*
+Copyright (c) 2020 Loadsmart, Inc.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package coverallstest
+
+import (
+	"context"
+	"strings"
+
+	coveralls "github.com/stone-payments/go-coveralls-api"
+)
+
+// Organizations is a fake of coveralls.OrganizationsService whose
+// organizations are the owners of the repositories of a Repositories fake,
+// e.g. user for user/repository
+type Organizations struct {
+	Recorder
+	repos *Repositories
+}
+
+var _ coveralls.OrganizationsService = (*Organizations)(nil)
+
+// NewOrganizations returns a fake of the organizations of repos
+func NewOrganizations(repos *Repositories) *Organizations {
+	return &Organizations{repos: repos}
+}
+
+// ListRepos returns the repositories of an organization, sorted by name,
+// or coveralls.ErrOrganizationNotFound if it has none. Opts are ignored.
+func (f *Organizations) ListRepos(ctx context.Context, svc string, org string, opts *coveralls.ListOptions, callOpts ...coveralls.CallOption) ([]*coveralls.Repository, error) {
+	if err := f.record("ListRepos", svc, org, opts); err != nil {
+		return nil, err
+	}
+	return f.list(svc, org)
+}
+
+// Summary aggregates the coverage of the repositories of an organization
+func (f *Organizations) Summary(ctx context.Context, svc string, org string, opts ...coveralls.CallOption) (*coveralls.OrganizationSummary, error) {
+	if err := f.record("Summary", svc, org); err != nil {
+		return nil, err
+	}
+	repos, err := f.list(svc, org)
+	if err != nil {
+		return nil, err
+	}
+
+	summary := &coveralls.OrganizationSummary{Service: svc, Name: org, Repos: len(repos)}
+	var total float64
+	for _, r := range repos {
+		if r.CoveredPercent == nil {
+			continue
+		}
+		coverage := *r.CoveredPercent
+		summary.ReposWithBuilds++
+		total += coverage
+		if summary.MinCoverage == nil || coverage < *summary.MinCoverage {
+			summary.MinCoverage = &coverage
+		}
+		if summary.MaxCoverage == nil || coverage > *summary.MaxCoverage {
+			summary.MaxCoverage = &coverage
+		}
+	}
+	if summary.ReposWithBuilds > 0 {
+		average := total / float64(summary.ReposWithBuilds)
+		summary.AverageCoverage = &average
+	}
+	return summary, nil
+}
+
+// list returns the repositories of an organization
+func (f *Organizations) list(svc string, org string) ([]*coveralls.Repository, error) {
+	repos := f.repos.filter(func(r *coveralls.Repository) bool {
+		return r.Service == svc && strings.HasPrefix(r.Name, org+"/")
+	})
+	if len(repos) == 0 {
+		return nil, coveralls.ErrOrganizationNotFound
+	}
+	return repos, nil
+}
@@ -0,0 +1,110 @@
+/*
+Copyright (c) 2020 Loadsmart, Inc.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package coverallstest
+
+import (
+	"sync"
+)
+
+// Call is a call to a fake service
+type Call struct {
+	Method string        // Name of the method, e.g. Get
+	Args   []interface{} // Arguments, without the context and call options
+}
+
+// Recorder records the calls to a fake service and makes them fail as
+// configured. It is embedded in every fake.
+type Recorder struct {
+	mu       sync.Mutex
+	calls    []Call
+	failures map[string]error
+	failFunc func(call Call) error
+}
+
+// Calls returns the calls made so far, in order
+func (r *Recorder) Calls() []Call {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]Call(nil), r.calls...)
+}
+
+// CallsTo returns the calls to method made so far, in order
+func (r *Recorder) CallsTo(method string) []Call {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var calls []Call
+	for _, c := range r.calls {
+		if c.Method == method {
+			calls = append(calls, c)
+		}
+	}
+	return calls
+}
+
+// Fail makes the calls to method return err, e.g.
+// coveralls.ErrServiceUnavailable{}, or succeed again if err is nil
+func (r *Recorder) Fail(method string, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.failures == nil {
+		r.failures = make(map[string]error)
+	}
+	if err == nil {
+		delete(r.failures, method)
+		return
+	}
+	r.failures[method] = err
+}
+
+// FailFunc makes the calls for which fn returns an error fail with it, e.g.
+// to fail the calls of one repository only. Nil removes it.
+func (r *Recorder) FailFunc(fn func(call Call) error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.failFunc = fn
+}
+
+// Reset forgets the calls made and the failures set
+func (r *Recorder) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.calls = nil
+	r.failures = nil
+	r.failFunc = nil
+}
+
+// record records a call to method and returns the error it must fail with,
+// if any
+func (r *Recorder) record(method string, args ...interface{}) error {
+	call := Call{Method: method, Args: args}
+	r.mu.Lock()
+	r.calls = append(r.calls, call)
+	err := r.failures[method]
+	fn := r.failFunc
+	r.mu.Unlock()
+
+	if err == nil && fn != nil {
+		err = fn(call)
+	}
+	return err
+}
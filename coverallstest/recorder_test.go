@@ -0,0 +1,64 @@
+/*
+Copyright (c) 2020 Loadsmart, Inc.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package coverallstest
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecorder(t *testing.T) {
+	errGet := errors.New("get failed")
+	errWeb := errors.New("web failed")
+	var r Recorder
+
+	assert.Nil(t, r.record("Get", "github", "user/api"))
+
+	r.Fail("Get", errGet)
+	r.FailFunc(func(call Call) error {
+		if call.Args[1] == "user/web" {
+			return errWeb
+		}
+		return nil
+	})
+
+	assert.Equal(t, errGet, r.record("Get", "github", "user/api"))
+	assert.Equal(t, errWeb, r.record("Update", "github", "user/web"))
+	assert.Nil(t, r.record("Update", "github", "user/api"))
+
+	r.Fail("Get", nil)
+
+	assert.Nil(t, r.record("Get", "github", "user/api"))
+	assert.Len(t, r.Calls(), 5)
+	assert.Equal(t, []Call{
+		{Method: "Update", Args: []interface{}{"github", "user/web"}},
+		{Method: "Update", Args: []interface{}{"github", "user/api"}},
+	}, r.CallsTo("Update"))
+
+	r.Reset()
+
+	assert.Empty(t, r.Calls())
+	assert.Nil(t, r.record("Update", "github", "user/web"))
+}
@@ -0,0 +1,223 @@
+/*
+Copyright (c) 2020 Loadsmart, Inc.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package coverallstest
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	coveralls "github.com/stone-payments/go-coveralls-api"
+)
+
+// Repositories is a fake of coveralls.RepositoryService that keeps
+// repositories in memory
+type Repositories struct {
+	Recorder
+	mu     sync.Mutex
+	repos  map[string]*coveralls.Repository
+	nextID int
+}
+
+var _ coveralls.RepositoryService = (*Repositories)(nil)
+
+// NewRepositories returns a fake without repositories
+func NewRepositories() *Repositories {
+	return &Repositories{repos: make(map[string]*coveralls.Repository)}
+}
+
+// Put adds repositories, or replaces the ones with the same service and
+// name. Repositories without ID are given one.
+func (f *Repositories) Put(repos ...*coveralls.Repository) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, r := range repos {
+		r := *r
+		if r.ID == 0 {
+			f.nextID++
+			r.ID = f.nextID
+		} else if r.ID > f.nextID {
+			f.nextID = r.ID
+		}
+		f.repos[key(r.Service, r.Name)] = &r
+	}
+}
+
+// Get returns a repository put or added, or coveralls.ErrRepoNotFound
+func (f *Repositories) Get(ctx context.Context, svc string, repo string, opts ...coveralls.CallOption) (*coveralls.Repository, error) {
+	if err := f.record("Get", svc, repo); err != nil {
+		return nil, err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	r, ok := f.repos[key(svc, repo)]
+	if !ok {
+		return nil, coveralls.ErrRepoNotFound
+	}
+	return clone(r), nil
+}
+
+// Add adds a repository with the settings of data and a new ID and
+// repository token. It returns coveralls.ErrNameIsTaken if it exists.
+func (f *Repositories) Add(ctx context.Context, data *coveralls.RepositoryConfig, opts ...coveralls.CallOption) (*coveralls.Repository, error) {
+	if err := f.record("Add", data); err != nil {
+		return nil, err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, ok := f.repos[key(data.Service, data.Name)]; ok {
+		return nil, coveralls.ErrNameIsTaken
+	}
+	r := f.create(data.Service, data.Name)
+	update(r, data)
+	return clone(r), nil
+}
+
+// Update sets the settings data sets, leaving the unset ones unchanged. It
+// returns coveralls.ErrRepoNotFound if the repository doesn't exist.
+func (f *Repositories) Update(ctx context.Context, svc string, repo string, data *coveralls.RepositoryConfig, opts ...coveralls.CallOption) (*coveralls.Repository, error) {
+	if err := f.record("Update", svc, repo, data); err != nil {
+		return nil, err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	r, ok := f.repos[key(svc, repo)]
+	if !ok {
+		return nil, coveralls.ErrRepoNotFound
+	}
+	update(r, data)
+	return clone(r), nil
+}
+
+// List returns all repositories, sorted by service and name. Opts are
+// ignored.
+func (f *Repositories) List(ctx context.Context, opts *coveralls.ListOptions, callOpts ...coveralls.CallOption) ([]*coveralls.Repository, error) {
+	if err := f.record("List", opts); err != nil {
+		return nil, err
+	}
+	return f.filter(func(r *coveralls.Repository) bool { return true }), nil
+}
+
+// Delete removes a repository, or returns coveralls.ErrRepoNotFound
+func (f *Repositories) Delete(ctx context.Context, svc string, repo string, opts ...coveralls.CallOption) error {
+	if err := f.record("Delete", svc, repo); err != nil {
+		return err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, ok := f.repos[key(svc, repo)]; !ok {
+		return coveralls.ErrRepoNotFound
+	}
+	delete(f.repos, key(svc, repo))
+	return nil
+}
+
+// Exists reports whether a repository was put or added
+func (f *Repositories) Exists(ctx context.Context, svc string, repo string, opts ...coveralls.CallOption) (bool, error) {
+	if err := f.record("Exists", svc, repo); err != nil {
+		return false, err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	_, ok := f.repos[key(svc, repo)]
+	return ok, nil
+}
+
+// Ensure adds the repository of data, or updates it if it exists
+func (f *Repositories) Ensure(ctx context.Context, data *coveralls.RepositoryConfig, opts ...coveralls.CallOption) (*coveralls.Repository, error) {
+	if err := f.record("Ensure", data); err != nil {
+		return nil, err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	r, ok := f.repos[key(data.Service, data.Name)]
+	if !ok {
+		r = f.create(data.Service, data.Name)
+	}
+	update(r, data)
+	return clone(r), nil
+}
+
+// GetByID returns the repository with the given ID, or
+// coveralls.ErrRepoNotFound
+func (f *Repositories) GetByID(ctx context.Context, id int, opts ...coveralls.CallOption) (*coveralls.Repository, error) {
+	if err := f.record("GetByID", id); err != nil {
+		return nil, err
+	}
+	found := f.filter(func(r *coveralls.Repository) bool { return r.ID == id })
+	if len(found) == 0 {
+		return nil, coveralls.ErrRepoNotFound
+	}
+	return found[0], nil
+}
+
+// create adds a repository with a new ID and repository token. Callers
+// hold f.mu.
+func (f *Repositories) create(svc string, name string) *coveralls.Repository {
+	f.nextID++
+	r := &coveralls.Repository{ID: f.nextID, Service: svc, Name: name, Token: fmt.Sprintf("fake-repo-token-%d", f.nextID)}
+	f.repos[key(svc, name)] = r
+	return r
+}
+
+// filter returns copies of the repositories for which match returns true,
+// sorted by service and name
+func (f *Repositories) filter(match func(r *coveralls.Repository) bool) []*coveralls.Repository {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	keys := make([]string, 0, len(f.repos))
+	for k, r := range f.repos {
+		if match(r) {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	repos := make([]*coveralls.Repository, len(keys))
+	for i, k := range keys {
+		repos[i] = clone(f.repos[k])
+	}
+	return repos
+}
+
+// update sets the settings of r that data sets
+func update(r *coveralls.Repository, data *coveralls.RepositoryConfig) {
+	if data.CommentOnPullRequests != nil {
+		r.CommentOnPullRequests = data.CommentOnPullRequests
+	}
+	if data.SendBuildStatus != nil {
+		r.SendBuildStatus = data.SendBuildStatus
+	}
+	if data.CommitStatusFailThreshold != nil {
+		r.CommitStatusFailThreshold = data.CommitStatusFailThreshold
+	}
+	if data.CommitStatusFailChangeThreshold != nil {
+		r.CommitStatusFailChangeThreshold = data.CommitStatusFailChangeThreshold
+	}
+}
+
+// clone returns a copy of r, so callers can't change the fake
+func clone(r *coveralls.Repository) *coveralls.Repository {
+	c := *r
+	return &c
+}
@@ -0,0 +1,154 @@
+/*
+Copyright (c) 2020 Loadsmart, Inc.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package coverallstest
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	coveralls "github.com/stone-payments/go-coveralls-api"
+)
+
+func pfloat64(v float64) *float64 {
+	return &v
+}
+
+func TestRepositories(t *testing.T) {
+	ctx := context.Background()
+	f := NewRepositories()
+	f.Put(&coveralls.Repository{Service: "github", Name: "user/api", CommitStatusFailThreshold: pfloat64(70)})
+
+	r, err := f.Get(ctx, "github", "user/api")
+
+	require.Nil(t, err)
+	assert.Equal(t, 1, r.ID)
+	assert.Equal(t, 70.0, *r.CommitStatusFailThreshold)
+
+	_, err = f.Get(ctx, "github", "user/missing")
+
+	assert.True(t, errors.Is(err, coveralls.ErrRepoNotFound))
+
+	added, err := f.Add(ctx, &coveralls.RepositoryConfig{Service: "github", Name: "user/web"})
+
+	require.Nil(t, err)
+	assert.Equal(t, 2, added.ID)
+	assert.Equal(t, "fake-repo-token-2", added.Token)
+
+	_, err = f.Add(ctx, &coveralls.RepositoryConfig{Service: "github", Name: "user/web"})
+
+	assert.True(t, errors.Is(err, coveralls.ErrNameIsTaken))
+
+	updated, err := f.Update(ctx, "github", "user/api", &coveralls.RepositoryConfig{Service: "github", Name: "user/api", CommitStatusFailChangeThreshold: pfloat64(0.5)})
+
+	require.Nil(t, err)
+	assert.Equal(t, 70.0, *updated.CommitStatusFailThreshold)
+	assert.Equal(t, 0.5, *updated.CommitStatusFailChangeThreshold)
+
+	byID, err := f.GetByID(ctx, 2)
+
+	require.Nil(t, err)
+	assert.Equal(t, "user/web", byID.Name)
+
+	repos, err := f.List(ctx, nil)
+
+	require.Nil(t, err)
+	require.Len(t, repos, 2)
+	assert.Equal(t, "user/api", repos[0].Name)
+	assert.Equal(t, "user/web", repos[1].Name)
+
+	require.Nil(t, f.Delete(ctx, "github", "user/web"))
+	exists, err := f.Exists(ctx, "github", "user/web")
+
+	require.Nil(t, err)
+	assert.False(t, exists)
+	assert.True(t, errors.Is(f.Delete(ctx, "github", "user/web"), coveralls.ErrRepoNotFound))
+
+	ensured, err := f.Ensure(ctx, &coveralls.RepositoryConfig{Service: "github", Name: "user/web", CommitStatusFailThreshold: pfloat64(90)})
+
+	require.Nil(t, err)
+	assert.Equal(t, 3, ensured.ID)
+	assert.Equal(t, 90.0, *ensured.CommitStatusFailThreshold)
+
+	// Results are copies
+	ensured.Name = "changed"
+	r, err = f.Get(ctx, "github", "user/web")
+
+	require.Nil(t, err)
+	assert.Equal(t, "user/web", r.Name)
+}
+
+func TestRepositoriesFail(t *testing.T) {
+	f := NewRepositories()
+	f.Put(&coveralls.Repository{Service: "github", Name: "user/api"})
+	f.Fail("Get", coveralls.ErrUnauthorized)
+
+	_, err := f.Get(context.Background(), "github", "user/api")
+
+	assert.True(t, errors.Is(err, coveralls.ErrUnauthorized))
+	assert.Equal(t, []Call{{Method: "Get", Args: []interface{}{"github", "user/api"}}}, f.Calls())
+}
+
+func TestRepositoriesBulk(t *testing.T) {
+	ctx := context.Background()
+	f := NewRepositories()
+	f.Put(
+		&coveralls.Repository{Service: "github", Name: "user/api"},
+		&coveralls.Repository{Service: "github", Name: "user/web"},
+	)
+	targets := []coveralls.RepoRef{{Service: "github", Name: "user/api"}, {Service: "github", Name: "user/missing"}}
+	var progress []int
+
+	results, err := coveralls.BulkUpdate(ctx, f, targets, coveralls.RepositoryConfig{CommitStatusFailThreshold: pfloat64(80)}, &coveralls.BulkOptions{
+		Progress: func(done int, total int, result coveralls.BulkResult) { progress = append(progress, done) },
+	})
+
+	var bulkErr *coveralls.BulkError
+	require.True(t, errors.As(err, &bulkErr))
+	assert.Equal(t, 2, bulkErr.Total)
+	assert.Len(t, bulkErr.Failed, 1)
+	assert.Equal(t, 80.0, *results[0].Repository.CommitStatusFailThreshold)
+	assert.True(t, errors.Is(results[1].Err, coveralls.ErrRepoNotFound))
+	assert.Equal(t, []int{1, 2}, progress)
+	assert.Len(t, f.CallsTo("Update"), 2)
+
+	configs, err := coveralls.Export(ctx, f, nil)
+
+	require.Nil(t, err)
+	require.Len(t, configs, 2)
+	assert.Equal(t, 80.0, *configs[0].CommitStatusFailThreshold)
+
+	_, err = coveralls.Export(ctx, f, targets)
+
+	assert.True(t, errors.As(err, &bulkErr))
+
+	configs = append(configs, coveralls.RepositoryConfig{Service: "github", Name: "user/new"})
+	results, err = coveralls.Restore(ctx, f, configs, nil)
+
+	require.Nil(t, err)
+	assert.Len(t, results, 3)
+	assert.Equal(t, "user/new", results[2].Repository.Name)
+}
@@ -0,0 +1,440 @@
+/*
+Copyright (c) 2020 Loadsmart, Inc.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package coverallstest
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"mime"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+
+	coveralls "github.com/stone-payments/go-coveralls-api"
+)
+
+// defaultPerPage is the page size of listings when the request sets none
+const defaultPerPage = 10
+
+// Server is a fake Coveralls server for integration tests, an
+// httptest.Server that serves the endpoints of repositories, builds, jobs,
+// source files and organizations from a Fake.
+//
+// Responses have the status codes of Coveralls: 404 for what is missing,
+// 422 when a repository name is taken or a job has an unknown repository
+// token, and 401 when the API token is wrong. Errors set with the Fail
+// method of the fakes are turned into their responses too, e.g.
+// coveralls.ErrRateLimited into 429 with a Retry-After header.
+type Server struct {
+	*httptest.Server
+	Fake   *Fake
+	Token  string            // Personal access token the API endpoints accept
+	Client *coveralls.Client // Client of the server, with Token
+}
+
+// NewServer starts a Server with empty fakes and returns it with a Client
+// created with opts. Callers close it when done, e.g. with defer
+// server.Close().
+func NewServer(opts ...coveralls.Option) *Server {
+	s := &Server{Fake: NewFake(), Token: "fake-token"}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serve))
+	client, err := coveralls.NewEnterpriseClient(s.URL, s.Token, opts...)
+	if err != nil {
+		s.Close()
+		panic(fmt.Sprintf("coverallstest: creating the client: %s", err))
+	}
+	s.Client = client
+	return s
+}
+
+// serve routes a request to its endpoint
+func (s *Server) serve(w http.ResponseWriter, r *http.Request) {
+	if strings.EqualFold(r.Header.Get("Content-Encoding"), "gzip") {
+		body, err := gzip.NewReader(r.Body)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, errorBody(err.Error()))
+			return
+		}
+		r.Body = body
+	}
+
+	path := r.URL.Path
+	switch {
+	case strings.HasPrefix(path, "/api/"):
+		rest := strings.TrimPrefix(strings.TrimPrefix(path, "/api/"), "v1/")
+		if rest == "jobs" {
+			s.submitJob(w, r)
+			return
+		}
+		if r.Header.Get("Authorization") != "token "+s.Token {
+			writeError(w, coveralls.ErrUnauthorized)
+			return
+		}
+		s.serveAPI(w, r, strings.Split(rest, "/"))
+	case path == "/webhook":
+		s.closeBuild(w, r)
+	case strings.HasPrefix(path, "/builds/"):
+		s.serveBuild(w, r, strings.Split(strings.TrimPrefix(path, "/builds/"), "/"))
+	case strings.HasPrefix(path, "/jobs/") && strings.HasSuffix(path, ".json"):
+		s.getJob(w, r, strings.TrimSuffix(strings.TrimPrefix(path, "/jobs/"), ".json"))
+	case strings.HasSuffix(path, ".json") && strings.Count(path, "/") >= 3:
+		s.listBuilds(w, r, strings.TrimSuffix(strings.TrimPrefix(path, "/"), ".json"))
+	default:
+		writeJSON(w, http.StatusNotFound, errorBody("not found"))
+	}
+}
+
+// serveAPI serves the endpoints under /api, given the segments of the path
+// after it
+func (s *Server) serveAPI(w http.ResponseWriter, r *http.Request, segments []string) {
+	ctx := r.Context()
+	repos := s.Fake.Repositories
+	switch {
+	case len(segments) == 1 && segments[0] == "repos":
+		switch r.Method {
+		case http.MethodGet:
+			all, err := repos.List(ctx, nil)
+			if err != nil {
+				writeError(w, err)
+				return
+			}
+			writeRepositoryPage(w, r, all)
+		case http.MethodPost:
+			var body struct {
+				Repo *coveralls.RepositoryConfig `json:"repo"`
+			}
+			if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body.Repo == nil {
+				writeJSON(w, http.StatusUnprocessableEntity, errorBody("invalid repository"))
+				return
+			}
+			writeResult(w, http.StatusCreated)(repos.Add(ctx, body.Repo))
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	case len(segments) == 2 && segments[0] == "repos":
+		id, err := strconv.Atoi(segments[1])
+		if err != nil || r.Method != http.MethodGet {
+			writeJSON(w, http.StatusNotFound, errorBody("not found"))
+			return
+		}
+		writeResult(w, http.StatusOK)(repos.GetByID(ctx, id))
+	case len(segments) >= 3 && segments[0] == "repos":
+		svc, name := segments[1], strings.Join(segments[2:], "/")
+		switch r.Method {
+		case http.MethodGet:
+			writeResult(w, http.StatusOK)(repos.Get(ctx, svc, name))
+		case http.MethodPut:
+			var body struct {
+				Repo *coveralls.RepositoryConfig `json:"repo"`
+			}
+			if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body.Repo == nil {
+				writeJSON(w, http.StatusUnprocessableEntity, errorBody("invalid repository"))
+				return
+			}
+			writeResult(w, http.StatusOK)(repos.Update(ctx, svc, name, body.Repo))
+		case http.MethodDelete:
+			if err := repos.Delete(ctx, svc, name); err != nil {
+				writeError(w, err)
+				return
+			}
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	case len(segments) == 4 && segments[0] == "orgs" && segments[3] == "repos" && r.Method == http.MethodGet:
+		orgRepos, err := s.Fake.Organizations.ListRepos(ctx, segments[1], segments[2], nil)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		writeRepositoryPage(w, r, orgRepos)
+	default:
+		writeJSON(w, http.StatusNotFound, errorBody("not found"))
+	}
+}
+
+// serveBuild serves the endpoints under /builds, given the segments of the
+// path after it
+func (s *Server) serveBuild(w http.ResponseWriter, r *http.Request, segments []string) {
+	ctx := r.Context()
+	builds := s.Fake.Builds
+	switch {
+	case len(segments) == 1 && strings.HasSuffix(segments[0], ".json") && r.Method == http.MethodGet:
+		sha := strings.TrimSuffix(segments[0], ".json")
+		svc, repo, ok := builds.repoOf(sha)
+		if !ok {
+			writeError(w, coveralls.ErrBuildNotFound)
+			return
+		}
+		build, err := builds.Get(ctx, svc, repo, sha)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		if build.RepoName == "" {
+			build.RepoName = repo
+		}
+		writeJSON(w, http.StatusOK, build)
+	case len(segments) == 2 && segments[1] == "rerun" && r.Method == http.MethodPost:
+		svc, repo, ok := builds.repoOf(segments[0])
+		if !ok {
+			writeError(w, coveralls.ErrBuildNotFound)
+			return
+		}
+		if err := builds.Rerun(ctx, svc, repo, segments[0]); err != nil {
+			writeError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]string{"message": "rerun scheduled"})
+	case len(segments) == 2 && segments[1] == "source.json" && r.Method == http.MethodGet:
+		q := r.URL.Query()
+		writeResult(w, http.StatusOK)(s.Fake.SourceFiles.Get(ctx, q.Get("service"), q.Get("repo_name"), segments[0], q.Get("filename")))
+	default:
+		writeJSON(w, http.StatusNotFound, errorBody("not found"))
+	}
+}
+
+// listBuilds serves the builds of the repository path, e.g. github/user/repository
+func (s *Server) listBuilds(w http.ResponseWriter, r *http.Request, path string) {
+	parts := strings.SplitN(path, "/", 2)
+	q := r.URL.Query()
+	opts := &coveralls.BuildListOptions{Branch: q.Get("branch")}
+	opts.PullRequest, _ = strconv.Atoi(q.Get("pull_request"))
+	builds, err := s.Fake.Builds.List(r.Context(), parts[0], parts[1], opts)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	page, pages, start, end := paginate(r, len(builds))
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"page":   page,
+		"pages":  pages,
+		"total":  len(builds),
+		"builds": builds[start:end],
+	})
+}
+
+// closeBuild serves the webhook that closes parallel builds
+func (s *Server) closeBuild(w http.ResponseWriter, r *http.Request) {
+	token := r.URL.Query().Get("repo_token")
+	if !s.knownRepoToken(token) {
+		writeJSON(w, http.StatusUnprocessableEntity, errorBody("Couldn't find a repository matching this job."))
+		return
+	}
+	var body struct {
+		Payload struct {
+			BuildNum string `json:"build_num"`
+		} `json:"payload"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeJSON(w, http.StatusUnprocessableEntity, errorBody(err.Error()))
+		return
+	}
+	if err := s.Fake.Builds.Close(r.Context(), token, body.Payload.BuildNum); err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]bool{"done": true})
+}
+
+// submitJob serves the endpoint that receives jobs, sent as JSON or as
+// the json_file of a multipart form
+func (s *Server) submitJob(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	job, err := readJob(r)
+	if err != nil {
+		writeJSON(w, http.StatusUnprocessableEntity, errorBody(err.Error()))
+		return
+	}
+	if !s.knownRepoToken(job.RepoToken) {
+		writeJSON(w, http.StatusUnprocessableEntity, errorBody("Couldn't find a repository matching this job."))
+		return
+	}
+	writeResult(w, http.StatusOK)(s.Fake.Jobs.Submit(r.Context(), job))
+}
+
+// getJob serves a job, given its ID
+func (s *Server) getJob(w http.ResponseWriter, r *http.Request, id string) {
+	jobID, err := strconv.Atoi(id)
+	if err != nil || r.Method != http.MethodGet {
+		writeJSON(w, http.StatusNotFound, errorBody("not found"))
+		return
+	}
+	writeResult(w, http.StatusOK)(s.Fake.Jobs.Get(r.Context(), jobID))
+}
+
+// knownRepoToken reports whether token is the repository token of a
+// repository of the fake
+func (s *Server) knownRepoToken(token string) bool {
+	return token != "" && len(s.Fake.Repositories.filter(func(r *coveralls.Repository) bool { return r.Token == token })) > 0
+}
+
+// readJob decodes the job sent in the body of r
+func readJob(r *http.Request) (*coveralls.Job, error) {
+	body := r.Body
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType == "multipart/form-data" {
+		mr, err := r.MultipartReader()
+		if err != nil {
+			return nil, err
+		}
+		for {
+			part, err := mr.NextPart()
+			if err == io.EOF {
+				return nil, errors.New("missing json_file")
+			}
+			if err != nil {
+				return nil, err
+			}
+			if part.FormName() != "json_file" {
+				continue
+			}
+			body = part
+			if part.Header.Get("Content-Type") == "application/gzip" || strings.HasSuffix(part.FileName(), ".gz") {
+				if body, err = gzip.NewReader(part); err != nil {
+					return nil, err
+				}
+			}
+			break
+		}
+	}
+
+	var job coveralls.Job
+	if err := json.NewDecoder(body).Decode(&job); err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// writeRepositoryPage writes the page of repos requested by r
+func writeRepositoryPage(w http.ResponseWriter, r *http.Request, repos []*coveralls.Repository) {
+	page, pages, start, end := paginate(r, len(repos))
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"page":  page,
+		"pages": pages,
+		"total": len(repos),
+		"repos": repos[start:end],
+	})
+}
+
+// paginate returns the page requested by r, the number of pages and the
+// range of the items of the page, out of total
+func paginate(r *http.Request, total int) (page int, pages int, start int, end int) {
+	perPage, err := strconv.Atoi(r.URL.Query().Get("per_page"))
+	if err != nil || perPage <= 0 {
+		perPage = defaultPerPage
+	}
+	page, err = strconv.Atoi(r.URL.Query().Get("page"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+	pages = int(math.Ceil(float64(total) / float64(perPage)))
+	if pages == 0 {
+		pages = 1
+	}
+	start = (page - 1) * perPage
+	if start > total {
+		start = total
+	}
+	end = start + perPage
+	if end > total {
+		end = total
+	}
+	return page, pages, start, end
+}
+
+// writeResult returns a function that writes the result of a fake call, v
+// with status or the response of err
+func writeResult(w http.ResponseWriter, status int) func(v interface{}, err error) {
+	return func(v interface{}, err error) {
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		writeJSON(w, status, v)
+	}
+}
+
+// writeError writes the response Coveralls sends for err
+func writeError(w http.ResponseWriter, err error) {
+	var unprocessable coveralls.ErrUnprocessableEntity
+	var rateLimited coveralls.ErrRateLimited
+	var unavailable coveralls.ErrServiceUnavailable
+	switch {
+	case errors.Is(err, coveralls.ErrRepoNotFound),
+		errors.Is(err, coveralls.ErrBuildNotFound),
+		errors.Is(err, coveralls.ErrJobNotFound),
+		errors.Is(err, coveralls.ErrSourceFileNotFound),
+		errors.Is(err, coveralls.ErrOrganizationNotFound):
+		writeJSON(w, http.StatusNotFound, errorBody("not found"))
+	case errors.Is(err, coveralls.ErrNameIsTaken):
+		writeJSON(w, http.StatusUnprocessableEntity, map[string]interface{}{
+			"message": "Validation failed",
+			"errors":  map[string][]string{"name": {"has already been taken"}},
+		})
+	case errors.As(err, &unprocessable):
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		io.WriteString(w, unprocessable.ErrorBody)
+	case errors.Is(err, coveralls.ErrUnauthorized):
+		writeJSON(w, http.StatusUnauthorized, errorBody("unauthorized"))
+	case errors.Is(err, coveralls.ErrForbidden):
+		writeJSON(w, http.StatusForbidden, errorBody("forbidden"))
+	case errors.As(err, &rateLimited):
+		setRetryAfter(w, rateLimited.RetryAfter.Seconds())
+		writeJSON(w, http.StatusTooManyRequests, errorBody("rate limit exceeded"))
+	case errors.As(err, &unavailable):
+		setRetryAfter(w, unavailable.RetryAfter.Seconds())
+		writeJSON(w, http.StatusServiceUnavailable, errorBody("service unavailable"))
+	default:
+		writeJSON(w, http.StatusInternalServerError, errorBody(err.Error()))
+	}
+}
+
+// setRetryAfter sets the Retry-After header, rounded up to whole seconds,
+// if seconds is positive
+func setRetryAfter(w http.ResponseWriter, seconds float64) {
+	if seconds > 0 {
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(seconds))))
+	}
+}
+
+// errorBody returns the body of an error response
+func errorBody(message string) map[string]interface{} {
+	return map[string]interface{}{"message": message, "error": true}
+}
+
+// writeJSON writes v as JSON with status
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,174 @@
+/*
+Copyright (c) 2020 Loadsmart, Inc.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package coverallstest
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	coveralls "github.com/stone-payments/go-coveralls-api"
+)
+
+func TestServerRepositories(t *testing.T) {
+	ctx := context.Background()
+	server := NewServer()
+	defer server.Close()
+	client := server.Client
+
+	_, err := client.Repositories.Get(ctx, "github", "user/api")
+
+	assert.True(t, errors.Is(err, coveralls.ErrRepoNotFound))
+
+	repo, err := client.Repositories.Add(ctx, &coveralls.RepositoryConfig{Service: "github", Name: "user/api"})
+
+	require.Nil(t, err)
+	assert.Equal(t, "user/api", repo.Name)
+
+	_, err = client.Repositories.Add(ctx, &coveralls.RepositoryConfig{Service: "github", Name: "user/api"})
+
+	assert.True(t, errors.Is(err, coveralls.ErrNameIsTaken))
+
+	repo, err = client.Repositories.Get(ctx, "github", "user/api")
+
+	require.Nil(t, err)
+	assert.Equal(t, "user/api", repo.Name)
+
+	for _, name := range []string{"user/a", "user/b", "user/c", "user/d"} {
+		server.Fake.Repositories.Put(&coveralls.Repository{Service: "github", Name: name})
+	}
+	repos, err := client.Repositories.List(ctx, &coveralls.ListOptions{PerPage: 2})
+
+	require.Nil(t, err)
+	assert.Len(t, repos, 5)
+
+	require.Nil(t, client.Repositories.Delete(ctx, "github", "user/a"))
+	err = client.Repositories.Delete(ctx, "github", "user/a")
+
+	assert.True(t, errors.Is(err, coveralls.ErrRepoNotFound))
+}
+
+func TestServerErrors(t *testing.T) {
+	ctx := context.Background()
+	server := NewServer()
+	defer server.Close()
+	server.Fake.Repositories.Put(&coveralls.Repository{Service: "github", Name: "user/api"})
+
+	server.Fake.Repositories.Fail("Get", coveralls.ErrRateLimited{RetryAfter: 2 * time.Second})
+	_, err := server.Client.Repositories.Get(ctx, "github", "user/api")
+
+	var rateLimited coveralls.ErrRateLimited
+	require.True(t, errors.As(err, &rateLimited))
+	assert.Equal(t, 2*time.Second, rateLimited.RetryAfter)
+
+	server.Fake.Repositories.Fail("Get", nil)
+	client, err := coveralls.NewEnterpriseClient(server.URL, "wrong-token")
+	require.Nil(t, err)
+	_, err = client.Repositories.Get(ctx, "github", "user/api")
+
+	assert.True(t, errors.Is(err, coveralls.ErrUnauthorized))
+
+	resp, err := http.Get(server.URL + "/unknown")
+	require.Nil(t, err)
+	resp.Body.Close()
+
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
+
+func TestServerBuilds(t *testing.T) {
+	ctx := context.Background()
+	server := NewServer()
+	defer server.Close()
+	client := server.Client
+	server.Fake.Builds.Put("github", "user/api",
+		&coveralls.Build{CommitSHA: "c2", Branch: "main"},
+		&coveralls.Build{CommitSHA: "c1", Branch: "feature"},
+	)
+
+	build, err := client.Builds.Get(ctx, "github", "user/api", "c1")
+
+	require.Nil(t, err)
+	assert.Equal(t, "feature", build.Branch)
+
+	_, err = client.Builds.Get(ctx, "github", "user/api", "c3")
+
+	assert.True(t, errors.Is(err, coveralls.ErrBuildNotFound))
+
+	builds, err := client.Builds.List(ctx, "github", "user/api", &coveralls.BuildListOptions{Branch: "main"})
+
+	require.Nil(t, err)
+	require.Len(t, builds, 1)
+	assert.Equal(t, "c2", builds[0].CommitSHA)
+
+	_, err = client.Builds.List(ctx, "github", "user/web", nil)
+
+	assert.True(t, errors.Is(err, coveralls.ErrRepoNotFound))
+}
+
+func TestServerJobs(t *testing.T) {
+	ctx := context.Background()
+	server := NewServer()
+	defer server.Close()
+	client := server.Client
+	server.Fake.Repositories.Put(&coveralls.Repository{Service: "github", Name: "user/api", Token: "repo-token"})
+
+	cases := []struct {
+		name   string
+		submit func(job *coveralls.Job) (*coveralls.JobResult, error)
+	}{
+		{"json", func(job *coveralls.Job) (*coveralls.JobResult, error) {
+			return client.Jobs.Submit(ctx, job)
+		}},
+		{"multipart", func(job *coveralls.Job) (*coveralls.JobResult, error) {
+			return client.Jobs.SubmitMultipart(ctx, job, nil)
+		}},
+		{"gzip", func(job *coveralls.Job) (*coveralls.JobResult, error) {
+			return client.Jobs.SubmitMultipart(ctx, job, &coveralls.MultipartOptions{Gzip: true})
+		}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			result, err := tc.submit(&coveralls.Job{RepoToken: "repo-token", CommitSHA: tc.name})
+
+			require.Nil(t, err)
+			id, ok := result.JobID()
+			require.True(t, ok)
+			info, err := client.Jobs.Get(ctx, id)
+			require.Nil(t, err)
+			assert.Equal(t, tc.name, info.CommitSHA)
+
+			_, err = tc.submit(&coveralls.Job{RepoToken: "unknown", CommitSHA: tc.name})
+
+			var unprocessable coveralls.ErrUnprocessableEntity
+			assert.True(t, errors.As(err, &unprocessable))
+		})
+	}
+
+	assert.Len(t, server.Fake.Jobs.Submitted(), len(cases))
+}
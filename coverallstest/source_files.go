@@ -0,0 +1,75 @@
+/*
+Copyright (c) 2020 Loadsmart, Inc.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package coverallstest
+
+import (
+	"context"
+	"sync"
+
+	coveralls "github.com/stone-payments/go-coveralls-api"
+)
+
+// SourceFiles is a fake of coveralls.SourceFilesService that keeps the
+// coverage of files in memory
+type SourceFiles struct {
+	Recorder
+	mu    sync.Mutex
+	files map[string]*coveralls.SourceFile
+}
+
+var _ coveralls.SourceFilesService = (*SourceFiles)(nil)
+
+// NewSourceFiles returns a fake without files
+func NewSourceFiles() *SourceFiles {
+	return &SourceFiles{files: make(map[string]*coveralls.SourceFile)}
+}
+
+// Put sets the coverage of files in the build of the commit sha
+func (f *SourceFiles) Put(svc string, repo string, sha string, files ...*coveralls.SourceFile) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, file := range files {
+		c := *file
+		f.files[sourceFileKey(svc, repo, sha, file.Name)] = &c
+	}
+}
+
+// Get returns a file put, or coveralls.ErrSourceFileNotFound
+func (f *SourceFiles) Get(ctx context.Context, svc string, repo string, sha string, path string, opts ...coveralls.CallOption) (*coveralls.SourceFile, error) {
+	if err := f.record("Get", svc, repo, sha, path); err != nil {
+		return nil, err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	file, ok := f.files[sourceFileKey(svc, repo, sha, path)]
+	if !ok {
+		return nil, coveralls.ErrSourceFileNotFound
+	}
+	c := *file
+	return &c, nil
+}
+
+// sourceFileKey returns the key of a file put with Put
+func sourceFileKey(svc string, repo string, sha string, path string) string {
+	return key(svc, repo) + "@" + sha + ":" + path
+}
@@ -0,0 +1,121 @@
+/*
+Copyright (c) 2020 Loadsmart, Inc.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package coveralls
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+// WithDebug makes the Client write a trace of every request and response to
+// w, including headers and bodies, e.g. to find out why the API rejected a
+// RepositoryConfig. Credentials are redacted from headers, URLs and bodies.
+//
+// Streamed bodies, such as the ones sent by SubmitMultipart, are omitted.
+func WithDebug(w io.Writer) Option {
+	return func(o *options) {
+		o.debug = &debugWriter{w: w}
+	}
+}
+
+// debugWriter writes traces of requests, one at a time
+type debugWriter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// request writes a trace of req
+func (d *debugWriter) request(req *http.Request, secrets []string) {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "--> %s %s\n", req.Method, redactURL(req.URL))
+	writeHeader(&buf, redactHeader(req.Header))
+
+	switch {
+	case req.Body == nil || req.Body == http.NoBody:
+	case req.GetBody == nil:
+		buf.WriteString("[streamed body omitted]\n")
+	default:
+		body, err := req.GetBody()
+		if err == nil && req.Header.Get("Content-Encoding") == "gzip" {
+			body, err = gzip.NewReader(body)
+		}
+		var b []byte
+		if err == nil {
+			b, err = ioutil.ReadAll(body)
+		}
+		if err == nil {
+			buf.Write(redactBody(b, secrets))
+			buf.WriteString("\n")
+		}
+	}
+	buf.WriteString("\n")
+	d.write(buf.Bytes())
+}
+
+// response writes a trace of resp, whose body was already read
+func (d *debugWriter) response(req *http.Request, resp *http.Response, body []byte, elapsed time.Duration, secrets []string) {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "<-- %s %s %s (%s)\n", req.Method, redactURL(req.URL), resp.Status, elapsed)
+	writeHeader(&buf, resp.Header)
+	if len(body) > 0 {
+		buf.Write(redactBody(body, secrets))
+		buf.WriteString("\n")
+	}
+	buf.WriteString("\n")
+	d.write(buf.Bytes())
+}
+
+// failure writes a trace of a request that failed without a response
+func (d *debugWriter) failure(req *http.Request, err error, elapsed time.Duration) {
+	d.write([]byte(fmt.Sprintf("<-- %s %s error: %s (%s)\n\n", req.Method, redactURL(req.URL), err, elapsed)))
+}
+
+// writeHeader writes h to buf, one header per line sorted by name, followed by a blank line
+func writeHeader(buf *bytes.Buffer, h http.Header) {
+	keys := make([]string, 0, len(h))
+	for k := range h {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		for _, v := range h[k] {
+			fmt.Fprintf(buf, "%s: %s\n", k, v)
+		}
+	}
+	buf.WriteString("\n")
+}
+
+// write writes b to the underlying writer, so traces of concurrent requests don't mix
+func (d *debugWriter) write(b []byte) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	_, _ = d.w.Write(b)
+}
@@ -0,0 +1,89 @@
+/*
+Copyright (c) 2020 Loadsmart, Inc.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package coveralls
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithDebug(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		_, _ = w.Write([]byte(`{"message": "invalid threshold"}`))
+	}))
+	defer server.Close()
+
+	var out bytes.Buffer
+	client := NewClient("secret token", WithDebug(&out))
+	client.HostURL, _ = url.Parse(server.URL)
+
+	_, err := client.Repositories.Update(context.Background(), "github", "user/fakerepo", &RepositoryConfig{Service: "github", Name: "user/fakerepo"})
+
+	assert.True(t, errors.As(err, &ErrUnprocessableEntity{}))
+	trace := out.String()
+	assert.Contains(t, trace, "--> PUT "+server.URL+"/api/repos/github/user/fakerepo\n")
+	assert.Contains(t, trace, "Authorization: [REDACTED]\n")
+	assert.Contains(t, trace, `{"repo":{"service":"github","name":"user/fakerepo"}}`)
+	assert.Contains(t, trace, "<-- PUT "+server.URL+"/api/repos/github/user/fakerepo 422 Unprocessable Entity (")
+	assert.Contains(t, trace, `{"message": "invalid threshold"}`)
+	assert.False(t, strings.Contains(trace, "secret token"))
+}
+
+func TestWithDebugStreamedBody(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"message": "Job #1.1", "url": "https://coveralls.io/jobs/1"}`))
+	}))
+	defer server.Close()
+
+	var out bytes.Buffer
+	client := NewClient("fake token", WithDebug(&out))
+	client.HostURL, _ = url.Parse(server.URL)
+
+	_, err := client.Jobs.SubmitMultipart(context.Background(), &Job{RepoToken: "repo token"}, nil)
+
+	assert.Nil(t, err)
+	assert.Contains(t, out.String(), "[streamed body omitted]\n")
+	assert.False(t, strings.Contains(out.String(), "repo token"))
+}
+
+func TestWithDebugTransportError(t *testing.T) {
+	var out bytes.Buffer
+	client := NewClient("fake token", WithDebug(&out))
+	client.HostURL, _ = url.Parse("http://127.0.0.1:1")
+
+	_, err := client.Repositories.Get(context.Background(), "github", "user/fakerepo")
+
+	assert.NotNil(t, err)
+	assert.Contains(t, out.String(), "<-- GET http://127.0.0.1:1/api/repos/github/user/fakerepo error: ")
+}
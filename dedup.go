@@ -0,0 +1,107 @@
+/*
+Copyright (c) 2020 Loadsmart, Inc.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package coveralls
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync"
+)
+
+// WithDeduplication makes concurrent GET calls for the same URL share a
+// single request, e.g. when many goroutines call Repositories.Get for the
+// same repository at once. Each call gets its own copy of the result.
+//
+// Calls that capture the response with CaptureResponse or CaptureMetadata,
+// or that set their own token or host URL, always send requests of their
+// own. A shared request is canceled when the context of the call that sent
+// it is, failing all the calls waiting for it.
+func WithDeduplication() Option {
+	return func(o *options) {
+		o.deduplicate = true
+	}
+}
+
+// flightGroup tracks the requests in flight that calls may share
+type flightGroup struct {
+	mu      sync.Mutex
+	flights map[string]*flight
+}
+
+// flight is a request in flight and, once done, its outcome
+type flight struct {
+	done chan struct{}
+	resp *response
+	err  error
+}
+
+func newFlightGroup() *flightGroup {
+	return &flightGroup{flights: make(map[string]*flight)}
+}
+
+// do calls send for r unless a request with the same key is in flight, in
+// which case it waits for it and shares its response
+func (g *flightGroup) do(r *request, key string, send func() (*response, error)) (*response, error) {
+	g.mu.Lock()
+	if f, ok := g.flights[key]; ok {
+		g.mu.Unlock()
+		select {
+		case <-f.done:
+			return r.share(f.resp), f.err
+		case <-r.ctx.Done():
+			return nil, wrapTransportError(r.ctx.Err())
+		}
+	}
+	f := &flight{done: make(chan struct{})}
+	g.flights[key] = f
+	g.mu.Unlock()
+
+	f.resp, f.err = send()
+
+	g.mu.Lock()
+	delete(g.flights, key)
+	g.mu.Unlock()
+	close(f.done)
+	return f.resp, f.err
+}
+
+// shareable reports whether the request may share the response of another
+func (r *request) shareable(method string) bool {
+	s := r.settings
+	return method == http.MethodGet && s.response == nil && s.metadata == nil && s.token == "" && s.hostURL == ""
+}
+
+// share returns a copy of the response of another request, with the body
+// decoded into the result of r
+func (r *request) share(resp *response) *response {
+	if resp == nil {
+		return nil
+	}
+	shared := *resp
+	shared.result = r.result
+	if r.result != nil && resp.StatusCode() >= 200 && resp.StatusCode() < 300 && len(resp.body) > 0 {
+		// The body was already decoded once, so it is valid
+		_ = json.Unmarshal(resp.body, r.result)
+	}
+	return &shared
+}
@@ -0,0 +1,119 @@
+/*
+Copyright (c) 2020 Loadsmart, Inc.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package coveralls
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// newSlowRepoServer returns a server that answers repository requests after
+// a short delay, so concurrent calls overlap. It counts the requests received.
+func newSlowRepoServer(t *testing.T) (*httptest.Server, *int32) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		time.Sleep(50 * time.Millisecond)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"name": "user/fakerepo", "service": "github"}`))
+	}))
+	t.Cleanup(server.Close)
+	return server, &requests
+}
+
+func TestWithDeduplication(t *testing.T) {
+	var testCases = []struct {
+		name         string
+		opts         []Option
+		callOpts     func() []CallOption
+		wantRequests int32
+	}{
+		{name: "deduplicated", opts: []Option{WithDeduplication()}, wantRequests: 1},
+		{name: "disabled", wantRequests: 5},
+		{
+			name: "captured",
+			opts: []Option{WithDeduplication()},
+			callOpts: func() []CallOption {
+				var metadata ResponseMetadata
+				return []CallOption{CaptureMetadata(&metadata)}
+			},
+			wantRequests: 5,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			server, requests := newSlowRepoServer(t)
+			client, _ := NewEnterpriseClient(server.URL, "fake token", tt.opts...)
+
+			var wg sync.WaitGroup
+			repos := make([]*Repository, 5)
+			errs := make([]error, 5)
+			for i := range repos {
+				wg.Add(1)
+				go func(i int) {
+					defer wg.Done()
+					var callOpts []CallOption
+					if tt.callOpts != nil {
+						callOpts = tt.callOpts()
+					}
+					repos[i], errs[i] = client.Repositories.Get(context.Background(), "github", "user/fakerepo", callOpts...)
+				}(i)
+			}
+			wg.Wait()
+
+			assert.Equal(t, tt.wantRequests, atomic.LoadInt32(requests))
+			for i := range repos {
+				assert.Nil(t, errs[i])
+				assert.Equal(t, "user/fakerepo", repos[i].Name)
+				if i > 0 {
+					assert.False(t, repos[i] == repos[0], "calls share the same result")
+				}
+			}
+		})
+	}
+}
+
+func TestWithDeduplicationContextDone(t *testing.T) {
+	server, requests := newSlowRepoServer(t)
+	client, _ := NewEnterpriseClient(server.URL, "fake token", WithDeduplication())
+
+	go func() {
+		_, _ = client.Repositories.Get(context.Background(), "github", "user/fakerepo")
+	}()
+	time.Sleep(10 * time.Millisecond)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := client.Repositories.Get(ctx, "github", "user/fakerepo")
+
+	assert.NotNil(t, err)
+	assert.Equal(t, int32(1), atomic.LoadInt32(requests))
+}
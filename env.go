@@ -0,0 +1,70 @@
+/*
+Copyright (c) 2020 Loadsmart, Inc.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package coveralls
+
+import (
+	"errors"
+	"os"
+)
+
+// Environment variables read by NewClientFromEnv
+const (
+	EnvRepoToken = "COVERALLS_REPO_TOKEN" // Token of the repository, as used by most Coveralls tools
+	EnvToken     = "COVERALLS_TOKEN"      // Alternative to COVERALLS_REPO_TOKEN, as used by goveralls
+	EnvEndpoint  = "COVERALLS_ENDPOINT"   // Address of a self-hosted Coveralls server
+)
+
+// ErrMissingToken is returned by NewClientFromEnv when no token is set in the environment
+var ErrMissingToken = errors.New("missing Coveralls token: set " + EnvRepoToken + " or " + EnvToken)
+
+// NewClientFromEnv returns a new Coveralls API Client configured from the
+// environment, following the conventions of other Coveralls tools.
+//
+// The token is read from COVERALLS_REPO_TOKEN, or COVERALLS_TOKEN if the
+// former is empty. JobsService also uses it as Job.RepoToken when the job has
+// none. If COVERALLS_ENDPOINT is set, the client talks to that server as with
+// NewEnterpriseClient.
+//
+// It returns ErrMissingToken if no token is set.
+func NewClientFromEnv(opts ...Option) (*Client, error) {
+	token := os.Getenv(EnvRepoToken)
+	if token == "" {
+		token = os.Getenv(EnvToken)
+	}
+	if token == "" {
+		return nil, ErrMissingToken
+	}
+
+	var c *Client
+	if endpoint := os.Getenv(EnvEndpoint); endpoint != "" {
+		var err error
+		c, err = NewEnterpriseClient(endpoint, token, opts...)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		c = NewClient(token, opts...)
+	}
+	c.repoToken = token
+	return c, nil
+}
@@ -0,0 +1,100 @@
+/*
+Copyright (c) 2020 Loadsmart, Inc.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package coveralls
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewClientFromEnv(t *testing.T) {
+	cases := []struct {
+		name      string
+		repoToken string
+		token     string
+		endpoint  string
+		wantToken string
+		wantHost  string
+		wantErr   bool
+	}{
+		{"repo token", "repo-token", "", "", "repo-token", "https://coveralls.io", false},
+		{"token", "", "goveralls-token", "", "goveralls-token", "https://coveralls.io", false},
+		{"repo token takes precedence", "repo-token", "goveralls-token", "", "repo-token", "https://coveralls.io", false},
+		{"endpoint", "repo-token", "", "https://coveralls.example.com/", "repo-token", "https://coveralls.example.com", false},
+		{"missing token", "", "", "", "", "", true},
+		{"invalid endpoint", "repo-token", "", "coveralls.example.com", "", "", true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Setenv(EnvRepoToken, tc.repoToken)
+			t.Setenv(EnvToken, tc.token)
+			t.Setenv(EnvEndpoint, tc.endpoint)
+
+			client, err := NewClientFromEnv()
+
+			if tc.wantErr {
+				assert.NotNil(t, err)
+				assert.Nil(t, client)
+				return
+			}
+			assert.Nil(t, err)
+			assert.Equal(t, "token "+tc.wantToken, client.header.Get("Authorization"))
+			assert.Equal(t, tc.wantHost, client.HostURL.String())
+		})
+	}
+}
+
+func TestNewClientFromEnvMissingToken(t *testing.T) {
+	t.Setenv(EnvRepoToken, "")
+	t.Setenv(EnvToken, "")
+
+	_, err := NewClientFromEnv()
+
+	assert.Equal(t, ErrMissingToken, err)
+}
+
+func TestNewClientFromEnvSubmit(t *testing.T) {
+	var job Job
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		_ = json.NewDecoder(req.Body).Decode(&job)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"message": "Job #1.1", "url": "https://coveralls.io/jobs/1"}`))
+	}))
+	defer server.Close()
+
+	t.Setenv(EnvRepoToken, "repo-token")
+	t.Setenv(EnvEndpoint, server.URL)
+
+	client, err := NewClientFromEnv()
+	assert.Nil(t, err)
+	_, err = client.Jobs.Submit(context.Background(), &Job{ServiceName: "github-actions"})
+
+	assert.Nil(t, err)
+	assert.Equal(t, "repo-token", job.RepoToken)
+}
@@ -0,0 +1,275 @@
+/*
+Copyright (c) 2020 Loadsmart, Inc.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package coveralls
+
+import (
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Besides the errors documented in each of them, all service methods may
+// return ErrUnauthorized, ErrForbidden, ErrRateLimited and ErrServiceUnavailable.
+//
+// Errors caused by an API response are returned wrapped in an *APIError, so
+// use errors.Is and errors.As to check them rather than ==.
+var (
+	// ErrUnauthorized is returned when we receive a 401 Unauthorized status code,
+	// i.e. the token is missing, invalid or expired
+	ErrUnauthorized = fmt.Errorf("unauthorized (status code %d): check the API token", http.StatusUnauthorized)
+
+	// ErrForbidden is returned when we receive a 403 Forbidden status code,
+	// i.e. the token is valid but has no access to the resource
+	ErrForbidden = fmt.Errorf("forbidden (status code %d): the token has no access to the resource", http.StatusForbidden)
+)
+
+// APIError is returned when the API responds with an error. It tells which
+// request failed and wraps a more specific error, such as ErrRepoNotFound
+// or ErrUnexpectedStatusCode, that errors.Is and errors.As find.
+type APIError struct {
+	Method     string // HTTP method of the request
+	URL        string // URL of the request, with credentials redacted
+	StatusCode int    // Status code of the response
+	RequestID  string // ID the API assigned to the request, if any. Coveralls support may ask for it
+	Body       string // Body of the response
+	Err        error  // Specific error
+}
+
+func (e *APIError) Error() string {
+	if e.RequestID != "" {
+		return fmt.Sprintf("%s %s (request ID %s): %s", e.Method, e.URL, e.RequestID, e.Err)
+	}
+	return fmt.Sprintf("%s %s: %s", e.Method, e.URL, e.Err)
+}
+
+// Unwrap returns the specific error
+func (e *APIError) Unwrap() error {
+	return e.Err
+}
+
+// ErrRateLimited is returned when the API responds with 429 Too Many Requests.
+//
+// RetryAfter is how long the API asked to wait before trying again, taken
+// from the Retry-After header. It is zero when the API gave no hint.
+// Clients created with WithRetry wait for it before retrying automatically.
+type ErrRateLimited struct {
+	RetryAfter time.Duration
+	ErrorBody  string
+}
+
+func (e ErrRateLimited) Error() string {
+	if e.RetryAfter > 0 {
+		return fmt.Sprintf("rate limited (status code %d), retry after %s. Error body: '%s'", http.StatusTooManyRequests, e.RetryAfter, e.ErrorBody)
+	}
+	return fmt.Sprintf("rate limited (status code %d). Error body: '%s'", http.StatusTooManyRequests, e.ErrorBody)
+}
+
+func newErrRateLimited(retryAfter time.Duration, errorBody string) ErrRateLimited {
+	return ErrRateLimited{
+		RetryAfter: retryAfter,
+		ErrorBody:  errorBody,
+	}
+}
+
+// Retryable reports that rate limits are transient
+func (e ErrRateLimited) Retryable() bool {
+	return true
+}
+
+// ErrServiceUnavailable is returned when the API responds with 503 Service
+// Unavailable, which Coveralls does while it is down for maintenance.
+//
+// RetryAfter is how long the API asked to wait before trying again, taken
+// from the Retry-After header. It is zero when the API gave no hint.
+type ErrServiceUnavailable struct {
+	RetryAfter time.Duration
+	ErrorBody  string
+}
+
+func (e ErrServiceUnavailable) Error() string {
+	if e.RetryAfter > 0 {
+		return fmt.Sprintf("service unavailable (status code %d), retry after %s. Error body: '%s'", http.StatusServiceUnavailable, e.RetryAfter, e.ErrorBody)
+	}
+	return fmt.Sprintf("service unavailable (status code %d). Error body: '%s'", http.StatusServiceUnavailable, e.ErrorBody)
+}
+
+func newErrServiceUnavailable(retryAfter time.Duration, errorBody string) ErrServiceUnavailable {
+	return ErrServiceUnavailable{
+		RetryAfter: retryAfter,
+		ErrorBody:  errorBody,
+	}
+}
+
+// Retryable reports that maintenance windows are transient
+func (e ErrServiceUnavailable) Retryable() bool {
+	return true
+}
+
+var (
+	// errEmptyBody is the cause of ErrDecodeFailure when a response has no body
+	errEmptyBody = errors.New("empty body")
+
+	// errNoResult is the cause of ErrDecodeFailure when a response has no result of the expected type
+	errNoResult = errors.New("no result of the expected type")
+)
+
+// ErrDecodeFailure is returned when the body of a successful response can't
+// be decoded, e.g. because it is empty or truncated
+type ErrDecodeFailure struct {
+	Body string // Body of the response
+	Err  error  // Cause of the failure
+}
+
+func (e ErrDecodeFailure) Error() string {
+	return fmt.Sprintf("decoding response body: %s. Body: '%s'", e.Err, e.Body)
+}
+
+// Unwrap returns the cause of the failure
+func (e ErrDecodeFailure) Unwrap() error {
+	return e.Err
+}
+
+func newErrDecodeFailure(body string, err error) ErrDecodeFailure {
+	return ErrDecodeFailure{
+		Body: body,
+		Err:  err,
+	}
+}
+
+// nonJSONSnippetSize is how much of a non-JSON body ErrNonJSONResponse keeps
+const nonJSONSnippetSize = 256
+
+// ErrNonJSONResponse is returned when a successful response has a body that
+// isn't JSON, e.g. an HTML page served by a proxy or a Cloudflare challenge
+// in front of the API, instead of decoding it into a zero-valued result.
+type ErrNonJSONResponse struct {
+	ContentType string // Content-Type header of the response
+	Snippet     string // Start of the response body
+}
+
+func (e ErrNonJSONResponse) Error() string {
+	return fmt.Sprintf("response is not JSON (content type '%s'). Body starts with: '%s'", e.ContentType, e.Snippet)
+}
+
+func newErrNonJSONResponse(contentType string, body string) ErrNonJSONResponse {
+	if len(body) > nonJSONSnippetSize {
+		body = body[:nonJSONSnippetSize] + "..."
+	}
+	return ErrNonJSONResponse{
+		ContentType: contentType,
+		Snippet:     body,
+	}
+}
+
+// jsonContentType reports whether a Content-Type header announces JSON.
+// A missing header is given the benefit of the doubt.
+func jsonContentType(contentType string) bool {
+	if contentType == "" {
+		return true
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	return mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
+}
+
+// IsRetryable reports whether err is a transient error, so the call that
+// returned it may succeed if tried again later: timeouts, network errors,
+// ErrRateLimited, ErrServiceUnavailable, ErrCircuitOpen and 5xx status codes. Other errors, e.g.
+// ErrRepoNotFound or ErrUnprocessableEntity, are permanent.
+func IsRetryable(err error) bool {
+	if errors.Is(err, ErrCircuitOpen) {
+		return true
+	}
+	var retryable interface{ Retryable() bool }
+	return errors.As(err, &retryable) && retryable.Retryable()
+}
+
+// StatusCode returns the status code of the API response that caused err.
+//
+// Ok is false when the request never reached the API or got no response,
+// e.g. on timeouts, network errors or ErrCircuitOpen, and when err wasn't
+// caused by a request at all.
+func StatusCode(err error) (code int, ok bool) {
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		return 0, false
+	}
+	return apiErr.StatusCode, true
+}
+
+// newErrFromResponse returns the error for a response with a status code
+// that the service method has no specific error for
+func newErrFromResponse(resp *response) *APIError {
+	switch resp.StatusCode() {
+	case http.StatusUnauthorized:
+		return resp.apiError(ErrUnauthorized)
+	case http.StatusForbidden:
+		return resp.apiError(ErrForbidden)
+	case http.StatusTooManyRequests:
+		return resp.apiError(newErrRateLimited(retryAfter(resp.Header()), resp.errorBody()))
+	case http.StatusServiceUnavailable:
+		return resp.apiError(newErrServiceUnavailable(retryAfter(resp.Header()), resp.errorBody()))
+	default:
+		return resp.apiError(newErrUnexpectedStatusCode(resp.StatusCode(), resp.errorBody()))
+	}
+}
+
+// retryAfter parses the Retry-After header, given either in seconds or as
+// an HTTP date. It returns zero if the header is missing or invalid.
+func retryAfter(h http.Header) time.Duration {
+	value := h.Get("Retry-After")
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0
+		}
+		return time.Duration(seconds) * time.Second
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		if d := time.Until(date); d > 0 {
+			return d
+		}
+	}
+	return 0
+}
+
+// requestIDHeaders are the headers that may carry the ID of a request, in order of preference
+var requestIDHeaders = []string{"X-Request-Id", "X-Correlation-Id", "X-Amzn-Trace-Id", "Cf-Ray"}
+
+// requestID returns the ID the API or a proxy in front of it assigned to a request
+func requestID(h http.Header) string {
+	for _, name := range requestIDHeaders {
+		if id := h.Get(name); id != "" {
+			return id
+		}
+	}
+	return ""
+}
@@ -0,0 +1,379 @@
+/*
+Copyright (c) 2020 Loadsmart, Inc.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package coveralls
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestErrRateLimited(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Retry-After", "30")
+		w.WriteHeader(http.StatusTooManyRequests)
+		_, _ = w.Write([]byte("slow down"))
+	}))
+	defer server.Close()
+
+	client, _ := NewEnterpriseClient(server.URL, "fake token")
+
+	_, err := client.Repositories.Get(context.Background(), "github", "user/fakerepo")
+
+	var rateLimited ErrRateLimited
+	assert.True(t, errors.As(err, &rateLimited))
+	assert.Equal(t, ErrRateLimited{RetryAfter: 30 * time.Second, ErrorBody: "slow down"}, rateLimited)
+	assert.Equal(t, "rate limited (status code 429), retry after 30s. Error body: 'slow down'", rateLimited.Error())
+}
+
+func TestErrServiceUnavailable(t *testing.T) {
+	var testCases = []struct {
+		name       string
+		header     string
+		want       ErrServiceUnavailable
+		wantString string
+	}{
+		{
+			name:       "with retry hint",
+			header:     "120",
+			want:       ErrServiceUnavailable{RetryAfter: 2 * time.Minute, ErrorBody: "<html>maintenance</html>"},
+			wantString: "service unavailable (status code 503), retry after 2m0s. Error body: '<html>maintenance</html>'",
+		},
+		{
+			name:       "without retry hint",
+			want:       ErrServiceUnavailable{ErrorBody: "<html>maintenance</html>"},
+			wantString: "service unavailable (status code 503). Error body: '<html>maintenance</html>'",
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				if tt.header != "" {
+					w.Header().Set("Retry-After", tt.header)
+				}
+				w.Header().Set("Content-Type", "text/html")
+				w.WriteHeader(http.StatusServiceUnavailable)
+				_, _ = w.Write([]byte("<html>maintenance</html>"))
+			}))
+			defer server.Close()
+
+			client, _ := NewEnterpriseClient(server.URL, "fake token")
+
+			_, err := client.Builds.Get(context.Background(), "github", "user/fakerepo", "abc")
+
+			var unavailable ErrServiceUnavailable
+			assert.True(t, errors.As(err, &unavailable))
+			assert.Equal(t, tt.want, unavailable)
+			assert.Equal(t, tt.wantString, unavailable.Error())
+			assert.True(t, IsRetryable(err))
+		})
+	}
+}
+
+func TestErrNonJSONResponse(t *testing.T) {
+	longPage := "<html>" + strings.Repeat("a", 300) + "</html>"
+
+	var testCases = []struct {
+		name        string
+		contentType string
+		body        string
+		wantErr     error
+	}{
+		{
+			name:        "json",
+			contentType: "application/json; charset=utf-8",
+			body:        `{"name": "user/fakerepo"}`,
+		},
+		{
+			name:        "json without content type",
+			contentType: "text/plain; charset=utf-8",
+			body:        `{"name": "user/fakerepo"}`,
+		},
+		{
+			name:        "html",
+			contentType: "text/html",
+			body:        "<html>Just a moment...</html>",
+			wantErr:     ErrNonJSONResponse{ContentType: "text/html", Snippet: "<html>Just a moment...</html>"},
+		},
+		{
+			name:        "truncated",
+			contentType: "text/html",
+			body:        longPage,
+			wantErr:     ErrNonJSONResponse{ContentType: "text/html", Snippet: longPage[:256] + "..."},
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				w.Header().Set("Content-Type", tt.contentType)
+				_, _ = w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			client, _ := NewEnterpriseClient(server.URL, "fake token")
+
+			repo, err := client.Repositories.Get(context.Background(), "github", "user/fakerepo")
+
+			if tt.wantErr == nil {
+				assert.Nil(t, err)
+				assert.Equal(t, "user/fakerepo", repo.Name)
+				return
+			}
+			assert.Nil(t, repo)
+			var nonJSON ErrNonJSONResponse
+			assert.True(t, errors.As(err, &nonJSON))
+			assert.Equal(t, tt.wantErr, nonJSON)
+		})
+	}
+}
+
+func TestErrRateLimitedRetry(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		requests++
+		if requests == 1 {
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	client, _ := NewEnterpriseClient(server.URL, "fake token", WithRetry(1, time.Millisecond, time.Millisecond))
+
+	start := time.Now()
+	err := client.Repositories.Delete(context.Background(), "github", "user/fakerepo")
+
+	assert.Nil(t, err)
+	assert.Equal(t, 2, requests)
+	assert.True(t, time.Since(start) >= time.Second, "retried before Retry-After")
+}
+
+func TestRetryAfter(t *testing.T) {
+	var testCases = []struct {
+		name   string
+		header string
+		want   time.Duration
+	}{
+		{name: "missing", header: "", want: 0},
+		{name: "seconds", header: "120", want: 2 * time.Minute},
+		{name: "negative", header: "-1", want: 0},
+		{name: "past date", header: "Wed, 21 Oct 2015 07:28:00 GMT", want: 0},
+		{name: "invalid", header: "soon", want: 0},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			h := http.Header{}
+			if tt.header != "" {
+				h.Set("Retry-After", tt.header)
+			}
+			assert.Equal(t, tt.want, retryAfter(h))
+		})
+	}
+
+	h := http.Header{}
+	h.Set("Retry-After", time.Now().Add(time.Hour).UTC().Format(http.TimeFormat))
+	d := retryAfter(h)
+	assert.True(t, d > 59*time.Minute && d <= time.Hour, "unexpected delay %s", d)
+}
+
+func TestErrUnauthorizedAndForbidden(t *testing.T) {
+	var testCases = []struct {
+		name string
+		code int
+		want error
+	}{
+		{name: "unauthorized", code: http.StatusUnauthorized, want: ErrUnauthorized},
+		{name: "forbidden", code: http.StatusForbidden, want: ErrForbidden},
+		{name: "other", code: http.StatusTeapot, want: ErrUnexpectedStatusCode{StatusCode: http.StatusTeapot, ErrorBody: "body"}},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				w.WriteHeader(tt.code)
+				_, _ = w.Write([]byte("body"))
+			}))
+			defer server.Close()
+
+			client, _ := NewEnterpriseClient(server.URL, "fake token")
+
+			_, repoErr := client.Repositories.Get(context.Background(), "github", "user/fakerepo")
+			_, buildErr := client.Builds.Get(context.Background(), "github", "user/fakerepo", "abc")
+			_, jobErr := client.Jobs.Submit(context.Background(), &Job{})
+			deleteErr := client.Repositories.Delete(context.Background(), "github", "user/fakerepo")
+
+			assert.True(t, errors.Is(repoErr, tt.want))
+			assert.True(t, errors.Is(buildErr, tt.want))
+			assert.True(t, errors.Is(jobErr, tt.want))
+			assert.True(t, errors.Is(deleteErr, tt.want))
+		})
+	}
+}
+
+func TestAPIError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`{"error": "not found"}`))
+	}))
+	defer server.Close()
+
+	client, _ := NewEnterpriseClient(server.URL, "fake token")
+
+	err := client.Builds.Close(context.Background(), "secret-repo-token", "42")
+
+	var apiErr *APIError
+	assert.True(t, errors.As(err, &apiErr))
+	assert.Equal(t, http.MethodPost, apiErr.Method)
+	assert.Equal(t, server.URL+"/webhook?repo_token=%5BREDACTED%5D", apiErr.URL)
+	assert.Equal(t, http.StatusNotFound, apiErr.StatusCode)
+	assert.Equal(t, `{"error": "not found"}`, apiErr.Body)
+	assert.True(t, errors.Is(err, ErrBuildNotFound))
+	assert.Equal(t, "POST "+server.URL+"/webhook?repo_token=%5BREDACTED%5D: "+ErrBuildNotFound.Error(), err.Error())
+}
+
+func TestIsRetryable(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	var testCases = []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "nil", err: nil, want: false},
+		{name: "other", err: errors.New("oops"), want: false},
+		{name: "timeout", err: wrapTransportError(context.DeadlineExceeded), want: true},
+		{name: "network error", err: wrapTransportError(errors.New("connection refused")), want: true},
+		{name: "canceled", err: wrapTransportError(ctx.Err()), want: false},
+		{name: "rate limited", err: &APIError{Err: ErrRateLimited{}}, want: true},
+		{name: "service unavailable", err: &APIError{Err: ErrServiceUnavailable{}}, want: true},
+		{name: "circuit open", err: ErrCircuitOpen, want: true},
+		{name: "server error", err: &APIError{Err: ErrUnexpectedStatusCode{StatusCode: http.StatusBadGateway}}, want: true},
+		{name: "request timeout", err: &APIError{Err: ErrUnexpectedStatusCode{StatusCode: http.StatusRequestTimeout}}, want: true},
+		{name: "client error", err: &APIError{Err: ErrUnexpectedStatusCode{StatusCode: http.StatusTeapot}}, want: false},
+		{name: "not found", err: &APIError{Err: ErrRepoNotFound}, want: false},
+		{name: "unprocessable", err: &APIError{Err: newErrUnprocessableEntity("{}")}, want: false},
+		{name: "unauthorized", err: &APIError{Err: ErrUnauthorized}, want: false},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, IsRetryable(tt.err))
+		})
+	}
+}
+
+func TestRequestID(t *testing.T) {
+	var testCases = []struct {
+		name    string
+		header  string
+		code    int
+		wantErr bool
+	}{
+		{name: "success", header: "X-Request-Id", code: http.StatusNoContent},
+		{name: "error", header: "X-Request-Id", code: http.StatusInternalServerError, wantErr: true},
+		{name: "cloudflare", header: "CF-Ray", code: http.StatusInternalServerError, wantErr: true},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				w.Header().Set(tt.header, "req-123")
+				w.WriteHeader(tt.code)
+			}))
+			defer server.Close()
+
+			client, _ := NewEnterpriseClient(server.URL, "fake token")
+
+			var metadata ResponseMetadata
+			err := client.Repositories.Delete(context.Background(), "github", "user/fakerepo", CaptureMetadata(&metadata))
+
+			assert.Equal(t, ResponseMetadata{StatusCode: tt.code, RequestID: "req-123"}, metadata)
+			if !tt.wantErr {
+				assert.Nil(t, err)
+				return
+			}
+			var apiErr *APIError
+			assert.True(t, errors.As(err, &apiErr))
+			assert.Equal(t, "req-123", apiErr.RequestID)
+			assert.Contains(t, err.Error(), "(request ID req-123)")
+		})
+	}
+}
+
+func TestStatusCode(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		switch req.URL.Path {
+		case "/api/repos/github/user/notfound":
+			w.WriteHeader(http.StatusNotFound)
+		case "/api/repos/github/user/broken":
+			w.WriteHeader(http.StatusInternalServerError)
+		default:
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{"name": `))
+		}
+	}))
+	defer server.Close()
+
+	client, _ := NewEnterpriseClient(server.URL, "fake token")
+	unreachable, _ := NewEnterpriseClient("http://127.0.0.1:1", "fake token")
+
+	_, notFoundErr := client.Repositories.Get(context.Background(), "github", "user/notfound")
+	_, serverErr := client.Repositories.Get(context.Background(), "github", "user/broken")
+	_, decodeErr := client.Repositories.Get(context.Background(), "github", "user/truncated")
+	_, transportErr := unreachable.Repositories.Get(context.Background(), "github", "user/fakerepo")
+
+	var testCases = []struct {
+		name     string
+		err      error
+		wantCode int
+		wantOk   bool
+	}{
+		{name: "nil", err: nil},
+		{name: "other", err: errors.New("oops")},
+		{name: "not found", err: notFoundErr, wantCode: http.StatusNotFound, wantOk: true},
+		{name: "server error", err: serverErr, wantCode: http.StatusInternalServerError, wantOk: true},
+		{name: "decode error", err: decodeErr, wantCode: http.StatusOK, wantOk: true},
+		{name: "transport error", err: transportErr},
+		{name: "circuit open", err: ErrCircuitOpen},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			code, ok := StatusCode(tt.err)
+			assert.Equal(t, tt.wantCode, code)
+			assert.Equal(t, tt.wantOk, ok)
+		})
+	}
+}
@@ -0,0 +1,170 @@
+/*
+Copyright (c) 2020 Loadsmart, Inc.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+// Package gate checks coverage reports against thresholds, e.g. to fail a
+// CI job before or after uploading the report to Coveralls
+package gate
+
+import (
+	"fmt"
+	"sort"
+
+	coveralls "github.com/stone-payments/go-coveralls-api"
+	"github.com/stone-payments/go-coveralls-api/report"
+)
+
+// Rule names, as found in Violation.Rule
+const (
+	RuleMinTotal   = "min_total"
+	RuleMaxDrop    = "max_drop"
+	RulePerFileMin = "per_file_min"
+)
+
+// Violation is a threshold a report doesn't meet
+type Violation struct {
+	Rule      string  `json:"rule"`           // Rule broken, e.g. RuleMinTotal
+	File      string  `json:"file,omitempty"` // File that broke the rule, for per-file rules
+	Actual    float64 `json:"actual"`         // Coverage, or decrease of coverage for RuleMaxDrop
+	Threshold float64 `json:"threshold"`      // Threshold of the rule
+}
+
+func (v Violation) String() string {
+	switch v.Rule {
+	case RuleMinTotal:
+		return fmt.Sprintf("coverage %.2f%% is below the minimum of %.2f%%", v.Actual, v.Threshold)
+	case RuleMaxDrop:
+		return fmt.Sprintf("coverage dropped %.2f points, more than the maximum of %.2f", v.Actual, v.Threshold)
+	case RulePerFileMin:
+		return fmt.Sprintf("coverage of %s %.2f%% is below the minimum of %.2f%%", v.File, v.Actual, v.Threshold)
+	default:
+		return fmt.Sprintf("%s: %.2f (threshold %.2f)", v.Rule, v.Actual, v.Threshold)
+	}
+}
+
+// Option sets a threshold checked by Check
+type Option func(*thresholds)
+
+// thresholds holds the settings collected from the Options passed to Check
+type thresholds struct {
+	minTotal   *float64
+	maxDrop    *float64
+	perFileMin *float64
+	baseline   *float64
+}
+
+// MinTotal requires the coverage of the report to be at least pct percent
+func MinTotal(pct float64) Option {
+	return func(t *thresholds) {
+		t.minTotal = &pct
+	}
+}
+
+// MaxDrop requires the coverage of the report to be at most points
+// percentage points lower than the coverage set with Baseline, e.g. that
+// of the last build of the base branch. It is ignored without a baseline.
+func MaxDrop(points float64) Option {
+	return func(t *thresholds) {
+		t.maxDrop = &points
+	}
+}
+
+// PerFileMin requires the coverage of each file of the report with
+// relevant lines to be at least pct percent
+func PerFileMin(pct float64) Option {
+	return func(t *thresholds) {
+		t.perFileMin = &pct
+	}
+}
+
+// Baseline sets the coverage MaxDrop compares the report to, in percent
+func Baseline(pct float64) Option {
+	return func(t *thresholds) {
+		t.baseline = &pct
+	}
+}
+
+// FromConfig returns the thresholds Coveralls checks for a repository with
+// config: its CommitStatusFailThreshold as MinTotal and its
+// CommitStatusFailChangeThreshold as MaxDrop. Thresholds config doesn't set
+// are left out.
+func FromConfig(config *coveralls.RepositoryConfig) []Option {
+	var opts []Option
+	if config.CommitStatusFailThreshold != nil {
+		opts = append(opts, MinTotal(*config.CommitStatusFailThreshold))
+	}
+	if config.CommitStatusFailChangeThreshold != nil {
+		opts = append(opts, MaxDrop(*config.CommitStatusFailChangeThreshold))
+	}
+	return opts
+}
+
+// FromRepository returns the thresholds of FromConfig for repo, with the
+// coverage of its latest build as Baseline
+func FromRepository(repo *coveralls.Repository) []Option {
+	opts := FromConfig(repo.Config())
+	if repo.CoveredPercent != nil {
+		opts = append(opts, Baseline(*repo.CoveredPercent))
+	}
+	return opts
+}
+
+// Check returns the thresholds set by opts that r doesn't meet, if any.
+// Per-file violations are sorted by file name.
+func Check(r *report.Report, opts ...Option) []Violation {
+	t := &thresholds{}
+	for _, opt := range opts {
+		opt(t)
+	}
+
+	var violations []Violation
+	total := r.Coverage()
+	if t.minTotal != nil && total < *t.minTotal {
+		violations = append(violations, Violation{Rule: RuleMinTotal, Actual: total, Threshold: *t.minTotal})
+	}
+	if t.maxDrop != nil && t.baseline != nil {
+		if drop := *t.baseline - total; drop > *t.maxDrop {
+			violations = append(violations, Violation{Rule: RuleMaxDrop, Actual: drop, Threshold: *t.maxDrop})
+		}
+	}
+	if t.perFileMin != nil {
+		for _, name := range sortedNames(r) {
+			f := r.Files[name]
+			if _, relevant := f.Covered(); relevant == 0 {
+				continue
+			}
+			if coverage := f.Coverage(); coverage < *t.perFileMin {
+				violations = append(violations, Violation{Rule: RulePerFileMin, File: name, Actual: coverage, Threshold: *t.perFileMin})
+			}
+		}
+	}
+	return violations
+}
+
+// sortedNames returns the names of the files of r, sorted
+func sortedNames(r *report.Report) []string {
+	names := make([]string, 0, len(r.Files))
+	for name := range r.Files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,138 @@
+/*
+Copyright (c) 2020 Loadsmart, Inc.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package gate
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	coveralls "github.com/stone-payments/go-coveralls-api"
+	"github.com/stone-payments/go-coveralls-api/report"
+)
+
+// newReport returns a report with 75% coverage: main.go has 1 of 2 lines
+// covered, pkg/even.go 2 of 2 and empty.go no relevant lines
+func newReport() *report.Report {
+	r := report.New()
+	r.File("main.go").Lines[5] = 1
+	r.File("main.go").Lines[6] = 0
+	r.File("pkg/even.go").Lines[4] = 3
+	r.File("pkg/even.go").Lines[5] = 1
+	r.File("empty.go")
+	return r
+}
+
+func float(f float64) *float64 {
+	return &f
+}
+
+func TestCheck(t *testing.T) {
+	tests := []struct {
+		name string
+		opts []Option
+		want []Violation
+	}{
+		{
+			name: "no thresholds",
+		},
+		{
+			name: "total above minimum",
+			opts: []Option{MinTotal(75)},
+		},
+		{
+			name: "total below minimum",
+			opts: []Option{MinTotal(80)},
+			want: []Violation{{Rule: RuleMinTotal, Actual: 75, Threshold: 80}},
+		},
+		{
+			name: "drop without baseline",
+			opts: []Option{MaxDrop(0.5)},
+		},
+		{
+			name: "drop within maximum",
+			opts: []Option{MaxDrop(0.5), Baseline(75.5)},
+		},
+		{
+			name: "drop above maximum",
+			opts: []Option{MaxDrop(0.5), Baseline(76)},
+			want: []Violation{{Rule: RuleMaxDrop, Actual: 1, Threshold: 0.5}},
+		},
+		{
+			name: "coverage increased",
+			opts: []Option{MaxDrop(0), Baseline(50)},
+		},
+		{
+			name: "file below minimum",
+			opts: []Option{PerFileMin(60)},
+			want: []Violation{{Rule: RulePerFileMin, File: "main.go", Actual: 50, Threshold: 60}},
+		},
+		{
+			name: "all rules",
+			opts: []Option{MinTotal(80), MaxDrop(0.5), Baseline(80), PerFileMin(60)},
+			want: []Violation{
+				{Rule: RuleMinTotal, Actual: 75, Threshold: 80},
+				{Rule: RuleMaxDrop, Actual: 5, Threshold: 0.5},
+				{Rule: RulePerFileMin, File: "main.go", Actual: 50, Threshold: 60},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, Check(newReport(), tt.opts...))
+		})
+	}
+}
+
+func TestFromRepository(t *testing.T) {
+	repo := &coveralls.Repository{
+		CommitStatusFailThreshold:       float(80),
+		CommitStatusFailChangeThreshold: float(0.5),
+		CoveredPercent:                  float(76),
+	}
+	assert.Equal(t, []Violation{
+		{Rule: RuleMinTotal, Actual: 75, Threshold: 80},
+		{Rule: RuleMaxDrop, Actual: 1, Threshold: 0.5},
+	}, Check(newReport(), FromRepository(repo)...))
+
+	assert.Empty(t, FromRepository(&coveralls.Repository{}))
+	assert.Empty(t, FromConfig(&coveralls.RepositoryConfig{}))
+}
+
+func TestViolationString(t *testing.T) {
+	tests := []struct {
+		violation Violation
+		want      string
+	}{
+		{Violation{Rule: RuleMinTotal, Actual: 75, Threshold: 80}, "coverage 75.00% is below the minimum of 80.00%"},
+		{Violation{Rule: RuleMaxDrop, Actual: 1, Threshold: 0.5}, "coverage dropped 1.00 points, more than the maximum of 0.50"},
+		{Violation{Rule: RulePerFileMin, File: "main.go", Actual: 50, Threshold: 60}, "coverage of main.go 50.00% is below the minimum of 60.00%"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.violation.Rule, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.violation.String())
+		})
+	}
+}
@@ -0,0 +1,207 @@
+/*
+Copyright (c) 2020 Loadsmart, Inc.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+// Package githubimport adds the repositories of a GitHub organization to
+// Coveralls, e.g. to onboard an organization without adding each of its
+// repositories in the web interface
+package githubimport
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+
+	coveralls "github.com/stone-payments/go-coveralls-api"
+)
+
+// defaultBaseURL is the address of the GitHub API
+const defaultBaseURL = "https://api.github.com"
+
+// perPage is how many repositories are requested per page, the most GitHub allows
+const perPage = 100
+
+// Client lists the repositories of GitHub organizations
+type Client struct {
+	token      string
+	baseURL    string
+	httpClient *http.Client
+}
+
+// Option changes the settings of a Client
+type Option func(*Client)
+
+// WithBaseURL sets the address of the GitHub API, e.g. of GitHub Enterprise
+// Server at https://github.example.com/api/v3
+func WithBaseURL(baseURL string) Option {
+	return func(c *Client) {
+		c.baseURL = strings.TrimSuffix(baseURL, "/")
+	}
+}
+
+// WithHTTPClient sets the HTTP client used to call the GitHub API
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = httpClient
+	}
+}
+
+// NewClient returns a client of the GitHub API that authenticates with
+// token, which needs read access to the repositories of the organization
+func NewClient(token string, opts ...Option) *Client {
+	c := &Client{token: token, baseURL: defaultBaseURL, httpClient: http.DefaultClient}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// Repository is a GitHub repository, with the fields used to filter them
+type Repository struct {
+	Name     string   `json:"name"`
+	FullName string   `json:"full_name"` // Name with the owner, e.g. user/repository
+	Language string   `json:"language"`  // Main language, empty if GitHub detected none
+	Topics   []string `json:"topics"`
+	Archived bool     `json:"archived"`
+	Fork     bool     `json:"fork"`
+}
+
+// OrgRepos returns all repositories of the organization org that the token
+// has access to
+func (c *Client) OrgRepos(ctx context.Context, org string) ([]*Repository, error) {
+	var repos []*Repository
+	for page := 1; ; page++ {
+		var batch []*Repository
+		endpoint := fmt.Sprintf("%s/orgs/%s/repos?per_page=%d&page=%d", c.baseURL, url.PathEscape(org), perPage, page)
+		if err := c.get(ctx, endpoint, &batch); err != nil {
+			return nil, fmt.Errorf("listing repositories of %s: %w", org, err)
+		}
+		repos = append(repos, batch...)
+		if len(batch) < perPage {
+			return repos, nil
+		}
+	}
+}
+
+// get decodes the JSON response to a GET request to endpoint into v
+func (c *Client) get(ctx context.Context, endpoint string, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("github: unexpected response (status code %d): %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+// Filter selects the repositories of an organization to import. Archived
+// repositories and forks are left out unless included.
+type Filter struct {
+	Topics          []string // Only repositories with any of these topics, if set
+	Languages       []string // Only repositories whose main language is one of these, ignoring case, if set
+	IncludeArchived bool
+	IncludeForks    bool
+}
+
+// Match reports whether r passes the filter. A nil filter only leaves out
+// archived repositories and forks.
+func (f *Filter) Match(r *Repository) bool {
+	if f == nil {
+		f = &Filter{}
+	}
+	if (r.Archived && !f.IncludeArchived) || (r.Fork && !f.IncludeForks) {
+		return false
+	}
+	if len(f.Languages) > 0 && !containsFold(f.Languages, r.Language) {
+		return false
+	}
+	if len(f.Topics) == 0 {
+		return true
+	}
+	for _, topic := range r.Topics {
+		if containsFold(f.Topics, topic) {
+			return true
+		}
+	}
+	return false
+}
+
+// containsFold reports whether list has s, ignoring case
+func containsFold(list []string, s string) bool {
+	for _, item := range list {
+		if strings.EqualFold(item, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// Import ensures that every repository of the GitHub organization org
+// passing filter is in Coveralls, configured as config. The service and name
+// of config are ignored.
+//
+// Like coveralls.BulkUpdate, it goes on after a repository fails,
+// returning the result of each one and a *coveralls.BulkError if any failed.
+func Import(ctx context.Context, gh *Client, repos coveralls.RepositoryService, org string, filter *Filter, config coveralls.RepositoryConfig, opts ...coveralls.CallOption) ([]coveralls.BulkResult, error) {
+	ghRepos, err := gh.OrgRepos(ctx, org)
+	if err != nil {
+		return nil, err
+	}
+
+	var results []coveralls.BulkResult
+	var failed []coveralls.BulkResult
+	for _, r := range ghRepos {
+		if !filter.Match(r) {
+			continue
+		}
+		data := config
+		data.Service = "github"
+		data.Name = r.FullName
+		repository, err := repos.Ensure(ctx, &data, opts...)
+		result := coveralls.BulkResult{Repo: coveralls.RepoRef{Service: data.Service, Name: data.Name}, Repository: repository, Err: err}
+		results = append(results, result)
+		if err != nil {
+			failed = append(failed, result)
+		}
+	}
+	if len(failed) > 0 {
+		return results, &coveralls.BulkError{Total: len(results), Failed: failed}
+	}
+	return results, nil
+}
@@ -0,0 +1,163 @@
+/*
+Copyright (c) 2020 Loadsmart, Inc.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package githubimport
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	coveralls "github.com/stone-payments/go-coveralls-api"
+)
+
+// githubServer serves the repositories of the organization org, in pages
+// of perPage
+func githubServer(t *testing.T, repos []string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/orgs/org/repos", r.URL.Path)
+		assert.Equal(t, "Bearer gh-token", r.Header.Get("Authorization"))
+		assert.Equal(t, "100", r.URL.Query().Get("per_page"))
+		var page int
+		fmt.Sscan(r.URL.Query().Get("page"), &page)
+		start := (page - 1) * perPage
+		end := start + perPage
+		if start > len(repos) {
+			start = len(repos)
+		}
+		if end > len(repos) {
+			end = len(repos)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, "[")
+		for i, repo := range repos[start:end] {
+			if i > 0 {
+				fmt.Fprint(w, ",")
+			}
+			fmt.Fprint(w, repo)
+		}
+		fmt.Fprint(w, "]")
+	}))
+}
+
+func TestOrgRepos(t *testing.T) {
+	var repos []string
+	for i := 0; i < 150; i++ {
+		repos = append(repos, fmt.Sprintf(`{"name": "r%d", "full_name": "org/r%d", "language": "Go"}`, i, i))
+	}
+	server := githubServer(t, repos)
+	defer server.Close()
+	gh := NewClient("gh-token", WithBaseURL(server.URL+"/"))
+
+	result, err := gh.OrgRepos(context.Background(), "org")
+
+	require.NoError(t, err)
+	require.Len(t, result, 150)
+	assert.Equal(t, &Repository{Name: "r149", FullName: "org/r149", Language: "Go"}, result[149])
+}
+
+func TestOrgReposError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"message": "Not Found"}`))
+	}))
+	defer server.Close()
+	gh := NewClient("gh-token", WithBaseURL(server.URL))
+
+	result, err := gh.OrgRepos(context.Background(), "org")
+
+	assert.Nil(t, result)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "listing repositories of org")
+	assert.Contains(t, err.Error(), "status code 404")
+}
+
+func TestFilterMatch(t *testing.T) {
+	repo := &Repository{FullName: "org/api", Language: "Go", Topics: []string{"backend", "payments"}}
+	var testCases = []struct {
+		name   string
+		filter *Filter
+		repo   *Repository
+		match  bool
+	}{
+		{name: "nil filter", filter: nil, repo: repo, match: true},
+		{name: "language", filter: &Filter{Languages: []string{"go"}}, repo: repo, match: true},
+		{name: "other language", filter: &Filter{Languages: []string{"Ruby"}}, repo: repo, match: false},
+		{name: "topic", filter: &Filter{Topics: []string{"frontend", "payments"}}, repo: repo, match: true},
+		{name: "other topic", filter: &Filter{Topics: []string{"frontend"}}, repo: repo, match: false},
+		{name: "language and topic", filter: &Filter{Languages: []string{"Go"}, Topics: []string{"frontend"}}, repo: repo, match: false},
+		{name: "archived", filter: &Filter{}, repo: &Repository{Archived: true}, match: false},
+		{name: "archived included", filter: &Filter{IncludeArchived: true}, repo: &Repository{Archived: true}, match: true},
+		{name: "fork", filter: nil, repo: &Repository{Fork: true}, match: false},
+		{name: "fork included", filter: &Filter{IncludeForks: true}, repo: &Repository{Fork: true}, match: true},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.match, tt.filter.Match(tt.repo))
+		})
+	}
+}
+
+// fakeRepositories records the repositories ensured in Coveralls
+type fakeRepositories struct {
+	coveralls.RepositoryService
+	ensured []coveralls.RepositoryConfig
+}
+
+func (f *fakeRepositories) Ensure(ctx context.Context, data *coveralls.RepositoryConfig, opts ...coveralls.CallOption) (*coveralls.Repository, error) {
+	if data.Name == "org/broken" {
+		return nil, coveralls.ErrForbidden
+	}
+	f.ensured = append(f.ensured, *data)
+	return &coveralls.Repository{Service: data.Service, Name: data.Name}, nil
+}
+
+func TestImport(t *testing.T) {
+	server := githubServer(t, []string{
+		`{"name": "api", "full_name": "org/api", "language": "Go"}`,
+		`{"name": "web", "full_name": "org/web", "language": "TypeScript"}`,
+		`{"name": "old", "full_name": "org/old", "language": "Go", "archived": true}`,
+		`{"name": "broken", "full_name": "org/broken", "language": "Go"}`,
+	})
+	defer server.Close()
+	gh := NewClient("gh-token", WithBaseURL(server.URL))
+	repos := &fakeRepositories{}
+	threshold := 80.0
+	config := coveralls.RepositoryConfig{Service: "ignored", CommitStatusFailThreshold: &threshold}
+
+	results, err := Import(context.Background(), gh, repos, "org", &Filter{Languages: []string{"Go"}}, config)
+
+	require.Len(t, results, 2)
+	assert.Equal(t, "org/api", results[0].Repository.Name)
+	assert.Equal(t, coveralls.RepoRef{Service: "github", Name: "org/broken"}, results[1].Repo)
+	assert.True(t, errors.Is(err, coveralls.ErrForbidden))
+	assert.Equal(t, []coveralls.RepositoryConfig{
+		{Service: "github", Name: "org/api", CommitStatusFailThreshold: &threshold},
+	}, repos.ensured)
+}
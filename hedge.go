@@ -0,0 +1,121 @@
+/*
+Copyright (c) 2020 Loadsmart, Inc.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package coveralls
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"time"
+)
+
+// WithHedging makes the Client send a second, identical GET request when
+// the first one has no response after delay, and use whichever response
+// arrives first, canceling the other request. It trims the tail latency of
+// reads at the cost of some extra load on the API. Zero or less disables it.
+//
+// A hedged call counts as a single request for hooks, logs, the circuit
+// breaker and WithMaxConcurrency.
+func WithHedging(delay time.Duration) Option {
+	return func(o *options) {
+		o.hedgeDelay = delay
+	}
+}
+
+// hedge is one of the requests sent by a hedged call
+type hedge struct {
+	index int
+	resp  *http.Response
+	err   error
+}
+
+// do sends req with the Doer of the Client, hedging it if enabled
+func (r *request) do(req *http.Request) (*http.Response, error) {
+	delay := r.client.hedgeDelay
+	if delay <= 0 || req.Method != http.MethodGet {
+		return r.client.doer.Do(req)
+	}
+
+	results := make(chan hedge, 2)
+	var cancels []context.CancelFunc
+	send := func() {
+		ctx, cancel := context.WithCancel(req.Context())
+		index := len(cancels)
+		cancels = append(cancels, cancel)
+		go func() {
+			resp, err := r.client.doer.Do(req.Clone(ctx))
+			results <- hedge{index: index, resp: resp, err: err}
+		}()
+	}
+
+	send()
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	pending := 1
+	for {
+		select {
+		case <-timer.C:
+			send()
+			pending++
+		case res := <-results:
+			pending--
+			if res.err != nil && pending > 0 {
+				// Wait for the other request
+				continue
+			}
+			for i, cancel := range cancels {
+				if i != res.index {
+					cancel()
+				}
+			}
+			go discard(results, pending)
+			if res.err != nil {
+				cancels[res.index]()
+				return nil, res.err
+			}
+			res.resp.Body = &cancelOnClose{ReadCloser: res.resp.Body, cancel: cancels[res.index]}
+			return res.resp, nil
+		}
+	}
+}
+
+// discard closes the responses of the n requests of a hedged call that lost
+func discard(results <-chan hedge, n int) {
+	for i := 0; i < n; i++ {
+		if res := <-results; res.resp != nil {
+			res.resp.Body.Close()
+		}
+	}
+}
+
+// cancelOnClose cancels the context of a request when its response body is closed
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelOnClose) Close() error {
+	defer b.cancel()
+	return b.ReadCloser.Close()
+}
@@ -0,0 +1,102 @@
+/*
+Copyright (c) 2020 Loadsmart, Inc.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package coveralls
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// newSlowFirstServer returns a server that waits slow before answering the first
+// request and answers the next ones right away. It counts the requests received.
+func newSlowFirstServer(t *testing.T, slow time.Duration) (*httptest.Server, *int32) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if atomic.AddInt32(&requests, 1) == 1 {
+			select {
+			case <-time.After(slow):
+			case <-req.Context().Done():
+				return
+			}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"name": "user/fakerepo", "service": "github"}`))
+	}))
+	t.Cleanup(server.Close)
+	return server, &requests
+}
+
+func TestWithHedging(t *testing.T) {
+	var testCases = []struct {
+		name         string
+		opts         []Option
+		slow         time.Duration
+		wantRequests int32
+		wantFast     bool
+	}{
+		{name: "hedged", opts: []Option{WithHedging(20 * time.Millisecond)}, slow: time.Second, wantRequests: 2, wantFast: true},
+		{name: "fast enough", opts: []Option{WithHedging(time.Second)}, slow: 0, wantRequests: 1, wantFast: true},
+		{name: "disabled", slow: 300 * time.Millisecond, wantRequests: 1},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			server, requests := newSlowFirstServer(t, tt.slow)
+			client, _ := NewEnterpriseClient(server.URL, "fake token", tt.opts...)
+
+			start := time.Now()
+			repo, err := client.Repositories.Get(context.Background(), "github", "user/fakerepo")
+
+			assert.Nil(t, err)
+			assert.Equal(t, "user/fakerepo", repo.Name)
+			assert.Equal(t, tt.wantFast, time.Since(start) < 200*time.Millisecond)
+			assert.Equal(t, tt.wantRequests, atomic.LoadInt32(requests))
+		})
+	}
+}
+
+func TestWithHedgingOnlyGet(t *testing.T) {
+	server, requests := newSlowFirstServer(t, 100*time.Millisecond)
+	client, _ := NewEnterpriseClient(server.URL, "fake token", WithHedging(time.Millisecond))
+
+	_, err := client.Repositories.Update(context.Background(), "github", "user/fakerepo", &RepositoryConfig{})
+
+	assert.Nil(t, err)
+	assert.Equal(t, int32(1), atomic.LoadInt32(requests))
+}
+
+func TestWithHedgingError(t *testing.T) {
+	client, _ := NewEnterpriseClient("http://127.0.0.1:1", "fake token", WithHedging(time.Millisecond))
+
+	_, err := client.Repositories.Get(context.Background(), "github", "user/fakerepo")
+
+	assert.NotNil(t, err)
+	_, ok := StatusCode(err)
+	assert.False(t, ok)
+}
@@ -0,0 +1,129 @@
+/*
+Copyright (c) 2020 Loadsmart, Inc.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package coveralls
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"regexp"
+	"time"
+)
+
+// redacted replaces the value of headers carrying credentials in what
+// hooks and logs see
+const redacted = "[REDACTED]"
+
+// RequestHook is called before each request is sent, e.g. for logging or auditing.
+//
+// It receives a copy of the request with the Authorization header redacted
+// and without a body. Changes to it are not sent.
+type RequestHook func(req *http.Request)
+
+// ResponseHook is called after each response is received, e.g. for logging
+// or metrics, with the time elapsed since the request was sent.
+//
+// It receives a copy of the response whose Request has the Authorization
+// header redacted. Its body can be read freely, and has credentials redacted.
+type ResponseHook func(resp *http.Response, elapsed time.Duration)
+
+// WithRequestHook makes the Client call hook before sending each request.
+// It may be used several times to add more hooks, called in order.
+func WithRequestHook(hook RequestHook) Option {
+	return func(o *options) {
+		o.requestHooks = append(o.requestHooks, hook)
+	}
+}
+
+// WithResponseHook makes the Client call hook after receiving each response.
+// It may be used several times to add more hooks, called in order.
+func WithResponseHook(hook ResponseHook) Option {
+	return func(o *options) {
+		o.responseHooks = append(o.responseHooks, hook)
+	}
+}
+
+// runRequestHooks calls the request hooks of c with a redacted copy of req
+func (c *Client) runRequestHooks(req *http.Request) {
+	for _, hook := range c.requestHooks {
+		hook(redactRequest(req))
+	}
+}
+
+// runResponseHooks calls the response hooks of c with a copy of resp, whose body was already read
+func (c *Client) runResponseHooks(resp *http.Response, body []byte, elapsed time.Duration) {
+	for _, hook := range c.responseHooks {
+		copy := *resp
+		copy.Header = resp.Header.Clone()
+		copy.Body = ioutil.NopCloser(bytes.NewReader(body))
+		if resp.Request != nil {
+			copy.Request = redactRequest(resp.Request)
+		}
+		hook(&copy, elapsed)
+	}
+}
+
+// redactRequest returns a copy of req without body and credentials
+func redactRequest(req *http.Request) *http.Request {
+	copy := req.Clone(req.Context())
+	copy.Body = http.NoBody
+	copy.GetBody = nil
+	copy.Header = redactHeader(req.Header)
+	copy.URL = redactURL(req.URL)
+	return copy
+}
+
+// secretFieldPattern matches JSON string fields that hold credentials, e.g. "repo_token": "..."
+var secretFieldPattern = regexp.MustCompile(`("(?:repo_token|token|api_token|access_token|password|secret)"\s*:\s*)"(?:[^"\\]|\\.)*"`)
+
+// redactBody returns a copy of body with credentials redacted: the values
+// of JSON fields that hold them and any occurrence of secrets
+func redactBody(body []byte, secrets []string) []byte {
+	redactedBody := secretFieldPattern.ReplaceAll(body, []byte(`$1"`+redacted+`"`))
+	for _, secret := range secrets {
+		redactedBody = bytes.ReplaceAll(redactedBody, []byte(secret), []byte(redacted))
+	}
+	return redactedBody
+}
+
+// redactURL returns a copy of u with credentials in the query string redacted,
+// e.g. the repository token sent by BuildsService.Close
+func redactURL(u *url.URL) *url.URL {
+	copy := *u
+	query := u.Query()
+	if query.Get("repo_token") != "" {
+		query.Set("repo_token", redacted)
+		copy.RawQuery = query.Encode()
+	}
+	return &copy
+}
+
+// redactHeader returns a copy of h with credentials redacted
+func redactHeader(h http.Header) http.Header {
+	copy := h.Clone()
+	if copy.Get("Authorization") != "" {
+		copy.Set("Authorization", redacted)
+	}
+	return copy
+}
@@ -0,0 +1,147 @@
+/*
+Copyright (c) 2020 Loadsmart, Inc.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package coveralls
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHooks(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		assert.Equal(t, "token fake token", req.Header.Get("Authorization"))
+		body, _ := ioutil.ReadAll(req.Body)
+		assert.JSONEq(t, `{"repo": {"service": "github", "name": "user/fakerepo"}}`, string(body))
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"name": "user/fakerepo", "service": "github"}`))
+	}))
+	defer server.Close()
+
+	var calls []string
+	var requests []*http.Request
+	var responses []*http.Response
+	client := NewClient("fake token",
+		WithRequestHook(func(req *http.Request) {
+			calls = append(calls, "request 1")
+			requests = append(requests, req)
+			req.Header.Set("X-Changed", "true")
+		}),
+		WithRequestHook(func(req *http.Request) {
+			calls = append(calls, "request 2")
+		}),
+		WithResponseHook(func(resp *http.Response, elapsed time.Duration) {
+			calls = append(calls, "response")
+			responses = append(responses, resp)
+			assert.True(t, elapsed > 0)
+		}),
+	)
+	client.HostURL, _ = url.Parse(server.URL)
+
+	repo, err := client.Repositories.Update(context.Background(), "github", "user/fakerepo", &RepositoryConfig{Service: "github", Name: "user/fakerepo"})
+
+	assert.Nil(t, err)
+	assert.Equal(t, "user/fakerepo", repo.Name)
+	assert.Equal(t, []string{"request 1", "request 2", "response"}, calls)
+
+	assert.Equal(t, http.MethodPut, requests[0].Method)
+	assert.Equal(t, server.URL+"/api/repos/github/user/fakerepo", requests[0].URL.String())
+	assert.Equal(t, "[REDACTED]", requests[0].Header.Get("Authorization"))
+
+	assert.Equal(t, http.StatusOK, responses[0].StatusCode)
+	assert.Equal(t, "[REDACTED]", responses[0].Request.Header.Get("Authorization"))
+	body, _ := ioutil.ReadAll(responses[0].Body)
+	assert.Equal(t, `{"name": "user/fakerepo", "service": "github"}`, string(body))
+}
+
+func TestRedactHeader(t *testing.T) {
+	h := http.Header{}
+	h.Set("Authorization", "token secret")
+	h.Set("Accept", "application/json")
+
+	redactedHeader := redactHeader(h)
+
+	assert.Equal(t, "[REDACTED]", redactedHeader.Get("Authorization"))
+	assert.Equal(t, "application/json", redactedHeader.Get("Accept"))
+	assert.Equal(t, "token secret", h.Get("Authorization"))
+	assert.Equal(t, "", redactHeader(http.Header{}).Get("Authorization"))
+}
+
+func TestRedactBody(t *testing.T) {
+	var testCases = []struct {
+		name    string
+		body    string
+		secrets []string
+		want    string
+	}{
+		{name: "no secrets", body: `{"name": "user/repo"}`, want: `{"name": "user/repo"}`},
+		{name: "repo token field", body: `{"repo_token": "abc\"123", "name": "user/repo"}`, want: `{"repo_token": "[REDACTED]", "name": "user/repo"}`},
+		{name: "compact", body: `{"token":"abc"}`, want: `{"token":"[REDACTED]"}`},
+		{name: "known secret", body: `invalid token my-secret-token`, secrets: []string{"my-secret-token"}, want: `invalid token [REDACTED]`},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, string(redactBody([]byte(tt.body), tt.secrets)))
+		})
+	}
+}
+
+func TestRedactedErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = w.Write([]byte(`{"message": "bad token secret-api-token", "job": {"repo_token": "secret-repo-token"}}`))
+	}))
+	defer server.Close()
+
+	var out bytes.Buffer
+	var hookBody []byte
+	client, _ := NewEnterpriseClient(server.URL, "secret-api-token", WithDebug(&out), WithResponseHook(func(resp *http.Response, elapsed time.Duration) {
+		hookBody, _ = ioutil.ReadAll(resp.Body)
+	}))
+
+	_, err := client.Jobs.Submit(context.Background(), &Job{RepoToken: "secret-repo-token"})
+
+	assert.NotNil(t, err)
+	for _, text := range []string{err.Error(), out.String(), string(hookBody)} {
+		assert.NotContains(t, text, "secret-api-token")
+		assert.NotContains(t, text, "secret-repo-token")
+		assert.Contains(t, text, "[REDACTED]")
+	}
+}
+
+func TestRedactedTransportError(t *testing.T) {
+	client, _ := NewEnterpriseClient("http://127.0.0.1:1", "fake token")
+
+	err := client.Builds.Close(context.Background(), "secret-repo-token", "42")
+
+	assert.NotNil(t, err)
+	assert.NotContains(t, err.Error(), "secret-repo-token")
+}
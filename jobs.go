@@ -0,0 +1,208 @@
+/*
+Copyright (c) 2020 Loadsmart, Inc.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package coveralls
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"path"
+	"strconv"
+)
+
+var (
+	// ErrJobNotFound is returned when we receive a 404 Not Found status code while querying a job
+	ErrJobNotFound = fmt.Errorf("job was not found (status code %d)", http.StatusNotFound)
+)
+
+// JobsService holds information to access job-related endpoints
+type JobsService interface {
+	Submit(ctx context.Context, job *Job, opts ...CallOption) (*JobResult, error)
+	Get(ctx context.Context, jobID int, opts ...CallOption) (*JobInfo, error)
+	SubmitMultipart(ctx context.Context, job *Job, upload *MultipartOptions, opts ...CallOption) (*JobResult, error)
+	SubmitFile(ctx context.Context, name string, upload *MultipartOptions, opts ...CallOption) (*JobResult, error)
+}
+
+// JobsServiceImpl holds information to access job-related endpoints
+type JobsServiceImpl service
+
+// Job represents a coverage report sent to Coveralls.
+//
+// Either RepoToken or ServiceName plus ServiceJobID must be set so Coveralls
+// can find out which repository the job belongs to.
+type Job struct {
+	RepoToken          string        `json:"repo_token,omitempty"`           // Secret token of the repository (not your personal access token)
+	ServiceName        string        `json:"service_name,omitempty"`         // CI service that ran the job. E.g. github-actions, travis-ci, circleci
+	ServiceNumber      string        `json:"service_number,omitempty"`       // Build number in the CI service
+	ServiceJobID       string        `json:"service_job_id,omitempty"`       // Job ID in the CI service
+	ServicePullRequest string        `json:"service_pull_request,omitempty"` // Number of the pull request being built, if any
+	Parallel           bool          `json:"parallel,omitempty"`             // Whether more jobs will be sent for the same build. See BuildsService.Close
+	FlagName           string        `json:"flag_name,omitempty"`            // Name of the job in a parallel build, e.g. the module covered. See Carryforward
+	CommitSHA          string        `json:"commit_sha,omitempty"`
+	RunAt              string        `json:"run_at,omitempty"` // Time the job ran, e.g. 2013-02-18 00:52:48 -0800
+	Git                *Git          `json:"git,omitempty"`
+	SourceFiles        []*SourceFile `json:"source_files"` // Must be the last field, see writeJob
+}
+
+// SourceFile holds coverage information for a single file in a Job
+type SourceFile struct {
+	Name         string `json:"name"`                    // Path of the file, relative to the repository root
+	SourceDigest string `json:"source_digest,omitempty"` // MD5 digest of the file contents
+	Source       string `json:"source,omitempty"`        // Full file contents. Only needed when the git provider can't serve the file
+	Coverage     []*int `json:"coverage"`                // Hits for each line of the file. Nil means the line is not relevant
+	Branches     []int  `json:"branches,omitempty"`      // Branch coverage, as quadruples of line number, block, branch and hits
+}
+
+// Git holds information about the commit a Job was run against
+type Git struct {
+	Head    GitHead     `json:"head"`
+	Branch  string      `json:"branch,omitempty"`
+	Remotes []GitRemote `json:"remotes,omitempty"`
+}
+
+// GitHead holds information about the commit at the head of the branch
+type GitHead struct {
+	ID             string `json:"id"` // Commit SHA
+	AuthorName     string `json:"author_name,omitempty"`
+	AuthorEmail    string `json:"author_email,omitempty"`
+	CommitterName  string `json:"committer_name,omitempty"`
+	CommitterEmail string `json:"committer_email,omitempty"`
+	Message        string `json:"message,omitempty"`
+}
+
+// GitRemote holds information about one git remote
+type GitRemote struct {
+	Name string `json:"name"`
+	URL  string `json:"url"`
+}
+
+// JobResult holds the response for a successfully submitted Job
+type JobResult struct {
+	Message string `json:"message,omitempty"`
+	URL     string `json:"url,omitempty"` // URL of the job page in Coveralls
+}
+
+// JobID returns the ID of the job created, taken from its URL
+func (r *JobResult) JobID() (int, bool) {
+	id, err := strconv.Atoi(path.Base(r.URL))
+	if err != nil {
+		return 0, false
+	}
+	return id, true
+}
+
+// JobInfo holds information about a job already submitted to Coveralls
+type JobInfo struct {
+	ID             int      `json:"id"`
+	URL            string   `json:"url,omitempty"`
+	ServiceJobID   string   `json:"service_job_id,omitempty"`
+	CommitSHA      string   `json:"commit_sha,omitempty"`
+	CoveredPercent *float64 `json:"covered_percent,omitempty"` // Coverage of the job, between 0 and 100. Null while Coveralls is processing it
+	CreatedAt      string   `json:"created_at,omitempty"`
+}
+
+// Processed reports whether Coveralls has finished processing the job
+func (j *JobInfo) Processed() bool {
+	return j.CoveredPercent != nil
+}
+
+// Submit sends a coverage report to Coveralls. See DryRun to check the
+// report without sending it.
+//
+// It may return errors ErrUnprocessableEntity or ErrUnexpectedStatusCode
+func (s JobsServiceImpl) Submit(ctx context.Context, job *Job, opts ...CallOption) (*JobResult, error) {
+	endpoint := s.client.jobsEndpoint()
+	job = s.withRepoToken(ctx, job, opts)
+
+	req := s.client.newRequest(ctx, opts)
+	req.SetBody(req.sentJob(job)).
+		SetResult(&JobResult{})
+	if req.dryRun() {
+		return previewJob(req, job, endpoint)
+	}
+
+	resp, err := req.Post(endpoint)
+
+	if err != nil {
+		return nil, err
+	}
+
+	switch resp.StatusCode() {
+	case http.StatusOK, http.StatusCreated:
+		result, ok := resp.Result().(*JobResult)
+		if !ok {
+			return nil, resp.decodeFailure(errNoResult)
+		}
+		return result, nil
+	case http.StatusUnprocessableEntity:
+		return nil, resp.apiError(newErrUnprocessableEntity(resp.errorBody()))
+	default:
+		return nil, newErrFromResponse(resp)
+	}
+}
+
+// Get information about a job already submitted to Coveralls.
+//
+// JobID can be found with JobResult.JobID after a submission.
+//
+// It may return errors ErrJobNotFound or ErrUnexpectedStatusCode
+func (s JobsServiceImpl) Get(ctx context.Context, jobID int, opts ...CallOption) (*JobInfo, error) {
+	endpoint := fmt.Sprintf("/jobs/%d.json", jobID)
+
+	resp, err := s.client.newRequest(ctx, opts).
+		SetResult(&JobInfo{}).
+		Get(endpoint)
+
+	if err != nil {
+		return nil, err
+	}
+
+	switch resp.StatusCode() {
+	case http.StatusOK:
+		info, ok := resp.Result().(*JobInfo)
+		if !ok {
+			return nil, resp.decodeFailure(errNoResult)
+		}
+		return info, nil
+	case http.StatusNotFound:
+		return nil, resp.apiError(ErrJobNotFound)
+	default:
+		return nil, newErrFromResponse(resp)
+	}
+}
+
+// withRepoToken returns job with the token set by WithRepoToken or, failing
+// that, the default token of the client, if job has none
+func (s JobsServiceImpl) withRepoToken(ctx context.Context, job *Job, opts []CallOption) *Job {
+	token := newCallSettings(ctx, opts).token
+	if token == "" {
+		token = s.client.repoToken
+	}
+	if job.RepoToken != "" || token == "" {
+		return job
+	}
+
+	copy := *job
+	copy.RepoToken = token
+	return &copy
+}
@@ -0,0 +1,199 @@
+/*
+Copyright (c) 2020 Loadsmart, Inc.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package coveralls
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// Preview is a request built by a call made with DryRun, as it would have
+// been sent
+type Preview struct {
+	Method string
+	URL    string
+	Header http.Header // Headers of the request, with the Authorization header redacted
+	Body   []byte      // Body of the request, as sent but with the repository token redacted: gzip-compressed if the Content-Encoding header says so
+}
+
+// DryRun makes JobsService.Submit, JobsService.SubmitMultipart and
+// JobsService.SubmitFile validate the job with Job.Validate and store in dst
+// the request that would send it, instead of sending it. They return an
+// empty JobResult on success. ParallelBuild.Submit previews its job the same
+// way, without closing the build. Other methods ignore it.
+//
+// The repository token of the job is redacted from the body in dst, as
+// previews often end up in CI logs. See DryRunWithSecrets to keep it.
+func DryRun(dst *Preview) CallOption {
+	return func(s *callSettings) {
+		s.preview = dst
+	}
+}
+
+// DryRunWithSecrets is like DryRun, but the body in dst holds the job
+// exactly as it would be sent, repository token included
+func DryRunWithSecrets(dst *Preview) CallOption {
+	return func(s *callSettings) {
+		s.preview = dst
+		s.previewSecrets = true
+	}
+}
+
+// ErrInvalidJob is returned by Job.Validate when a job misses fields
+// Coveralls requires or has invalid values. Check for it with errors.As.
+type ErrInvalidJob struct {
+	FieldErrors map[string][]string // Problems of each invalid field, e.g. {"source_files[0].name": ["must be set"]}
+}
+
+func (e ErrInvalidJob) Error() string {
+	fields := make([]string, 0, len(e.FieldErrors))
+	for field := range e.FieldErrors {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+
+	problems := make([]string, 0, len(fields))
+	for _, field := range fields {
+		problems = append(problems, fmt.Sprintf("%s %s", field, strings.Join(e.FieldErrors[field], ", ")))
+	}
+	return "invalid job: " + strings.Join(problems, "; ")
+}
+
+// add records a problem of field
+func (e *ErrInvalidJob) add(field string, problem string) {
+	if e.FieldErrors == nil {
+		e.FieldErrors = make(map[string][]string)
+	}
+	e.FieldErrors[field] = append(e.FieldErrors[field], problem)
+}
+
+// Validate checks that the job has the fields Coveralls requires, and that
+// its source files are consistent. It returns an ErrInvalidJob listing all
+// the problems found, if any.
+func (j *Job) Validate() error {
+	var e ErrInvalidJob
+	if j.RepoToken == "" && (j.ServiceName == "" || j.ServiceJobID == "") {
+		e.add("repo_token", "must be set, unless service_name and service_job_id are")
+	}
+	if j.Git != nil && j.Git.Head.ID == "" {
+		e.add("git.head.id", "must be set")
+	}
+	if j.SourceFiles == nil {
+		e.add("source_files", "must be set")
+	}
+
+	names := make(map[string]bool)
+	for i, f := range j.SourceFiles {
+		field := fmt.Sprintf("source_files[%d]", i)
+		if f == nil {
+			e.add(field, "must not be null")
+			continue
+		}
+		if f.Name == "" {
+			e.add(field+".name", "must be set")
+		} else if names[f.Name] {
+			e.add(field+".name", "is duplicated")
+		}
+		names[f.Name] = true
+		if f.SourceDigest == "" && f.Source == "" {
+			e.add(field+".source_digest", "must be set, unless source is")
+		}
+		if f.Coverage == nil {
+			e.add(field+".coverage", "must be set")
+		}
+		if len(f.Branches)%4 != 0 {
+			e.add(field+".branches", "must be quadruples of line, block, branch and hits")
+			continue
+		}
+		for b := 0; b < len(f.Branches); b += 4 {
+			if line := f.Branches[b]; line < 1 || line > len(f.Coverage) {
+				e.add(field+".branches", fmt.Sprintf("has line %d, outside of coverage", line))
+			}
+		}
+	}
+
+	if e.FieldErrors != nil {
+		return e
+	}
+	return nil
+}
+
+// previewJob validates job and stores the request that would send it in
+// the Preview of DryRun
+func previewJob(r *request, job *Job, endpoint string) (*JobResult, error) {
+	if err := job.Validate(); err != nil {
+		return nil, err
+	}
+	if err := r.preview(http.MethodPost, endpoint); err != nil {
+		return nil, err
+	}
+	return &JobResult{}, nil
+}
+
+// dryRun reports whether the request was made with DryRun
+func (r *request) dryRun() bool {
+	return r.settings.preview != nil
+}
+
+// sentJob returns the job to send in the body of the request: job itself,
+// or for previews a copy with the repository token redacted, unless they
+// were made with DryRunWithSecrets
+func (r *request) sentJob(job *Job) *Job {
+	if !r.dryRun() || r.settings.previewSecrets || job.RepoToken == "" {
+		return job
+	}
+	copy := *job
+	copy.RepoToken = redacted
+	return &copy
+}
+
+// preview builds the request to endpoint as execute does, and stores it in
+// the Preview of DryRun instead of sending it
+func (r *request) preview(method string, endpoint string) error {
+	rawURL, err := r.url(endpoint)
+	if err != nil {
+		return err
+	}
+	req, err := r.build(r.ctx, method, rawURL)
+	if err != nil {
+		return err
+	}
+
+	var body []byte
+	if req.Body != nil {
+		defer req.Body.Close()
+		if body, err = ioutil.ReadAll(req.Body); err != nil {
+			return fmt.Errorf("reading request body: %w", err)
+		}
+	}
+	*r.settings.preview = Preview{
+		Method: method,
+		URL:    redactURL(req.URL).String(),
+		Header: redactHeader(req.Header),
+		Body:   body,
+	}
+	return nil
+}
@@ -0,0 +1,248 @@
+/*
+Copyright (c) 2020 Loadsmart, Inc.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package coveralls
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJobValidate(t *testing.T) {
+	valid := func() *Job {
+		return &Job{
+			RepoToken: "fake-repo-token",
+			Git:       &Git{Head: GitHead{ID: "abc123"}},
+			SourceFiles: []*SourceFile{
+				{Name: "a.go", SourceDigest: "digest", Coverage: []*int{nil, pint(1)}, Branches: []int{2, 0, 0, 1}},
+				{Name: "b.go", Source: "package b", Coverage: []*int{}},
+			},
+		}
+	}
+
+	var testCases = []struct {
+		name   string
+		change func(j *Job)
+		errors map[string][]string
+	}{
+		{
+			name:   "valid",
+			change: func(j *Job) {},
+		},
+		{
+			name: "service job",
+			change: func(j *Job) {
+				j.RepoToken, j.ServiceName, j.ServiceJobID = "", "travis-ci", "42"
+			},
+		},
+		{
+			name: "no token",
+			change: func(j *Job) {
+				j.RepoToken, j.ServiceName = "", "travis-ci"
+			},
+			errors: map[string][]string{"repo_token": {"must be set, unless service_name and service_job_id are"}},
+		},
+		{
+			name:   "no commit",
+			change: func(j *Job) { j.Git.Head.ID = "" },
+			errors: map[string][]string{"git.head.id": {"must be set"}},
+		},
+		{
+			name:   "no source files",
+			change: func(j *Job) { j.SourceFiles = nil },
+			errors: map[string][]string{"source_files": {"must be set"}},
+		},
+		{
+			name: "invalid source files",
+			change: func(j *Job) {
+				j.SourceFiles[1] = &SourceFile{Name: "a.go", Branches: []int{1, 0, 0}}
+				j.SourceFiles[0].Branches = []int{3, 0, 0, 1}
+				j.SourceFiles = append(j.SourceFiles, nil, &SourceFile{Coverage: []*int{}, Source: "package c"})
+			},
+			errors: map[string][]string{
+				"source_files[0].branches":      {"has line 3, outside of coverage"},
+				"source_files[1].name":          {"is duplicated"},
+				"source_files[1].source_digest": {"must be set, unless source is"},
+				"source_files[1].coverage":      {"must be set"},
+				"source_files[1].branches":      {"must be quadruples of line, block, branch and hits"},
+				"source_files[2]":               {"must not be null"},
+				"source_files[3].name":          {"must be set"},
+			},
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			job := valid()
+			tt.change(job)
+
+			err := job.Validate()
+
+			if tt.errors == nil {
+				assert.Nil(t, err)
+				return
+			}
+			var invalid ErrInvalidJob
+			assert.True(t, errors.As(err, &invalid))
+			assert.Equal(t, tt.errors, invalid.FieldErrors)
+		})
+	}
+}
+
+func TestErrInvalidJob(t *testing.T) {
+	err := ErrInvalidJob{FieldErrors: map[string][]string{
+		"source_files[0].name": {"must be set"},
+		"repo_token":           {"must be set"},
+	}}
+
+	assert.Equal(t, "invalid job: repo_token must be set; source_files[0].name must be set", err.Error())
+}
+
+func TestJobsServiceSubmitDryRun(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("request sent in dry run")
+	}))
+	defer server.Close()
+
+	client, err := NewEnterpriseClient(server.URL, "fake token")
+	assert.Nil(t, err)
+	job := &Job{
+		ServiceJobID: "42",
+		SourceFiles:  []*SourceFile{{Name: "a.go", SourceDigest: "digest", Coverage: []*int{nil, pint(1)}}},
+	}
+
+	var preview Preview
+	result, err := client.Jobs.Submit(context.Background(), job, WithRepoToken("fake-repo-token"), DryRun(&preview))
+
+	assert.Nil(t, err)
+	assert.Equal(t, &JobResult{}, result)
+	assert.Equal(t, http.MethodPost, preview.Method)
+	assert.Equal(t, server.URL+"/api/v1/jobs", preview.URL)
+	assert.Equal(t, "application/json", preview.Header.Get("Content-Type"))
+	assert.Equal(t, "[REDACTED]", preview.Header.Get("Authorization"))
+	received := &Job{}
+	assert.Nil(t, json.Unmarshal(preview.Body, received))
+	job.RepoToken = "[REDACTED]"
+	assert.Equal(t, job, received)
+	assert.NotContains(t, string(preview.Body), "fake-repo-token")
+
+	job.RepoToken = ""
+	_, err = client.Jobs.Submit(context.Background(), job, WithRepoToken("fake-repo-token"), DryRunWithSecrets(&preview))
+
+	assert.Nil(t, err)
+	assert.Nil(t, json.Unmarshal(preview.Body, received))
+	assert.Equal(t, "fake-repo-token", received.RepoToken)
+}
+
+func TestJobsServiceSubmitDryRunInvalid(t *testing.T) {
+	client := NewClient("fake token")
+
+	var preview Preview
+	result, err := client.Jobs.Submit(context.Background(), &Job{}, DryRun(&preview))
+
+	assert.Nil(t, result)
+	var invalid ErrInvalidJob
+	assert.True(t, errors.As(err, &invalid))
+	assert.Equal(t, Preview{}, preview)
+}
+
+func TestJobsServiceSubmitMultipartDryRun(t *testing.T) {
+	client := NewClient("fake token")
+	job := &Job{
+		RepoToken:   "fake-repo-token",
+		SourceFiles: []*SourceFile{{Name: "a.go", SourceDigest: "digest", Coverage: []*int{nil, pint(1)}}},
+	}
+
+	var preview Preview
+	result, err := client.Jobs.SubmitMultipart(context.Background(), job, nil, DryRun(&preview))
+
+	assert.Nil(t, err)
+	assert.Equal(t, &JobResult{}, result)
+	assert.Equal(t, "https://coveralls.io/api/v1/jobs", preview.URL)
+	mediaType, params, err := mime.ParseMediaType(preview.Header.Get("Content-Type"))
+	assert.Nil(t, err)
+	assert.Equal(t, "multipart/form-data", mediaType)
+	part, err := multipart.NewReader(bytes.NewReader(preview.Body), params["boundary"]).NextPart()
+	assert.Nil(t, err)
+	received := &Job{}
+	assert.Nil(t, json.NewDecoder(part).Decode(received))
+	assert.Equal(t, "[REDACTED]", received.RepoToken)
+	assert.Equal(t, "fake-repo-token", job.RepoToken)
+	received.RepoToken = job.RepoToken
+	assert.Equal(t, job, received)
+}
+
+func TestJobsServiceSubmitFileDryRun(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		requests++
+	}))
+	defer server.Close()
+	client, _ := NewEnterpriseClient(server.URL, "fake token")
+	job := &Job{
+		RepoToken:   "fake-repo-token",
+		SourceFiles: []*SourceFile{{Name: "a.go", SourceDigest: "digest", Coverage: []*int{nil, pint(1)}}},
+	}
+	name := filepath.Join(t.TempDir(), "coverage.json")
+	content, _ := json.Marshal(job)
+	assert.Nil(t, os.WriteFile(name, content, 0o644))
+
+	var preview Preview
+	result, err := client.Jobs.SubmitFile(context.Background(), name, nil, DryRun(&preview))
+
+	assert.Nil(t, err)
+	assert.Equal(t, &JobResult{}, result)
+	assert.Equal(t, 0, requests)
+	mediaType, params, err := mime.ParseMediaType(preview.Header.Get("Content-Type"))
+	assert.Nil(t, err)
+	assert.Equal(t, "multipart/form-data", mediaType)
+	part, err := multipart.NewReader(bytes.NewReader(preview.Body), params["boundary"]).NextPart()
+	assert.Nil(t, err)
+	received := &Job{}
+	assert.Nil(t, json.NewDecoder(part).Decode(received))
+	assert.Equal(t, "[REDACTED]", received.RepoToken)
+	received.RepoToken = job.RepoToken
+	assert.Equal(t, job, received)
+}
+
+func TestJobsServiceSubmitFileDryRunInvalid(t *testing.T) {
+	client := NewClient("fake token")
+	name := filepath.Join(t.TempDir(), "coverage.json")
+	assert.Nil(t, os.WriteFile(name, []byte(`{"source_files": [}`), 0o644))
+
+	var preview Preview
+	result, err := client.Jobs.SubmitFile(context.Background(), name, nil, DryRun(&preview))
+
+	assert.Nil(t, result)
+	assert.Contains(t, err.Error(), "decoding job file")
+}
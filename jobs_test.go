@@ -0,0 +1,176 @@
+/*
+Copyright (c) 2020 Loadsmart, Inc.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package coveralls
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJobsServiceSubmit(t *testing.T) {
+	job := &Job{
+		RepoToken:    "fake-repo-token",
+		ServiceName:  "github-actions",
+		ServiceJobID: "42",
+		Git: &Git{
+			Head:    GitHead{ID: "abc123", AuthorName: "John Doe", Message: "Fix everything"},
+			Branch:  "master",
+			Remotes: []GitRemote{{Name: "origin", URL: "git@github.com:user/fakerepo.git"}},
+		},
+		SourceFiles: []*SourceFile{
+			{
+				Name:         "main.go",
+				SourceDigest: "d41d8cd98f00b204e9800998ecf8427e",
+				Coverage:     []*int{nil, pint(1), pint(0)},
+			},
+		},
+	}
+	fakeUrl := "https://coveralls.io/api/v1/jobs"
+	httpmock.RegisterResponder("POST", fakeUrl, func(req *http.Request) (*http.Response, error) {
+		received := &Job{}
+		if err := json.NewDecoder(req.Body).Decode(received); err != nil {
+			return httpmock.NewStringResponse(400, ""), nil
+		}
+
+		assert.Equal(t, job, received)
+
+		return httpmock.NewJsonResponse(200, &JobResult{Message: "Job #1.1", URL: "https://coveralls.io/jobs/1"})
+	})
+
+	client := NewClient("fake token")
+	httpmock.ActivateNonDefault(client.client)
+	defer httpmock.DeactivateAndReset()
+
+	result, err := client.Jobs.Submit(context.Background(), job)
+
+	assert.Nil(t, err)
+	assert.Equal(t, &JobResult{Message: "Job #1.1", URL: "https://coveralls.io/jobs/1"}, result)
+}
+
+func TestJobsServiceSubmitError(t *testing.T) {
+	fakeUrl := "https://coveralls.io/api/v1/jobs"
+	httpmock.RegisterResponder("POST", fakeUrl, httpmock.NewStringResponder(422, `{"message":"Couldn't find a repository matching this job.","error":true}`))
+
+	client := NewClient("fake token")
+	httpmock.ActivateNonDefault(client.client)
+	defer httpmock.DeactivateAndReset()
+
+	result, err := client.Jobs.Submit(context.Background(), &Job{RepoToken: "wrong"})
+
+	assert.True(t, errors.Is(err, newErrUnprocessableEntity(`{"message":"Couldn't find a repository matching this job.","error":true}`)))
+	assert.Nil(t, result)
+}
+
+func TestJobsServiceSubmitWithRepoToken(t *testing.T) {
+	fakeUrl := "https://coveralls.io/api/v1/jobs"
+	httpmock.RegisterResponder("POST", fakeUrl, func(req *http.Request) (*http.Response, error) {
+		received := &Job{}
+		if err := json.NewDecoder(req.Body).Decode(received); err != nil {
+			return httpmock.NewStringResponse(400, ""), nil
+		}
+		assert.Equal(t, "fake-repo-token", received.RepoToken)
+		assert.Equal(t, "token fake-repo-token", req.Header.Get("Authorization"))
+
+		return httpmock.NewJsonResponse(200, &JobResult{Message: "Job #1.1", URL: "https://coveralls.io/jobs/1"})
+	})
+
+	client := NewClient("fake token")
+	httpmock.ActivateNonDefault(client.client)
+	defer httpmock.DeactivateAndReset()
+
+	job := &Job{ServiceJobID: "42"}
+	_, err := client.Jobs.Submit(context.Background(), job, WithRepoToken("fake-repo-token"))
+
+	assert.Nil(t, err)
+	assert.Equal(t, "", job.RepoToken)
+}
+
+func TestJobsServiceGet(t *testing.T) {
+	var testCases = []struct {
+		name string
+		code int
+		job  *JobInfo
+		err  error
+	}{
+		{
+			name: "processed",
+			code: http.StatusOK,
+			job:  &JobInfo{ID: 5869, ServiceJobID: "42", CommitSHA: "abc123", CoveredPercent: pfloat64(81.5)},
+			err:  nil,
+		},
+		{
+			name: "notfound",
+			code: http.StatusNotFound,
+			job:  nil,
+			err:  ErrJobNotFound,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			fakeUrl := "https://coveralls.io/jobs/5869.json"
+			responder, _ := httpmock.NewJsonResponder(tt.code, tt.job)
+			httpmock.RegisterResponder("GET", fakeUrl, responder)
+
+			client := NewClient("fake token")
+			httpmock.ActivateNonDefault(client.client)
+			defer httpmock.DeactivateAndReset()
+
+			result, err := client.Jobs.Get(context.Background(), 5869)
+
+			if !errors.Is(err, tt.err) {
+				t.Errorf("Errors do not match.\n\texpected: '%v'\n\tgot: '%v'", tt.err, err)
+			}
+			assert.Equal(t, tt.job, result)
+		})
+	}
+}
+
+func TestJobResultJobID(t *testing.T) {
+	id, ok := (&JobResult{URL: "https://coveralls.io/jobs/5869"}).JobID()
+	assert.True(t, ok)
+	assert.Equal(t, 5869, id)
+
+	_, ok = (&JobResult{}).JobID()
+	assert.False(t, ok)
+}
+
+func TestJobInfoProcessed(t *testing.T) {
+	assert.False(t, (&JobInfo{ID: 1}).Processed())
+	assert.True(t, (&JobInfo{ID: 1, CoveredPercent: pfloat64(0)}).Processed())
+}
+
+func TestSourceFileMarshall(t *testing.T) {
+	in := SourceFile{Name: "main.go", SourceDigest: "abc", Coverage: []*int{nil, pint(3), pint(0)}}
+
+	content, err := json.Marshal(in)
+
+	assert.Nil(t, err)
+	assert.JSONEq(t, `{"name": "main.go", "source_digest": "abc", "coverage": [null, 3, 0]}`, string(content))
+}
@@ -0,0 +1,278 @@
+/*
+Copyright (c) 2020 Loadsmart, Inc.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package coveralls
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"os"
+)
+
+// MultipartOptions specifies how JobsService.SubmitMultipart encodes the job
+type MultipartOptions struct {
+	Gzip bool // Whether the json_file is gzip-compressed
+}
+
+// SubmitMultipart sends a coverage report to Coveralls as a multipart
+// json_file upload, the format used by most Coveralls clients.
+//
+// The request body is streamed while the job is encoded one source file at
+// a time, so the whole payload is never held in memory. Opts may be nil to
+// send the file uncompressed.
+//
+// It may return errors ErrUnprocessableEntity or ErrUnexpectedStatusCode
+func (s JobsServiceImpl) SubmitMultipart(ctx context.Context, job *Job, upload *MultipartOptions, opts ...CallOption) (*JobResult, error) {
+	endpoint := s.client.jobsEndpoint()
+	job = s.withRepoToken(ctx, job, opts)
+
+	if upload == nil {
+		upload = &MultipartOptions{}
+	}
+
+	req := s.client.newRequest(ctx, opts)
+	pr, contentType := multipartJob(req.sentJob(job), upload.Gzip)
+	req.SetHeader("Content-Type", contentType).
+		SetBody(pr).
+		SetResult(&JobResult{})
+	if req.dryRun() {
+		defer pr.Close()
+		return previewJob(req, job, endpoint)
+	}
+
+	resp, err := req.Post(endpoint)
+
+	// Unblock the writer goroutine if the request ended before reading the whole body
+	pr.Close()
+
+	if err != nil {
+		return nil, err
+	}
+
+	switch resp.StatusCode() {
+	case http.StatusOK, http.StatusCreated:
+		result, ok := resp.Result().(*JobResult)
+		if !ok {
+			return nil, resp.decodeFailure(errNoResult)
+		}
+		return result, nil
+	case http.StatusUnprocessableEntity:
+		return nil, resp.apiError(newErrUnprocessableEntity(resp.errorBody()))
+	default:
+		return nil, newErrFromResponse(resp)
+	}
+}
+
+// SubmitFile sends the coverage report stored as JSON in the file name to
+// Coveralls, as SubmitMultipart does. The file is streamed from disk, so
+// huge reports never need to fit in memory.
+//
+// The file is sent as is, so it must hold a complete job, repository token
+// included. As with Submit, the call is retried after transient errors only
+// with WithRetries; each retry reads the file again from the start.
+//
+// With DryRun, the file is decoded into a Job and previewed as
+// SubmitMultipart does.
+//
+// It may return errors ErrUnprocessableEntity or ErrUnexpectedStatusCode
+func (s JobsServiceImpl) SubmitFile(ctx context.Context, name string, upload *MultipartOptions, opts ...CallOption) (*JobResult, error) {
+	endpoint := s.client.jobsEndpoint()
+
+	if upload == nil {
+		upload = &MultipartOptions{}
+	}
+	if _, err := os.Stat(name); err != nil {
+		return nil, err
+	}
+
+	req := s.client.newRequest(ctx, opts)
+	if req.dryRun() {
+		job, err := readJobFile(name)
+		if err != nil {
+			return nil, err
+		}
+		pr, contentType := multipartJob(req.sentJob(job), upload.Gzip)
+		defer pr.Close()
+		req.SetHeader("Content-Type", contentType).SetBody(pr)
+		return previewJob(req, job, endpoint)
+	}
+
+	boundary := multipart.NewWriter(nil).Boundary()
+	open := func() (io.ReadCloser, error) {
+		f, err := os.Open(name)
+		if err != nil {
+			return nil, err
+		}
+		pr, pw := io.Pipe()
+		mw := multipart.NewWriter(pw)
+		if err := mw.SetBoundary(boundary); err != nil {
+			f.Close()
+			return nil, err
+		}
+		go func() {
+			err := writeMultipart(mw, upload.Gzip, func(w io.Writer) error {
+				_, err := io.Copy(w, f)
+				return err
+			})
+			f.Close()
+			pw.CloseWithError(err)
+		}()
+		return pr, nil
+	}
+
+	resp, err := req.
+		SetHeader("Content-Type", "multipart/form-data; boundary="+boundary).
+		SetBody(bodyOpener(open)).
+		SetResult(&JobResult{}).
+		Post(endpoint)
+
+	if err != nil {
+		return nil, err
+	}
+
+	switch resp.StatusCode() {
+	case http.StatusOK, http.StatusCreated:
+		result, ok := resp.Result().(*JobResult)
+		if !ok {
+			return nil, resp.decodeFailure(errNoResult)
+		}
+		return result, nil
+	case http.StatusUnprocessableEntity:
+		return nil, resp.apiError(newErrUnprocessableEntity(resp.errorBody()))
+	default:
+		return nil, newErrFromResponse(resp)
+	}
+}
+
+// readJobFile decodes the job stored as JSON in the file name
+func readJobFile(name string) (*Job, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	job := &Job{}
+	if err := json.NewDecoder(f).Decode(job); err != nil {
+		return nil, fmt.Errorf("decoding job file %s: %w", name, err)
+	}
+	return job, nil
+}
+
+// multipartJob returns the multipart body with job as its json_file part,
+// encoded as it is read, and its content type. The reader must be closed.
+func multipartJob(job *Job, compress bool) (*io.PipeReader, string) {
+	pr, pw := io.Pipe()
+	mw := multipart.NewWriter(pw)
+	go func() {
+		pw.CloseWithError(writeMultipartJob(mw, job, compress))
+	}()
+	return pr, mw.FormDataContentType()
+}
+
+// writeMultipartJob writes the multipart body with the job as its json_file part
+func writeMultipartJob(mw *multipart.Writer, job *Job, compress bool) error {
+	return writeMultipart(mw, compress, func(w io.Writer) error {
+		return writeJob(w, job)
+	})
+}
+
+// writeMultipart writes a multipart body with a json_file part, whose
+// contents are written by write
+func writeMultipart(mw *multipart.Writer, compress bool, write func(w io.Writer) error) error {
+	header := make(textproto.MIMEHeader)
+	if compress {
+		header.Set("Content-Disposition", `form-data; name="json_file"; filename="coverage.json.gz"`)
+		header.Set("Content-Type", "application/gzip")
+	} else {
+		header.Set("Content-Disposition", `form-data; name="json_file"; filename="coverage.json"`)
+		header.Set("Content-Type", "application/json")
+	}
+
+	part, err := mw.CreatePart(header)
+	if err != nil {
+		return err
+	}
+
+	if compress {
+		gw := gzip.NewWriter(part)
+		if err := write(gw); err != nil {
+			return err
+		}
+		if err := gw.Close(); err != nil {
+			return err
+		}
+	} else if err := write(part); err != nil {
+		return err
+	}
+
+	return mw.Close()
+}
+
+// writeJob writes job as JSON, encoding one source file at a time. The
+// output is the same as json.Marshal(job).
+func writeJob(w io.Writer, job *Job) error {
+	// SourceFiles is the last field of Job, so we encode everything else
+	// and replace the trailing null with the streamed array.
+	header := *job
+	header.SourceFiles = nil
+	b, err := json.Marshal(&header)
+	if err != nil {
+		return err
+	}
+	b = bytes.TrimSuffix(b, []byte("null}"))
+	if _, err := w.Write(b); err != nil {
+		return err
+	}
+
+	if job.SourceFiles == nil {
+		_, err := io.WriteString(w, "null}")
+		return err
+	}
+
+	if _, err := io.WriteString(w, "["); err != nil {
+		return err
+	}
+	for i, f := range job.SourceFiles {
+		if i > 0 {
+			if _, err := io.WriteString(w, ","); err != nil {
+				return err
+			}
+		}
+		b, err := json.Marshal(f)
+		if err != nil {
+			return err
+		}
+		if _, err := w.Write(b); err != nil {
+			return err
+		}
+	}
+	_, err = io.WriteString(w, "]}")
+	return err
+}
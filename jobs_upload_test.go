@@ -0,0 +1,175 @@
+/*
+Copyright (c) 2020 Loadsmart, Inc.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package coveralls
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJobsServiceSubmitMultipart(t *testing.T) {
+	job := &Job{
+		RepoToken:    "fake-repo-token",
+		ServiceJobID: "42",
+		SourceFiles: []*SourceFile{
+			{Name: "a.go", Coverage: []*int{nil, pint(1)}},
+			{Name: "b.go", Coverage: []*int{pint(0)}},
+		},
+	}
+
+	for _, compress := range []bool{false, true} {
+		fakeUrl := "https://coveralls.io/api/v1/jobs"
+		httpmock.RegisterResponder("POST", fakeUrl, func(req *http.Request) (*http.Response, error) {
+			mediaType, params, err := mime.ParseMediaType(req.Header.Get("Content-Type"))
+			assert.Nil(t, err)
+			assert.Equal(t, "multipart/form-data", mediaType)
+
+			part, err := multipart.NewReader(req.Body, params["boundary"]).NextPart()
+			assert.Nil(t, err)
+			assert.Equal(t, "json_file", part.FormName())
+
+			var content io.Reader = part
+			if compress {
+				content, err = gzip.NewReader(part)
+				assert.Nil(t, err)
+			}
+
+			received := &Job{}
+			if err := json.NewDecoder(content).Decode(received); err != nil {
+				return httpmock.NewStringResponse(400, ""), nil
+			}
+			assert.Equal(t, job, received)
+
+			return httpmock.NewJsonResponse(200, &JobResult{Message: "Job #1.1", URL: "https://coveralls.io/jobs/1"})
+		})
+
+		client := NewClient("fake token")
+		httpmock.ActivateNonDefault(client.client)
+
+		result, err := client.Jobs.SubmitMultipart(context.Background(), job, &MultipartOptions{Gzip: compress})
+
+		assert.Nil(t, err)
+		assert.Equal(t, &JobResult{Message: "Job #1.1", URL: "https://coveralls.io/jobs/1"}, result)
+		httpmock.DeactivateAndReset()
+	}
+}
+
+func TestWriteJob(t *testing.T) {
+	var testCases = []struct {
+		name string
+		job  *Job
+	}{
+		{name: "empty", job: &Job{}},
+		{name: "nofiles", job: &Job{RepoToken: "token", SourceFiles: []*SourceFile{}}},
+		{name: "files", job: &Job{RepoToken: "token", Git: &Git{Head: GitHead{ID: "abc"}}, SourceFiles: []*SourceFile{
+			{Name: "a.go", Coverage: []*int{nil, pint(1)}},
+			{Name: "b.go", SourceDigest: "abc", Coverage: []*int{pint(0)}},
+		}}},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			expected, _ := json.Marshal(tt.job)
+
+			var buf bytes.Buffer
+			err := writeJob(&buf, tt.job)
+
+			assert.Nil(t, err)
+			assert.Equal(t, string(expected), buf.String())
+		})
+	}
+}
+
+func TestJobsServiceSubmitFile(t *testing.T) {
+	job := &Job{
+		RepoToken: "fake-repo-token",
+		SourceFiles: []*SourceFile{
+			{Name: "a.go", Coverage: []*int{nil, pint(1)}},
+		},
+	}
+	name := filepath.Join(t.TempDir(), "coverage.json")
+	content, _ := json.Marshal(job)
+	assert.Nil(t, os.WriteFile(name, content, 0o644))
+
+	for _, compress := range []bool{false, true} {
+		requests := 0
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			requests++
+			mediaType, params, err := mime.ParseMediaType(req.Header.Get("Content-Type"))
+			assert.Nil(t, err)
+			assert.Equal(t, "multipart/form-data", mediaType)
+
+			part, err := multipart.NewReader(req.Body, params["boundary"]).NextPart()
+			assert.Nil(t, err)
+			var content io.Reader = part
+			if compress {
+				content, err = gzip.NewReader(part)
+				assert.Nil(t, err)
+			}
+			received := &Job{}
+			assert.Nil(t, json.NewDecoder(content).Decode(received))
+			assert.Equal(t, job, received)
+
+			if requests == 1 {
+				w.WriteHeader(http.StatusBadGateway)
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"message": "Job #1.1", "url": "https://coveralls.io/jobs/1"}`))
+		}))
+
+		client, err := NewEnterpriseClient(server.URL, "fake token", WithRetry(1, time.Millisecond, 0))
+		assert.Nil(t, err)
+
+		result, err := client.Jobs.SubmitFile(context.Background(), name, &MultipartOptions{Gzip: compress}, WithRetries(1))
+
+		assert.Nil(t, err)
+		assert.Equal(t, &JobResult{Message: "Job #1.1", URL: "https://coveralls.io/jobs/1"}, result)
+		assert.Equal(t, 2, requests)
+		server.Close()
+	}
+}
+
+func TestJobsServiceSubmitFileNotFound(t *testing.T) {
+	client := NewClient("fake token")
+
+	result, err := client.Jobs.SubmitFile(context.Background(), filepath.Join(t.TempDir(), "missing.json"), nil)
+
+	assert.Nil(t, result)
+	assert.True(t, errors.Is(err, os.ErrNotExist))
+}
@@ -0,0 +1,117 @@
+/*
+Copyright (c) 2020 Loadsmart, Inc.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package coveralls
+
+import (
+	"context"
+	"sync"
+)
+
+// WithMaxConcurrency limits the Client to n requests in flight at a time,
+// so many goroutines sharing it don't stampede the API. Further requests
+// wait for a free slot, or until their context is done. Zero or less means
+// no limit.
+//
+// Clients made with Client.Clone share the limit, unless given one of their own.
+func WithMaxConcurrency(n int) Option {
+	return func(o *options) {
+		o.maxConcurrency = n
+	}
+}
+
+// WithRetryBudget limits the retries of the Client as a whole, on top of
+// the retries each call may do with WithRetry, so retries don't snowball
+// when the API struggles. Each call adds ratio to the budget, e.g. 0.1
+// earns one retry every ten calls, and each retry takes one from it.
+// The budget starts full and holds at most burst retries. When it runs out,
+// calls return their last error instead of retrying.
+//
+// Clients made with Client.Clone share the budget, unless given one of their own.
+func WithRetryBudget(ratio float64, burst int) Option {
+	return func(o *options) {
+		o.retryBudget = &retryBudgetSettings{ratio: ratio, burst: burst}
+	}
+}
+
+// concurrencyLimiter holds a slot for each request in flight
+type concurrencyLimiter chan struct{}
+
+func newConcurrencyLimiter(n int) concurrencyLimiter {
+	return make(concurrencyLimiter, n)
+}
+
+// acquire waits for a free slot, returning early with an error if ctx is done
+func (l concurrencyLimiter) acquire(ctx context.Context) error {
+	select {
+	case l <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// release frees a slot taken by acquire
+func (l concurrencyLimiter) release() {
+	<-l
+}
+
+// retryBudgetSettings holds the settings of WithRetryBudget
+type retryBudgetSettings struct {
+	ratio float64
+	burst int
+}
+
+// retryBudget tracks how many retries the Client may still do
+type retryBudget struct {
+	settings retryBudgetSettings
+
+	mu     sync.Mutex
+	tokens float64 // Retries available
+}
+
+func newRetryBudget(settings retryBudgetSettings) *retryBudget {
+	return &retryBudget{settings: settings, tokens: float64(settings.burst)}
+}
+
+// deposit adds to the budget for a call
+func (b *retryBudget) deposit() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.tokens += b.settings.ratio
+	if max := float64(b.settings.burst); b.tokens > max {
+		b.tokens = max
+	}
+}
+
+// withdraw takes a retry from the budget, reporting whether there was one
+func (b *retryBudget) withdraw() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
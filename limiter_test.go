@@ -0,0 +1,138 @@
+/*
+Copyright (c) 2020 Loadsmart, Inc.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package coveralls
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithMaxConcurrency(t *testing.T) {
+	var inFlight, maxInFlight int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		n := atomic.AddInt32(&inFlight, 1)
+		defer atomic.AddInt32(&inFlight, -1)
+		for {
+			max := atomic.LoadInt32(&maxInFlight)
+			if n <= max || atomic.CompareAndSwapInt32(&maxInFlight, max, n) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	client, _ := NewEnterpriseClient(server.URL, "fake token", WithMaxConcurrency(2))
+
+	var wg sync.WaitGroup
+	errs := make([]error, 10)
+	for i := range errs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs[i] = client.Repositories.Delete(context.Background(), "github", "user/fakerepo")
+		}(i)
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		assert.Nil(t, err)
+	}
+	assert.Equal(t, int32(2), atomic.LoadInt32(&maxInFlight))
+}
+
+func TestWithMaxConcurrencyContextDone(t *testing.T) {
+	client, _ := NewEnterpriseClient("http://127.0.0.1:1", "fake token", WithMaxConcurrency(1))
+	_ = client.limiter.acquire(context.Background())
+	defer client.limiter.release()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err := client.Repositories.Get(ctx, "github", "user/fakerepo")
+
+	assert.True(t, errors.Is(err, ErrTimeout))
+	assert.True(t, errors.Is(err, context.DeadlineExceeded))
+}
+
+func TestWithRetryBudget(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	var requests int32
+	client, _ := NewEnterpriseClient(server.URL, "fake token",
+		WithRetry(3, time.Millisecond, time.Millisecond),
+		WithRetryBudget(0, 2),
+		WithRequestHook(func(req *http.Request) { atomic.AddInt32(&requests, 1) }),
+	)
+
+	err := client.Repositories.Delete(context.Background(), "github", "user/fakerepo")
+	assert.NotNil(t, err)
+	assert.Equal(t, int32(3), atomic.LoadInt32(&requests), "budget allows two retries")
+
+	err = client.Repositories.Delete(context.Background(), "github", "user/fakerepo")
+	assert.NotNil(t, err)
+	assert.Equal(t, int32(4), atomic.LoadInt32(&requests), "budget is exhausted")
+}
+
+func TestRetryBudget(t *testing.T) {
+	budget := newRetryBudget(retryBudgetSettings{ratio: 0.5, burst: 2})
+
+	assert.True(t, budget.withdraw())
+	assert.True(t, budget.withdraw())
+	assert.False(t, budget.withdraw())
+
+	budget.deposit()
+	assert.False(t, budget.withdraw())
+	budget.deposit()
+	assert.True(t, budget.withdraw())
+
+	for i := 0; i < 10; i++ {
+		budget.deposit()
+	}
+	assert.True(t, budget.withdraw())
+	assert.True(t, budget.withdraw())
+	assert.False(t, budget.withdraw(), "budget holds at most burst retries")
+}
+
+func TestCloneSharesLimits(t *testing.T) {
+	client := NewClient("fake token", WithMaxConcurrency(2), WithRetryBudget(0.1, 5))
+
+	clone := client.Clone()
+	assert.True(t, client.limiter == clone.limiter)
+	assert.True(t, client.budget == clone.budget)
+
+	clone = client.Clone(WithMaxConcurrency(4), WithRetryBudget(0.2, 10))
+	assert.Equal(t, 4, cap(clone.limiter))
+	assert.False(t, client.budget == clone.budget)
+}
@@ -0,0 +1,82 @@
+/*
+Copyright (c) 2020 Loadsmart, Inc.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package coveralls
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// WithLogger makes the Client log every API call to logger, with the
+// method, endpoint, status code, latency, number of retries and request ID
+// as attributes.
+// Each retry of a call is logged separately.
+//
+// Successful calls are logged at level Debug, calls answered with an error
+// status code at level Warn and calls that got no response at level Error.
+// Credentials are redacted from the logged endpoints.
+func WithLogger(logger *slog.Logger) Option {
+	return func(o *options) {
+		o.logger = logger
+	}
+}
+
+// logResponse logs a call that got resp as response
+func (c *Client) logResponse(ctx context.Context, req *http.Request, resp *http.Response, elapsed time.Duration, retries int) {
+	if c.logger == nil {
+		return
+	}
+
+	level := slog.LevelDebug
+	if resp.StatusCode >= http.StatusBadRequest {
+		level = slog.LevelWarn
+	}
+	attrs := []slog.Attr{
+		slog.String("method", req.Method),
+		slog.String("endpoint", redactURL(req.URL).String()),
+		slog.Int("status", resp.StatusCode),
+		slog.Duration("latency", elapsed),
+		slog.Int("retries", retries),
+	}
+	if id := requestID(resp.Header); id != "" {
+		attrs = append(attrs, slog.String("request_id", id))
+	}
+	c.logger.LogAttrs(ctx, level, "coveralls API call", attrs...)
+}
+
+// logFailure logs a call that failed without a response
+func (c *Client) logFailure(ctx context.Context, req *http.Request, err error, elapsed time.Duration, retries int) {
+	if c.logger == nil {
+		return
+	}
+
+	c.logger.LogAttrs(ctx, slog.LevelError, "coveralls API call failed",
+		slog.String("method", req.Method),
+		slog.String("endpoint", redactURL(req.URL).String()),
+		slog.Duration("latency", elapsed),
+		slog.Int("retries", retries),
+		slog.String("error", err.Error()),
+	)
+}
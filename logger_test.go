@@ -0,0 +1,100 @@
+/*
+Copyright (c) 2020 Loadsmart, Inc.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package coveralls
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// logRecords decodes the JSON records written by a slog.JSONHandler
+func logRecords(t *testing.T, out *bytes.Buffer) []map[string]interface{} {
+	var records []map[string]interface{}
+	dec := json.NewDecoder(out)
+	for dec.More() {
+		var record map[string]interface{}
+		assert.Nil(t, dec.Decode(&record))
+		records = append(records, record)
+	}
+	return records
+}
+
+func TestWithLogger(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Path == "/webhook" {
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"id": 123}`))
+	}))
+	defer server.Close()
+
+	var out bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&out, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	client := NewClient("fake token", WithLogger(logger))
+	client.HostURL, _ = url.Parse(server.URL)
+
+	_, err := client.Repositories.Get(context.Background(), "github", "user/fakerepo")
+	assert.Nil(t, err)
+	err = client.Builds.Close(context.Background(), "secret-repo-token", "1")
+	assert.NotNil(t, err)
+
+	records := logRecords(t, &out)
+	assert.Len(t, records, 2)
+
+	assert.Equal(t, "DEBUG", records[0]["level"])
+	assert.Equal(t, "coveralls API call", records[0]["msg"])
+	assert.Equal(t, "GET", records[0]["method"])
+	assert.Equal(t, server.URL+"/api/repos/github/user/fakerepo", records[0]["endpoint"])
+	assert.Equal(t, float64(http.StatusOK), records[0]["status"])
+	assert.Contains(t, records[0], "latency")
+
+	assert.Equal(t, "WARN", records[1]["level"])
+	assert.Equal(t, float64(http.StatusUnprocessableEntity), records[1]["status"])
+	assert.Equal(t, server.URL+"/webhook?repo_token=%5BREDACTED%5D", records[1]["endpoint"])
+}
+
+func TestWithLoggerTransportError(t *testing.T) {
+	var out bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&out, nil))
+	client := NewClient("fake token", WithLogger(logger))
+	client.HostURL, _ = url.Parse("http://127.0.0.1:1")
+
+	_, err := client.Repositories.Get(context.Background(), "github", "user/fakerepo")
+	assert.NotNil(t, err)
+
+	records := logRecords(t, &out)
+	assert.Len(t, records, 1)
+	assert.Equal(t, "ERROR", records[0]["level"])
+	assert.Equal(t, "coveralls API call failed", records[0]["msg"])
+	assert.Contains(t, records[0], "error")
+}
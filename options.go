@@ -0,0 +1,304 @@
+/*
+Copyright (c) 2020 Loadsmart, Inc.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package coveralls
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Option customizes a Client created by NewClient
+type Option func(*options)
+
+// options holds the settings collected from the Options passed to NewClient
+type options struct {
+	token      *string
+	doer       Doer
+	httpClient *http.Client
+	transport  http.RoundTripper
+	rootCAs    *x509.CertPool
+	tlsConfig  *tls.Config
+	proxy      *string
+
+	tokenProvider TokenProvider
+	timeout       time.Duration
+	userAgent     string
+
+	requestHooks  []RequestHook
+	responseHooks []ResponseHook
+	debug         *debugWriter
+	logger        *slog.Logger
+	apiPath       *string
+	apiVersion    string
+	gzipMinSize   int
+	retry         retryPolicy
+
+	circuitBreaker *circuitBreakerSettings
+	maxConcurrency int
+	retryBudget    *retryBudgetSettings
+	deduplicate    bool
+	cache          Cache
+	staleIfError   *time.Duration
+	hedgeDelay     time.Duration
+
+	maxIdleConns    *int
+	idleConnTimeout *time.Duration
+	forceHTTP2      bool
+}
+
+// WithToken makes the Client use t as Coveralls API token. It is mostly
+// useful with Client.Clone; NewClient takes the token as argument.
+func WithToken(t string) Option {
+	return func(o *options) {
+		o.token = &t
+	}
+}
+
+// WithHTTPClient makes the Client send requests through hc instead of a
+// client of its own. Use it to share connection pools or plug in
+// instrumented clients.
+//
+// Hc itself is never changed. Options that change the transport, such as
+// WithTLSConfig, apply to a copy of its transport.
+func WithHTTPClient(hc *http.Client) Option {
+	return func(o *options) {
+		o.httpClient = hc
+	}
+}
+
+// WithTransport makes the Client send requests through rt. Use it to plug in
+// proxies, custom TLS settings, instrumentation or recorded transports for tests.
+//
+// When used together with WithHTTPClient, rt replaces the transport of that client.
+func WithTransport(rt http.RoundTripper) Option {
+	return func(o *options) {
+		o.transport = rt
+	}
+}
+
+// WithTLSConfig makes the Client use config for TLS connections, e.g. to
+// trust internal CAs or enforce a minimum TLS version. The config is
+// cloned; later changes to it have no effect on the Client.
+//
+// RootCAs set with WithRootCAs take precedence over config.RootCAs. It has
+// no effect when the transport is not an *http.Transport.
+func WithTLSConfig(config *tls.Config) Option {
+	return func(o *options) {
+		o.tlsConfig = config.Clone()
+	}
+}
+
+// WithRootCAs makes the Client trust the certificate authorities in pool
+// instead of the system ones. Use it with self-hosted Coveralls servers
+// whose certificates are signed by an internal CA.
+//
+// It has no effect when the transport is not an *http.Transport.
+func WithRootCAs(pool *x509.CertPool) Option {
+	return func(o *options) {
+		o.rootCAs = pool
+	}
+}
+
+// WithMaxIdleConns sets how many idle connections to the Coveralls server
+// are kept open for reuse. Raise it when issuing many concurrent calls, e.g.
+// during a sync run, to avoid opening a connection for each of them.
+// The default transport keeps only 2. Zero or less keeps the default.
+//
+// It has no effect when the transport is not an *http.Transport.
+func WithMaxIdleConns(n int) Option {
+	return func(o *options) {
+		if n > 0 {
+			o.maxIdleConns = &n
+		}
+	}
+}
+
+// WithIdleConnTimeout sets how long idle connections are kept open before
+// being closed. The default transport closes them after 90 seconds.
+// Zero means no limit.
+//
+// It has no effect when the transport is not an *http.Transport.
+func WithIdleConnTimeout(d time.Duration) Option {
+	return func(o *options) {
+		o.idleConnTimeout = &d
+	}
+}
+
+// WithForceHTTP2 makes the Client attempt HTTP/2 on transports that would
+// not, such as an *http.Transport with a custom TLSClientConfig or dialer
+// given to WithTransport. HTTP/2 sends concurrent calls over a single
+// connection. The default transport already attempts it.
+//
+// It has no effect when the transport is not an *http.Transport.
+func WithForceHTTP2() Option {
+	return func(o *options) {
+		o.forceHTTP2 = true
+	}
+}
+
+// WithTimeout limits how long each request may take, including reading
+// the response body. Requests that time out return an error matching
+// ErrTimeout. A shorter deadline in the context of a call takes precedence.
+func WithTimeout(d time.Duration) Option {
+	return func(o *options) {
+		o.timeout = d
+	}
+}
+
+// WithProxy makes the Client send requests through the proxy at proxyURL,
+// e.g. http://proxy.example.com:3128. An empty proxyURL disables proxies.
+// An invalid proxyURL makes all requests fail.
+//
+// Without this option, the proxy is taken from the HTTPS_PROXY, HTTP_PROXY
+// and NO_PROXY environment variables. It has no effect when the transport
+// is not an *http.Transport.
+func WithProxy(proxyURL string) Option {
+	return func(o *options) {
+		o.proxy = &proxyURL
+	}
+}
+
+// proxyFunc returns the Proxy function of http.Transport for proxyURL
+func proxyFunc(proxyURL string) func(*http.Request) (*url.URL, error) {
+	if proxyURL == "" {
+		return nil
+	}
+
+	u, err := url.Parse(proxyURL)
+	return func(*http.Request) (*url.URL, error) {
+		if err != nil {
+			return nil, fmt.Errorf("invalid proxy URL: %w", err)
+		}
+		return u, nil
+	}
+}
+
+// WithUserAgent sets the User-Agent header sent in all requests, instead of
+// DefaultUserAgent. Consider appending DefaultUserAgent to your own, e.g.
+// "my-tool/1.2 " + DefaultUserAgent.
+func WithUserAgent(ua string) Option {
+	return func(o *options) {
+		o.userAgent = ua
+	}
+}
+
+// WithAPIPath sets the path prefix of the API endpoints, relative to the
+// host URL. It defaults to /api, so repositories are found at /api/repos;
+// an empty path serves them from /repos.
+//
+// Use it on installs that expose the API under a different path. To talk to
+// a server mounted under a subpath, use NewEnterpriseClient instead.
+func WithAPIPath(path string) Option {
+	return func(o *options) {
+		path = "/" + strings.Trim(path, "/")
+		if path == "/" {
+			path = ""
+		}
+		o.apiPath = &path
+	}
+}
+
+// WithAPIVersion sets the version segment added to the API path prefix,
+// e.g. v2 to use /api/v2/repos and /api/v2/jobs. By default the repository
+// and organization endpoints are unversioned and jobs are submitted to /api/v1/jobs.
+func WithAPIVersion(version string) Option {
+	return func(o *options) {
+		o.apiVersion = strings.Trim(version, "/")
+	}
+}
+
+// WithDoer makes the Client send all requests through d, e.g. an adapter
+// for another HTTP library. Options that configure the HTTP client, such
+// as WithTransport, WithTLSConfig or WithProxy, have no effect on d.
+func WithDoer(d Doer) Option {
+	return func(o *options) {
+		o.doer = d
+	}
+}
+
+// newHTTPClient creates the HTTP client according to o
+func newHTTPClient(o *options) *http.Client {
+	hc := &http.Client{}
+	if o.httpClient != nil {
+		// Copy it, so we don't change a client that may be shared with others
+		copy := *o.httpClient
+		hc = &copy
+	}
+
+	if o.transport != nil {
+		hc.Transport = o.transport
+	}
+	if hc.Transport == nil {
+		hc.Transport = http.DefaultTransport
+	}
+
+	transport, ok := hc.Transport.(*http.Transport)
+	if ok && o.changesTransport() {
+		// Don't change a transport that may be shared with others
+		transport = transport.Clone()
+		if o.tlsConfig != nil {
+			transport.TLSClientConfig = o.tlsConfig
+		}
+		if o.rootCAs != nil {
+			if transport.TLSClientConfig == nil {
+				transport.TLSClientConfig = &tls.Config{}
+			}
+			transport.TLSClientConfig.RootCAs = o.rootCAs
+		}
+		if o.proxy != nil {
+			transport.Proxy = proxyFunc(*o.proxy)
+		}
+		if o.maxIdleConns != nil {
+			transport.MaxIdleConns = *o.maxIdleConns
+			transport.MaxIdleConnsPerHost = *o.maxIdleConns
+		}
+		if o.idleConnTimeout != nil {
+			transport.IdleConnTimeout = *o.idleConnTimeout
+		}
+		if o.forceHTTP2 {
+			transport.ForceAttemptHTTP2 = true
+		}
+		hc.Transport = transport
+	}
+	return hc
+}
+
+// changesTransport reports whether o has settings applied to an *http.Transport
+func (o *options) changesTransport() bool {
+	return o.tlsConfig != nil || o.rootCAs != nil || o.proxy != nil ||
+		o.maxIdleConns != nil || o.idleConnTimeout != nil || o.forceHTTP2
+}
+
+// clone returns a copy of o that can be changed without affecting o
+func (o *options) clone() *options {
+	copy := *o
+	copy.requestHooks = append([]RequestHook(nil), o.requestHooks...)
+	copy.responseHooks = append([]ResponseHook(nil), o.responseHooks...)
+	return &copy
+}
@@ -0,0 +1,288 @@
+/*
+Copyright (c) 2020 Loadsmart, Inc.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package coveralls
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// roundTripperFunc adapts a function to http.RoundTripper
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestWithTransport(t *testing.T) {
+	var requests []string
+	transport := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		requests = append(requests, req.URL.String())
+		assert.Equal(t, "token fake token", req.Header.Get("Authorization"))
+		return &http.Response{StatusCode: http.StatusNotFound, Body: http.NoBody, Header: http.Header{}, Request: req}, nil
+	})
+
+	client := NewClient("fake token", WithTransport(transport))
+	_, err := client.Repositories.Get(context.Background(), "github", "user/fakerepo")
+
+	assert.True(t, errors.Is(err, ErrRepoNotFound))
+	assert.Equal(t, []string{"https://coveralls.io/api/repos/github/user/fakerepo"}, requests)
+}
+
+// doerFunc adapts a function to Doer
+type doerFunc func(*http.Request) (*http.Response, error)
+
+func (f doerFunc) Do(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestWithDoer(t *testing.T) {
+	var requests []string
+	doer := doerFunc(func(req *http.Request) (*http.Response, error) {
+		requests = append(requests, req.Method+" "+req.URL.String())
+		assert.Equal(t, "token fake token", req.Header.Get("Authorization"))
+		assert.Equal(t, "application/json", req.Header.Get("Content-Type"))
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     http.Header{"Content-Type": []string{"application/json"}},
+			Body:       ioutil.NopCloser(strings.NewReader(`{"name": "user/fakerepo", "service": "github"}`)),
+		}, nil
+	})
+
+	client := NewClient("fake token", WithDoer(doer))
+	repo, err := client.Repositories.Update(context.Background(), "github", "user/fakerepo", &RepositoryConfig{})
+
+	assert.Nil(t, err)
+	assert.Equal(t, "user/fakerepo", repo.Name)
+	assert.Equal(t, []string{"PUT https://coveralls.io/api/repos/github/user/fakerepo"}, requests)
+}
+
+func TestWithHTTPClient(t *testing.T) {
+	hc := &http.Client{Timeout: time.Minute}
+
+	client := NewClient("fake token", WithHTTPClient(hc))
+
+	assert.Equal(t, time.Minute, client.client.Timeout)
+	assert.Nil(t, hc.Transport)
+	assert.Equal(t, "token fake token", client.header.Get("Authorization"))
+}
+
+func TestWithTLSConfig(t *testing.T) {
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"id": 123}`)
+	}))
+	server.TLS = &tls.Config{MaxVersion: tls.VersionTLS12}
+	server.StartTLS()
+	defer server.Close()
+
+	pool := x509.NewCertPool()
+	pool.AddCert(server.Certificate())
+
+	var testCases = []struct {
+		name   string
+		config *tls.Config
+		fails  bool
+	}{
+		{name: "trusted", config: &tls.Config{RootCAs: pool}, fails: false},
+		{name: "untrusted", config: &tls.Config{}, fails: true},
+		{name: "minversion", config: &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS13}, fails: true},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			client, _ := NewEnterpriseClient(server.URL, "fake token", WithTLSConfig(tt.config))
+
+			_, err := client.Repositories.Get(context.Background(), "github", "user/fakerepo")
+
+			assert.Equal(t, tt.fails, err != nil, "unexpected error: %v", err)
+		})
+	}
+}
+
+func TestWithTLSConfigAndRootCAs(t *testing.T) {
+	pool := x509.NewCertPool()
+	config := &tls.Config{MinVersion: tls.VersionTLS12}
+
+	client := NewClient("fake token", WithTLSConfig(config), WithRootCAs(pool))
+
+	transport := client.client.Transport.(*http.Transport)
+	assert.Equal(t, uint16(tls.VersionTLS12), transport.TLSClientConfig.MinVersion)
+	assert.Same(t, pool, transport.TLSClientConfig.RootCAs)
+	assert.Nil(t, config.RootCAs)
+}
+
+func TestWithProxy(t *testing.T) {
+	var proxied []string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		proxied = append(proxied, req.URL.String())
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"id": 123}`)
+	}))
+	defer proxy.Close()
+
+	client, _ := NewEnterpriseClient("http://coveralls.invalid", "fake token", WithProxy(proxy.URL))
+
+	repo, err := client.Repositories.Get(context.Background(), "github", "user/fakerepo")
+
+	assert.Nil(t, err)
+	assert.Equal(t, &Repository{ID: 123}, repo)
+	assert.Equal(t, []string{"http://coveralls.invalid/api/repos/github/user/fakerepo"}, proxied)
+}
+
+func TestWithProxyInvalidURL(t *testing.T) {
+	client := NewClient("fake token", WithProxy("http://[::1"))
+
+	_, err := client.Repositories.Get(context.Background(), "github", "user/fakerepo")
+
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "invalid proxy URL")
+}
+
+func TestProxyFromEnvironmentByDefault(t *testing.T) {
+	client := NewClient("fake token")
+
+	transport := client.client.Transport.(*http.Transport)
+	assert.NotNil(t, transport.Proxy)
+
+	client = NewClient("fake token", WithProxy(""))
+
+	transport = client.client.Transport.(*http.Transport)
+	assert.Nil(t, transport.Proxy)
+}
+
+func TestTransportOptionsDoNotChangeSharedTransport(t *testing.T) {
+	shared := &http.Transport{}
+	hc := &http.Client{Transport: shared}
+
+	client := NewClient("fake token", WithHTTPClient(hc), WithTLSConfig(&tls.Config{MinVersion: tls.VersionTLS12}))
+
+	transport := client.client.Transport.(*http.Transport)
+	assert.False(t, shared == transport)
+	assert.Equal(t, uint16(tls.VersionTLS12), transport.TLSClientConfig.MinVersion)
+	if shared.TLSClientConfig != nil {
+		assert.Equal(t, uint16(0), shared.TLSClientConfig.MinVersion)
+	}
+}
+
+func TestWithUserAgent(t *testing.T) {
+	var testCases = []struct {
+		name     string
+		opts     []Option
+		expected string
+	}{
+		{name: "default", opts: nil, expected: "go-coveralls-api/" + Version},
+		{name: "custom", opts: []Option{WithUserAgent("my-tool/1.2")}, expected: "my-tool/1.2"},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			var received string
+			transport := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+				received = req.Header.Get("User-Agent")
+				return &http.Response{StatusCode: http.StatusNotFound, Body: http.NoBody, Header: http.Header{}, Request: req}, nil
+			})
+
+			client := NewClient("fake token", append(tt.opts, WithTransport(transport))...)
+			client.Repositories.Get(context.Background(), "github", "user/fakerepo")
+
+			assert.Equal(t, tt.expected, received)
+		})
+	}
+}
+
+func TestWithAPIPath(t *testing.T) {
+	cases := []struct {
+		name     string
+		opts     []Option
+		wantRepo string
+		wantJobs string
+	}{
+		{"default", nil, "https://coveralls.io/api/repos/github/user/fakerepo", "https://coveralls.io/api/v1/jobs"},
+		{"path", []Option{WithAPIPath("custom/api/")}, "https://coveralls.io/custom/api/repos/github/user/fakerepo", "https://coveralls.io/custom/api/v1/jobs"},
+		{"empty path", []Option{WithAPIPath("")}, "https://coveralls.io/repos/github/user/fakerepo", "https://coveralls.io/v1/jobs"},
+		{"version", []Option{WithAPIVersion("v2")}, "https://coveralls.io/api/v2/repos/github/user/fakerepo", "https://coveralls.io/api/v2/jobs"},
+		{"path and version", []Option{WithAPIPath("/rest"), WithAPIVersion("/v2/")}, "https://coveralls.io/rest/v2/repos/github/user/fakerepo", "https://coveralls.io/rest/v2/jobs"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			var requests []string
+			transport := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+				requests = append(requests, req.URL.String())
+				return &http.Response{StatusCode: http.StatusNotFound, Body: http.NoBody, Header: http.Header{}}, nil
+			})
+			client := NewClient("fake token", append(tc.opts, WithTransport(transport))...)
+
+			_, _ = client.Repositories.Get(context.Background(), "github", "user/fakerepo")
+			_, _ = client.Jobs.Submit(context.Background(), &Job{})
+
+			assert.Equal(t, []string{tc.wantRepo, tc.wantJobs}, requests)
+		})
+	}
+}
+
+func TestConnectionPoolOptions(t *testing.T) {
+	client := NewClient("fake token", WithMaxIdleConns(100), WithIdleConnTimeout(time.Minute), WithForceHTTP2())
+
+	transport := client.client.Transport.(*http.Transport)
+	assert.Equal(t, 100, transport.MaxIdleConns)
+	assert.Equal(t, 100, transport.MaxIdleConnsPerHost)
+	assert.Equal(t, time.Minute, transport.IdleConnTimeout)
+	assert.True(t, transport.ForceAttemptHTTP2)
+	assert.False(t, transport == http.DefaultTransport)
+}
+
+func TestWithForceHTTP2(t *testing.T) {
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"id": 123}`)
+	}))
+	server.EnableHTTP2 = true
+	server.StartTLS()
+	defer server.Close()
+
+	pool := x509.NewCertPool()
+	pool.AddCert(server.Certificate())
+	transport := &http.Transport{}
+
+	client, _ := NewEnterpriseClient(server.URL, "fake token", WithTransport(transport), WithRootCAs(pool), WithForceHTTP2())
+
+	var resp *http.Response
+	_, err := client.Repositories.Get(context.Background(), "github", "user/fakerepo", CaptureResponse(&resp))
+
+	assert.Nil(t, err)
+	assert.Equal(t, "HTTP/2.0", resp.Proto)
+	assert.False(t, transport.ForceAttemptHTTP2)
+}
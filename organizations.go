@@ -0,0 +1,104 @@
+/*
+Copyright (c) 2020 Loadsmart, Inc.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package coveralls
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+)
+
+var (
+	// ErrOrganizationNotFound is returned when we receive a 404 Not Found status code
+	// while querying an organization
+	ErrOrganizationNotFound = fmt.Errorf("organization was not found (status code %d)", http.StatusNotFound)
+)
+
+// OrganizationsService holds information to access organization-related endpoints
+type OrganizationsService interface {
+	ListRepos(ctx context.Context, svc string, org string, opts *ListOptions, callOpts ...CallOption) ([]*Repository, error)
+	Summary(ctx context.Context, svc string, org string, opts ...CallOption) (*OrganizationSummary, error)
+}
+
+// OrganizationsServiceImpl holds information to access organization-related endpoints
+type OrganizationsServiceImpl service
+
+// OrganizationSummary holds coverage aggregated over all repositories of an organization
+type OrganizationSummary struct {
+	Service         string
+	Name            string
+	Repos           int      // Number of repositories in Coveralls
+	ReposWithBuilds int      // Number of repositories with coverage information
+	AverageCoverage *float64 // Mean coverage of repositories with builds. Nil if there are none
+	MinCoverage     *float64
+	MaxCoverage     *float64
+}
+
+// ListRepos lists all repositories of an organization.
+//
+// Svc has the same meaning as in RepositoryService.Get. Org is the
+// organization (or user) name in the git provider. Results are paginated
+// by the API; ListRepos walks through all pages.
+//
+// It may return errors ErrOrganizationNotFound or ErrUnexpectedStatusCode
+func (s OrganizationsServiceImpl) ListRepos(ctx context.Context, svc string, org string, opts *ListOptions, callOpts ...CallOption) ([]*Repository, error) {
+	endpoint := s.client.apiEndpoint("orgs/%s/%s/repos", svc, org)
+	return listRepositories(ctx, s.client, endpoint, opts, ErrOrganizationNotFound, callOpts)
+}
+
+// Summary aggregates the coverage of all repositories of an organization.
+//
+// It may return errors ErrOrganizationNotFound or ErrUnexpectedStatusCode
+func (s OrganizationsServiceImpl) Summary(ctx context.Context, svc string, org string, opts ...CallOption) (*OrganizationSummary, error) {
+	repos, err := s.ListRepos(ctx, svc, org, nil, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return summarizeOrganization(svc, org, repos), nil
+}
+
+func summarizeOrganization(svc string, org string, repos []*Repository) *OrganizationSummary {
+	summary := &OrganizationSummary{Service: svc, Name: org, Repos: len(repos)}
+
+	var total float64
+	for _, r := range repos {
+		if r.CoveredPercent == nil {
+			continue
+		}
+		coverage := *r.CoveredPercent
+		summary.ReposWithBuilds++
+		total += coverage
+		if summary.MinCoverage == nil || coverage < *summary.MinCoverage {
+			summary.MinCoverage = &coverage
+		}
+		if summary.MaxCoverage == nil || coverage > *summary.MaxCoverage {
+			summary.MaxCoverage = &coverage
+		}
+	}
+
+	if summary.ReposWithBuilds > 0 {
+		average := total / float64(summary.ReposWithBuilds)
+		summary.AverageCoverage = &average
+	}
+	return summary
+}
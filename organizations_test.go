@@ -0,0 +1,91 @@
+/*
+Copyright (c) 2020 Loadsmart, Inc.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package coveralls
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOrganizationsServiceListRepos(t *testing.T) {
+	fakeUrl := "https://coveralls.io/api/orgs/github/user/repos"
+	page := &repositoryPage{Page: 1, Pages: 1, Total: 2, Repos: []*Repository{{ID: 1, Name: "user/repo1"}, {ID: 2, Name: "user/repo2"}}}
+	responder, _ := httpmock.NewJsonResponder(http.StatusOK, page)
+	httpmock.RegisterResponder("GET", fakeUrl, responder)
+
+	client := NewClient("fake token")
+	httpmock.ActivateNonDefault(client.client)
+	defer httpmock.DeactivateAndReset()
+
+	result, err := client.Organizations.ListRepos(context.Background(), "github", "user", nil)
+
+	assert.Nil(t, err)
+	assert.Equal(t, page.Repos, result)
+}
+
+func TestOrganizationsServiceListReposNotFound(t *testing.T) {
+	fakeUrl := "https://coveralls.io/api/orgs/github/user/repos"
+	httpmock.RegisterResponder("GET", fakeUrl, httpmock.NewStringResponder(http.StatusNotFound, ""))
+
+	client := NewClient("fake token")
+	httpmock.ActivateNonDefault(client.client)
+	defer httpmock.DeactivateAndReset()
+
+	result, err := client.Organizations.ListRepos(context.Background(), "github", "user", nil)
+
+	assert.True(t, errors.Is(err, ErrOrganizationNotFound))
+	assert.Nil(t, result)
+}
+
+func TestOrganizationsServiceSummary(t *testing.T) {
+	fakeUrl := "https://coveralls.io/api/orgs/github/user/repos"
+	page := &repositoryPage{Page: 1, Pages: 1, Total: 3, Repos: []*Repository{
+		{ID: 1, Name: "user/repo1", CoveredPercent: pfloat64(90)},
+		{ID: 2, Name: "user/repo2", CoveredPercent: pfloat64(60)},
+		{ID: 3, Name: "user/repo3"},
+	}}
+	responder, _ := httpmock.NewJsonResponder(http.StatusOK, page)
+	httpmock.RegisterResponder("GET", fakeUrl, responder)
+
+	client := NewClient("fake token")
+	httpmock.ActivateNonDefault(client.client)
+	defer httpmock.DeactivateAndReset()
+
+	result, err := client.Organizations.Summary(context.Background(), "github", "user")
+
+	assert.Nil(t, err)
+	assert.Equal(t, &OrganizationSummary{
+		Service:         "github",
+		Name:            "user",
+		Repos:           3,
+		ReposWithBuilds: 2,
+		AverageCoverage: pfloat64(75),
+		MinCoverage:     pfloat64(60),
+		MaxCoverage:     pfloat64(90),
+	}, result)
+}
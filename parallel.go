@@ -0,0 +1,168 @@
+/*
+Copyright (c) 2020 Loadsmart, Inc.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package coveralls
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// ErrParallelBuildClosed is returned by ParallelBuild.Submit once the build
+// is closed
+var ErrParallelBuildClosed = errors.New("parallel build is already closed")
+
+// ParallelBuild submits the jobs of a parallel build, one for each of a set
+// of flags, e.g. the modules of a monorepo, and closes the build once all
+// of them are submitted. It is safe for concurrent use.
+type ParallelBuild struct {
+	client    *Client
+	repoToken string
+	buildNum  string
+
+	mu         sync.Mutex
+	pending    map[string]bool // Flags with no job submitted yet
+	submitting map[string]bool // Flags with a job being submitted
+	closed     bool
+}
+
+// NewParallelBuild returns a ParallelBuild that submits jobs with client.
+//
+// RepoToken is the secret token of the repository and buildNum the build
+// number in the CI service, as in BuildsService.Close. Flags are the names
+// of the jobs expected in the build.
+func NewParallelBuild(client *Client, repoToken string, buildNum string, flags ...string) *ParallelBuild {
+	pending := make(map[string]bool, len(flags))
+	for _, flag := range flags {
+		pending[flag] = true
+	}
+	return &ParallelBuild{
+		client:     client,
+		repoToken:  repoToken,
+		buildNum:   buildNum,
+		pending:    pending,
+		submitting: make(map[string]bool),
+	}
+}
+
+// Submit sends job as the job of the flag job.FlagName, which must be one of
+// the flags of the build. Job.Parallel, Job.ServiceNumber and Job.RepoToken
+// are set for the build. Once jobs of all flags are submitted, Submit
+// closes the build too.
+//
+// The flag is reserved while its job is sent, so concurrent calls with the
+// same flag send it once. It returns ErrParallelBuildClosed once the build
+// is closed.
+//
+// With DryRun, Submit only previews the job: the flag stays pending and the
+// build is never closed.
+func (b *ParallelBuild) Submit(ctx context.Context, job *Job, opts ...CallOption) (*JobResult, error) {
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		return nil, ErrParallelBuildClosed
+	}
+	if !b.pending[job.FlagName] {
+		b.mu.Unlock()
+		return nil, fmt.Errorf("flag %q is not pending in the parallel build", job.FlagName)
+	}
+	if newCallSettings(ctx, opts).preview != nil {
+		b.mu.Unlock()
+		return b.client.Jobs.Submit(ctx, b.job(job), opts...)
+	}
+	delete(b.pending, job.FlagName)
+	b.submitting[job.FlagName] = true
+	b.mu.Unlock()
+
+	result, err := b.client.Jobs.Submit(ctx, b.job(job), opts...)
+
+	b.mu.Lock()
+	delete(b.submitting, job.FlagName)
+	if err != nil {
+		b.pending[job.FlagName] = true
+		b.mu.Unlock()
+		return nil, err
+	}
+	done := len(b.pending) == 0 && len(b.submitting) == 0
+	b.mu.Unlock()
+	if done {
+		if err := b.Close(ctx, opts...); err != nil {
+			return result, err
+		}
+	}
+	return result, nil
+}
+
+// job returns a copy of job set up as a job of the build
+func (b *ParallelBuild) job(job *Job) *Job {
+	copy := *job
+	copy.Parallel = true
+	if copy.ServiceNumber == "" {
+		copy.ServiceNumber = b.buildNum
+	}
+	if copy.RepoToken == "" {
+		copy.RepoToken = b.repoToken
+	}
+	return &copy
+}
+
+// Pending returns the flags with no job submitted yet, including those
+// being submitted, sorted
+func (b *ParallelBuild) Pending() []string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	flags := make([]string, 0, len(b.pending)+len(b.submitting))
+	for flag := range b.pending {
+		flags = append(flags, flag)
+	}
+	for flag := range b.submitting {
+		flags = append(flags, flag)
+	}
+	sort.Strings(flags)
+	return flags
+}
+
+// Close closes the build without waiting for the pending flags, carrying
+// forward their coverage from the previous build. It does nothing if the
+// build is already closed.
+func (b *ParallelBuild) Close(ctx context.Context, opts ...CallOption) error {
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		return nil
+	}
+	b.closed = true
+	b.mu.Unlock()
+
+	opts = append(opts[:len(opts):len(opts)], Carryforward(b.Pending()...))
+	if err := b.client.Builds.Close(ctx, b.repoToken, b.buildNum, opts...); err != nil {
+		b.mu.Lock()
+		b.closed = false
+		b.mu.Unlock()
+		return err
+	}
+	return nil
+}
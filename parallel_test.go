@@ -0,0 +1,188 @@
+/*
+Copyright (c) 2020 Loadsmart, Inc.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package coveralls
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// newParallelServer returns a server that accepts jobs and closes builds,
+// and the jobs and webhook bodies it received
+func newParallelServer(t *testing.T) (*httptest.Server, *[]Job, *[]webhookBody) {
+	var mu sync.Mutex
+	var jobs []Job
+	var closes []webhookBody
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		switch req.URL.Path {
+		case "/api/v1/jobs":
+			var job Job
+			_ = json.NewDecoder(req.Body).Decode(&job)
+			jobs = append(jobs, job)
+			_, _ = w.Write([]byte(`{"message": "Job #42.1", "url": "https://coveralls.io/jobs/1"}`))
+		case "/webhook":
+			var body webhookBody
+			_ = json.NewDecoder(req.Body).Decode(&body)
+			closes = append(closes, body)
+			_, _ = w.Write([]byte(`{"done": true}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server, &jobs, &closes
+}
+
+func TestParallelBuild(t *testing.T) {
+	server, jobs, closes := newParallelServer(t)
+	client, _ := NewEnterpriseClient(server.URL, "fake token")
+	build := NewParallelBuild(client, "repo-token", "42", "backend", "frontend")
+
+	_, err := build.Submit(context.Background(), &Job{FlagName: "backend"})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"frontend"}, build.Pending())
+	assert.Len(t, *closes, 0)
+
+	_, err = build.Submit(context.Background(), &Job{FlagName: "other"})
+	assert.NotNil(t, err)
+
+	_, err = build.Submit(context.Background(), &Job{FlagName: "frontend"})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{}, build.Pending())
+
+	assert.Equal(t, []Job{
+		{RepoToken: "repo-token", ServiceNumber: "42", Parallel: true, FlagName: "backend"},
+		{RepoToken: "repo-token", ServiceNumber: "42", Parallel: true, FlagName: "frontend"},
+	}, *jobs)
+	assert.Equal(t, []webhookBody{{Payload: &webhookPayload{BuildNum: "42", Status: "done"}}}, *closes)
+
+	assert.Nil(t, build.Close(context.Background()), "closing twice")
+	assert.Len(t, *closes, 1)
+}
+
+func TestParallelBuildDryRun(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		requests++
+	}))
+	defer server.Close()
+	client, _ := NewEnterpriseClient(server.URL, "fake token")
+	build := NewParallelBuild(client, "repo-token", "42", "backend")
+
+	var preview Preview
+	job := &Job{
+		FlagName:    "backend",
+		Git:         &Git{Head: GitHead{ID: "abc123"}},
+		SourceFiles: []*SourceFile{{Name: "main.go", Source: "package main", Coverage: []*int{nil}}},
+	}
+	_, err := build.Submit(context.Background(), job, DryRun(&preview))
+
+	assert.Nil(t, err)
+	assert.Equal(t, http.MethodPost, preview.Method)
+	assert.Equal(t, 0, requests)
+	assert.Equal(t, []string{"backend"}, build.Pending())
+}
+
+func TestParallelBuildCarryforward(t *testing.T) {
+	server, _, closes := newParallelServer(t)
+	client, _ := NewEnterpriseClient(server.URL, "fake token")
+	build := NewParallelBuild(client, "repo-token", "42", "backend", "frontend", "docs")
+
+	_, err := build.Submit(context.Background(), &Job{FlagName: "frontend"})
+	assert.Nil(t, err)
+	err = build.Close(context.Background())
+	assert.Nil(t, err)
+
+	assert.Equal(t, []webhookBody{{
+		Carryforward: "backend,docs",
+		Payload:      &webhookPayload{BuildNum: "42", Status: "done"},
+	}}, *closes)
+}
+
+func TestParallelBuildConcurrentSubmit(t *testing.T) {
+	server, jobs, closes := newParallelServer(t)
+	client, _ := NewEnterpriseClient(server.URL, "fake token")
+	build := NewParallelBuild(client, "repo-token", "42", "backend", "frontend")
+
+	var wg sync.WaitGroup
+	errs := make([]error, 10)
+	for i := range errs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, errs[i] = build.Submit(context.Background(), &Job{FlagName: "backend"})
+		}(i)
+	}
+	wg.Wait()
+
+	failed := 0
+	for _, err := range errs {
+		if err != nil {
+			failed++
+		}
+	}
+	assert.Equal(t, len(errs)-1, failed)
+	assert.Len(t, *jobs, 1)
+	assert.Equal(t, []string{"frontend"}, build.Pending())
+
+	assert.Nil(t, build.Close(context.Background()))
+	_, err := build.Submit(context.Background(), &Job{FlagName: "frontend"})
+
+	assert.True(t, errors.Is(err, ErrParallelBuildClosed))
+	assert.Len(t, *jobs, 1)
+	assert.Len(t, *closes, 1)
+}
+
+func TestParallelBuildSubmitFailure(t *testing.T) {
+	rejected := true
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if rejected {
+			rejected = false
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			return
+		}
+		_, _ = w.Write([]byte(`{"message": "Job #42.1", "url": "https://coveralls.io/jobs/1"}`))
+	}))
+	defer server.Close()
+	client, _ := NewEnterpriseClient(server.URL, "fake token")
+	build := NewParallelBuild(client, "repo-token", "42", "backend", "frontend")
+
+	_, err := build.Submit(context.Background(), &Job{FlagName: "backend"})
+
+	assert.NotNil(t, err)
+	assert.Equal(t, []string{"backend", "frontend"}, build.Pending())
+
+	_, err = build.Submit(context.Background(), &Job{FlagName: "backend"})
+
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"frontend"}, build.Pending())
+}
@@ -0,0 +1,166 @@
+/*
+Copyright (c) 2020 Loadsmart, Inc.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+// Package reconciler keeps Coveralls repositories in sync with a manifest
+// from a long-running service, such as a platform controller, instead of
+// running coveralls sync from cron. Run plans and applies the manifest of
+// package reposync at an interval, and Hooks report each run, e.g. to
+// record metrics.
+package reconciler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"time"
+
+	coveralls "github.com/stone-payments/go-coveralls-api"
+	"github.com/stone-payments/go-coveralls-api/reposync"
+)
+
+// ErrInvalidInterval is returned by Reconciler.Run when the interval
+// between runs is not positive
+var ErrInvalidInterval = errors.New("interval between reconciliations must be positive")
+
+// Source returns the manifest to reconcile. It is called on each run, so
+// changes to the manifest are picked up without restarting.
+type Source func(ctx context.Context) (*reposync.Manifest, error)
+
+// File returns a Source that reads the manifest file name on each run
+func File(name string) Source {
+	return func(ctx context.Context) (*reposync.Manifest, error) {
+		return reposync.ReadFile(name)
+	}
+}
+
+// Static returns a Source that always returns m
+func Static(m *reposync.Manifest) Source {
+	return func(ctx context.Context) (*reposync.Manifest, error) {
+		return m, nil
+	}
+}
+
+// Result is the outcome of one run of a Reconciler
+type Result struct {
+	Start    time.Time      // When the run started
+	Duration time.Duration  // How long the run took
+	Plan     *reposync.Plan // Changes found, nil if the manifest or the repositories could not be read
+	Applied  bool           // Whether the changes of Plan were applied
+	Err      error          // Error of the run, if any
+}
+
+// Hooks are called by a Reconciler as it runs, e.g. to record metrics or
+// log. Nil hooks are not called.
+type Hooks struct {
+	OnPlan   func(ctx context.Context, plan *reposync.Plan) // Called after planning, before applying
+	OnResult func(ctx context.Context, result Result)       // Called at the end of each run
+}
+
+// Reconciler applies a manifest to the repositories in Coveralls
+type Reconciler struct {
+	Repos       coveralls.RepositoryService
+	Source      Source
+	Jitter      float64 // Fraction by which each wait of Run varies at random, e.g. 0.1 for up to 10% more or less. Zero means none
+	DryRun      bool    // Plan without applying, e.g. to alert on drift
+	Hooks       Hooks
+	CallOptions []coveralls.CallOption
+}
+
+// New returns a Reconciler that applies the manifest of source to the
+// repositories in repos, calling it with opts
+func New(repos coveralls.RepositoryService, source Source, opts ...coveralls.CallOption) *Reconciler {
+	return &Reconciler{Repos: repos, Source: source, CallOptions: opts}
+}
+
+// Reconcile runs once: it reads the manifest, plans the changes and, unless
+// DryRun is set, applies them
+func (r *Reconciler) Reconcile(ctx context.Context) Result {
+	result := Result{Start: time.Now()}
+	result.Plan, result.Err = r.plan(ctx)
+	if result.Err == nil && !r.DryRun && result.Plan.Pending() > 0 {
+		result.Err = result.Plan.Apply(ctx, r.Repos, r.CallOptions...)
+		result.Applied = true
+	}
+	result.Duration = time.Since(result.Start)
+	if r.Hooks.OnResult != nil {
+		r.Hooks.OnResult(ctx, result)
+	}
+	return result
+}
+
+// plan reads the manifest and returns the changes needed to apply it
+func (r *Reconciler) plan(ctx context.Context) (*reposync.Plan, error) {
+	m, err := r.Source(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := m.Validate(); err != nil {
+		return nil, err
+	}
+	plan, err := reposync.NewPlan(ctx, r.Repos, m, r.CallOptions...)
+	if err != nil {
+		return nil, err
+	}
+	if r.Hooks.OnPlan != nil {
+		r.Hooks.OnPlan(ctx, plan)
+	}
+	return plan, nil
+}
+
+// Run reconciles right away and then every interval, varied by Jitter so
+// that many replicas don't call the API at once, until ctx is done. Failed
+// runs don't stop it; they are reported to Hooks.OnResult and retried on
+// the next run.
+//
+// Interval must be positive: Run returns ErrInvalidInterval without
+// reconciling otherwise, rather than calling the API in a tight loop.
+//
+// It returns the error of ctx when it is done
+func (r *Reconciler) Run(ctx context.Context, interval time.Duration) error {
+	if interval <= 0 {
+		return fmt.Errorf("%w: %s", ErrInvalidInterval, interval)
+	}
+	for {
+		r.Reconcile(ctx)
+
+		timer := time.NewTimer(jittered(interval, r.Jitter, rand.Float64()))
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		}
+	}
+}
+
+// jittered returns interval varied by up to jitter of it, more or less as f,
+// a random number in [0, 1), is above or below one half
+func jittered(interval time.Duration, jitter float64, f float64) time.Duration {
+	if jitter <= 0 {
+		return interval
+	}
+	if jitter > 1 {
+		jitter = 1
+	}
+	return interval + time.Duration(float64(interval)*jitter*(2*f-1))
+}
@@ -0,0 +1,241 @@
+/*
+Copyright (c) 2020 Loadsmart, Inc.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package reconciler
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	coveralls "github.com/stone-payments/go-coveralls-api"
+	"github.com/stone-payments/go-coveralls-api/reposync"
+)
+
+// fakeRepositories serves repositories from memory, applying updates
+type fakeRepositories struct {
+	coveralls.RepositoryService
+	mu      sync.Mutex
+	repos   map[string]*coveralls.Repository
+	updates int
+	err     error
+}
+
+func (f *fakeRepositories) Get(ctx context.Context, svc string, repo string, opts ...coveralls.CallOption) (*coveralls.Repository, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.err != nil {
+		return nil, f.err
+	}
+	r, ok := f.repos[svc+"/"+repo]
+	if !ok {
+		return nil, coveralls.ErrRepoNotFound
+	}
+	return r, nil
+}
+
+func (f *fakeRepositories) Update(ctx context.Context, svc string, repo string, data *coveralls.RepositoryConfig, opts ...coveralls.CallOption) (*coveralls.Repository, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.updates++
+	r := &coveralls.Repository{Service: svc, Name: repo, CommitStatusFailThreshold: data.CommitStatusFailThreshold}
+	f.repos[svc+"/"+repo] = r
+	return r, nil
+}
+
+func newFakeRepositories() *fakeRepositories {
+	return &fakeRepositories{repos: map[string]*coveralls.Repository{
+		"github/user/api": {Service: "github", Name: "user/api"},
+	}}
+}
+
+var errUnavailable = errors.New("unavailable")
+
+func pfloat64(v float64) *float64 {
+	return &v
+}
+
+var manifest = &reposync.Manifest{
+	Defaults:     reposync.Settings{CommitStatusFailThreshold: pfloat64(80)},
+	Repositories: []reposync.Repository{{Service: "github", Name: "user/api"}},
+}
+
+func TestReconcile(t *testing.T) {
+	repos := newFakeRepositories()
+	var plans []*reposync.Plan
+	var results []Result
+	r := New(repos, Static(manifest))
+	r.Hooks = Hooks{
+		OnPlan:   func(ctx context.Context, plan *reposync.Plan) { plans = append(plans, plan) },
+		OnResult: func(ctx context.Context, result Result) { results = append(results, result) },
+	}
+
+	result := r.Reconcile(context.Background())
+
+	assert.Nil(t, result.Err)
+	assert.True(t, result.Applied)
+	assert.Equal(t, 1, result.Plan.Pending())
+	assert.Equal(t, 1, repos.updates)
+	assert.Equal(t, []*reposync.Plan{result.Plan}, plans)
+	assert.Equal(t, []Result{result}, results)
+
+	// Nothing left to change
+	result = r.Reconcile(context.Background())
+
+	assert.Nil(t, result.Err)
+	assert.False(t, result.Applied)
+	assert.Equal(t, 0, result.Plan.Pending())
+	assert.Equal(t, 1, repos.updates)
+	assert.Len(t, results, 2)
+}
+
+func TestReconcileDryRun(t *testing.T) {
+	repos := newFakeRepositories()
+	r := New(repos, Static(manifest))
+	r.DryRun = true
+
+	result := r.Reconcile(context.Background())
+
+	assert.Nil(t, result.Err)
+	assert.False(t, result.Applied)
+	assert.Equal(t, 1, result.Plan.Pending())
+	assert.Equal(t, 0, repos.updates)
+}
+
+func TestReconcileError(t *testing.T) {
+	var testCases = []struct {
+		name   string
+		source Source
+		err    error
+	}{
+		{
+			name:   "source",
+			source: func(ctx context.Context) (*reposync.Manifest, error) { return nil, os.ErrNotExist },
+			err:    os.ErrNotExist,
+		},
+		{
+			name:   "repositories",
+			source: Static(manifest),
+			err:    errUnavailable,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			repos := newFakeRepositories()
+			repos.err = errUnavailable
+			var results []Result
+			r := New(repos, tt.source)
+			r.Hooks.OnResult = func(ctx context.Context, result Result) { results = append(results, result) }
+
+			result := r.Reconcile(context.Background())
+
+			assert.True(t, errors.Is(result.Err, tt.err))
+			assert.Nil(t, result.Plan)
+			assert.False(t, result.Applied)
+			assert.Len(t, results, 1)
+		})
+	}
+}
+
+func TestFile(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "repos.yaml")
+	require.Nil(t, os.WriteFile(name, []byte("repositories:\n  - service: github\n    name: user/api\n"), 0644))
+	source := File(name)
+
+	m, err := source(context.Background())
+
+	require.Nil(t, err)
+	assert.Equal(t, []reposync.Repository{{Service: "github", Name: "user/api"}}, m.Repositories)
+
+	// Changes are read on the next run
+	require.Nil(t, os.WriteFile(name, []byte("repositories:\n  - service: github\n    name: user/web\n"), 0644))
+
+	m, err = source(context.Background())
+
+	require.Nil(t, err)
+	assert.Equal(t, []reposync.Repository{{Service: "github", Name: "user/web"}}, m.Repositories)
+}
+
+func TestRun(t *testing.T) {
+	repos := newFakeRepositories()
+	ctx, cancel := context.WithCancel(context.Background())
+	runs := 0
+	r := New(repos, Static(manifest))
+	r.Jitter = 0.5
+	r.Hooks.OnResult = func(ctx context.Context, result Result) {
+		assert.Nil(t, result.Err)
+		if runs++; runs == 3 {
+			cancel()
+		}
+	}
+
+	err := r.Run(ctx, time.Millisecond)
+
+	assert.Equal(t, context.Canceled, err)
+	assert.Equal(t, 3, runs)
+	assert.Equal(t, 1, repos.updates)
+}
+
+func TestRunInvalidInterval(t *testing.T) {
+	for _, interval := range []time.Duration{0, -time.Second} {
+		repos := newFakeRepositories()
+		runs := 0
+		r := New(repos, Static(manifest))
+		r.Hooks.OnResult = func(ctx context.Context, result Result) {
+			runs++
+		}
+
+		err := r.Run(context.Background(), interval)
+
+		assert.True(t, errors.Is(err, ErrInvalidInterval), err)
+		assert.Equal(t, 0, runs)
+	}
+}
+
+func TestJittered(t *testing.T) {
+	var testCases = []struct {
+		name     string
+		jitter   float64
+		f        float64
+		expected time.Duration
+	}{
+		{name: "no jitter", jitter: 0, f: 0, expected: time.Minute},
+		{name: "least", jitter: 0.1, f: 0, expected: 54 * time.Second},
+		{name: "middle", jitter: 0.1, f: 0.5, expected: time.Minute},
+		{name: "most", jitter: 0.1, f: 1, expected: 66 * time.Second},
+		{name: "capped", jitter: 2, f: 0, expected: 0},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, jittered(time.Minute, tt.jitter, tt.f))
+		})
+	}
+}
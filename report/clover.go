@@ -0,0 +1,98 @@
+/*
+Copyright (c) 2020 Loadsmart, Inc.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package report
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+)
+
+// cloverFile is a file in a Clover XML report
+type cloverFile struct {
+	Name  string `xml:"name,attr"`
+	Path  string `xml:"path,attr"`
+	Lines []struct {
+		Num        int    `xml:"num,attr"`
+		Type       string `xml:"type,attr"`
+		Count      *int   `xml:"count,attr"`
+		TrueCount  int    `xml:"truecount,attr"`
+		FalseCount int    `xml:"falsecount,attr"`
+	} `xml:"line"`
+}
+
+// cloverReport is the root element of a Clover XML report
+type cloverReport struct {
+	Project struct {
+		Files    []cloverFile `xml:"file"`
+		Packages []struct {
+			Files []cloverFile `xml:"file"`
+		} `xml:"package"`
+	} `xml:"project"`
+}
+
+// ParseClover parses a Clover XML report, such as the clover.xml written by
+// PHPUnit or Jest. The true and false outcomes of conditions are reported as
+// two branches of their line.
+//
+// Files are named by their path attribute, or their name if they have none,
+// made relative to root, the root of the repository, as ParseLCOV does.
+func ParseClover(r io.Reader, root string) (*Report, error) {
+	var doc cloverReport
+	if err := xml.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("invalid Clover report: %w", err)
+	}
+
+	files := doc.Project.Files
+	for _, pkg := range doc.Project.Packages {
+		files = append(files, pkg.Files...)
+	}
+
+	report := New()
+	for _, f := range files {
+		name := f.Path
+		if name == "" {
+			name = f.Name
+		}
+		file := report.File(relativeName(name, root))
+		for _, line := range f.Lines {
+			switch line.Type {
+			case "stmt":
+				file.Lines[line.Num] += cloverCount(line.Count, 0)
+			case "cond":
+				file.Lines[line.Num] += cloverCount(line.Count, line.TrueCount+line.FalseCount)
+				file.AddBranch(line.Num, 0, 0, line.TrueCount)
+				file.AddBranch(line.Num, 0, 1, line.FalseCount)
+			}
+		}
+	}
+	return report, nil
+}
+
+// cloverCount returns the count of a line, or fallback if it has none
+func cloverCount(count *int, fallback int) int {
+	if count == nil {
+		return fallback
+	}
+	return *count
+}
@@ -0,0 +1,71 @@
+/*
+Copyright (c) 2020 Loadsmart, Inc.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package report
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const cloverXML = `<?xml version="1.0" encoding="UTF-8"?>
+<coverage generated="1">
+	<project timestamp="1">
+		<file name="main.go">
+			<line num="5" type="stmt" count="1"/>
+		</file>
+		<package name="pkg">
+			<file name="even.go" path="pkg/even.go">
+				<line num="3" type="method" name="Even" count="3"/>
+				<line num="4" type="stmt" count="3"/>
+				<line num="5" type="cond" truecount="2" falsecount="0"/>
+				<line num="6" type="stmt" count="2"/>
+				<line num="8" type="stmt" count="0"/>
+			</file>
+		</package>
+	</project>
+</coverage>`
+
+func TestParseClover(t *testing.T) {
+	report, err := ParseClover(strings.NewReader(cloverXML), "")
+
+	assert.Nil(t, err)
+	assert.Equal(t, &Report{Files: map[string]*File{
+		"main.go": {Name: "main.go", Lines: map[int]int{5: 1}},
+		"pkg/even.go": {
+			Name:  "pkg/even.go",
+			Lines: map[int]int{4: 3, 5: 2, 6: 2, 8: 0},
+			Branches: []Branch{
+				{Line: 5, Block: 0, Branch: 0, Hits: 2},
+				{Line: 5, Block: 0, Branch: 1, Hits: 0},
+			},
+		},
+	}}, report)
+}
+
+func TestParseCloverInvalid(t *testing.T) {
+	_, err := ParseClover(strings.NewReader("<coverage><project>"), "")
+
+	assert.NotNil(t, err)
+}
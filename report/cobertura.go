@@ -0,0 +1,110 @@
+/*
+Copyright (c) 2020 Loadsmart, Inc.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package report
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+)
+
+// coberturaReport is the root element of a Cobertura XML report
+type coberturaReport struct {
+	Sources  []string `xml:"sources>source"`
+	Packages []struct {
+		Classes []struct {
+			Filename string `xml:"filename,attr"`
+			Lines    []struct {
+				Number            int    `xml:"number,attr"`
+				Hits              int    `xml:"hits,attr"`
+				Branch            bool   `xml:"branch,attr"`
+				ConditionCoverage string `xml:"condition-coverage,attr"`
+			} `xml:"lines>line"`
+		} `xml:"classes>class"`
+	} `xml:"packages>package"`
+}
+
+// conditionCoverage matches the condition-coverage attribute of a line in a
+// Cobertura report, e.g. 50% (1/2)
+var conditionCoverage = regexp.MustCompile(`\((\d+)/(\d+)\)`)
+
+// ParseCobertura parses a Cobertura XML report, such as the coverage.xml
+// written by coverage.py or the Cobertura Maven plugin.
+//
+// Cobertura only counts how many branches of a line were taken, so each of
+// them is reported with one hit or none. File names are resolved against
+// the sources of the report and made relative to root, the root of the
+// repository, as ParseLCOV does.
+func ParseCobertura(r io.Reader, root string) (*Report, error) {
+	var doc coberturaReport
+	if err := xml.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("invalid Cobertura report: %w", err)
+	}
+
+	report := New()
+	for _, pkg := range doc.Packages {
+		for _, class := range pkg.Classes {
+			file := report.File(relativeName(resolveSource(class.Filename, doc.Sources), root))
+			for _, line := range class.Lines {
+				if hits, ok := file.Lines[line.Number]; !ok || line.Hits > hits {
+					file.Lines[line.Number] = line.Hits
+				}
+				if !line.Branch {
+					continue
+				}
+				m := conditionCoverage.FindStringSubmatch(line.ConditionCoverage)
+				if m == nil {
+					continue
+				}
+				covered, _ := strconv.Atoi(m[1])
+				total, _ := strconv.Atoi(m[2])
+				setCoveredBranches(file, line.Number, covered, total)
+			}
+		}
+	}
+	return report, nil
+}
+
+// resolveSource returns the absolute path of name, found in the first of
+// the source directories of a report where it exists, or in the first one
+func resolveSource(name string, sources []string) string {
+	if filepath.IsAbs(name) || len(sources) == 0 {
+		return name
+	}
+	path := filepath.Join(sources[0], filepath.FromSlash(name))
+	for _, source := range sources {
+		candidate := filepath.Join(source, filepath.FromSlash(name))
+		if _, err := os.Stat(candidate); err == nil {
+			path = candidate
+			break
+		}
+	}
+	if abs, err := filepath.Abs(path); err == nil {
+		return abs
+	}
+	return path
+}
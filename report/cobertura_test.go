@@ -0,0 +1,131 @@
+/*
+Copyright (c) 2020 Loadsmart, Inc.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package report
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const coberturaXML = `<?xml version="1.0" ?>
+<!DOCTYPE coverage SYSTEM "http://cobertura.sourceforge.net/xml/coverage-04.dtd">
+<coverage line-rate="0.8" branch-rate="0.5" version="1.9" timestamp="1">
+	<sources>
+		<source>/somewhere/else</source>
+		<source>%s</source>
+	</sources>
+	<packages>
+		<package name="pkg" line-rate="0.8" branch-rate="0.5">
+			<classes>
+				<class name="even" filename="pkg/even.go" line-rate="0.8" branch-rate="0.5">
+					<lines>
+						<line number="4" hits="3"/>
+						<line number="5" hits="3" branch="true" condition-coverage="50%% (1/2)"/>
+						<line number="6" hits="2"/>
+						<line number="8" hits="0"/>
+					</lines>
+				</class>
+				<class name="even$inner" filename="pkg/even.go" line-rate="1" branch-rate="1">
+					<lines>
+						<line number="8" hits="1"/>
+					</lines>
+				</class>
+			</classes>
+		</package>
+	</packages>
+</coverage>`
+
+func TestParseCobertura(t *testing.T) {
+	root, _ := filepath.Abs("testdata/project")
+
+	report, err := ParseCobertura(strings.NewReader(fmt.Sprintf(coberturaXML, root)), "testdata/project")
+
+	assert.Nil(t, err)
+	assert.Equal(t, &Report{Files: map[string]*File{
+		"pkg/even.go": {
+			Name:  "pkg/even.go",
+			Lines: map[int]int{4: 3, 5: 3, 6: 2, 8: 1},
+			Branches: []Branch{
+				{Line: 5, Block: 0, Branch: 0, Hits: 1},
+				{Line: 5, Block: 0, Branch: 1, Hits: 0},
+			},
+		},
+	}}, report)
+
+	job, err := report.Job("testdata/project")
+	assert.Nil(t, err)
+	assert.Equal(t, []int{5, 0, 0, 1, 5, 0, 1, 0}, job.SourceFiles[0].Branches)
+}
+
+// coveragePyXML is a report written by coverage xml, with coverage.py's
+// extra attributes
+const coveragePyXML = `<?xml version="1.0" ?>
+<coverage version="7.2.3" timestamp="1681000000000" lines-valid="4" lines-covered="3" line-rate="0.75" branches-covered="1" branches-valid="2" branch-rate="0.5" complexity="0">
+	<!-- Generated by coverage.py: https://coverage.readthedocs.io/en/7.2.3 -->
+	<sources>
+		<source>%s</source>
+	</sources>
+	<packages>
+		<package name="app" line-rate="0.75" branch-rate="0.5" complexity="0">
+			<classes>
+				<class name="even.py" filename="app/even.py" complexity="0" line-rate="0.75" branch-rate="0.5">
+					<methods/>
+					<lines>
+						<line number="1" hits="1"/>
+						<line number="2" hits="1" branch="true" condition-coverage="50%% (1/2)" missing-branches="5"/>
+						<line number="3" hits="1"/>
+						<line number="5" hits="0"/>
+					</lines>
+				</class>
+			</classes>
+		</package>
+	</packages>
+</coverage>`
+
+func TestParseCoberturaCoveragePy(t *testing.T) {
+	root, _ := filepath.Abs("testdata/project")
+
+	report, err := ParseCobertura(strings.NewReader(fmt.Sprintf(coveragePyXML, root)), "testdata/project")
+
+	assert.Nil(t, err)
+	assert.Equal(t, &Report{Files: map[string]*File{
+		"app/even.py": {
+			Name:  "app/even.py",
+			Lines: map[int]int{1: 1, 2: 1, 3: 1, 5: 0},
+			Branches: []Branch{
+				{Line: 2, Block: 0, Branch: 0, Hits: 1},
+				{Line: 2, Block: 0, Branch: 1, Hits: 0},
+			},
+		},
+	}}, report)
+}
+
+func TestParseCoberturaInvalid(t *testing.T) {
+	_, err := ParseCobertura(strings.NewReader("<coverage>"), "")
+
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,137 @@
+/*
+Copyright (c) 2020 Loadsmart, Inc.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package report
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// goCoverBlock matches a block of a Go cover profile, e.g.
+// github.com/user/repo/main.go:10.2,12.16 2 1
+var goCoverBlock = regexp.MustCompile(`^(.+):((\d+)\.\d+,(\d+)\.\d+) (\d+) (\d+)$`)
+
+// goBlock is a block of statements in a Go cover profile
+type goBlock struct {
+	name      string // Import path of the file
+	position  string // Start and end of the block, e.g. 10.2,12.16
+	startLine int
+	endLine   int
+}
+
+// ParseGoCoverProfile parses a profile written by go test -coverprofile.
+// Profiles of many packages may be concatenated; blocks found more than once
+// are merged as go tool cover does. Go only measures the coverage of blocks
+// of statements, so reports of Go profiles have no branch coverage.
+//
+// Profiles name files by import path. Files in the module modulePath are
+// renamed to their path relative to the module root, e.g. main.go for
+// github.com/user/repo/main.go in module github.com/user/repo. See
+// GoModulePath to read it from go.mod.
+func ParseGoCoverProfile(r io.Reader, modulePath string) (*Report, error) {
+	mode := ""
+	hits := make(map[goBlock]int)
+	var blocks []goBlock
+
+	scanner := bufio.NewScanner(r)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		if strings.HasPrefix(line, "mode: ") {
+			mode = strings.TrimPrefix(line, "mode: ")
+			continue
+		}
+
+		m := goCoverBlock.FindStringSubmatch(line)
+		if m == nil {
+			return nil, fmt.Errorf("invalid Go cover profile: line %d: %q", lineNumber, line)
+		}
+		start, _ := strconv.Atoi(m[3])
+		end, _ := strconv.Atoi(m[4])
+		statements, _ := strconv.Atoi(m[5])
+		count, _ := strconv.Atoi(m[6])
+		if statements == 0 {
+			continue
+		}
+
+		block := goBlock{name: m[1], position: m[2], startLine: start, endLine: end}
+		previous, seen := hits[block]
+		if !seen {
+			blocks = append(blocks, block)
+		}
+		switch {
+		case mode != "set":
+			hits[block] = previous + count
+		case count > previous:
+			hits[block] = count
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("reading Go cover profile: %w", err)
+	}
+
+	report := New()
+	for _, block := range blocks {
+		f := report.File(goFileName(block.name, modulePath))
+		for line := block.startLine; line <= block.endLine; line++ {
+			if current, ok := f.Lines[line]; !ok || hits[block] > current {
+				f.Lines[line] = hits[block]
+			}
+		}
+	}
+	return report, nil
+}
+
+// goFileName returns the name of a file in a Go cover profile relative to
+// the root of module modulePath
+func goFileName(name string, modulePath string) string {
+	if modulePath != "" && strings.HasPrefix(name, modulePath+"/") {
+		return strings.TrimPrefix(name, modulePath+"/")
+	}
+	return name
+}
+
+// GoModulePath returns the path of the Go module whose go.mod is in root
+func GoModulePath(root string) (string, error) {
+	data, err := ioutil.ReadFile(filepath.Join(root, "go.mod"))
+	if err != nil {
+		return "", fmt.Errorf("reading go.mod: %w", err)
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) >= 2 && fields[0] == "module" {
+			return strings.Trim(fields[1], `"`), nil
+		}
+	}
+	return "", fmt.Errorf("go.mod has no module directive")
+}
@@ -0,0 +1,97 @@
+/*
+Copyright (c) 2020 Loadsmart, Inc.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package report
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseGoCoverProfile(t *testing.T) {
+	f, err := os.Open("testdata/project/coverage.out")
+	assert.Nil(t, err)
+	defer f.Close()
+
+	report, err := ParseGoCoverProfile(f, "example.com/project")
+
+	assert.Nil(t, err)
+	assert.Equal(t, &Report{Files: map[string]*File{
+		"main.go":     {Name: "main.go", Lines: map[int]int{5: 1, 6: 1, 7: 1, 8: 1}},
+		"pkg/even.go": {Name: "pkg/even.go", Lines: map[int]int{4: 3, 5: 3, 6: 2, 7: 2, 8: 1}},
+	}}, report)
+}
+
+func TestParseGoCoverProfileModes(t *testing.T) {
+	var testCases = []struct {
+		name    string
+		profile string
+		want    map[int]int
+		wantErr bool
+	}{
+		{
+			name:    "set",
+			profile: "mode: set\na/b.go:1.1,2.2 1 1\na/b.go:1.1,2.2 1 1\n",
+			want:    map[int]int{1: 1, 2: 1},
+		},
+		{
+			name:    "atomic",
+			profile: "mode: atomic\na/b.go:1.1,2.2 1 3\na/b.go:1.1,2.2 1 4\n",
+			want:    map[int]int{1: 7, 2: 7},
+		},
+		{
+			name:    "empty block",
+			profile: "mode: set\na/b.go:1.1,2.2 0 0\na/b.go:3.1,3.5 1 0\n",
+			want:    map[int]int{3: 0},
+		},
+		{
+			name:    "invalid",
+			profile: "mode: set\nnot a block\n",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			report, err := ParseGoCoverProfile(strings.NewReader(tt.profile), "a")
+
+			if tt.wantErr {
+				assert.NotNil(t, err)
+				return
+			}
+			assert.Nil(t, err)
+			assert.Equal(t, tt.want, report.Files["b.go"].Lines)
+		})
+	}
+}
+
+func TestGoModulePath(t *testing.T) {
+	path, err := GoModulePath("testdata/project")
+	assert.Nil(t, err)
+	assert.Equal(t, "example.com/project", path)
+
+	_, err = GoModulePath("testdata/missing")
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,112 @@
+/*
+Copyright (c) 2020 Loadsmart, Inc.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+)
+
+// istanbulFile is the coverage of a file in an Istanbul report
+type istanbulFile struct {
+	Path         string                       `json:"path"`
+	StatementMap map[string]istanbulRange     `json:"statementMap"`
+	BranchMap    map[string]istanbulBranchMap `json:"branchMap"`
+	S            map[string]int               `json:"s"` // Hits of each statement
+	B            map[string][]int             `json:"b"` // Hits of each location of each branch
+}
+
+// istanbulRange is the location of a statement or branch in a file
+type istanbulRange struct {
+	Start struct {
+		Line int `json:"line"`
+	} `json:"start"`
+}
+
+// istanbulBranchMap describes a branch point, e.g. an if and its else
+type istanbulBranchMap struct {
+	Line int           `json:"line"` // Line of the branch point, only set by older versions
+	Loc  istanbulRange `json:"loc"`
+}
+
+// ParseIstanbul parses the coverage-final.json written by Istanbul and nyc
+// for JavaScript and TypeScript projects.
+//
+// The hits of a line are the most hits of the statements starting on it.
+// Each branch point of a file is a block of its branches, numbered as in
+// the report. Absolute paths under root, the root of the repository, are
+// made relative to it, as ParseLCOV does.
+func ParseIstanbul(r io.Reader, root string) (*Report, error) {
+	var doc map[string]istanbulFile
+	if err := json.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("invalid Istanbul report: %w", err)
+	}
+
+	report := New()
+	for key, entry := range doc {
+		name := entry.Path
+		if name == "" {
+			name = key
+		}
+		file := report.File(relativeName(name, root))
+
+		for id, hits := range entry.S {
+			statement, ok := entry.StatementMap[id]
+			if !ok {
+				return nil, fmt.Errorf("invalid Istanbul report: %s: unknown statement %q", name, id)
+			}
+			line := statement.Start.Line
+			if current, ok := file.Lines[line]; !ok || hits > current {
+				file.Lines[line] = hits
+			}
+		}
+
+		blocks := make([]int, 0, len(entry.B))
+		for id := range entry.B {
+			block, err := strconv.Atoi(id)
+			if err != nil {
+				return nil, fmt.Errorf("invalid Istanbul report: %s: invalid branch %q", name, id)
+			}
+			blocks = append(blocks, block)
+		}
+		sort.Ints(blocks)
+		for _, block := range blocks {
+			id := strconv.Itoa(block)
+			branch, ok := entry.BranchMap[id]
+			if !ok {
+				return nil, fmt.Errorf("invalid Istanbul report: %s: unknown branch %q", name, id)
+			}
+			line := branch.Line
+			if line == 0 {
+				line = branch.Loc.Start.Line
+			}
+			for i, hits := range entry.B[id] {
+				setBranch(file, line, block, i, hits)
+			}
+		}
+	}
+	return report, nil
+}
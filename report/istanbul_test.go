@@ -0,0 +1,97 @@
+/*
+Copyright (c) 2020 Loadsmart, Inc.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package report
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const istanbulJSON = `{
+	"%[1]s": {
+		"path": "%[1]s",
+		"statementMap": {
+			"0": {"start": {"line": 5, "column": 2}, "end": {"line": 7, "column": 3}},
+			"1": {"start": {"line": 6, "column": 4}, "end": {"line": 6, "column": 16}},
+			"2": {"start": {"line": 8, "column": 2}, "end": {"line": 8, "column": 15}},
+			"3": {"start": {"line": 8, "column": 16}, "end": {"line": 8, "column": 20}}
+		},
+		"fnMap": {},
+		"branchMap": {
+			"0": {
+				"loc": {"start": {"line": 5, "column": 2}, "end": {"line": 7, "column": 3}},
+				"type": "if",
+				"locations": [{"start": {"line": 5}}, {"start": {"line": 5}}]
+			},
+			"1": {"line": 8, "type": "binary-expr", "locations": [{}, {}, {}]}
+		},
+		"s": {"0": 3, "1": 2, "2": 1, "3": 0},
+		"f": {},
+		"b": {"0": [2, 1], "1": [1, 0, 0]}
+	}
+}`
+
+func TestParseIstanbul(t *testing.T) {
+	root, _ := filepath.Abs("testdata/project")
+	doc := fmt.Sprintf(istanbulJSON, filepath.ToSlash(filepath.Join(root, "pkg", "even.go")))
+
+	report, err := ParseIstanbul(strings.NewReader(doc), "testdata/project")
+
+	assert.Nil(t, err)
+	assert.Equal(t, &Report{Files: map[string]*File{
+		"pkg/even.go": {
+			Name:  "pkg/even.go",
+			Lines: map[int]int{5: 3, 6: 2, 8: 1},
+			Branches: []Branch{
+				{Line: 5, Block: 0, Branch: 0, Hits: 2},
+				{Line: 5, Block: 0, Branch: 1, Hits: 1},
+				{Line: 8, Block: 1, Branch: 0, Hits: 1},
+				{Line: 8, Block: 1, Branch: 1, Hits: 0},
+				{Line: 8, Block: 1, Branch: 2, Hits: 0},
+			},
+		},
+	}}, report)
+}
+
+func TestParseIstanbulInvalid(t *testing.T) {
+	var testCases = []struct {
+		name string
+		doc  string
+	}{
+		{name: "not JSON", doc: "SF:main.go"},
+		{name: "unknown statement", doc: `{"main.js": {"statementMap": {}, "s": {"0": 1}}}`},
+		{name: "unknown branch", doc: `{"main.js": {"branchMap": {}, "b": {"0": [1, 0]}}}`},
+		{name: "invalid branch", doc: `{"main.js": {"branchMap": {}, "b": {"first": [1, 0]}}}`},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseIstanbul(strings.NewReader(tt.doc), "")
+			assert.NotNil(t, err)
+		})
+	}
+}
@@ -0,0 +1,98 @@
+/*
+Copyright (c) 2020 Loadsmart, Inc.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package report
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+)
+
+// jacocoReport is the root element of a JaCoCo XML report
+type jacocoReport struct {
+	Packages []struct {
+		Name        string `xml:"name,attr"`
+		SourceFiles []struct {
+			Name  string `xml:"name,attr"`
+			Lines []struct {
+				Number              int `xml:"nr,attr"`
+				MissedInstructions  int `xml:"mi,attr"`
+				CoveredInstructions int `xml:"ci,attr"`
+				MissedBranches      int `xml:"mb,attr"`
+				CoveredBranches     int `xml:"cb,attr"`
+			} `xml:"line"`
+		} `xml:"sourcefile"`
+	} `xml:"package"`
+}
+
+// ParseJaCoCo parses a JaCoCo XML report, such as the jacoco.xml written by
+// the JaCoCo Maven and Gradle plugins.
+//
+// JaCoCo counts covered instructions rather than hits, so lines are
+// reported with one hit or none, and branches as ParseCobertura does.
+// Files are named relative to their source directory, so they are looked up
+// in each of sourceDirs, relative to root, e.g. src/main/java, and named
+// after the first where they exist.
+func ParseJaCoCo(r io.Reader, root string, sourceDirs ...string) (*Report, error) {
+	var doc jacocoReport
+	if err := xml.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("invalid JaCoCo report: %w", err)
+	}
+
+	report := New()
+	for _, pkg := range doc.Packages {
+		for _, source := range pkg.SourceFiles {
+			file := report.File(jacocoFileName(path.Join(pkg.Name, source.Name), root, sourceDirs))
+			for _, line := range source.Lines {
+				hits := 0
+				if line.CoveredInstructions > 0 {
+					hits = 1
+				}
+				if current, ok := file.Lines[line.Number]; !ok || hits > current {
+					file.Lines[line.Number] = hits
+				}
+				total := line.MissedBranches + line.CoveredBranches
+				setCoveredBranches(file, line.Number, line.CoveredBranches, total)
+			}
+		}
+	}
+	return report, nil
+}
+
+// jacocoFileName returns the name of a file of a JaCoCo report relative to
+// root, looking it up in sourceDirs
+func jacocoFileName(name string, root string, sourceDirs []string) string {
+	for _, dir := range sourceDirs {
+		candidate := path.Join(filepath.ToSlash(dir), name)
+		if _, err := os.Stat(filepath.Join(root, filepath.FromSlash(candidate))); err == nil {
+			return candidate
+		}
+	}
+	if len(sourceDirs) > 0 {
+		return path.Join(filepath.ToSlash(sourceDirs[0]), name)
+	}
+	return name
+}
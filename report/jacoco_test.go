@@ -0,0 +1,80 @@
+/*
+Copyright (c) 2020 Loadsmart, Inc.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package report
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const jacocoXML = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<!DOCTYPE report PUBLIC "-//JACOCO//DTD Report 1.1//EN" "report.dtd">
+<report name="example">
+	<package name="com/example">
+		<class name="com/example/Even" sourcefilename="Even.java"/>
+		<sourcefile name="Even.java">
+			<line nr="3" mi="0" ci="3" mb="0" cb="0"/>
+			<line nr="5" mi="0" ci="4" mb="1" cb="1"/>
+			<line nr="6" mi="0" ci="2" mb="0" cb="0"/>
+			<line nr="8" mi="2" ci="0" mb="0" cb="0"/>
+		</sourcefile>
+	</package>
+</report>`
+
+func TestParseJaCoCo(t *testing.T) {
+	var testCases = []struct {
+		name       string
+		sourceDirs []string
+		want       string
+	}{
+		{name: "found", sourceDirs: []string{"src/test/java", "src/main/java"}, want: "src/main/java/com/example/Even.java"},
+		{name: "not found", sourceDirs: []string{"java"}, want: "java/com/example/Even.java"},
+		{name: "no source dirs", want: "com/example/Even.java"},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			report, err := ParseJaCoCo(strings.NewReader(jacocoXML), "testdata/project", tt.sourceDirs...)
+
+			assert.Nil(t, err)
+			assert.Equal(t, &Report{Files: map[string]*File{
+				tt.want: {
+					Name:  tt.want,
+					Lines: map[int]int{3: 1, 5: 1, 6: 1, 8: 0},
+					Branches: []Branch{
+						{Line: 5, Block: 0, Branch: 0, Hits: 1},
+						{Line: 5, Block: 0, Branch: 1, Hits: 0},
+					},
+				},
+			}}, report)
+		})
+	}
+}
+
+func TestParseJaCoCoInvalid(t *testing.T) {
+	_, err := ParseJaCoCo(strings.NewReader("<report>"), "")
+
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,126 @@
+/*
+Copyright (c) 2020 Loadsmart, Inc.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package report
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// ParseLCOV parses an LCOV tracefile, e.g. the lcov.info written by
+// Istanbul or genhtml, including its branch coverage. Hits of files found
+// in more than one record add up.
+//
+// Absolute paths under root, the root of the repository, are made relative
+// to it; other paths are kept as they are.
+func ParseLCOV(r io.Reader, root string) (*Report, error) {
+	report := New()
+	var file *File
+
+	scanner := bufio.NewScanner(r)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+		field, value := line, ""
+		if i := strings.Index(line, ":"); i >= 0 {
+			field, value = line[:i], line[i+1:]
+		}
+
+		switch field {
+		case "SF":
+			file = report.File(relativeName(value, root))
+		case "DA":
+			if file == nil {
+				return nil, fmt.Errorf("invalid LCOV file: line %d: DA outside of a record", lineNumber)
+			}
+			parts := strings.Split(value, ",")
+			if len(parts) < 2 {
+				return nil, fmt.Errorf("invalid LCOV file: line %d: %q", lineNumber, line)
+			}
+			n, err := strconv.Atoi(parts[0])
+			if err != nil {
+				return nil, fmt.Errorf("invalid LCOV file: line %d: %q", lineNumber, line)
+			}
+			hits, err := strconv.Atoi(parts[1])
+			if err != nil {
+				return nil, fmt.Errorf("invalid LCOV file: line %d: %q", lineNumber, line)
+			}
+			file.Lines[n] += hits
+		case "BRDA":
+			if file == nil {
+				return nil, fmt.Errorf("invalid LCOV file: line %d: BRDA outside of a record", lineNumber)
+			}
+			branch, err := parseBRDA(value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid LCOV file: line %d: %q", lineNumber, line)
+			}
+			file.AddBranch(branch.Line, branch.Block, branch.Branch, branch.Hits)
+		case "end_of_record":
+			file = nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("reading LCOV file: %w", err)
+	}
+	return report, nil
+}
+
+// parseBRDA parses the value of a BRDA field, e.g. 5,0,1,2 for 2 hits of
+// branch 1 of block 0 of line 5. Hits are - for branches of blocks that
+// never ran.
+func parseBRDA(value string) (Branch, error) {
+	parts := strings.Split(value, ",")
+	if len(parts) != 4 {
+		return Branch{}, fmt.Errorf("invalid branch %q", value)
+	}
+	var numbers [4]int
+	for i, part := range parts {
+		if i == 3 && part == "-" {
+			break
+		}
+		n, err := strconv.Atoi(part)
+		if err != nil {
+			return Branch{}, fmt.Errorf("invalid branch %q", value)
+		}
+		numbers[i] = n
+	}
+	return Branch{Line: numbers[0], Block: numbers[1], Branch: numbers[2], Hits: numbers[3]}, nil
+}
+
+// relativeName returns the name of a file relative to root, with forward
+// slashes, if it is an absolute path under root
+func relativeName(name string, root string) string {
+	if filepath.IsAbs(name) && root != "" {
+		if absRoot, err := filepath.Abs(root); err == nil {
+			if rel, err := filepath.Rel(absRoot, name); err == nil && !strings.HasPrefix(rel, "..") {
+				name = rel
+			}
+		}
+	}
+	return filepath.ToSlash(name)
+}
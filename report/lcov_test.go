@@ -0,0 +1,99 @@
+/*
+Copyright (c) 2020 Loadsmart, Inc.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package report
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseLCOV(t *testing.T) {
+	f, err := os.Open("testdata/project/lcov.info")
+	assert.Nil(t, err)
+	defer f.Close()
+
+	report, err := ParseLCOV(f, "testdata/project")
+
+	assert.Nil(t, err)
+	assert.Equal(t, &Report{Files: map[string]*File{
+		"main.go": {
+			Name:  "main.go",
+			Lines: map[int]int{5: 1, 6: 1, 7: 2},
+			Branches: []Branch{
+				{Line: 6, Block: 0, Branch: 0, Hits: 0},
+				{Line: 6, Block: 0, Branch: 1, Hits: 0},
+			},
+		},
+		"pkg/even.go": {
+			Name:  "pkg/even.go",
+			Lines: map[int]int{4: 3, 5: 3, 6: 2, 8: 1},
+			Branches: []Branch{
+				{Line: 5, Block: 0, Branch: 0, Hits: 2},
+				{Line: 5, Block: 0, Branch: 1, Hits: 1},
+			},
+		},
+	}}, report)
+
+	job, err := report.Job("testdata/project")
+	assert.Nil(t, err)
+	assert.Equal(t, []int{5, 0, 0, 2, 5, 0, 1, 1}, job.SourceFiles[1].Branches)
+}
+
+func TestParseLCOVAbsolutePaths(t *testing.T) {
+	root, _ := filepath.Abs("testdata/project")
+	outside, _ := filepath.Abs("testdata/other.go")
+	lcov := "SF:" + filepath.Join(root, "pkg", "even.go") + "\nDA:4,1\nend_of_record\n" +
+		"SF:" + outside + "\nDA:1,1\nend_of_record\n"
+
+	report, err := ParseLCOV(strings.NewReader(lcov), "testdata/project")
+
+	assert.Nil(t, err)
+	assert.Contains(t, report.Files, "pkg/even.go")
+	assert.Contains(t, report.Files, filepath.ToSlash(outside))
+}
+
+func TestParseLCOVInvalid(t *testing.T) {
+	var testCases = []struct {
+		name string
+		lcov string
+	}{
+		{name: "outside of a record", lcov: "DA:1,1\n"},
+		{name: "missing hits", lcov: "SF:main.go\nDA:1\n"},
+		{name: "invalid line", lcov: "SF:main.go\nDA:one,1\n"},
+		{name: "invalid hits", lcov: "SF:main.go\nDA:1,many\n"},
+		{name: "branch outside of a record", lcov: "BRDA:1,0,0,1\n"},
+		{name: "missing branch hits", lcov: "SF:main.go\nBRDA:1,0,0\n"},
+		{name: "invalid branch", lcov: "SF:main.go\nBRDA:1,0,then,1\n"},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseLCOV(strings.NewReader(tt.lcov), "")
+			assert.NotNil(t, err)
+		})
+	}
+}
@@ -0,0 +1,50 @@
+/*
+Copyright (c) 2020 Loadsmart, Inc.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package report
+
+// Merge returns a report with the coverage of all reports, e.g. of parallel
+// CI shards: it has the files of all of them, and the hits of lines and
+// branches found in more than one add up. The reports are left unchanged.
+func Merge(reports ...*Report) *Report {
+	merged := New()
+	for _, r := range reports {
+		if r == nil {
+			continue
+		}
+		for _, name := range r.names() {
+			merged.add(name, r.Files[name])
+		}
+	}
+	return merged
+}
+
+// add adds the hits of f to those of the file with the given name
+func (r *Report) add(name string, f *File) {
+	file := r.File(name)
+	for line, hits := range f.Lines {
+		file.Lines[line] += hits
+	}
+	for _, b := range f.Branches {
+		file.AddBranch(b.Line, b.Block, b.Branch, b.Hits)
+	}
+}
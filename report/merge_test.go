@@ -0,0 +1,62 @@
+/*
+Copyright (c) 2020 Loadsmart, Inc.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package report
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMerge(t *testing.T) {
+	first := New()
+	first.File("main.go").Lines[5] = 1
+	first.File("main.go").Lines[6] = 0
+	first.File("main.go").AddBranch(6, 0, 0, 1)
+
+	second := New()
+	second.File("main.go").Lines[6] = 2
+	second.File("main.go").AddBranch(6, 0, 0, 1)
+	second.File("main.go").AddBranch(6, 0, 1, 1)
+	second.File("pkg/even.go").Lines[4] = 3
+
+	merged := Merge(first, nil, second)
+
+	assert.Equal(t, &Report{Files: map[string]*File{
+		"main.go": {
+			Name:  "main.go",
+			Lines: map[int]int{5: 1, 6: 2},
+			Branches: []Branch{
+				{Line: 6, Block: 0, Branch: 0, Hits: 2},
+				{Line: 6, Block: 0, Branch: 1, Hits: 1},
+			},
+		},
+		"pkg/even.go": {Name: "pkg/even.go", Lines: map[int]int{4: 3}},
+	}}, merged)
+	assert.Equal(t, map[int]int{5: 1, 6: 0}, first.Files["main.go"].Lines, "reports are changed")
+	assert.Equal(t, 1, first.Files["main.go"].Branches[0].Hits, "reports are changed")
+}
+
+func TestMergeNone(t *testing.T) {
+	assert.Equal(t, New(), Merge())
+}
@@ -0,0 +1,269 @@
+/*
+Copyright (c) 2020 Loadsmart, Inc.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package report
+
+import (
+	"path"
+	"regexp"
+	"runtime"
+	"strings"
+)
+
+// Option customizes how a Report is converted into a job
+type Option func(*options)
+
+// options holds the settings collected from the Options passed to SourceFiles
+type options struct {
+	pathMappings []pathMapping
+	includes     []*regexp.Regexp
+	excludes     []*regexp.Regexp
+
+	excludeGenerated bool
+	concurrency      int
+}
+
+// pathMapping replaces the prefix from of file names with to
+type pathMapping struct {
+	from string
+	to   string
+}
+
+func newOptions(opts []Option) *options {
+	o := &options{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// MapPath renames files whose names start with the directory from to start
+// with the directory to instead, e.g. to map /app, where the repository is
+// in a build container, to the root of the repository with MapPath("/app", "").
+// An empty from matches all files, e.g. to move them into a subdirectory.
+// Mappings are tried in the order given, and only the first that matches
+// applies. Files that end up with the same name are merged.
+func MapPath(from string, to string) Option {
+	return func(o *options) {
+		o.pathMappings = append(o.pathMappings, pathMapping{from: cleanDir(from), to: cleanDir(to)})
+	}
+}
+
+// StripPrefix removes the directory prefix from the names of files, e.g. the
+// module path or $GOPATH/src/github.com/user/repo. It is the same as
+// MapPath(prefix, "").
+func StripPrefix(prefix string) Option {
+	return MapPath(prefix, "")
+}
+
+// Include keeps only the files whose names, after MapPath, match one of the
+// glob patterns. In patterns, * matches any characters but /, ? a single
+// one and ** any number of directories, e.g. cmd/**/*.go.
+func Include(patterns ...string) Option {
+	return func(o *options) {
+		for _, pattern := range patterns {
+			o.includes = append(o.includes, globRegexp(pattern))
+		}
+	}
+}
+
+// Exclude drops the files whose names, after MapPath, match one of the
+// glob patterns, as in Include, e.g. **/*_test.go or vendor/**. Exclusions
+// apply after inclusions.
+func Exclude(patterns ...string) Option {
+	return func(o *options) {
+		for _, pattern := range patterns {
+			o.excludes = append(o.excludes, globRegexp(pattern))
+		}
+	}
+}
+
+// IncludeRegexp keeps only the files whose names match one of the regular
+// expressions, as Include does with glob patterns
+func IncludeRegexp(exprs ...*regexp.Regexp) Option {
+	return func(o *options) {
+		o.includes = append(o.includes, exprs...)
+	}
+}
+
+// ExcludeRegexp drops the files whose names match one of the regular
+// expressions, as Exclude does with glob patterns
+func ExcludeRegexp(exprs ...*regexp.Regexp) Option {
+	return func(o *options) {
+		o.excludes = append(o.excludes, exprs...)
+	}
+}
+
+// ExcludeGenerated drops the Go files marked as generated with the standard
+// comment, e.g. "// Code generated by protoc-gen-go. DO NOT EDIT.", before
+// the package clause.
+func ExcludeGenerated() Option {
+	return func(o *options) {
+		o.excludeGenerated = true
+	}
+}
+
+// generatedComment matches the comment that marks generated Go files,
+// see https://golang.org/s/generatedcode
+var generatedComment = regexp.MustCompile(`^// Code generated .* DO NOT EDIT\.$`)
+
+// generated reports whether src, the contents of the file with the given
+// name, is generated Go code
+func generated(name string, src []byte) bool {
+	if !strings.HasSuffix(name, ".go") {
+		return false
+	}
+	for _, line := range strings.Split(string(src), "\n") {
+		line = strings.TrimRight(line, "\r")
+		if generatedComment.MatchString(line) {
+			return true
+		}
+		if strings.HasPrefix(line, "package ") {
+			return false
+		}
+	}
+	return false
+}
+
+// Concurrency sets how many files are read at a time. It defaults to the
+// number of CPUs, as set by GOMAXPROCS.
+func Concurrency(n int) Option {
+	return func(o *options) {
+		o.concurrency = n
+	}
+}
+
+// workers returns how many goroutines read the given number of files
+func (o *options) workers(files int) int {
+	n := o.concurrency
+	if n <= 0 {
+		n = runtime.GOMAXPROCS(0)
+	}
+	if n > files {
+		n = files
+	}
+	return n
+}
+
+// Filter returns the report with its files renamed and filtered by opts,
+// as SourceFiles would send them, e.g. to check the coverage of the files
+// sent with the gate package. Options that read the files, such as
+// ExcludeGenerated, have no effect.
+func (r *Report) Filter(opts ...Option) *Report {
+	return newOptions(opts).apply(r)
+}
+
+// apply returns r changed by the options, or r itself if they change nothing
+func (o *options) apply(r *Report) *Report {
+	if len(o.pathMappings) == 0 && len(o.includes) == 0 && len(o.excludes) == 0 {
+		return r
+	}
+	changed := New()
+	for _, name := range r.names() {
+		if renamed := o.rename(name); o.keep(renamed) {
+			changed.add(renamed, r.Files[name])
+		}
+	}
+	return changed
+}
+
+// keep reports whether the file with the given name passes the filters
+func (o *options) keep(name string) bool {
+	if len(o.includes) > 0 && !matchAny(o.includes, name) {
+		return false
+	}
+	return !matchAny(o.excludes, name)
+}
+
+// matchAny reports whether name matches any of exprs
+func matchAny(exprs []*regexp.Regexp, name string) bool {
+	for _, expr := range exprs {
+		if expr.MatchString(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// globRegexp converts a glob pattern, as described in Include, into a
+// regular expression that matches whole names
+func globRegexp(pattern string) *regexp.Regexp {
+	var expr strings.Builder
+	expr.WriteString("^")
+	for i := 0; i < len(pattern); i++ {
+		switch c := pattern[i]; {
+		case strings.HasPrefix(pattern[i:], "**/"):
+			expr.WriteString("(?:.*/)?")
+			i += 2
+		case strings.HasPrefix(pattern[i:], "**"):
+			expr.WriteString(".*")
+			i++
+		case c == '*':
+			expr.WriteString("[^/]*")
+		case c == '?':
+			expr.WriteString("[^/]")
+		default:
+			expr.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	expr.WriteString("$")
+	return regexp.MustCompile(expr.String())
+}
+
+// rename returns the name of a file after the first path mapping that matches
+func (o *options) rename(name string) string {
+	for _, m := range o.pathMappings {
+		rest, ok := trimDir(name, m.from)
+		if !ok {
+			continue
+		}
+		if m.to == "" {
+			return rest
+		}
+		return path.Join(m.to, rest)
+	}
+	return name
+}
+
+// trimDir removes the directory dir from the start of name, reporting
+// whether name is inside dir
+func trimDir(name string, dir string) (string, bool) {
+	if dir == "" {
+		return name, true
+	}
+	if dir == "/" {
+		return strings.TrimPrefix(name, "/"), strings.HasPrefix(name, "/")
+	}
+	if !strings.HasPrefix(name, dir+"/") {
+		return name, false
+	}
+	return strings.TrimPrefix(name, dir+"/"), true
+}
+
+// cleanDir returns the path of a directory with forward slashes and no trailing slash
+func cleanDir(dir string) string {
+	dir = path.Clean(strings.ReplaceAll(dir, "\\", "/"))
+	if dir == "." {
+		return ""
+	}
+	return dir
+}
@@ -0,0 +1,174 @@
+/*
+Copyright (c) 2020 Loadsmart, Inc.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package report
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMapPath(t *testing.T) {
+	var testCases = []struct {
+		name string
+		opts []Option
+		file string
+		want string
+	}{
+		{name: "no mappings", file: "/app/main.go", want: "/app/main.go"},
+		{name: "container path", opts: []Option{MapPath("/app", "")}, file: "/app/pkg/even.go", want: "pkg/even.go"},
+		{name: "trailing slash", opts: []Option{MapPath("/app/", "")}, file: "/app/main.go", want: "main.go"},
+		{name: "other directory", opts: []Option{MapPath("/app", "")}, file: "/application/main.go", want: "/application/main.go"},
+		{name: "into directory", opts: []Option{MapPath("/build/src", "backend")}, file: "/build/src/main.go", want: "backend/main.go"},
+		{name: "everything", opts: []Option{MapPath("", "backend")}, file: "main.go", want: "backend/main.go"},
+		{name: "module path", opts: []Option{StripPrefix("github.com/user/repo")}, file: "github.com/user/repo/main.go", want: "main.go"},
+		{name: "windows", opts: []Option{StripPrefix(`C:\build`)}, file: "C:/build/main.go", want: "main.go"},
+		{
+			name: "first match",
+			opts: []Option{MapPath("/app/vendor", "third_party"), MapPath("/app", "")},
+			file: "/app/vendor/lib.go",
+			want: "third_party/lib.go",
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, newOptions(tt.opts).rename(tt.file))
+		})
+	}
+}
+
+func TestJobWithPathMapping(t *testing.T) {
+	report := New()
+	report.File("/app/main.go").Lines[5] = 1
+	report.File("/tmp/build/main.go").Lines[5] = 2
+	report.File("/app/pkg/even.go").Lines[4] = 3
+
+	job, err := report.Job("testdata/project", MapPath("/app", ""), MapPath("/tmp/build", ""))
+
+	assert.Nil(t, err)
+	assert.Len(t, job.SourceFiles, 2)
+	assert.Equal(t, "main.go", job.SourceFiles[0].Name)
+	assert.Equal(t, 3, *job.SourceFiles[0].Coverage[4], "merged files")
+	assert.Equal(t, "pkg/even.go", job.SourceFiles[1].Name)
+	assert.Contains(t, report.Files, "/app/main.go", "report is changed")
+}
+
+func TestFilter(t *testing.T) {
+	report := New()
+	report.File("/app/main.go").Lines[5] = 1
+	report.File("/app/main_test.go").Lines[5] = 1
+
+	filtered := report.Filter(MapPath("/app", ""), Exclude("*_test.go"))
+
+	assert.Equal(t, []string{"main.go"}, filtered.names())
+	assert.Same(t, report, report.Filter(), "no options change nothing")
+}
+
+func TestGlobRegexp(t *testing.T) {
+	var testCases = []struct {
+		pattern string
+		name    string
+		want    bool
+	}{
+		{pattern: "**/*_test.go", name: "main_test.go", want: true},
+		{pattern: "**/*_test.go", name: "pkg/even_test.go", want: true},
+		{pattern: "**/*_test.go", name: "pkg/even.go", want: false},
+		{pattern: "**/zz_generated*.go", name: "api/v1/zz_generated.deepcopy.go", want: true},
+		{pattern: "vendor/**", name: "vendor/github.com/lib/lib.go", want: true},
+		{pattern: "vendor/**", name: "pkg/vendor/lib.go", want: false},
+		{pattern: "*.go", name: "main.go", want: true},
+		{pattern: "*.go", name: "pkg/even.go", want: false},
+		{pattern: "pkg/?ven.go", name: "pkg/even.go", want: true},
+		{pattern: "cmd/**/*.go", name: "cmd/main.go", want: true},
+		{pattern: "cmd/**/*.go", name: "cmd/tool/sub/main.go", want: true},
+		{pattern: "a+b.go", name: "a+b.go", want: true},
+		{pattern: "a+b.go", name: "aab.go", want: false},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.pattern+" "+tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, globRegexp(tt.pattern).MatchString(tt.name))
+		})
+	}
+}
+
+func TestFilters(t *testing.T) {
+	report := New()
+	for _, name := range []string{"/app/main.go", "/app/main_test.go", "/app/pkg/even.go", "/app/vendor/lib/lib.go", "/app/api/zz_generated.go"} {
+		report.File(name).Lines[1] = 1
+	}
+
+	var testCases = []struct {
+		name string
+		opts []Option
+		want []string
+	}{
+		{name: "none", opts: nil, want: []string{"main.go", "main_test.go", "api/zz_generated.go", "pkg/even.go", "vendor/lib/lib.go"}},
+		{name: "exclude", opts: []Option{Exclude("**/*_test.go", "vendor/**", "**/zz_generated*.go")}, want: []string{"main.go", "pkg/even.go"}},
+		{name: "include", opts: []Option{Include("pkg/**")}, want: []string{"pkg/even.go"}},
+		{name: "include and exclude", opts: []Option{Include("*.go"), Exclude("*_test.go")}, want: []string{"main.go"}},
+		{name: "regexp", opts: []Option{IncludeRegexp(regexp.MustCompile(`^(pkg|api)/`)), ExcludeRegexp(regexp.MustCompile(`zz_`))}, want: []string{"pkg/even.go"}},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			o := newOptions(append([]Option{MapPath("/app", "")}, tt.opts...))
+			assert.ElementsMatch(t, tt.want, o.apply(report).names())
+		})
+	}
+}
+
+func TestGenerated(t *testing.T) {
+	var testCases = []struct {
+		name string
+		file string
+		src  string
+		want bool
+	}{
+		{name: "generated", file: "api.pb.go", src: "// Code generated by protoc-gen-go. DO NOT EDIT.\n\npackage api\n", want: true},
+		{name: "after license", file: "zz.go", src: "// Copyright\n\n// Code generated by deepcopy-gen. DO NOT EDIT.\r\n\npackage api\n", want: true},
+		{name: "handwritten", file: "main.go", src: "package main\n", want: false},
+		{name: "after package clause", file: "main.go", src: "package main\n\n// Code generated by hand. DO NOT EDIT.\n", want: false},
+		{name: "not go", file: "main.js", src: "// Code generated by webpack. DO NOT EDIT.\n", want: false},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, generated(tt.file, []byte(tt.src)))
+		})
+	}
+}
+
+func TestJobExcludeGenerated(t *testing.T) {
+	report := New()
+	report.File("main.go").Lines[5] = 1
+	report.File("api/api.pb.go").Lines[5] = 1
+
+	job, err := report.Job("testdata/project", ExcludeGenerated())
+
+	assert.Nil(t, err)
+	assert.Len(t, job.SourceFiles, 1)
+	assert.Equal(t, "main.go", job.SourceFiles[0].Name)
+}
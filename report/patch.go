@@ -0,0 +1,166 @@
+/*
+Copyright (c) 2020 Loadsmart, Inc.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package report
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Diff holds the lines added or changed by a diff, by the name of the file
+// after the change. Lines are numbered from 1.
+type Diff map[string][]int
+
+// ParseDiff parses a unified diff, e.g. the output of git diff, returning
+// the lines it adds or changes. Deleted files are left out.
+func ParseDiff(r io.Reader) (Diff, error) {
+	diff := make(Diff)
+	var name string
+	var line, oldRemaining, newRemaining int
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 1024*1024)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		text := scanner.Text()
+
+		if oldRemaining > 0 || newRemaining > 0 {
+			switch {
+			case strings.HasPrefix(text, "+"):
+				if name != "" {
+					diff[name] = append(diff[name], line)
+				}
+				line++
+				newRemaining--
+			case strings.HasPrefix(text, "-"):
+				oldRemaining--
+			case strings.HasPrefix(text, " "), text == "":
+				line++
+				oldRemaining--
+				newRemaining--
+			case strings.HasPrefix(text, `\`):
+				// No newline at end of file
+			default:
+				return nil, fmt.Errorf("invalid diff: line %d: %q", lineNumber, text)
+			}
+			continue
+		}
+
+		switch {
+		case strings.HasPrefix(text, "+++ "):
+			name = diffName(text[len("+++ "):])
+		case strings.HasPrefix(text, "@@ "):
+			var err error
+			line, oldRemaining, newRemaining, err = parseHunkHeader(text)
+			if err != nil {
+				return nil, fmt.Errorf("invalid diff: line %d: %q", lineNumber, text)
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("reading diff: %w", err)
+	}
+	return diff, nil
+}
+
+// diffName returns the name of a file in the +++ header of a diff, or "" if
+// the file was deleted
+func diffName(header string) string {
+	if i := strings.Index(header, "\t"); i >= 0 {
+		header = header[:i]
+	}
+	if header == "/dev/null" {
+		return ""
+	}
+	return strings.TrimPrefix(header, "b/")
+}
+
+// parseHunkHeader parses a header like @@ -1,3 +1,4 @@, returning the first
+// line of the hunk in the new file and the number of lines of the hunk in
+// the old and new files
+func parseHunkHeader(header string) (start int, oldLines int, newLines int, err error) {
+	fields := strings.Fields(header)
+	if len(fields) < 4 || !strings.HasPrefix(fields[1], "-") || !strings.HasPrefix(fields[2], "+") {
+		return 0, 0, 0, fmt.Errorf("invalid hunk header %q", header)
+	}
+	if _, oldLines, err = parseRange(fields[1][1:]); err != nil {
+		return 0, 0, 0, err
+	}
+	if start, newLines, err = parseRange(fields[2][1:]); err != nil {
+		return 0, 0, 0, err
+	}
+	return start, oldLines, newLines, nil
+}
+
+// parseRange parses a range of a hunk header like 1,3, where the number of
+// lines defaults to 1
+func parseRange(s string) (start int, lines int, err error) {
+	lines = 1
+	if i := strings.Index(s, ","); i >= 0 {
+		if lines, err = strconv.Atoi(s[i+1:]); err != nil {
+			return 0, 0, err
+		}
+		s = s[:i]
+	}
+	start, err = strconv.Atoi(s)
+	return start, lines, err
+}
+
+// Patch returns the coverage of the lines changed by d: a report with only
+// the relevant lines of r that d adds or changes. Its Coverage is the
+// coverage of the patch, and the UncoveredLines of its files the changed
+// lines no test runs.
+func (r *Report) Patch(d Diff) *Report {
+	patch := New()
+	for name, lines := range d {
+		f, ok := r.Files[name]
+		if !ok {
+			continue
+		}
+		for _, line := range lines {
+			hits, ok := f.Lines[line]
+			if !ok {
+				continue
+			}
+			patch.File(name).Lines[line] = hits
+		}
+	}
+	return patch
+}
+
+// UncoveredLines returns the relevant lines of the file without hits, sorted
+func (f *File) UncoveredLines() []int {
+	var lines []int
+	for line, hits := range f.Lines {
+		if hits == 0 {
+			lines = append(lines, line)
+		}
+	}
+	sort.Ints(lines)
+	return lines
+}
@@ -0,0 +1,106 @@
+/*
+Copyright (c) 2020 Loadsmart, Inc.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package report
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const unifiedDiff = `diff --git a/main.go b/main.go
+index 3b18e51..a5c1966 100644
+--- a/main.go
++++ b/main.go
+@@ -3,2 +3,3 @@ import "fmt"
+ func main() {
+-	fmt.Println("hello")
++	fmt.Println("hello,")
++	fmt.Println("world")
+@@ -10 +11,0 @@ func other() {
+-	return
+diff --git a/old.go b/old.go
+deleted file mode 100644
+--- a/old.go
++++ /dev/null
+@@ -1,2 +0,0 @@
+-package main
+-
+diff --git a/pkg/even.go b/pkg/even.go
+new file mode 100644
+--- /dev/null
++++ b/pkg/even.go
+@@ -0,0 +1,3 @@
++package pkg
++
++++counter
+\ No newline at end of file
+`
+
+func TestParseDiff(t *testing.T) {
+	diff, err := ParseDiff(strings.NewReader(unifiedDiff))
+
+	assert.Nil(t, err)
+	assert.Equal(t, Diff{
+		"main.go":     {4, 5},
+		"pkg/even.go": {1, 2, 3},
+	}, diff)
+}
+
+func TestParseDiffInvalid(t *testing.T) {
+	tests := []struct {
+		name string
+		diff string
+	}{
+		{name: "hunk header", diff: "+++ b/main.go\n@@ -1 +a @@\n"},
+		{name: "hunk line", diff: "+++ b/main.go\n@@ -1 +1 @@\n?main\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseDiff(strings.NewReader(tt.diff))
+			assert.NotNil(t, err)
+		})
+	}
+}
+
+func TestPatch(t *testing.T) {
+	r := New()
+	r.File("main.go").Lines[3] = 1
+	r.File("main.go").Lines[4] = 2
+	r.File("main.go").Lines[5] = 0
+	r.File("pkg/even.go").Lines[1] = 0
+
+	patch := r.Patch(Diff{
+		"main.go":    {2, 4, 5},
+		"README.md":  {1},
+		"pkg/odd.go": {1},
+	})
+
+	assert.Equal(t, &Report{Files: map[string]*File{
+		"main.go": {Name: "main.go", Lines: map[int]int{4: 2, 5: 0}},
+	}}, patch)
+	assert.Equal(t, 50.0, patch.Coverage())
+	assert.Equal(t, []int{5}, patch.Files["main.go"].UncoveredLines())
+}
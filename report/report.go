@@ -0,0 +1,294 @@
+/*
+Copyright (c) 2020 Loadsmart, Inc.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+// Package report builds Coveralls jobs from coverage reports: profiles
+// written by go test -coverprofile, LCOV tracefiles, Istanbul and SimpleCov
+// JSON reports and Cobertura (including coverage.py), Clover and JaCoCo XML
+// reports. All of them are parsed into a Report.
+package report
+
+import (
+	"bytes"
+	"crypto/md5"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	coveralls "github.com/stone-payments/go-coveralls-api"
+)
+
+// Report holds the coverage of a set of source files
+type Report struct {
+	Files map[string]*File // Files by name
+}
+
+// File holds the coverage of a single source file
+type File struct {
+	Name     string      `json:"name"`               // Path of the file, relative to the repository root
+	Lines    map[int]int `json:"lines"`              // Hits of each relevant line, numbered from 1
+	Branches []Branch    `json:"branches,omitempty"` // Hits of each branch, if the report has branch coverage
+}
+
+// Branch holds the hits of one of the branches of a line, e.g. the then
+// and else branches of an if. Block tells apart conditions of the same line.
+type Branch struct {
+	Line   int `json:"line"`
+	Block  int `json:"block"`
+	Branch int `json:"branch"`
+	Hits   int `json:"hits"`
+}
+
+// AddBranch adds hits to a branch of the file
+func (f *File) AddBranch(line int, block int, branch int, hits int) {
+	for i, b := range f.Branches {
+		if b.Line == line && b.Block == block && b.Branch == branch {
+			f.Branches[i].Hits += hits
+			return
+		}
+	}
+	f.Branches = append(f.Branches, Branch{Line: line, Block: block, Branch: branch, Hits: hits})
+}
+
+// New returns an empty Report
+func New() *Report {
+	return &Report{Files: make(map[string]*File)}
+}
+
+// File returns the coverage of the file with the given name, adding it to
+// the report if it has none yet
+func (r *Report) File(name string) *File {
+	f, ok := r.Files[name]
+	if !ok {
+		f = &File{Name: name, Lines: make(map[int]int)}
+		r.Files[name] = f
+	}
+	return f
+}
+
+// names returns the names of the files in the report, sorted
+func (r *Report) names() []string {
+	names := make([]string, 0, len(r.Files))
+	for name := range r.Files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// SourceFiles converts the report into the source files of a Coveralls job.
+// Each file is read from root, the root of the repository, to compute its
+// digest and number of lines. Opts change how, e.g. renaming files with
+// MapPath.
+//
+// Files are read concurrently, see Concurrency. To send large jobs without
+// encoding them in memory, use JobsService.SubmitMultipart.
+func (r *Report) SourceFiles(root string, opts ...Option) ([]*coveralls.SourceFile, error) {
+	o := newOptions(opts)
+	r = o.apply(r)
+	names := r.names()
+
+	files := make([]*coveralls.SourceFile, len(names))
+	errs := make([]error, len(names))
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < o.workers(len(names)); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				files[i], errs[i] = o.sourceFile(root, r.Files[names[i]])
+			}
+		}()
+	}
+	for i := range names {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+
+	result := make([]*coveralls.SourceFile, 0, len(files))
+	for i, f := range files {
+		if errs[i] != nil {
+			return nil, errs[i]
+		}
+		if f != nil {
+			result = append(result, f)
+		}
+	}
+	return result, nil
+}
+
+// Job returns a Coveralls job with the coverage of the report, reading
+// source files from root as SourceFiles does. Callers fill in the fields
+// that identify the repository and the build, e.g. RepoToken.
+func (r *Report) Job(root string, opts ...Option) (*coveralls.Job, error) {
+	files, err := r.SourceFiles(root, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &coveralls.Job{SourceFiles: files}, nil
+}
+
+// sourceFile reads f from root and converts its coverage into a Coveralls
+// source file. It returns nil if the file is left out.
+func (o *options) sourceFile(root string, f *File) (*coveralls.SourceFile, error) {
+	src, err := ioutil.ReadFile(filepath.Join(root, filepath.FromSlash(f.Name)))
+	if err != nil {
+		return nil, fmt.Errorf("reading source file: %w", err)
+	}
+	if o.excludeGenerated && generated(f.Name, src) {
+		return nil, nil
+	}
+	return f.sourceFile(src), nil
+}
+
+// sourceFile converts the coverage of f into a Coveralls source file, given
+// the contents of the file
+func (f *File) sourceFile(src []byte) *coveralls.SourceFile {
+	digest := md5.Sum(src)
+	coverage := make([]*int, lineCount(src))
+	for line, hits := range f.Lines {
+		if line < 1 || line > len(coverage) {
+			continue
+		}
+		hits := hits
+		coverage[line-1] = &hits
+	}
+	return &coveralls.SourceFile{
+		Name:         f.Name,
+		SourceDigest: hex.EncodeToString(digest[:]),
+		Coverage:     coverage,
+		Branches:     f.branches(len(coverage)),
+	}
+}
+
+// branches returns the branch coverage of f in the format of Coveralls,
+// skipping lines beyond lines, the number of lines of the file
+func (f *File) branches(lines int) []int {
+	sorted := make([]Branch, 0, len(f.Branches))
+	for _, b := range f.Branches {
+		if b.Line >= 1 && b.Line <= lines {
+			sorted = append(sorted, b)
+		}
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		a, b := sorted[i], sorted[j]
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+		if a.Block != b.Block {
+			return a.Block < b.Block
+		}
+		return a.Branch < b.Branch
+	})
+
+	var branches []int
+	for _, b := range sorted {
+		branches = append(branches, b.Line, b.Block, b.Branch, b.Hits)
+	}
+	return branches
+}
+
+// lineCount returns the number of lines of src
+func lineCount(src []byte) int {
+	if len(src) == 0 {
+		return 0
+	}
+	n := bytes.Count(src, []byte("\n"))
+	if src[len(src)-1] != '\n' {
+		n++
+	}
+	return n
+}
+
+// setBranch sets the hits of a branch of file to the most found in a report
+// that lists it more than once
+func setBranch(file *File, line int, block int, branch int, hits int) {
+	for i, b := range file.Branches {
+		if b.Line == line && b.Block == block && b.Branch == branch {
+			if hits > b.Hits {
+				file.Branches[i].Hits = hits
+			}
+			return
+		}
+	}
+	file.Branches = append(file.Branches, Branch{Line: line, Block: block, Branch: branch, Hits: hits})
+}
+
+// setCoveredBranches sets the branches of a line for formats that only
+// count how many of them were taken: the first covered ones get one hit,
+// the others none
+func setCoveredBranches(file *File, line int, covered int, total int) {
+	for branch := 0; branch < total; branch++ {
+		hits := 0
+		if branch < covered {
+			hits = 1
+		}
+		setBranch(file, line, 0, branch, hits)
+	}
+}
+
+// Covered returns how many relevant lines the file has and how many of them
+// were covered
+func (f *File) Covered() (covered int, relevant int) {
+	for _, hits := range f.Lines {
+		if hits > 0 {
+			covered++
+		}
+	}
+	return covered, len(f.Lines)
+}
+
+// Coverage returns the percentage of relevant lines of the file that were
+// covered, between 0 and 100. It is 0 if the file has no relevant lines.
+func (f *File) Coverage() float64 {
+	return percentage(f.Covered())
+}
+
+// Covered returns how many relevant lines the files of the report have and
+// how many of them were covered
+func (r *Report) Covered() (covered int, relevant int) {
+	for _, f := range r.Files {
+		c, n := f.Covered()
+		covered += c
+		relevant += n
+	}
+	return covered, relevant
+}
+
+// Coverage returns the percentage of relevant lines of the report that
+// were covered, between 0 and 100, as Coveralls computes it. It is 0 if
+// the report has no relevant lines.
+func (r *Report) Coverage() float64 {
+	return percentage(r.Covered())
+}
+
+// percentage returns covered as a percentage of relevant
+func percentage(covered int, relevant int) float64 {
+	if relevant == 0 {
+		return 0
+	}
+	return float64(covered) * 100 / float64(relevant)
+}
@@ -0,0 +1,137 @@
+/*
+Copyright (c) 2020 Loadsmart, Inc.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package report
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	coveralls "github.com/stone-payments/go-coveralls-api"
+	"github.com/stretchr/testify/assert"
+)
+
+func hits(n int) *int {
+	return &n
+}
+
+func TestReportJob(t *testing.T) {
+	report := New()
+	report.File("main.go").Lines[6] = 1
+	report.File("main.go").Lines[7] = 0
+	report.File("main.go").Lines[42] = 1 // Beyond the end of the file
+	report.File("pkg/even.go").Lines[4] = 3
+
+	job, err := report.Job("testdata/project")
+
+	assert.Nil(t, err)
+	assert.Equal(t, &coveralls.Job{SourceFiles: []*coveralls.SourceFile{
+		{
+			Name:         "main.go",
+			SourceDigest: "46d1dccdd8ca8bfed535105df9f338c8",
+			Coverage:     []*int{nil, nil, nil, nil, nil, hits(1), hits(0), nil, nil},
+		},
+		{
+			Name:         "pkg/even.go",
+			SourceDigest: "22c437b7b1aa1a50413cef6968a74840",
+			Coverage:     []*int{nil, nil, nil, hits(3), nil, nil, nil, nil, nil},
+		},
+	}}, job)
+}
+
+func TestReportJobMissingFile(t *testing.T) {
+	report := New()
+	report.File("missing.go").Lines[1] = 1
+
+	_, err := report.Job("testdata/project")
+
+	assert.NotNil(t, err)
+}
+
+func TestLineCount(t *testing.T) {
+	assert.Equal(t, 0, lineCount(nil))
+	assert.Equal(t, 1, lineCount([]byte("a")))
+	assert.Equal(t, 1, lineCount([]byte("a\n")))
+	assert.Equal(t, 2, lineCount([]byte("a\nb")))
+}
+
+func TestFileAddBranch(t *testing.T) {
+	f := New().File("main.go")
+
+	f.AddBranch(3, 0, 1, 2)
+	f.AddBranch(3, 0, 0, 1)
+	f.AddBranch(3, 0, 1, 1)
+
+	assert.Equal(t, []Branch{{Line: 3, Block: 0, Branch: 1, Hits: 3}, {Line: 3, Block: 0, Branch: 0, Hits: 1}}, f.Branches)
+	assert.Equal(t, []int{3, 0, 0, 1, 3, 0, 1, 3}, f.branches(3))
+	assert.Nil(t, f.branches(2))
+}
+
+func TestReportJobConcurrency(t *testing.T) {
+	root := t.TempDir()
+	report := New()
+	for i := 0; i < 50; i++ {
+		name := fmt.Sprintf("file%02d.go", i)
+		assert.Nil(t, os.WriteFile(filepath.Join(root, name), []byte(strings.Repeat("line\n", i+1)), 0o644))
+		report.File(name).Lines[i+1] = i
+	}
+
+	want, err := report.SourceFiles(root, Concurrency(1))
+	assert.Nil(t, err)
+	assert.Len(t, want, 50)
+
+	for _, n := range []int{0, 4, 100} {
+		files, err := report.SourceFiles(root, Concurrency(n))
+		assert.Nil(t, err)
+		assert.Equal(t, want, files, "concurrency %d", n)
+	}
+
+	report.File("missing.go").Lines[1] = 1
+	_, err = report.SourceFiles(root, Concurrency(4))
+	assert.NotNil(t, err)
+}
+
+func TestWorkers(t *testing.T) {
+	assert.Equal(t, 2, newOptions([]Option{Concurrency(2)}).workers(10))
+	assert.Equal(t, 3, newOptions([]Option{Concurrency(8)}).workers(3))
+	assert.Equal(t, 0, newOptions(nil).workers(0))
+	assert.True(t, newOptions(nil).workers(1000) >= 1)
+}
+
+func TestCoverage(t *testing.T) {
+	report := New()
+	assert.Equal(t, 0.0, report.Coverage())
+
+	report.File("main.go").Lines = map[int]int{1: 1, 2: 0, 3: 5, 4: 0}
+	report.File("empty.go")
+	report.File("pkg/even.go").Lines = map[int]int{1: 1}
+
+	covered, relevant := report.Covered()
+	assert.Equal(t, 3, covered)
+	assert.Equal(t, 5, relevant)
+	assert.Equal(t, 60.0, report.Coverage())
+	assert.Equal(t, 50.0, report.Files["main.go"].Coverage())
+	assert.Equal(t, 0.0, report.Files["empty.go"].Coverage())
+}
@@ -0,0 +1,70 @@
+/*
+Copyright (c) 2020 Loadsmart, Inc.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// savedVersion is the version of the format written by Save
+const savedVersion = 1
+
+// savedReport is a Report as written by Save, with its files sorted by name
+type savedReport struct {
+	Version int     `json:"version"`
+	Files   []*File `json:"files"`
+}
+
+// Save writes the report to w as JSON, to be read back with Load, e.g. to
+// pass the reports of CI stages as artifacts to a final stage that merges
+// and submits them
+func (r *Report) Save(w io.Writer) error {
+	saved := savedReport{Version: savedVersion, Files: make([]*File, 0, len(r.Files))}
+	for _, name := range r.names() {
+		saved.Files = append(saved.Files, r.Files[name])
+	}
+	return json.NewEncoder(w).Encode(&saved)
+}
+
+// Load reads a report written by Save. Hits of files found more than once
+// add up, as in Merge.
+func Load(r io.Reader) (*Report, error) {
+	var saved savedReport
+	if err := json.NewDecoder(r).Decode(&saved); err != nil {
+		return nil, fmt.Errorf("invalid saved report: %w", err)
+	}
+	if saved.Version != savedVersion {
+		return nil, fmt.Errorf("invalid saved report: unsupported version %d", saved.Version)
+	}
+
+	report := New()
+	for i, f := range saved.Files {
+		if f == nil || f.Name == "" {
+			return nil, fmt.Errorf("invalid saved report: file %d has no name", i)
+		}
+		report.add(f.Name, f)
+	}
+	return report, nil
+}
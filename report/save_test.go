@@ -0,0 +1,83 @@
+/*
+Copyright (c) 2020 Loadsmart, Inc.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package report
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSaveLoad(t *testing.T) {
+	r := New()
+	r.File("main.go").Lines[5] = 1
+	r.File("main.go").Lines[6] = 0
+	r.File("main.go").AddBranch(6, 0, 0, 1)
+	r.File("main.go").AddBranch(6, 0, 1, 0)
+	r.File("pkg/even.go").Lines[4] = 3
+	r.File("empty.go")
+
+	var buf bytes.Buffer
+	assert.Nil(t, r.Save(&buf))
+	assert.Equal(t, `{"version":1,"files":[`+
+		`{"name":"empty.go","lines":{}},`+
+		`{"name":"main.go","lines":{"5":1,"6":0},"branches":[{"line":6,"block":0,"branch":0,"hits":1},{"line":6,"block":0,"branch":1,"hits":0}]},`+
+		`{"name":"pkg/even.go","lines":{"4":3}}]}`+"\n", buf.String())
+
+	loaded, err := Load(&buf)
+
+	assert.Nil(t, err)
+	assert.Equal(t, r, loaded)
+}
+
+func TestLoadDuplicates(t *testing.T) {
+	saved := `{"version":1,"files":[{"name":"main.go","lines":{"5":1}},{"name":"main.go","lines":{"5":2,"6":0}}]}`
+
+	loaded, err := Load(strings.NewReader(saved))
+
+	assert.Nil(t, err)
+	assert.Equal(t, map[int]int{5: 3, 6: 0}, loaded.Files["main.go"].Lines)
+}
+
+func TestLoadInvalid(t *testing.T) {
+	var testCases = []struct {
+		name  string
+		saved string
+	}{
+		{name: "not JSON", saved: "mode: set"},
+		{name: "no version", saved: `{"files":[]}`},
+		{name: "unsupported version", saved: `{"version":2,"files":[]}`},
+		{name: "no name", saved: `{"version":1,"files":[{"lines":{"5":1}}]}`},
+		{name: "null file", saved: `{"version":1,"files":[null]}`},
+		{name: "invalid line", saved: `{"version":1,"files":[{"name":"main.go","lines":{"five":1}}]}`},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Load(strings.NewReader(tt.saved))
+			assert.NotNil(t, err)
+		})
+	}
+}
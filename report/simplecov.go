@@ -0,0 +1,141 @@
+/*
+Copyright (c) 2020 Loadsmart, Inc.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// simpleCovFile is the coverage of a file in a SimpleCov result set. Older
+// versions of SimpleCov only write the hits of each line, as an array.
+type simpleCovFile struct {
+	Lines    []interface{}             `json:"lines"`    // Hits of each line: a number, null or "ignored"
+	Branches map[string]map[string]int `json:"branches"` // Hits of each branch of each condition
+}
+
+// ParseSimpleCov parses the .resultset.json written by SimpleCov for Ruby
+// projects. Hits of the results of different test suites, e.g. RSpec and
+// Minitest, add up.
+//
+// Each condition is a block of the branches of its first line, numbered by
+// the id SimpleCov gives it. Absolute paths under root, the root of the
+// repository, are made relative to it, as ParseLCOV does.
+func ParseSimpleCov(r io.Reader, root string) (*Report, error) {
+	var doc map[string]struct {
+		Coverage map[string]json.RawMessage `json:"coverage"`
+	}
+	if err := json.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("invalid SimpleCov result set: %w", err)
+	}
+
+	report := New()
+	for _, result := range doc {
+		for name, raw := range result.Coverage {
+			var entry simpleCovFile
+			if strings.HasPrefix(strings.TrimSpace(string(raw)), "[") {
+				if err := json.Unmarshal(raw, &entry.Lines); err != nil {
+					return nil, fmt.Errorf("invalid SimpleCov result set: %s: %w", name, err)
+				}
+			} else if err := json.Unmarshal(raw, &entry); err != nil {
+				return nil, fmt.Errorf("invalid SimpleCov result set: %s: %w", name, err)
+			}
+
+			file := report.File(relativeName(name, root))
+			for i, hits := range entry.Lines {
+				if n, ok := hits.(float64); ok {
+					file.Lines[i+1] += int(n)
+				}
+			}
+			if err := addSimpleCovBranches(file, entry.Branches); err != nil {
+				return nil, fmt.Errorf("invalid SimpleCov result set: %s: %w", name, err)
+			}
+		}
+	}
+	return report, nil
+}
+
+// addSimpleCovBranches adds the hits of the branches of each condition to
+// file. Conditions and branches are keyed by their description, e.g.
+// [:if, 0, 5, 4, 9, 7] for an if with id 0 spanning from line 5, column 4
+// to line 9, column 7.
+func addSimpleCovBranches(file *File, conditions map[string]map[string]int) error {
+	keys := make([]string, 0, len(conditions))
+	for condition := range conditions {
+		keys = append(keys, condition)
+	}
+	if err := sortByID(keys); err != nil {
+		return err
+	}
+	for _, condition := range keys {
+		id, line, _ := parseSimpleCovBranch(condition)
+		branches := make([]string, 0, len(conditions[condition]))
+		for branch := range conditions[condition] {
+			branches = append(branches, branch)
+		}
+		if err := sortByID(branches); err != nil {
+			return err
+		}
+		for i, branch := range branches {
+			file.AddBranch(line, id, i, conditions[condition][branch])
+		}
+	}
+	return nil
+}
+
+// sortByID sorts the keys of conditions or branches of a SimpleCov result
+// set by id
+func sortByID(keys []string) error {
+	ids := make(map[string]int, len(keys))
+	for _, key := range keys {
+		id, _, err := parseSimpleCovBranch(key)
+		if err != nil {
+			return err
+		}
+		ids[key] = id
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return ids[keys[i]] < ids[keys[j]]
+	})
+	return nil
+}
+
+// parseSimpleCovBranch returns the id and first line of a condition or
+// branch of a SimpleCov result set
+func parseSimpleCovBranch(key string) (id int, line int, err error) {
+	fields := strings.Split(strings.Trim(key, "[]"), ",")
+	if len(fields) < 3 {
+		return 0, 0, fmt.Errorf("invalid branch %q", key)
+	}
+	if id, err = strconv.Atoi(strings.TrimSpace(fields[1])); err != nil {
+		return 0, 0, fmt.Errorf("invalid branch %q", key)
+	}
+	if line, err = strconv.Atoi(strings.TrimSpace(fields[2])); err != nil {
+		return 0, 0, fmt.Errorf("invalid branch %q", key)
+	}
+	return id, line, nil
+}
@@ -0,0 +1,100 @@
+/*
+Copyright (c) 2020 Loadsmart, Inc.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package report
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const simpleCovJSON = `{
+	"RSpec": {
+		"coverage": {
+			"%[1]s/lib/even.rb": {
+				"lines": [null, 3, 3, 2, "ignored", 0, null],
+				"branches": {
+					"[:if, 1, 3, 4, 7, 7]": {
+						"[:else, 3, 6, 6, 6, 11]": 0,
+						"[:then, 2, 4, 6, 4, 10]": 2
+					},
+					"[:&, 0, 2, 4, 2, 20]": {
+						"[:then, 1, 2, 4, 2, 20]": 1,
+						"[:else, 0, 2, 4, 2, 20]": 2
+					}
+				}
+			}
+		},
+		"timestamp": 1650000000
+	},
+	"Minitest": {
+		"coverage": {
+			"%[1]s/lib/even.rb": [null, 1, 1, 0, null, 1, null]
+		},
+		"timestamp": 1650000000
+	}
+}`
+
+func TestParseSimpleCov(t *testing.T) {
+	root, _ := filepath.Abs("testdata/project")
+	doc := fmt.Sprintf(simpleCovJSON, filepath.ToSlash(root))
+
+	report, err := ParseSimpleCov(strings.NewReader(doc), "testdata/project")
+
+	assert.Nil(t, err)
+	assert.Equal(t, &Report{Files: map[string]*File{
+		"lib/even.rb": {
+			Name:  "lib/even.rb",
+			Lines: map[int]int{2: 4, 3: 4, 4: 2, 6: 1},
+			Branches: []Branch{
+				{Line: 2, Block: 0, Branch: 0, Hits: 2},
+				{Line: 2, Block: 0, Branch: 1, Hits: 1},
+				{Line: 3, Block: 1, Branch: 0, Hits: 2},
+				{Line: 3, Block: 1, Branch: 1, Hits: 0},
+			},
+		},
+	}}, report)
+}
+
+func TestParseSimpleCovInvalid(t *testing.T) {
+	var testCases = []struct {
+		name string
+		doc  string
+	}{
+		{name: "not JSON", doc: "SF:main.rb"},
+		{name: "invalid coverage", doc: `{"RSpec": {"coverage": {"main.rb": "lines"}}}`},
+		{name: "invalid file", doc: `{"RSpec": {"coverage": {"main.rb": {"lines": 1}}}}`},
+		{name: "invalid condition", doc: `{"RSpec": {"coverage": {"main.rb": {"branches": {"[:if]": {}}}}}}`},
+		{name: "invalid branch", doc: `{"RSpec": {"coverage": {"main.rb": {"branches": {"[:if, 0, 1, 0, 1, 5]": {"[:then, x, 1, 0, 1, 5]": 1}}}}}}`},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseSimpleCov(strings.NewReader(tt.doc), "")
+			assert.NotNil(t, err)
+		})
+	}
+}
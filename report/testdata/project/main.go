@@ -0,0 +1,9 @@
+package main
+
+import "example.com/project/pkg"
+
+func main() {
+	if pkg.Even(2) {
+		println("even")
+	}
+}
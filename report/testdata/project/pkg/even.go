@@ -0,0 +1,9 @@
+package pkg
+
+// Even reports whether n is even
+func Even(n int) bool {
+	if n%2 == 0 {
+		return true
+	}
+	return false
+}
@@ -0,0 +1,10 @@
+package com.example;
+
+public class Even {
+    public static boolean even(int n) {
+        if (n % 2 == 0) {
+            return true;
+        }
+        return false;
+    }
+}
@@ -24,6 +24,8 @@ package coveralls
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"strings"
@@ -44,18 +46,57 @@ var (
 //
 // Its error message string includes the full body from the response.
 // That includes some error in the RepositoryConfig spec, but may include other conditions.
+// When the body is JSON, Message and FieldErrors hold the parts of it that
+// tell what was rejected.
 type ErrUnprocessableEntity struct {
-	ErrorBody string
+	ErrorBody   string
+	Message     string              // Message of the error, if any
+	FieldErrors map[string][]string // Validation errors of each rejected field, e.g. {"name": ["has already been taken"]}
 }
 
 func (e ErrUnprocessableEntity) Error() string {
 	return fmt.Sprintf("unprocessable entity (status code %d). Error body: '%s'", http.StatusUnprocessableEntity, e.ErrorBody)
 }
 
+// Is reports whether target is an ErrUnprocessableEntity with the same body,
+// as FieldErrors makes ErrUnprocessableEntity values not comparable with ==
+func (e ErrUnprocessableEntity) Is(target error) bool {
+	t, ok := target.(ErrUnprocessableEntity)
+	return ok && t.ErrorBody == e.ErrorBody
+}
+
 func newErrUnprocessableEntity(errorBody string) ErrUnprocessableEntity {
-	return ErrUnprocessableEntity{
+	e := ErrUnprocessableEntity{
 		ErrorBody: errorBody,
 	}
+
+	var body map[string]json.RawMessage
+	if json.Unmarshal([]byte(errorBody), &body) != nil {
+		return e
+	}
+	if raw, ok := body["message"]; ok {
+		_ = json.Unmarshal(raw, &e.Message)
+	}
+
+	// Validation errors come either in an errors object or at the top level
+	fields := body
+	if raw, ok := body["errors"]; ok {
+		var nested map[string]json.RawMessage
+		if json.Unmarshal(raw, &nested) == nil {
+			fields = nested
+		}
+	}
+	for name, raw := range fields {
+		var messages []string
+		if json.Unmarshal(raw, &messages) != nil || len(messages) == 0 {
+			continue
+		}
+		if e.FieldErrors == nil {
+			e.FieldErrors = make(map[string][]string)
+		}
+		e.FieldErrors[name] = messages
+	}
+	return e
 }
 
 // ErrUnexpectedStatusCode is returned when we receive an unexpected status code, not
@@ -69,6 +110,12 @@ func (e ErrUnexpectedStatusCode) Error() string {
 	return fmt.Sprintf("super unexpected status code %d. Error body: '%s'", e.StatusCode, e.ErrorBody)
 }
 
+// Retryable reports whether the status code is a transient error, i.e.
+// 408 Request Timeout or a 5xx
+func (e ErrUnexpectedStatusCode) Retryable() bool {
+	return e.StatusCode == http.StatusRequestTimeout || e.StatusCode >= http.StatusInternalServerError
+}
+
 func newErrUnexpectedStatusCode(c int, b string) ErrUnexpectedStatusCode {
 	return ErrUnexpectedStatusCode{
 		StatusCode: c,
@@ -78,9 +125,14 @@ func newErrUnexpectedStatusCode(c int, b string) ErrUnexpectedStatusCode {
 
 // RepositoryService holds information to access repository-related endpoints
 type RepositoryService interface {
-	Get(ctx context.Context, svc string, repo string) (*Repository, error)
-	Add(ctx context.Context, data *RepositoryConfig) (*RepositoryConfig, error)
-	Update(ctx context.Context, svc string, repo string, data *RepositoryConfig) (*RepositoryConfig, error)
+	Get(ctx context.Context, svc string, repo string, opts ...CallOption) (*Repository, error)
+	Add(ctx context.Context, data *RepositoryConfig, opts ...CallOption) (*Repository, error)
+	Update(ctx context.Context, svc string, repo string, data *RepositoryConfig, opts ...CallOption) (*Repository, error)
+	List(ctx context.Context, opts *ListOptions, callOpts ...CallOption) ([]*Repository, error)
+	Delete(ctx context.Context, svc string, repo string, opts ...CallOption) error
+	Exists(ctx context.Context, svc string, repo string, opts ...CallOption) (bool, error)
+	Ensure(ctx context.Context, data *RepositoryConfig, opts ...CallOption) (*Repository, error)
+	GetByID(ctx context.Context, id int, opts ...CallOption) (*Repository, error)
 }
 
 // RepositoryServiceImpl holds information to access repository-related endpoints
@@ -97,6 +149,9 @@ type Repository struct {
 	CommitStatusFailChangeThreshold *float64 `json:"commit_status_fail_change_threshold,omitempty"` // If coverage decreases, the maximum allowed amount of decrease that will be allowed for the build to pass (default is null, meaning that any decrease is a failure)
 	HasBadge                        bool     `json:"has_badge,omitempty"`
 	Token                           string   `json:"token,omitempty"`
+	CoveredPercent                  *float64 `json:"covered_percent,omitempty"`   // Coverage of the latest build, between 0 and 100 (null if there are no builds)
+	LastBuildNumber                 *int     `json:"last_build_number,omitempty"` // Number of the latest build (null if there are no builds)
+	LastBuildAt                     string   `json:"last_build_at,omitempty"`     // Creation time of the latest build
 	CreatedAt                       string   `json:"created_at,omitempty"`
 	UpdatedAt                       string   `json:"updated_at,omitempty"`
 }
@@ -111,6 +166,26 @@ type RepositoryConfig struct {
 	CommitStatusFailChangeThreshold *float64 `json:"commit_status_fail_change_threshold,omitempty"` // If coverage decreases, the maximum allowed amount of decrease that will be allowed for the build to pass (default is null, meaning that any decrease is a failure)
 }
 
+// Config returns the configuration settings of the repository
+func (r *Repository) Config() *RepositoryConfig {
+	return &RepositoryConfig{
+		Service:                         r.Service,
+		Name:                            r.Name,
+		CommentOnPullRequests:           r.CommentOnPullRequests,
+		SendBuildStatus:                 r.SendBuildStatus,
+		CommitStatusFailThreshold:       r.CommitStatusFailThreshold,
+		CommitStatusFailChangeThreshold: r.CommitStatusFailChangeThreshold,
+	}
+}
+
+// repositoryPage is one page of results returned when listing repositories
+type repositoryPage struct {
+	Page  int           `json:"page"`
+	Pages int           `json:"pages"`
+	Total int           `json:"total"`
+	Repos []*Repository `json:"repos"`
+}
+
 // Get information about a repository already in Coveralls.
 //
 // Ctx is a context that's propagated to underlying client. You can use
@@ -127,13 +202,12 @@ type RepositoryConfig struct {
 // available or an error if there was something wrong.
 //
 // It may return errors ErrRepoNotFound or ErrUnexpectedStatusCode
-func (s RepositoryServiceImpl) Get(ctx context.Context, svc string, repo string) (*Repository, error) {
-	url := fmt.Sprintf("%s/api/repos/%s/%s", s.client.HostURL, svc, repo)
+func (s RepositoryServiceImpl) Get(ctx context.Context, svc string, repo string, opts ...CallOption) (*Repository, error) {
+	endpoint := s.client.apiEndpoint("repos/%s/%s", svc, repo)
 
-	resp, err := s.client.client.R().
-		SetContext(ctx).
+	resp, err := s.client.newRequest(ctx, opts).
 		SetResult(&Repository{}).
-		Get(url)
+		Get(endpoint)
 
 	if err != nil {
 		return nil, err
@@ -141,29 +215,38 @@ func (s RepositoryServiceImpl) Get(ctx context.Context, svc string, repo string)
 
 	switch resp.StatusCode() {
 	case http.StatusOK:
-		return resp.Result().(*Repository), nil
+		repository, ok := resp.Result().(*Repository)
+		if !ok {
+			return nil, resp.decodeFailure(errNoResult)
+		}
+		return repository, nil
 	case http.StatusNotFound:
-		return nil, ErrRepoNotFound
+		return nil, resp.apiError(ErrRepoNotFound)
 	default:
-		return nil, newErrUnexpectedStatusCode(resp.StatusCode(), string(resp.Body()))
+		return nil, newErrFromResponse(resp)
 	}
 }
 
 // Add a repository to Coveralls
 //
+// The Repository returned includes the ID and the repo token assigned by
+// Coveralls, needed to submit coverage jobs.
+//
+// With IgnoreExisting, it returns the existing repository instead of
+// ErrNameIsTaken, so retried creations succeed. Its configuration is left as is.
+//
 // It may return errors ErrNameIsTaken, ErrUnprocessableEntity or ErrUnexpectedStatusCode
-func (s RepositoryServiceImpl) Add(ctx context.Context, data *RepositoryConfig) (*RepositoryConfig, error) {
-	url := fmt.Sprintf("%s/api/repos", s.client.HostURL)
+func (s RepositoryServiceImpl) Add(ctx context.Context, data *RepositoryConfig, opts ...CallOption) (*Repository, error) {
+	endpoint := s.client.apiEndpoint("repos")
 
 	body := map[string]*RepositoryConfig{
 		"repo": data,
 	}
 
-	resp, err := s.client.client.R().
-		SetContext(ctx).
+	resp, err := s.client.newRequest(ctx, opts).
 		SetBody(body).
-		SetResult(&RepositoryConfig{}).
-		Post(url)
+		SetResult(&Repository{}).
+		Post(endpoint)
 
 	if err != nil {
 		return nil, err
@@ -171,15 +254,22 @@ func (s RepositoryServiceImpl) Add(ctx context.Context, data *RepositoryConfig)
 
 	switch resp.StatusCode() {
 	case http.StatusCreated:
-		return resp.Result().(*RepositoryConfig), nil
+		repository, ok := resp.Result().(*Repository)
+		if !ok {
+			return nil, resp.decodeFailure(errNoResult)
+		}
+		return repository, nil
 	case http.StatusUnprocessableEntity:
-		errorBody := string(resp.Body())
+		errorBody := resp.errorBody()
 		if strings.Contains(errorBody, "has already been taken") {
-			return nil, ErrNameIsTaken
+			if newCallSettings(ctx, opts).ignoreExisting {
+				return s.Get(ctx, data.Service, data.Name, opts...)
+			}
+			return nil, resp.apiError(ErrNameIsTaken)
 		}
-		return nil, newErrUnprocessableEntity(errorBody)
+		return nil, resp.apiError(newErrUnprocessableEntity(errorBody))
 	default:
-		return nil, newErrUnexpectedStatusCode(resp.StatusCode(), string(resp.Body()))
+		return nil, newErrFromResponse(resp)
 	}
 
 }
@@ -187,18 +277,17 @@ func (s RepositoryServiceImpl) Add(ctx context.Context, data *RepositoryConfig)
 // Update repository configuration in Coveralls
 //
 // It may return errors ErrRepoNotFound, ErrUnprocessableEntity or ErrUnexpectedStatusCode
-func (s RepositoryServiceImpl) Update(ctx context.Context, svc string, repo string, data *RepositoryConfig) (*RepositoryConfig, error) {
-	url := fmt.Sprintf("%s/api/repos/%s/%s", s.client.HostURL, svc, repo)
+func (s RepositoryServiceImpl) Update(ctx context.Context, svc string, repo string, data *RepositoryConfig, opts ...CallOption) (*Repository, error) {
+	endpoint := s.client.apiEndpoint("repos/%s/%s", svc, repo)
 
 	body := map[string]*RepositoryConfig{
 		"repo": data,
 	}
 
-	resp, err := s.client.client.R().
-		SetContext(ctx).
+	resp, err := s.client.newRequest(ctx, opts).
 		SetBody(body).
-		SetResult(&RepositoryConfig{}).
-		Put(url)
+		SetResult(&Repository{}).
+		Put(endpoint)
 
 	if err != nil {
 		return nil, err
@@ -206,12 +295,154 @@ func (s RepositoryServiceImpl) Update(ctx context.Context, svc string, repo stri
 
 	switch resp.StatusCode() {
 	case http.StatusOK:
-		return resp.Result().(*RepositoryConfig), nil
+		repository, ok := resp.Result().(*Repository)
+		if !ok {
+			return nil, resp.decodeFailure(errNoResult)
+		}
+		return repository, nil
 	case http.StatusNotFound:
-		return nil, ErrRepoNotFound
+		return nil, resp.apiError(ErrRepoNotFound)
 	case http.StatusUnprocessableEntity:
-		return nil, newErrUnprocessableEntity(string(resp.Body()))
+		return nil, resp.apiError(newErrUnprocessableEntity(resp.errorBody()))
+	default:
+		return nil, newErrFromResponse(resp)
+	}
+}
+
+// List all repositories the API token has access to.
+//
+// Results are paginated by the API; List walks through all pages and
+// returns the repositories found in all of them. Opts may be nil to use
+// the API defaults.
+//
+// It may return error ErrUnexpectedStatusCode
+func (s RepositoryServiceImpl) List(ctx context.Context, opts *ListOptions, callOpts ...CallOption) ([]*Repository, error) {
+	endpoint := s.client.apiEndpoint("repos")
+	return listRepositories(ctx, s.client, endpoint, opts, nil, callOpts)
+}
+
+// listRepositories walks through all pages of a repository listing endpoint.
+// NotFound is the error returned on 404 Not Found; when nil, 404 is unexpected.
+func listRepositories(ctx context.Context, c *Client, endpoint string, opts *ListOptions, notFound error, callOpts []CallOption) ([]*Repository, error) {
+	var repos []*Repository
+	for page := 1; ; page++ {
+		resp, err := c.newRequest(ctx, callOpts).
+			SetQueryParams(opts.queryParams(page)).
+			SetResult(&repositoryPage{}).
+			Get(endpoint)
+
+		if err != nil {
+			return nil, err
+		}
+
+		switch {
+		case resp.StatusCode() == http.StatusOK:
+		case resp.StatusCode() == http.StatusNotFound && notFound != nil:
+			return nil, resp.apiError(notFound)
+		default:
+			return nil, newErrFromResponse(resp)
+		}
+
+		result, ok := resp.Result().(*repositoryPage)
+		if !ok {
+			return nil, resp.decodeFailure(errNoResult)
+		}
+		repos = append(repos, result.Repos...)
+		if len(result.Repos) == 0 || page >= result.Pages {
+			return repos, nil
+		}
+	}
+}
+
+// Delete a repository from Coveralls
+//
+// It may return errors ErrRepoNotFound or ErrUnexpectedStatusCode
+func (s RepositoryServiceImpl) Delete(ctx context.Context, svc string, repo string, opts ...CallOption) error {
+	endpoint := s.client.apiEndpoint("repos/%s/%s", svc, repo)
+
+	resp, err := s.client.newRequest(ctx, opts).
+		Delete(endpoint)
+
+	if err != nil {
+		return err
+	}
+
+	switch resp.StatusCode() {
+	case http.StatusOK, http.StatusNoContent:
+		return nil
+	case http.StatusNotFound:
+		return resp.apiError(ErrRepoNotFound)
+	default:
+		return newErrFromResponse(resp)
+	}
+}
+
+// Exists reports whether a repository is already in Coveralls
+//
+// It may return error ErrUnexpectedStatusCode
+func (s RepositoryServiceImpl) Exists(ctx context.Context, svc string, repo string, opts ...CallOption) (bool, error) {
+	_, err := s.Get(ctx, svc, repo, opts...)
+	switch {
+	case err == nil:
+		return true, nil
+	case errors.Is(err, ErrRepoNotFound):
+		return false, nil
+	default:
+		return false, err
+	}
+}
+
+// Ensure adds a repository to Coveralls or, if it already exists, updates
+// its configuration. The service and name of the repository are taken
+// from data.
+//
+// If someone else adds the same repository between our check and the
+// creation, Ensure falls back to an update instead of returning ErrNameIsTaken.
+//
+// It may return errors ErrUnprocessableEntity or ErrUnexpectedStatusCode
+func (s RepositoryServiceImpl) Ensure(ctx context.Context, data *RepositoryConfig, opts ...CallOption) (*Repository, error) {
+	exists, err := s.Exists(ctx, data.Service, data.Name, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	if !exists {
+		repo, err := s.Add(ctx, data, opts...)
+		if !errors.Is(err, ErrNameIsTaken) {
+			return repo, err
+		}
+	}
+
+	return s.Update(ctx, data.Service, data.Name, data, opts...)
+}
+
+// GetByID gets information about a repository from its Coveralls ID.
+//
+// Unlike the service and name, the ID does not change when the
+// repository is renamed in the git provider.
+//
+// It may return errors ErrRepoNotFound or ErrUnexpectedStatusCode
+func (s RepositoryServiceImpl) GetByID(ctx context.Context, id int, opts ...CallOption) (*Repository, error) {
+	endpoint := s.client.apiEndpoint("repos/%d", id)
+
+	resp, err := s.client.newRequest(ctx, opts).
+		SetResult(&Repository{}).
+		Get(endpoint)
+
+	if err != nil {
+		return nil, err
+	}
+
+	switch resp.StatusCode() {
+	case http.StatusOK:
+		repository, ok := resp.Result().(*Repository)
+		if !ok {
+			return nil, resp.decodeFailure(errNoResult)
+		}
+		return repository, nil
+	case http.StatusNotFound:
+		return nil, resp.apiError(ErrRepoNotFound)
 	default:
-		return nil, newErrUnexpectedStatusCode(resp.StatusCode(), string(resp.Body()))
+		return nil, newErrFromResponse(resp)
 	}
 }
@@ -0,0 +1,119 @@
+/*
+Copyright (c) 2020 Loadsmart, Inc.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package coveralls
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// backupVersion is the version of the format written by WriteBackup
+const backupVersion = 1
+
+// backup is the format of files written by WriteBackup
+type backup struct {
+	Version      int                `json:"version"`
+	Repositories []RepositoryConfig `json:"repositories"`
+}
+
+// Export returns the configuration of the repositories refs, read with
+// repos, e.g. to back them up with WriteBackup before a bulk change. If
+// refs is empty, it exports every repository the token has access to, as
+// listed by List.
+//
+// Repositories are read concurrently. If any of them fails, Export returns
+// a *BulkError and no configurations, so a backup is never incomplete.
+func Export(ctx context.Context, repos RepositoryService, refs []RepoRef, opts ...CallOption) ([]RepositoryConfig, error) {
+	if len(refs) == 0 {
+		all, err := repos.List(ctx, nil, opts...)
+		if err != nil {
+			return nil, err
+		}
+		configs := make([]RepositoryConfig, 0, len(all))
+		for _, r := range all {
+			configs = append(configs, *r.Config())
+		}
+		return configs, nil
+	}
+
+	results, err := bulk(refs, nil, func(ref RepoRef) (*Repository, error) {
+		return repos.Get(ctx, ref.Service, ref.Name, opts...)
+	})
+	if err != nil {
+		return nil, err
+	}
+	configs := make([]RepositoryConfig, 0, len(results))
+	for _, r := range results {
+		configs = append(configs, *r.Repository.Config())
+	}
+	return configs, nil
+}
+
+// Restore applies configurations exported by Export with repos, adding the
+// repositories that are missing from Coveralls, as Ensure does. Like
+// BulkUpdate, it goes through repositories concurrently, as set by opts,
+// which may be nil, and returns a *BulkError if any of them failed.
+func Restore(ctx context.Context, repos RepositoryService, configs []RepositoryConfig, opts *BulkOptions, callOpts ...CallOption) ([]BulkResult, error) {
+	refs := make([]RepoRef, 0, len(configs))
+	byRef := make(map[RepoRef]RepositoryConfig, len(configs))
+	for _, c := range configs {
+		ref := RepoRef{Service: c.Service, Name: c.Name}
+		refs = append(refs, ref)
+		byRef[ref] = c
+	}
+	return bulk(refs, opts, func(ref RepoRef) (*Repository, error) {
+		config := byRef[ref]
+		return repos.Ensure(ctx, &config, callOpts...)
+	})
+}
+
+// WriteBackup writes configurations, e.g. returned by Export, to w as JSON
+// that ReadBackup reads back
+func WriteBackup(w io.Writer, configs []RepositoryConfig) error {
+	if configs == nil {
+		configs = []RepositoryConfig{}
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(backup{Version: backupVersion, Repositories: configs})
+}
+
+// ReadBackup reads configurations written by WriteBackup, to pass them to
+// Restore
+func ReadBackup(r io.Reader) ([]RepositoryConfig, error) {
+	var b backup
+	if err := json.NewDecoder(r).Decode(&b); err != nil {
+		return nil, fmt.Errorf("invalid backup: %w", err)
+	}
+	if b.Version != backupVersion {
+		return nil, fmt.Errorf("invalid backup: unsupported version %d", b.Version)
+	}
+	for i, c := range b.Repositories {
+		if c.Service == "" || c.Name == "" {
+			return nil, fmt.Errorf("invalid backup: repository %d has no service or name", i+1)
+		}
+	}
+	return b.Repositories, nil
+}
@@ -0,0 +1,155 @@
+/*
+Copyright (c) 2020 Loadsmart, Inc.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package coveralls
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// backupServer serves repositories from memory, as the repositories API does
+func backupServer(t *testing.T, repos map[string]*Repository) *httptest.Server {
+	var mu sync.Mutex
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		name := strings.TrimPrefix(req.URL.Path, "/api/repos/github/")
+		var body struct {
+			Repo Repository `json:"repo"`
+		}
+		switch {
+		case req.Method == http.MethodGet && req.URL.Path == "/api/repos":
+			page := repositoryPage{Page: 1, Pages: 1, Total: len(repos)}
+			for _, key := range []string{"user/api", "user/web"} {
+				if r, ok := repos[key]; ok {
+					page.Repos = append(page.Repos, r)
+				}
+			}
+			json.NewEncoder(w).Encode(page)
+		case req.Method == http.MethodGet && repos[name] != nil:
+			json.NewEncoder(w).Encode(repos[name])
+		case req.Method == http.MethodGet:
+			w.WriteHeader(http.StatusNotFound)
+		case req.Method == http.MethodPost:
+			assert.Nil(t, json.NewDecoder(req.Body).Decode(&body))
+			repos[body.Repo.Name] = &body.Repo
+			w.WriteHeader(http.StatusCreated)
+			json.NewEncoder(w).Encode(body.Repo)
+		case req.Method == http.MethodPut:
+			assert.Nil(t, json.NewDecoder(req.Body).Decode(&body))
+			repos[name] = &body.Repo
+			json.NewEncoder(w).Encode(body.Repo)
+		default:
+			t.Errorf("unexpected request %s %s", req.Method, req.URL)
+		}
+	}))
+}
+
+func TestRepositoryServiceExportRestore(t *testing.T) {
+	repos := map[string]*Repository{
+		"user/api": {ID: 1, Service: "github", Name: "user/api", SendBuildStatus: pbool(true), CommitStatusFailThreshold: pfloat64(80), Token: "secret"},
+		"user/web": {ID: 2, Service: "github", Name: "user/web", CommentOnPullRequests: pbool(false)},
+	}
+	server := backupServer(t, repos)
+	defer server.Close()
+	client, _ := NewEnterpriseClient(server.URL, "fake token")
+	want := []RepositoryConfig{
+		{Service: "github", Name: "user/api", SendBuildStatus: pbool(true), CommitStatusFailThreshold: pfloat64(80)},
+		{Service: "github", Name: "user/web", CommentOnPullRequests: pbool(false)},
+	}
+
+	configs, err := Export(context.Background(), client.Repositories, []RepoRef{{Service: "github", Name: "user/api"}, {Service: "github", Name: "user/web"}})
+	require.NoError(t, err)
+	assert.Equal(t, want, configs)
+
+	configs, err = Export(context.Background(), client.Repositories, nil)
+	require.NoError(t, err)
+	assert.Equal(t, want, configs)
+
+	var b bytes.Buffer
+	require.NoError(t, WriteBackup(&b, configs))
+	assert.NotContains(t, b.String(), "secret", "backups have no repository tokens")
+
+	// Disaster: one repository changed, the other lost
+	repos["user/api"] = &Repository{ID: 1, Service: "github", Name: "user/api", CommitStatusFailThreshold: pfloat64(50)}
+	delete(repos, "user/web")
+
+	restored, err := ReadBackup(&b)
+	require.NoError(t, err)
+	results, err := Restore(context.Background(), client.Repositories, restored, &BulkOptions{Concurrency: 1})
+
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+	assert.Equal(t, pfloat64(80), repos["user/api"].CommitStatusFailThreshold)
+	require.NotNil(t, repos["user/web"])
+	assert.Equal(t, pbool(false), repos["user/web"].CommentOnPullRequests)
+}
+
+func TestRepositoryServiceExportError(t *testing.T) {
+	server := backupServer(t, map[string]*Repository{
+		"user/api": {ID: 1, Service: "github", Name: "user/api"},
+	})
+	defer server.Close()
+	client, _ := NewEnterpriseClient(server.URL, "fake token")
+
+	configs, err := Export(context.Background(), client.Repositories, []RepoRef{{Service: "github", Name: "user/api"}, {Service: "github", Name: "user/gone"}})
+
+	assert.Nil(t, configs)
+	assert.True(t, errors.Is(err, ErrRepoNotFound))
+	var bulkErr *BulkError
+	require.True(t, errors.As(err, &bulkErr))
+	assert.Equal(t, RepoRef{Service: "github", Name: "user/gone"}, bulkErr.Failed[0].Repo)
+}
+
+func TestReadBackupInvalid(t *testing.T) {
+	var testCases = []struct {
+		name  string
+		input string
+		err   string
+	}{
+		{name: "not json", input: "repositories:", err: "invalid backup"},
+		{name: "version", input: `{"version": 2, "repositories": []}`, err: "unsupported version 2"},
+		{name: "no name", input: `{"version": 1, "repositories": [{"service": "github"}]}`, err: "repository 1 has no service or name"},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			configs, err := ReadBackup(strings.NewReader(tt.input))
+
+			assert.Nil(t, configs)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.err)
+		})
+	}
+}
@@ -0,0 +1,156 @@
+/*
+Copyright (c) 2020 Loadsmart, Inc.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package coveralls
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// defaultBulkConcurrency is how many repositories BulkUpdate, Export and
+// Restore go through at a time unless told otherwise
+const defaultBulkConcurrency = 4
+
+// RepoRef identifies a repository in Coveralls
+type RepoRef struct {
+	Service string // Git provider, e.g. github
+	Name    string // Name of the repository, e.g. user/repository
+}
+
+func (r RepoRef) String() string {
+	return r.Service + "/" + r.Name
+}
+
+// BulkOptions specifies how BulkUpdate and Restore go
+// through repositories
+type BulkOptions struct {
+	Concurrency int // Repositories changed at a time. Zero means 4
+
+	// Progress, if set, is called after each repository is done, with how
+	// many are done out of the total. Calls are never concurrent.
+	Progress func(done int, total int, result BulkResult)
+}
+
+// BulkResult is the outcome of changing one repository
+type BulkResult struct {
+	Repo       RepoRef
+	Repository *Repository // Repository as changed, nil on error
+	Err        error
+}
+
+// BulkError is returned by BulkUpdate, Export and Restore when some
+// repositories failed. It wraps their errors, so errors.Is and errors.As find them.
+type BulkError struct {
+	Total  int          // Repositories tried
+	Failed []BulkResult // Results of the ones that failed, in the order given
+}
+
+func (e *BulkError) Error() string {
+	msgs := make([]string, 0, len(e.Failed))
+	for _, r := range e.Failed {
+		msgs = append(msgs, fmt.Sprintf("%s: %s", r.Repo, r.Err))
+	}
+	return fmt.Sprintf("%d of %d repositories failed: %s", len(e.Failed), e.Total, strings.Join(msgs, "; "))
+}
+
+func (e *BulkError) Unwrap() []error {
+	errs := make([]error, 0, len(e.Failed))
+	for _, r := range e.Failed {
+		errs = append(errs, r.Err)
+	}
+	return errs
+}
+
+// BulkUpdate applies the settings of template to every repository of
+// targets with repos, e.g. to enforce thresholds across an organization.
+// The service and name of template are ignored, and its unset settings left
+// unchanged.
+//
+// Repositories are updated concurrently, as set by opts, which may be nil.
+// A failure doesn't stop the others: the results of all targets are
+// returned in their order, and a *BulkError if any of them failed.
+func BulkUpdate(ctx context.Context, repos RepositoryService, targets []RepoRef, template RepositoryConfig, opts *BulkOptions, callOpts ...CallOption) ([]BulkResult, error) {
+	return bulk(targets, opts, func(target RepoRef) (*Repository, error) {
+		config := template
+		config.Service = target.Service
+		config.Name = target.Name
+		return repos.Update(ctx, target.Service, target.Name, &config, callOpts...)
+	})
+}
+
+// bulk calls do with each of targets concurrently, as set by opts, which
+// may be nil. It returns the results in the order of targets, and a
+// *BulkError if any failed.
+func bulk(targets []RepoRef, opts *BulkOptions, do func(target RepoRef) (*Repository, error)) ([]BulkResult, error) {
+	if opts == nil {
+		opts = &BulkOptions{}
+	}
+	workers := opts.Concurrency
+	if workers <= 0 {
+		workers = defaultBulkConcurrency
+	}
+	if workers > len(targets) {
+		workers = len(targets)
+	}
+
+	results := make([]BulkResult, len(targets))
+	indexes := make(chan int)
+	var mu sync.Mutex
+	done := 0
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				repository, err := do(targets[i])
+				results[i] = BulkResult{Repo: targets[i], Repository: repository, Err: err}
+
+				if opts.Progress != nil {
+					mu.Lock()
+					done++
+					opts.Progress(done, len(targets), results[i])
+					mu.Unlock()
+				}
+			}
+		}()
+	}
+	for i := range targets {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+
+	var failed []BulkResult
+	for _, r := range results {
+		if r.Err != nil {
+			failed = append(failed, r)
+		}
+	}
+	if len(failed) > 0 {
+		return results, &BulkError{Total: len(targets), Failed: failed}
+	}
+	return results, nil
+}
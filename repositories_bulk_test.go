@@ -0,0 +1,123 @@
+/*
+Copyright (c) 2020 Loadsmart, Inc.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package coveralls
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRepositoryServiceBulkUpdate(t *testing.T) {
+	var active, maxActive int32
+	var mu sync.Mutex
+	received := map[string]RepositoryConfig{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		n := atomic.AddInt32(&active, 1)
+		defer atomic.AddInt32(&active, -1)
+		for {
+			m := atomic.LoadInt32(&maxActive)
+			if n <= m || atomic.CompareAndSwapInt32(&maxActive, m, n) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+
+		assert.Equal(t, http.MethodPut, req.Method)
+		name := strings.TrimPrefix(req.URL.Path, "/api/repos/github/")
+		if name == "user/missing" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		var body struct {
+			Repo RepositoryConfig `json:"repo"`
+		}
+		assert.Nil(t, json.NewDecoder(req.Body).Decode(&body))
+		mu.Lock()
+		received[name] = body.Repo
+		mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"service": "github", "name": "` + name + `"}`))
+	}))
+	defer server.Close()
+	client, _ := NewEnterpriseClient(server.URL, "fake token")
+
+	targets := []RepoRef{
+		{Service: "github", Name: "user/a"},
+		{Service: "github", Name: "user/missing"},
+		{Service: "github", Name: "user/b"},
+		{Service: "github", Name: "user/c"},
+		{Service: "github", Name: "user/d"},
+	}
+	template := RepositoryConfig{Service: "ignored", Name: "ignored", CommitStatusFailThreshold: pfloat64(80)}
+	var progress []int
+	opts := &BulkOptions{
+		Concurrency: 2,
+		Progress: func(done int, total int, result BulkResult) {
+			assert.Equal(t, len(targets), total)
+			progress = append(progress, done)
+		},
+	}
+
+	results, err := BulkUpdate(context.Background(), client.Repositories, targets, template, opts)
+
+	require.Len(t, results, len(targets))
+	for i, r := range results {
+		assert.Equal(t, targets[i], r.Repo)
+	}
+	assert.Equal(t, "user/a", results[0].Repository.Name)
+	assert.Nil(t, results[1].Repository)
+	assert.True(t, errors.Is(results[1].Err, ErrRepoNotFound))
+
+	var bulkErr *BulkError
+	require.True(t, errors.As(err, &bulkErr))
+	assert.Equal(t, 5, bulkErr.Total)
+	require.Len(t, bulkErr.Failed, 1)
+	assert.Equal(t, targets[1], bulkErr.Failed[0].Repo)
+	assert.True(t, errors.Is(err, ErrRepoNotFound))
+	assert.Contains(t, err.Error(), "1 of 5 repositories failed: github/user/missing")
+
+	assert.Equal(t, []int{1, 2, 3, 4, 5}, progress)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&maxActive))
+	assert.Len(t, received, 4)
+	assert.Equal(t, RepositoryConfig{Service: "github", Name: "user/c", CommitStatusFailThreshold: pfloat64(80)}, received["user/c"])
+}
+
+func TestRepositoryServiceBulkUpdateEmpty(t *testing.T) {
+	client := NewClient("fake token")
+
+	results, err := BulkUpdate(context.Background(), client.Repositories, nil, RepositoryConfig{}, nil)
+
+	assert.Nil(t, err)
+	assert.Empty(t, results)
+}
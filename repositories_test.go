@@ -58,6 +58,19 @@ func TestRepositoryServiceGet(t *testing.T) {
 			},
 			err: nil,
 		},
+		{
+			name: "withbuilds",
+			code: http.StatusOK,
+			repo: &Repository{
+				ID:              123,
+				Service:         "github",
+				Name:            "user/fakerepo",
+				CoveredPercent:  pfloat64(87.25),
+				LastBuildNumber: pint(42),
+				LastBuildAt:     "2022-03-16T10:02:31Z",
+			},
+			err: nil,
+		},
 		{
 			name: "notfound",
 			code: http.StatusNotFound,
@@ -82,7 +95,7 @@ func TestRepositoryServiceGet(t *testing.T) {
 			httpmock.RegisterResponder("GET", fakeUrl, responder)
 
 			client := NewClient("fake token")
-			httpmock.ActivateNonDefault(client.client.GetClient())
+			httpmock.ActivateNonDefault(client.client)
 			defer httpmock.DeactivateAndReset()
 
 			result, err := client.Repositories.Get(context.Background(), "github", "user/fakerepo")
@@ -114,7 +127,17 @@ func TestRepositoryServiceAdd(t *testing.T) {
 
 		assert.Equal(t, repositoryConfig, cfg["repo"])
 
-		resp, err := httpmock.NewJsonResponse(201, cfg["repo"])
+		created := &Repository{
+			ID:                              123,
+			Service:                         cfg["repo"].Service,
+			Name:                            cfg["repo"].Name,
+			CommentOnPullRequests:           cfg["repo"].CommentOnPullRequests,
+			SendBuildStatus:                 cfg["repo"].SendBuildStatus,
+			CommitStatusFailThreshold:       cfg["repo"].CommitStatusFailThreshold,
+			CommitStatusFailChangeThreshold: cfg["repo"].CommitStatusFailChangeThreshold,
+			Token:                           "fake-repo-token",
+		}
+		resp, err := httpmock.NewJsonResponse(201, created)
 		if err != nil {
 			return httpmock.NewStringResponse(500, ""), nil
 		}
@@ -122,13 +145,57 @@ func TestRepositoryServiceAdd(t *testing.T) {
 	})
 
 	client := NewClient("fake token")
-	httpmock.ActivateNonDefault(client.client.GetClient())
+	httpmock.ActivateNonDefault(client.client)
 	defer httpmock.DeactivateAndReset()
 
 	result, err := client.Repositories.Add(context.Background(), repositoryConfig)
 
 	assert.Nil(t, err)
-	assert.Equal(t, repositoryConfig, result)
+	assert.Equal(t, 123, result.ID)
+	assert.Equal(t, "fake-repo-token", result.Token)
+	assert.Equal(t, repositoryConfig, result.Config())
+}
+
+func TestRepositoryServiceUpdate(t *testing.T) {
+	var testCases = []struct {
+		name string
+		code int
+		repo *Repository
+		err  error
+	}{
+		{
+			name: "updated",
+			code: http.StatusOK,
+			repo: &Repository{ID: 123, Service: "github", Name: "user/fakerepo", SendBuildStatus: pbool(false), Token: "fake-repo-token"},
+			err:  nil,
+		},
+		{
+			name: "notfound",
+			code: http.StatusNotFound,
+			repo: nil,
+			err:  ErrRepoNotFound,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			fakeUrl := "https://coveralls.io/api/repos/github/user/fakerepo"
+			responder, _ := httpmock.NewJsonResponder(tt.code, tt.repo)
+			httpmock.RegisterResponder("PUT", fakeUrl, responder)
+
+			client := NewClient("fake token")
+			httpmock.ActivateNonDefault(client.client)
+			defer httpmock.DeactivateAndReset()
+
+			cfg := &RepositoryConfig{Service: "github", Name: "user/fakerepo", SendBuildStatus: pbool(false)}
+			result, err := client.Repositories.Update(context.Background(), "github", "user/fakerepo", cfg)
+
+			if !errors.Is(err, tt.err) {
+				t.Errorf("Errors do not match.\n\texpected: '%v'\n\tgot: '%v'", tt.err, err)
+			}
+			assert.Equal(t, tt.repo, result)
+		})
+	}
 }
 
 func TestRepositoryServiceAddDuplicateError(t *testing.T) {
@@ -152,15 +219,202 @@ func TestRepositoryServiceAddDuplicateError(t *testing.T) {
 	})
 
 	client := NewClient("fake token")
-	httpmock.ActivateNonDefault(client.client.GetClient())
+	httpmock.ActivateNonDefault(client.client)
 	defer httpmock.DeactivateAndReset()
 
 	result, err := client.Repositories.Add(context.Background(), repositoryConfig)
 
-	assert.Equal(t, err, ErrNameIsTaken)
+	assert.True(t, errors.Is(err, ErrNameIsTaken))
+	assert.Nil(t, result)
+}
+
+func TestRepositoryServiceList(t *testing.T) {
+	pages := []*repositoryPage{
+		{Page: 1, Pages: 2, Total: 3, Repos: []*Repository{{ID: 1, Name: "user/repo1"}, {ID: 2, Name: "user/repo2"}}},
+		{Page: 2, Pages: 2, Total: 3, Repos: []*Repository{{ID: 3, Name: "user/repo3"}}},
+	}
+	fakeUrl := "https://coveralls.io/api/repos"
+	httpmock.RegisterResponder("GET", fakeUrl, func(req *http.Request) (*http.Response, error) {
+		assert.Equal(t, "2", req.URL.Query().Get("per_page"))
+
+		var page int
+		fmt.Sscan(req.URL.Query().Get("page"), &page)
+		if page < 1 || page > len(pages) {
+			return httpmock.NewStringResponse(400, ""), nil
+		}
+		return httpmock.NewJsonResponse(200, pages[page-1])
+	})
+
+	client := NewClient("fake token")
+	httpmock.ActivateNonDefault(client.client)
+	defer httpmock.DeactivateAndReset()
+
+	result, err := client.Repositories.List(context.Background(), &ListOptions{PerPage: 2})
+
+	assert.Nil(t, err)
+	assert.Equal(t, []*Repository{{ID: 1, Name: "user/repo1"}, {ID: 2, Name: "user/repo2"}, {ID: 3, Name: "user/repo3"}}, result)
+}
+
+func TestRepositoryServiceListError(t *testing.T) {
+	fakeUrl := "https://coveralls.io/api/repos"
+	httpmock.RegisterResponder("GET", fakeUrl, httpmock.NewStringResponder(500, "oops"))
+
+	client := NewClient("fake token")
+	httpmock.ActivateNonDefault(client.client)
+	defer httpmock.DeactivateAndReset()
+
+	result, err := client.Repositories.List(context.Background(), nil)
+
+	assert.True(t, errors.Is(err, newErrUnexpectedStatusCode(500, "oops")))
 	assert.Nil(t, result)
 }
 
+func TestRepositoryServiceDelete(t *testing.T) {
+	var testCases = []struct {
+		name string
+		code int
+		err  error
+	}{
+		{name: "deleted", code: http.StatusNoContent, err: nil},
+		{name: "notfound", code: http.StatusNotFound, err: ErrRepoNotFound},
+		{name: "unexpected", code: http.StatusUseProxy, err: ErrUnexpectedStatusCode{StatusCode: http.StatusUseProxy, ErrorBody: ""}},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			fakeUrl := "https://coveralls.io/api/repos/github/user/fakerepo"
+			httpmock.RegisterResponder("DELETE", fakeUrl, httpmock.NewStringResponder(tt.code, ""))
+
+			client := NewClient("fake token")
+			httpmock.ActivateNonDefault(client.client)
+			defer httpmock.DeactivateAndReset()
+
+			err := client.Repositories.Delete(context.Background(), "github", "user/fakerepo")
+
+			if !errors.Is(err, tt.err) {
+				t.Errorf("Errors do not match.\n\texpected: '%v'\n\tgot: '%v'", tt.err, err)
+			}
+		})
+	}
+}
+
+func TestRepositoryServiceExists(t *testing.T) {
+	var testCases = []struct {
+		name   string
+		code   int
+		exists bool
+		err    error
+	}{
+		{name: "existing", code: http.StatusOK, exists: true, err: nil},
+		{name: "notfound", code: http.StatusNotFound, exists: false, err: nil},
+		{name: "unexpected", code: http.StatusUseProxy, exists: false, err: ErrUnexpectedStatusCode{StatusCode: http.StatusUseProxy, ErrorBody: "{}"}},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			fakeUrl := "https://coveralls.io/api/repos/github/user/fakerepo"
+			httpmock.RegisterResponder("GET", fakeUrl, httpmock.NewStringResponder(tt.code, "{}"))
+
+			client := NewClient("fake token")
+			httpmock.ActivateNonDefault(client.client)
+			defer httpmock.DeactivateAndReset()
+
+			exists, err := client.Repositories.Exists(context.Background(), "github", "user/fakerepo")
+
+			if !errors.Is(err, tt.err) {
+				t.Errorf("Errors do not match.\n\texpected: '%v'\n\tgot: '%v'", tt.err, err)
+			}
+			assert.Equal(t, tt.exists, exists)
+		})
+	}
+}
+
+func TestRepositoryServiceEnsure(t *testing.T) {
+	var testCases = []struct {
+		name     string
+		getCode  int
+		postCode int
+		calls    []string
+	}{
+		{name: "new", getCode: http.StatusNotFound, postCode: http.StatusCreated, calls: []string{"GET", "POST"}},
+		{name: "existing", getCode: http.StatusOK, calls: []string{"GET", "PUT"}},
+		{name: "race", getCode: http.StatusNotFound, postCode: http.StatusUnprocessableEntity, calls: []string{"GET", "POST", "PUT"}},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &Repository{ID: 123, Service: "github", Name: "user/fakerepo", Token: "fake-repo-token"}
+			var calls []string
+			record := func(code int, body interface{}) httpmock.Responder {
+				return func(req *http.Request) (*http.Response, error) {
+					calls = append(calls, req.Method)
+					return httpmock.NewJsonResponse(code, body)
+				}
+			}
+			httpmock.RegisterResponder("GET", "https://coveralls.io/api/repos/github/user/fakerepo", record(tt.getCode, repo))
+			httpmock.RegisterResponder("PUT", "https://coveralls.io/api/repos/github/user/fakerepo", record(http.StatusOK, repo))
+			if tt.postCode == http.StatusCreated {
+				httpmock.RegisterResponder("POST", "https://coveralls.io/api/repos", record(tt.postCode, repo))
+			} else {
+				httpmock.RegisterResponder("POST", "https://coveralls.io/api/repos", record(tt.postCode, map[string]map[string]string{
+					"errors": {"name": "has already been taken"},
+				}))
+			}
+
+			client := NewClient("fake token")
+			httpmock.ActivateNonDefault(client.client)
+			defer httpmock.DeactivateAndReset()
+
+			result, err := client.Repositories.Ensure(context.Background(), &RepositoryConfig{Service: "github", Name: "user/fakerepo"})
+
+			assert.Nil(t, err)
+			assert.Equal(t, repo, result)
+			assert.Equal(t, tt.calls, calls)
+		})
+	}
+}
+
+func TestRepositoryServiceGetByID(t *testing.T) {
+	var testCases = []struct {
+		name string
+		code int
+		repo *Repository
+		err  error
+	}{
+		{
+			name: "existing",
+			code: http.StatusOK,
+			repo: &Repository{ID: 123, Service: "github", Name: "user/fakerepo"},
+			err:  nil,
+		},
+		{
+			name: "notfound",
+			code: http.StatusNotFound,
+			repo: nil,
+			err:  ErrRepoNotFound,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			fakeUrl := "https://coveralls.io/api/repos/123"
+			responder, _ := httpmock.NewJsonResponder(tt.code, tt.repo)
+			httpmock.RegisterResponder("GET", fakeUrl, responder)
+
+			client := NewClient("fake token")
+			httpmock.ActivateNonDefault(client.client)
+			defer httpmock.DeactivateAndReset()
+
+			result, err := client.Repositories.GetByID(context.Background(), 123)
+
+			if !errors.Is(err, tt.err) {
+				t.Errorf("Errors do not match.\n\texpected: '%v'\n\tgot: '%v'", tt.err, err)
+			}
+			assert.Equal(t, tt.repo, result)
+		})
+	}
+}
+
 func TestRepositoryConfigMarshall(t *testing.T) {
 	var testCases = []struct {
 		name string
@@ -207,3 +461,61 @@ func pfloat64(v float64) *float64 {
 func pbool(b bool) *bool {
 	return &b
 }
+
+func pint(v int) *int {
+	return &v
+}
+
+func TestNewErrUnprocessableEntity(t *testing.T) {
+	var testCases = []struct {
+		name        string
+		body        string
+		message     string
+		fieldErrors map[string][]string
+	}{
+		{name: "not json", body: "oops"},
+		{name: "message", body: `{"message": "Couldn't find a repository matching this job.", "error": true}`, message: "Couldn't find a repository matching this job."},
+		{
+			name:        "top level fields",
+			body:        `{"commit_status_fail_threshold": ["must be less than or equal to 100"], "name": ["can't be blank", "is invalid"]}`,
+			fieldErrors: map[string][]string{"commit_status_fail_threshold": {"must be less than or equal to 100"}, "name": {"can't be blank", "is invalid"}},
+		},
+		{
+			name:        "errors object",
+			body:        `{"message": "Validation failed", "errors": {"service": ["is not included in the list"]}}`,
+			message:     "Validation failed",
+			fieldErrors: map[string][]string{"service": {"is not included in the list"}},
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			err := newErrUnprocessableEntity(tt.body)
+
+			assert.Equal(t, tt.body, err.ErrorBody)
+			assert.Equal(t, tt.message, err.Message)
+			assert.Equal(t, tt.fieldErrors, err.FieldErrors)
+			assert.True(t, errors.Is(err, ErrUnprocessableEntity{ErrorBody: tt.body}))
+		})
+	}
+}
+
+func TestRepositoryServiceAddIgnoreExisting(t *testing.T) {
+	repositoryConfig := &RepositoryConfig{
+		Service: "github",
+		Name:    "user/fake-duplicate-repo",
+	}
+	httpmock.RegisterResponder("POST", "https://coveralls.io/api/repos",
+		httpmock.NewStringResponder(422, `{"errors": {"name": ["has already been taken"]}}`))
+	httpmock.RegisterResponder("GET", "https://coveralls.io/api/repos/github/user/fake-duplicate-repo",
+		httpmock.NewStringResponder(200, `{"id": 42, "name": "user/fake-duplicate-repo", "service": "github"}`))
+
+	client := NewClient("fake token")
+	httpmock.ActivateNonDefault(client.client)
+	defer httpmock.DeactivateAndReset()
+
+	result, err := client.Repositories.Add(context.Background(), repositoryConfig, IgnoreExisting())
+
+	assert.Nil(t, err)
+	assert.Equal(t, &Repository{ID: 42, Name: "user/fake-duplicate-repo", Service: "github"}, result)
+}
@@ -0,0 +1,233 @@
+/*
+Copyright (c) 2020 Loadsmart, Inc.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+// Package repostate manages Coveralls repositories with the semantics that
+// state-based tools, such as Terraform providers, expect: stable IDs, reads
+// that see the writes before them, partial updates and import by name.
+package repostate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	coveralls "github.com/stone-payments/go-coveralls-api"
+)
+
+// defaultPollInterval is how often a Store checks that a write is visible
+const defaultPollInterval = time.Second
+
+// Store creates, reads, updates and deletes repositories. Repositories are
+// identified by their Coveralls ID, as returned by ID, which unlike the
+// service and name doesn't change when the repository is renamed.
+//
+// Writes return once a read sees them, so the API caching an old version of
+// the repository doesn't make a plan show changes right after applying it.
+// The context bounds how long they wait, e.g. with the timeouts of a
+// Terraform resource.
+type Store struct {
+	Repos        coveralls.RepositoryService
+	PollInterval time.Duration // How often to check that a write is visible. Zero means a second
+	CallOptions  []coveralls.CallOption
+}
+
+// New returns a Store of the repositories in repos, calling it with opts
+func New(repos coveralls.RepositoryService, opts ...coveralls.CallOption) *Store {
+	return &Store{Repos: repos, CallOptions: opts}
+}
+
+// ID returns the stable ID of a repository, its Coveralls ID as a string
+func ID(r *coveralls.Repository) string {
+	return strconv.Itoa(r.ID)
+}
+
+// parseID parses an ID returned by ID
+func parseID(id string) (int, error) {
+	n, err := strconv.Atoi(id)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("invalid repository ID %q: expected a Coveralls ID, e.g. 123", id)
+	}
+	return n, nil
+}
+
+// Merge returns the configuration of current with the settings set in
+// patch, like a PATCH request would leave it. Unset settings of patch keep
+// their current values.
+func Merge(current *coveralls.Repository, patch *coveralls.RepositoryConfig) *coveralls.RepositoryConfig {
+	merged := current.Config()
+	if patch.CommentOnPullRequests != nil {
+		merged.CommentOnPullRequests = patch.CommentOnPullRequests
+	}
+	if patch.SendBuildStatus != nil {
+		merged.SendBuildStatus = patch.SendBuildStatus
+	}
+	if patch.CommitStatusFailThreshold != nil {
+		merged.CommitStatusFailThreshold = patch.CommitStatusFailThreshold
+	}
+	if patch.CommitStatusFailChangeThreshold != nil {
+		merged.CommitStatusFailChangeThreshold = patch.CommitStatusFailChangeThreshold
+	}
+	return merged
+}
+
+// Matches reports whether the settings of r are the ones set in want.
+// Unset settings of want match any value.
+func Matches(r *coveralls.Repository, want *coveralls.RepositoryConfig) bool {
+	return equalBool(r.CommentOnPullRequests, want.CommentOnPullRequests) &&
+		equalBool(r.SendBuildStatus, want.SendBuildStatus) &&
+		equalFloat(r.CommitStatusFailThreshold, want.CommitStatusFailThreshold) &&
+		equalFloat(r.CommitStatusFailChangeThreshold, want.CommitStatusFailChangeThreshold)
+}
+
+// equalBool reports whether actual has the value of want, if want is set
+func equalBool(actual *bool, want *bool) bool {
+	return want == nil || (actual != nil && *actual == *want)
+}
+
+// equalFloat reports whether actual has the value of want, if want is set
+func equalFloat(actual *float64, want *float64) bool {
+	return want == nil || (actual != nil && *actual == *want)
+}
+
+// Create adds the repository to Coveralls and returns it once reads see
+// its settings
+func (s *Store) Create(ctx context.Context, config *coveralls.RepositoryConfig) (*coveralls.Repository, error) {
+	if _, err := s.Repos.Add(ctx, config, s.CallOptions...); err != nil {
+		return nil, err
+	}
+	return s.waitFor(ctx, config.Service, config.Name, config)
+}
+
+// Read returns the repository with the given ID. It returns nil without an
+// error if the repository is gone, so it can be removed from the state.
+func (s *Store) Read(ctx context.Context, id string) (*coveralls.Repository, error) {
+	n, err := parseID(id)
+	if err != nil {
+		return nil, err
+	}
+	r, err := s.Repos.GetByID(ctx, n, s.CallOptions...)
+	if errors.Is(err, coveralls.ErrRepoNotFound) {
+		return nil, nil
+	}
+	return r, err
+}
+
+// Update changes the settings of the repository with the given ID that are
+// set in patch, leaving the others as they are, and returns the repository
+// once reads see the change
+func (s *Store) Update(ctx context.Context, id string, patch *coveralls.RepositoryConfig) (*coveralls.Repository, error) {
+	n, err := parseID(id)
+	if err != nil {
+		return nil, err
+	}
+	current, err := s.Repos.GetByID(ctx, n, s.CallOptions...)
+	if err != nil {
+		return nil, err
+	}
+	merged := Merge(current, patch)
+	if _, err := s.Repos.Update(ctx, current.Service, current.Name, merged, s.CallOptions...); err != nil {
+		return nil, err
+	}
+	return s.waitFor(ctx, current.Service, current.Name, merged)
+}
+
+// Delete removes the repository with the given ID from Coveralls, and
+// returns once reads no longer find it. Deleting a repository that is
+// already gone succeeds.
+func (s *Store) Delete(ctx context.Context, id string) error {
+	n, err := parseID(id)
+	if err != nil {
+		return err
+	}
+	current, err := s.Repos.GetByID(ctx, n, s.CallOptions...)
+	if errors.Is(err, coveralls.ErrRepoNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	err = s.Repos.Delete(ctx, current.Service, current.Name, s.CallOptions...)
+	if err != nil && !errors.Is(err, coveralls.ErrRepoNotFound) {
+		return err
+	}
+	return s.poll(ctx, func() (bool, error) {
+		exists, err := s.Repos.Exists(ctx, current.Service, current.Name, s.CallOptions...)
+		return !exists, err
+	})
+}
+
+// Import returns the repository identified by importID, either as
+// SERVICE/NAME, e.g. github/user/repository, or by its Coveralls ID. The
+// repository's ID is then found with ID.
+func (s *Store) Import(ctx context.Context, importID string) (*coveralls.Repository, error) {
+	if svc, name, ok := strings.Cut(importID, "/"); ok {
+		if svc == "" || name == "" {
+			return nil, fmt.Errorf("invalid import ID %q: expected SERVICE/NAME, e.g. github/user/repository", importID)
+		}
+		return s.Repos.Get(ctx, svc, name, s.CallOptions...)
+	}
+	n, err := parseID(importID)
+	if err != nil {
+		return nil, err
+	}
+	return s.Repos.GetByID(ctx, n, s.CallOptions...)
+}
+
+// waitFor returns the repository svc/name once its settings match want
+func (s *Store) waitFor(ctx context.Context, svc string, name string, want *coveralls.RepositoryConfig) (*coveralls.Repository, error) {
+	var r *coveralls.Repository
+	err := s.poll(ctx, func() (bool, error) {
+		var err error
+		r, err = s.Repos.Get(ctx, svc, name, s.CallOptions...)
+		if errors.Is(err, coveralls.ErrRepoNotFound) {
+			return false, nil
+		}
+		return err == nil && Matches(r, want), err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// poll calls done until it returns true or an error, pausing PollInterval
+// between calls, or until ctx is done
+func (s *Store) poll(ctx context.Context, done func() (bool, error)) error {
+	interval := s.PollInterval
+	if interval <= 0 {
+		interval = defaultPollInterval
+	}
+	for {
+		ok, err := done()
+		if err != nil || ok {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("waiting for the change to be visible: %w", ctx.Err())
+		case <-time.After(interval):
+		}
+	}
+}
@@ -0,0 +1,233 @@
+/*
+Copyright (c) 2020 Loadsmart, Inc.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package repostate
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	coveralls "github.com/stone-payments/go-coveralls-api"
+)
+
+func pbool(b bool) *bool {
+	return &b
+}
+
+func pfloat64(v float64) *float64 {
+	return &v
+}
+
+// fakeRepositories is an eventually consistent store of repositories:
+// reads see a write only after stale more reads
+type fakeRepositories struct {
+	coveralls.RepositoryService
+	repos   map[string]*coveralls.Repository // Repositories by SERVICE/NAME, as reads see them
+	pending map[string]*coveralls.Repository // Writes not yet visible, nil for deletes
+	stale   int                              // Reads that miss each write
+	reads   int
+	updated []*coveralls.RepositoryConfig
+}
+
+func newFakeRepositories(stale int) *fakeRepositories {
+	return &fakeRepositories{
+		repos: map[string]*coveralls.Repository{
+			"github/user/api": {ID: 7, Service: "github", Name: "user/api", SendBuildStatus: pbool(true), CommitStatusFailThreshold: pfloat64(70)},
+		},
+		pending: map[string]*coveralls.Repository{},
+		stale:   stale,
+	}
+}
+
+func (f *fakeRepositories) read(key string) *coveralls.Repository {
+	if r, ok := f.pending[key]; ok {
+		f.reads++
+		if f.reads > f.stale {
+			delete(f.pending, key)
+			f.reads = 0
+			if r == nil {
+				delete(f.repos, key)
+			} else {
+				f.repos[key] = r
+			}
+		}
+	}
+	return f.repos[key]
+}
+
+func (f *fakeRepositories) write(config *coveralls.RepositoryConfig, id int) *coveralls.Repository {
+	r := &coveralls.Repository{
+		ID:                              id,
+		Service:                         config.Service,
+		Name:                            config.Name,
+		CommentOnPullRequests:           config.CommentOnPullRequests,
+		SendBuildStatus:                 config.SendBuildStatus,
+		CommitStatusFailThreshold:       config.CommitStatusFailThreshold,
+		CommitStatusFailChangeThreshold: config.CommitStatusFailChangeThreshold,
+	}
+	f.pending[config.Service+"/"+config.Name] = r
+	return r
+}
+
+func (f *fakeRepositories) Get(ctx context.Context, svc string, repo string, opts ...coveralls.CallOption) (*coveralls.Repository, error) {
+	if r := f.read(svc + "/" + repo); r != nil {
+		return r, nil
+	}
+	return nil, coveralls.ErrRepoNotFound
+}
+
+func (f *fakeRepositories) GetByID(ctx context.Context, id int, opts ...coveralls.CallOption) (*coveralls.Repository, error) {
+	for _, r := range f.repos {
+		if r.ID == id {
+			return r, nil
+		}
+	}
+	return nil, coveralls.ErrRepoNotFound
+}
+
+func (f *fakeRepositories) Exists(ctx context.Context, svc string, repo string, opts ...coveralls.CallOption) (bool, error) {
+	return f.read(svc+"/"+repo) != nil, nil
+}
+
+func (f *fakeRepositories) Add(ctx context.Context, data *coveralls.RepositoryConfig, opts ...coveralls.CallOption) (*coveralls.Repository, error) {
+	return f.write(data, 8), nil
+}
+
+func (f *fakeRepositories) Update(ctx context.Context, svc string, repo string, data *coveralls.RepositoryConfig, opts ...coveralls.CallOption) (*coveralls.Repository, error) {
+	f.updated = append(f.updated, data)
+	return f.write(data, f.repos[svc+"/"+repo].ID), nil
+}
+
+func (f *fakeRepositories) Delete(ctx context.Context, svc string, repo string, opts ...coveralls.CallOption) error {
+	f.pending[svc+"/"+repo] = nil
+	return nil
+}
+
+func newStore(repos *fakeRepositories) *Store {
+	s := New(repos)
+	s.PollInterval = time.Millisecond
+	return s
+}
+
+func TestStoreCreate(t *testing.T) {
+	repos := newFakeRepositories(2)
+	config := &coveralls.RepositoryConfig{Service: "github", Name: "user/web", CommitStatusFailThreshold: pfloat64(80)}
+
+	r, err := newStore(repos).Create(context.Background(), config)
+
+	require.NoError(t, err)
+	assert.Equal(t, "8", ID(r))
+	assert.Equal(t, 80.0, *r.CommitStatusFailThreshold)
+}
+
+func TestStoreRead(t *testing.T) {
+	s := newStore(newFakeRepositories(0))
+
+	r, err := s.Read(context.Background(), "7")
+	require.NoError(t, err)
+	assert.Equal(t, "user/api", r.Name)
+
+	r, err = s.Read(context.Background(), "99")
+	assert.NoError(t, err, "a repository that is gone is not an error")
+	assert.Nil(t, r)
+
+	_, err = s.Read(context.Background(), "github/user/api")
+	assert.Error(t, err)
+}
+
+func TestStoreUpdate(t *testing.T) {
+	repos := newFakeRepositories(3)
+
+	r, err := newStore(repos).Update(context.Background(), "7", &coveralls.RepositoryConfig{CommitStatusFailThreshold: pfloat64(85)})
+
+	require.NoError(t, err)
+	assert.Equal(t, 85.0, *r.CommitStatusFailThreshold)
+	assert.Equal(t, []*coveralls.RepositoryConfig{
+		{Service: "github", Name: "user/api", SendBuildStatus: pbool(true), CommitStatusFailThreshold: pfloat64(85)},
+	}, repos.updated, "settings left out of the patch keep their values")
+}
+
+func TestStoreUpdateTimeout(t *testing.T) {
+	repos := newFakeRepositories(1000)
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	r, err := newStore(repos).Update(ctx, "7", &coveralls.RepositoryConfig{CommitStatusFailThreshold: pfloat64(85)})
+
+	assert.Nil(t, r)
+	assert.True(t, errors.Is(err, context.DeadlineExceeded))
+}
+
+func TestStoreDelete(t *testing.T) {
+	repos := newFakeRepositories(2)
+	s := newStore(repos)
+
+	require.NoError(t, s.Delete(context.Background(), "7"))
+	assert.Empty(t, repos.repos)
+
+	assert.NoError(t, s.Delete(context.Background(), "7"), "deleting twice succeeds")
+}
+
+func TestStoreImport(t *testing.T) {
+	var testCases = []struct {
+		name     string
+		importID string
+		err      bool
+	}{
+		{name: "service and name", importID: "github/user/api"},
+		{name: "coveralls id", importID: "7"},
+		{name: "missing name", importID: "github/", err: true},
+		{name: "invalid", importID: "api", err: true},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			r, err := newStore(newFakeRepositories(0)).Import(context.Background(), tt.importID)
+
+			if tt.err {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, "7", ID(r))
+		})
+	}
+}
+
+func TestMerge(t *testing.T) {
+	current := &coveralls.Repository{ID: 7, Service: "github", Name: "user/api", CommentOnPullRequests: pbool(true), CommitStatusFailThreshold: pfloat64(70)}
+	patch := &coveralls.RepositoryConfig{CommentOnPullRequests: pbool(false), CommitStatusFailChangeThreshold: pfloat64(1)}
+
+	merged := Merge(current, patch)
+
+	assert.Equal(t, &coveralls.RepositoryConfig{
+		Service: "github", Name: "user/api", CommentOnPullRequests: pbool(false),
+		CommitStatusFailThreshold: pfloat64(70), CommitStatusFailChangeThreshold: pfloat64(1),
+	}, merged)
+	assert.True(t, Matches(&coveralls.Repository{CommentOnPullRequests: pbool(false), CommitStatusFailThreshold: pfloat64(70), CommitStatusFailChangeThreshold: pfloat64(1)}, merged))
+	assert.False(t, Matches(current, merged))
+}
@@ -0,0 +1,93 @@
+/*
+Copyright (c) 2020 Loadsmart, Inc.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package reposync
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	coveralls "github.com/stone-payments/go-coveralls-api"
+)
+
+// FieldExists is the field of a Drift reporting a repository of the
+// manifest that is missing from Coveralls
+const FieldExists = "exists"
+
+// Drift is a setting of a repository in Coveralls that differs from the
+// manifest, e.g. a threshold changed in the web interface
+type Drift struct {
+	Repo     string      `json:"repo"`     // Repository, as SERVICE/NAME
+	Field    string      `json:"field"`    // Name of the setting, or FieldExists
+	Expected interface{} `json:"expected"` // Value in the manifest
+	Actual   interface{} `json:"actual"`   // Value in Coveralls, nil if unset
+}
+
+// Diff compares the repositories of m with the ones in Coveralls, fetched
+// with repos, and returns the settings that drifted from the manifest. It
+// changes nothing, so it can run on a schedule to alert about drift.
+func Diff(ctx context.Context, repos coveralls.RepositoryService, m *Manifest, opts ...coveralls.CallOption) ([]Drift, error) {
+	plan, err := NewPlan(ctx, repos, m, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return plan.Drift(), nil
+}
+
+// Drift returns the changes of the plan as drift, one per setting. A
+// repository to create is reported once, with field FieldExists.
+func (p *Plan) Drift() []Drift {
+	drift := []Drift{}
+	for _, c := range p.Changes {
+		repo := c.Service + "/" + c.Name
+		switch c.Action {
+		case ActionCreate:
+			drift = append(drift, Drift{Repo: repo, Field: FieldExists, Expected: true, Actual: false})
+		case ActionUpdate:
+			for _, f := range c.Fields {
+				drift = append(drift, Drift{Repo: repo, Field: f.Field, Expected: f.Desired, Actual: f.Current})
+			}
+		}
+	}
+	return drift
+}
+
+// WriteTable writes drift to w as a table aligned for terminals, with a
+// header line
+func WriteTable(w io.Writer, drift []Drift) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "REPO\tFIELD\tEXPECTED\tACTUAL")
+	for _, d := range drift {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", d.Repo, d.Field, formatValue(d.Expected), formatValue(d.Actual))
+	}
+	return tw.Flush()
+}
+
+// formatValue formats a setting for humans, with unset settings as "unset"
+func formatValue(v interface{}) string {
+	if v == nil {
+		return "unset"
+	}
+	return fmt.Sprint(v)
+}
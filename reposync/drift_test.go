@@ -0,0 +1,102 @@
+/*
+Copyright (c) 2020 Loadsmart, Inc.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package reposync
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	coveralls "github.com/stone-payments/go-coveralls-api"
+)
+
+func TestDiff(t *testing.T) {
+	repos := newFakeRepositories()
+	repos.repos["github/user/web"].SendBuildStatus = pbool(true)
+	m := &Manifest{
+		Defaults: manifest.Defaults,
+		Repositories: append([]Repository{
+			{Service: "github", Name: "user/web", Settings: Settings{SendBuildStatus: pbool(false), CommitStatusFailChangeThreshold: pfloat64(1)}},
+		}, manifest.Repositories[0], manifest.Repositories[2]),
+	}
+
+	drift, err := Diff(context.Background(), repos, m)
+
+	require.NoError(t, err)
+	assert.Equal(t, []Drift{
+		{Repo: "github/user/web", Field: "send_build_status", Expected: false, Actual: true},
+		{Repo: "github/user/web", Field: "commit_status_fail_change_threshold", Expected: 1.0},
+		{Repo: "github/user/api", Field: "commit_status_fail_threshold", Expected: 80.0, Actual: 70.0},
+		{Repo: "github/user/new", Field: FieldExists, Expected: true, Actual: false},
+	}, drift)
+	assert.Empty(t, repos.added)
+	assert.Empty(t, repos.updated)
+
+	b, err := json.Marshal(drift[1:3])
+	require.NoError(t, err)
+	assert.JSONEq(t, `[
+		{"repo": "github/user/web", "field": "commit_status_fail_change_threshold", "expected": 1, "actual": null},
+		{"repo": "github/user/api", "field": "commit_status_fail_threshold", "expected": 80, "actual": 70}
+	]`, string(b))
+}
+
+func TestDiffNone(t *testing.T) {
+	repos := newFakeRepositories()
+	m := &Manifest{Repositories: []Repository{{Service: "github", Name: "user/web", Settings: Settings{CommitStatusFailThreshold: pfloat64(80)}}}}
+
+	drift, err := Diff(context.Background(), repos, m)
+
+	require.NoError(t, err)
+	assert.NotNil(t, drift, "no drift is an empty list, encoded as []")
+	assert.Empty(t, drift)
+}
+
+func TestDiffError(t *testing.T) {
+	repos := newFakeRepositories()
+	repos.failing["github/user/api"] = coveralls.ErrForbidden
+
+	drift, err := Diff(context.Background(), repos, manifest)
+
+	assert.Nil(t, drift)
+	assert.True(t, errors.Is(err, coveralls.ErrForbidden))
+}
+
+func TestWriteTable(t *testing.T) {
+	var b strings.Builder
+
+	err := WriteTable(&b, []Drift{
+		{Repo: "github/user/api", Field: "commit_status_fail_threshold", Expected: 80.0, Actual: 70.5},
+		{Repo: "github/user/web", Field: "send_build_status", Expected: false},
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, `REPO             FIELD                         EXPECTED  ACTUAL
+github/user/api  commit_status_fail_threshold  80        70.5
+github/user/web  send_build_status             false     unset
+`, b.String())
+}
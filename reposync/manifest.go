@@ -0,0 +1,203 @@
+/*
+Copyright (c) 2020 Loadsmart, Inc.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+// Package reposync keeps the configuration of Coveralls repositories in sync
+// with a manifest, e.g. a repos.yaml file reviewed like code. NewPlan
+// compares the manifest with the repositories in Coveralls, and Apply adds
+// the missing ones and updates the settings that differ. Repositories left
+// out of the manifest are never changed nor deleted.
+package reposync
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+
+	yaml "gopkg.in/yaml.v2"
+
+	coveralls "github.com/stone-payments/go-coveralls-api"
+)
+
+// Manifest declares the repositories that should be in Coveralls and their
+// settings. In YAML:
+//
+//	defaults:
+//	  commit_status_fail_threshold: 80
+//	repositories:
+//	  - service: github
+//	    name: user/repository
+//	    comment_on_pull_requests: false
+type Manifest struct {
+	Defaults     Settings     `json:"defaults"`     // Settings of repositories that don't set them
+	Repositories []Repository `json:"repositories"` // Repositories, each listed once
+}
+
+// Settings are the settings of a repository, named as in the Coveralls API.
+// Nil settings are not managed by the manifest and are left unchanged.
+type Settings struct {
+	CommentOnPullRequests           *bool    `json:"comment_on_pull_requests,omitempty"`
+	SendBuildStatus                 *bool    `json:"send_build_status,omitempty"`
+	CommitStatusFailThreshold       *float64 `json:"commit_status_fail_threshold,omitempty"`
+	CommitStatusFailChangeThreshold *float64 `json:"commit_status_fail_change_threshold,omitempty"`
+}
+
+// Repository is a repository of the manifest
+type Repository struct {
+	Service string `json:"service"` // Git provider, e.g. github
+	Name    string `json:"name"`    // Name of the repository, e.g. user/repository
+	Settings
+}
+
+// Parse reads a manifest written as YAML or JSON. Unknown fields are
+// rejected, so typos in setting names don't go unnoticed.
+func Parse(r io.Reader) (*Manifest, error) {
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	// JSON is valid YAML, so both are parsed as YAML and converted to JSON
+	// to share the field names of the API
+	var doc interface{}
+	if err := yaml.Unmarshal(b, &doc); err != nil {
+		return nil, fmt.Errorf("parsing manifest: %w", err)
+	}
+	doc, err = jsonValue(doc)
+	if err != nil {
+		return nil, fmt.Errorf("parsing manifest: %w", err)
+	}
+	b, err = json.Marshal(doc)
+	if err != nil {
+		return nil, fmt.Errorf("parsing manifest: %w", err)
+	}
+
+	var m Manifest
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&m); err != nil {
+		return nil, fmt.Errorf("parsing manifest: %w", err)
+	}
+	if err := m.Validate(); err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+// ReadFile reads the manifest in the file name, as Parse does
+func ReadFile(name string) (*Manifest, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	m, err := Parse(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	return m, nil
+}
+
+// jsonValue converts a value parsed from YAML into one that can be encoded
+// as JSON, whose objects have string keys
+func jsonValue(v interface{}) (interface{}, error) {
+	switch v := v.(type) {
+	case map[interface{}]interface{}:
+		object := make(map[string]interface{}, len(v))
+		for key, value := range v {
+			name, ok := key.(string)
+			if !ok {
+				return nil, fmt.Errorf("invalid key %v: keys must be strings", key)
+			}
+			value, err := jsonValue(value)
+			if err != nil {
+				return nil, err
+			}
+			object[name] = value
+		}
+		return object, nil
+	case []interface{}:
+		for i, value := range v {
+			value, err := jsonValue(value)
+			if err != nil {
+				return nil, err
+			}
+			v[i] = value
+		}
+		return v, nil
+	default:
+		return v, nil
+	}
+}
+
+// Validate checks that every repository has a service and a name, and is
+// listed once
+func (m *Manifest) Validate() error {
+	seen := make(map[string]bool, len(m.Repositories))
+	for i, r := range m.Repositories {
+		if r.Service == "" || r.Name == "" {
+			return fmt.Errorf("repository %d: missing service or name", i+1)
+		}
+		key := r.Service + "/" + r.Name
+		if seen[key] {
+			return fmt.Errorf("repository %s is listed more than once", key)
+		}
+		seen[key] = true
+	}
+	return nil
+}
+
+// Configs returns the desired configuration of each repository, with the
+// defaults applied, in the order of the manifest
+func (m *Manifest) Configs() []*coveralls.RepositoryConfig {
+	configs := make([]*coveralls.RepositoryConfig, 0, len(m.Repositories))
+	for _, r := range m.Repositories {
+		s := r.Settings.withDefaults(m.Defaults)
+		configs = append(configs, &coveralls.RepositoryConfig{
+			Service:                         r.Service,
+			Name:                            r.Name,
+			CommentOnPullRequests:           s.CommentOnPullRequests,
+			SendBuildStatus:                 s.SendBuildStatus,
+			CommitStatusFailThreshold:       s.CommitStatusFailThreshold,
+			CommitStatusFailChangeThreshold: s.CommitStatusFailChangeThreshold,
+		})
+	}
+	return configs
+}
+
+// withDefaults returns s with its unset settings taken from defaults
+func (s Settings) withDefaults(defaults Settings) Settings {
+	if s.CommentOnPullRequests == nil {
+		s.CommentOnPullRequests = defaults.CommentOnPullRequests
+	}
+	if s.SendBuildStatus == nil {
+		s.SendBuildStatus = defaults.SendBuildStatus
+	}
+	if s.CommitStatusFailThreshold == nil {
+		s.CommitStatusFailThreshold = defaults.CommitStatusFailThreshold
+	}
+	if s.CommitStatusFailChangeThreshold == nil {
+		s.CommitStatusFailChangeThreshold = defaults.CommitStatusFailChangeThreshold
+	}
+	return s
+}
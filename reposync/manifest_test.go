@@ -0,0 +1,132 @@
+/*
+Copyright (c) 2020 Loadsmart, Inc.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package reposync
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	coveralls "github.com/stone-payments/go-coveralls-api"
+)
+
+func pbool(b bool) *bool {
+	return &b
+}
+
+func pfloat64(v float64) *float64 {
+	return &v
+}
+
+func TestParse(t *testing.T) {
+	want := &Manifest{
+		Defaults: Settings{CommitStatusFailThreshold: pfloat64(80), SendBuildStatus: pbool(true)},
+		Repositories: []Repository{
+			{Service: "github", Name: "user/api"},
+			{Service: "github", Name: "user/web", Settings: Settings{CommentOnPullRequests: pbool(false), CommitStatusFailThreshold: pfloat64(60)}},
+		},
+	}
+
+	var testCases = []struct {
+		name  string
+		input string
+	}{
+		{
+			name: "yaml",
+			input: `
+defaults:
+  commit_status_fail_threshold: 80
+  send_build_status: true
+repositories:
+  - service: github
+    name: user/api
+  - service: github
+    name: user/web
+    comment_on_pull_requests: false
+    commit_status_fail_threshold: 60.0
+`,
+		},
+		{
+			name: "json",
+			input: `{
+  "defaults": {"commit_status_fail_threshold": 80, "send_build_status": true},
+  "repositories": [
+    {"service": "github", "name": "user/api"},
+    {"service": "github", "name": "user/web", "comment_on_pull_requests": false, "commit_status_fail_threshold": 60}
+  ]
+}`,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := Parse(strings.NewReader(tt.input))
+
+			require.NoError(t, err)
+			assert.Equal(t, want, m)
+		})
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	var testCases = []struct {
+		name  string
+		input string
+		err   string
+	}{
+		{name: "syntax", input: "repositories: [", err: "parsing manifest"},
+		{name: "unknown field", input: "repositories:\n  - service: github\n    name: user/api\n    fail_threshold: 80\n", err: `unknown field "fail_threshold"`},
+		{name: "wrong type", input: "defaults:\n  send_build_status: yes please\n", err: "parsing manifest"},
+		{name: "missing name", input: "repositories:\n  - service: github\n", err: "repository 1: missing service or name"},
+		{name: "duplicate", input: "repositories:\n  - {service: github, name: user/api}\n  - {service: github, name: user/api}\n", err: "github/user/api is listed more than once"},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := Parse(strings.NewReader(tt.input))
+
+			assert.Nil(t, m)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.err)
+		})
+	}
+}
+
+func TestManifestConfigs(t *testing.T) {
+	m := &Manifest{
+		Defaults: Settings{CommentOnPullRequests: pbool(true), CommitStatusFailThreshold: pfloat64(80)},
+		Repositories: []Repository{
+			{Service: "github", Name: "user/api"},
+			{Service: "gitlab", Name: "user/web", Settings: Settings{CommitStatusFailThreshold: pfloat64(60)}},
+		},
+	}
+
+	configs := m.Configs()
+
+	assert.Equal(t, []*coveralls.RepositoryConfig{
+		{Service: "github", Name: "user/api", CommentOnPullRequests: pbool(true), CommitStatusFailThreshold: pfloat64(80)},
+		{Service: "gitlab", Name: "user/web", CommentOnPullRequests: pbool(true), CommitStatusFailThreshold: pfloat64(60)},
+	}, configs)
+}
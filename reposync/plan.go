@@ -0,0 +1,186 @@
+/*
+Copyright (c) 2020 Loadsmart, Inc.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package reposync
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	coveralls "github.com/stone-payments/go-coveralls-api"
+)
+
+// Action is what Apply does with a repository
+type Action string
+
+// Actions, as found in Change.Action
+const (
+	ActionCreate Action = "create" // Add the repository to Coveralls
+	ActionUpdate Action = "update" // Update settings that differ from the manifest
+	ActionNone   Action = "none"   // Leave the repository as it is
+)
+
+// FieldChange is a setting whose value in Coveralls differs from the manifest
+type FieldChange struct {
+	Field   string      `json:"field"`   // Name of the setting, e.g. commit_status_fail_threshold
+	Current interface{} `json:"current"` // Value in Coveralls, nil if unset or the repository is missing
+	Desired interface{} `json:"desired"` // Value in the manifest
+}
+
+func (c FieldChange) String() string {
+	if c.Current == nil {
+		return fmt.Sprintf("%s: %v", c.Field, c.Desired)
+	}
+	return fmt.Sprintf("%s: %v -> %v", c.Field, c.Current, c.Desired)
+}
+
+// Change is what Apply does to bring a repository in line with the manifest
+type Change struct {
+	Action  Action        `json:"action"`
+	Service string        `json:"service"`
+	Name    string        `json:"name"`
+	Fields  []FieldChange `json:"fields,omitempty"` // Settings that Apply sets
+
+	config *coveralls.RepositoryConfig
+}
+
+// Plan holds the changes that bring Coveralls in line with a manifest, in
+// the order of its repositories
+type Plan struct {
+	Changes []Change `json:"changes"`
+}
+
+// NewPlan compares the repositories of m with the ones in Coveralls,
+// fetched with repos, and returns the changes needed to apply it. Nothing
+// is changed until Plan.Apply is called, so it is a dry run.
+func NewPlan(ctx context.Context, repos coveralls.RepositoryService, m *Manifest, opts ...coveralls.CallOption) (*Plan, error) {
+	plan := &Plan{Changes: make([]Change, 0, len(m.Repositories))}
+	for _, config := range m.Configs() {
+		current, err := repos.Get(ctx, config.Service, config.Name, opts...)
+		if err != nil && !errors.Is(err, coveralls.ErrRepoNotFound) {
+			return nil, fmt.Errorf("getting %s/%s: %w", config.Service, config.Name, err)
+		}
+
+		change := Change{Service: config.Service, Name: config.Name, config: config}
+		if current == nil {
+			change.Action = ActionCreate
+			change.Fields = fieldChanges(config, &coveralls.Repository{})
+		} else if change.Fields = fieldChanges(config, current); len(change.Fields) > 0 {
+			change.Action = ActionUpdate
+		} else {
+			change.Action = ActionNone
+		}
+		plan.Changes = append(plan.Changes, change)
+	}
+	return plan, nil
+}
+
+// fieldChanges returns the settings of desired that differ from current.
+// Settings desired leaves unset are not compared.
+func fieldChanges(desired *coveralls.RepositoryConfig, current *coveralls.Repository) []FieldChange {
+	var changes []FieldChange
+	changes = appendBool(changes, "comment_on_pull_requests", current.CommentOnPullRequests, desired.CommentOnPullRequests)
+	changes = appendBool(changes, "send_build_status", current.SendBuildStatus, desired.SendBuildStatus)
+	changes = appendFloat(changes, "commit_status_fail_threshold", current.CommitStatusFailThreshold, desired.CommitStatusFailThreshold)
+	changes = appendFloat(changes, "commit_status_fail_change_threshold", current.CommitStatusFailChangeThreshold, desired.CommitStatusFailChangeThreshold)
+	return changes
+}
+
+// appendBool appends a change of field to changes if desired is set and
+// differs from current
+func appendBool(changes []FieldChange, field string, current *bool, desired *bool) []FieldChange {
+	if desired == nil || (current != nil && *current == *desired) {
+		return changes
+	}
+	change := FieldChange{Field: field, Desired: *desired}
+	if current != nil {
+		change.Current = *current
+	}
+	return append(changes, change)
+}
+
+// appendFloat appends a change of field to changes if desired is set and
+// differs from current
+func appendFloat(changes []FieldChange, field string, current *float64, desired *float64) []FieldChange {
+	if desired == nil || (current != nil && *current == *desired) {
+		return changes
+	}
+	change := FieldChange{Field: field, Desired: *desired}
+	if current != nil {
+		change.Current = *current
+	}
+	return append(changes, change)
+}
+
+// Pending returns how many repositories Apply changes
+func (p *Plan) Pending() int {
+	n := 0
+	for _, c := range p.Changes {
+		if c.Action != ActionNone {
+			n++
+		}
+	}
+	return n
+}
+
+// Apply adds and updates the repositories of the plan with repos. It goes
+// on after a repository fails, and returns the errors of all that failed.
+func (p *Plan) Apply(ctx context.Context, repos coveralls.RepositoryService, opts ...coveralls.CallOption) error {
+	var errs []error
+	for _, c := range p.Changes {
+		var err error
+		switch c.Action {
+		case ActionCreate:
+			_, err = repos.Add(ctx, c.config, opts...)
+		case ActionUpdate:
+			_, err = repos.Update(ctx, c.Service, c.Name, c.config, opts...)
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s %s/%s: %w", c.Action, c.Service, c.Name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// String returns the plan as text, one line per repository to change
+// followed by its settings, e.g.
+//
+//	update github/user/repository
+//	  commit_status_fail_threshold: 70 -> 80
+func (p *Plan) String() string {
+	if p.Pending() == 0 {
+		return "no changes\n"
+	}
+	var b strings.Builder
+	for _, c := range p.Changes {
+		if c.Action == ActionNone {
+			continue
+		}
+		fmt.Fprintf(&b, "%s %s/%s\n", c.Action, c.Service, c.Name)
+		for _, f := range c.Fields {
+			fmt.Fprintf(&b, "  %s\n", f)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,160 @@
+/*
+Copyright (c) 2020 Loadsmart, Inc.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package reposync
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	coveralls "github.com/stone-payments/go-coveralls-api"
+)
+
+// fakeRepositories serves repositories from memory, recording changes
+type fakeRepositories struct {
+	coveralls.RepositoryService
+	repos   map[string]*coveralls.Repository
+	failing map[string]error
+	added   []*coveralls.RepositoryConfig
+	updated []*coveralls.RepositoryConfig
+}
+
+func (f *fakeRepositories) Get(ctx context.Context, svc string, repo string, opts ...coveralls.CallOption) (*coveralls.Repository, error) {
+	if err := f.failing[svc+"/"+repo]; err != nil {
+		return nil, err
+	}
+	r, ok := f.repos[svc+"/"+repo]
+	if !ok {
+		return nil, coveralls.ErrRepoNotFound
+	}
+	return r, nil
+}
+
+func (f *fakeRepositories) Add(ctx context.Context, data *coveralls.RepositoryConfig, opts ...coveralls.CallOption) (*coveralls.Repository, error) {
+	f.added = append(f.added, data)
+	return &coveralls.Repository{Service: data.Service, Name: data.Name}, nil
+}
+
+func (f *fakeRepositories) Update(ctx context.Context, svc string, repo string, data *coveralls.RepositoryConfig, opts ...coveralls.CallOption) (*coveralls.Repository, error) {
+	if err := f.failing["update "+svc+"/"+repo]; err != nil {
+		return nil, err
+	}
+	f.updated = append(f.updated, data)
+	return &coveralls.Repository{Service: svc, Name: repo}, nil
+}
+
+func newFakeRepositories() *fakeRepositories {
+	return &fakeRepositories{
+		repos: map[string]*coveralls.Repository{
+			"github/user/api": {Service: "github", Name: "user/api", CommentOnPullRequests: pbool(true), CommitStatusFailThreshold: pfloat64(70)},
+			"github/user/web": {Service: "github", Name: "user/web", CommentOnPullRequests: pbool(true), CommitStatusFailThreshold: pfloat64(80)},
+		},
+		failing: map[string]error{},
+	}
+}
+
+var manifest = &Manifest{
+	Defaults: Settings{CommitStatusFailThreshold: pfloat64(80)},
+	Repositories: []Repository{
+		{Service: "github", Name: "user/api"},
+		{Service: "github", Name: "user/web"},
+		{Service: "github", Name: "user/new", Settings: Settings{CommentOnPullRequests: pbool(false)}},
+	},
+}
+
+func TestNewPlan(t *testing.T) {
+	repos := newFakeRepositories()
+
+	plan, err := NewPlan(context.Background(), repos, manifest)
+
+	require.NoError(t, err)
+	require.Len(t, plan.Changes, 3)
+	assert.Equal(t, ActionUpdate, plan.Changes[0].Action)
+	assert.Equal(t, []FieldChange{{Field: "commit_status_fail_threshold", Current: 70.0, Desired: 80.0}}, plan.Changes[0].Fields)
+	assert.Equal(t, ActionNone, plan.Changes[1].Action)
+	assert.Empty(t, plan.Changes[1].Fields)
+	assert.Equal(t, ActionCreate, plan.Changes[2].Action)
+	assert.Equal(t, []FieldChange{
+		{Field: "comment_on_pull_requests", Desired: false},
+		{Field: "commit_status_fail_threshold", Desired: 80.0},
+	}, plan.Changes[2].Fields)
+	assert.Equal(t, 2, plan.Pending())
+	assert.Equal(t, `update github/user/api
+  commit_status_fail_threshold: 70 -> 80
+create github/user/new
+  comment_on_pull_requests: false
+  commit_status_fail_threshold: 80
+`, plan.String())
+	assert.Empty(t, repos.added)
+	assert.Empty(t, repos.updated)
+}
+
+func TestNewPlanError(t *testing.T) {
+	repos := newFakeRepositories()
+	repos.failing["github/user/web"] = coveralls.ErrUnauthorized
+
+	plan, err := NewPlan(context.Background(), repos, manifest)
+
+	assert.Nil(t, plan)
+	assert.True(t, errors.Is(err, coveralls.ErrUnauthorized))
+	assert.Contains(t, err.Error(), "github/user/web")
+}
+
+func TestPlanApply(t *testing.T) {
+	repos := newFakeRepositories()
+	plan, err := NewPlan(context.Background(), repos, manifest)
+	require.NoError(t, err)
+
+	err = plan.Apply(context.Background(), repos)
+
+	require.NoError(t, err)
+	assert.Equal(t, []*coveralls.RepositoryConfig{
+		{Service: "github", Name: "user/new", CommentOnPullRequests: pbool(false), CommitStatusFailThreshold: pfloat64(80)},
+	}, repos.added)
+	assert.Equal(t, []*coveralls.RepositoryConfig{
+		{Service: "github", Name: "user/api", CommitStatusFailThreshold: pfloat64(80)},
+	}, repos.updated)
+}
+
+func TestPlanApplyError(t *testing.T) {
+	repos := newFakeRepositories()
+	plan, err := NewPlan(context.Background(), repos, manifest)
+	require.NoError(t, err)
+	repos.failing["update github/user/api"] = coveralls.ErrUnexpectedStatusCode{StatusCode: 500}
+
+	err = plan.Apply(context.Background(), repos)
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "update github/user/api")
+	assert.Len(t, repos.added, 1, "repositories after the failure are still applied")
+}
+
+func TestPlanNoChanges(t *testing.T) {
+	plan := &Plan{Changes: []Change{{Action: ActionNone, Service: "github", Name: "user/api"}}}
+
+	assert.Equal(t, 0, plan.Pending())
+	assert.Equal(t, "no changes\n", plan.String())
+}
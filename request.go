@@ -0,0 +1,569 @@
+/*
+Copyright (c) 2020 Loadsmart, Inc.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package coveralls
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// ErrTimeout is returned when a request does not finish before the timeout
+// set with WithTimeout or the deadline of its context. The error returned
+// also matches the underlying cause, e.g. context.DeadlineExceeded.
+var ErrTimeout = errors.New("request timed out")
+
+// timeoutError wraps the error of a request that timed out
+type timeoutError struct {
+	err error
+}
+
+func (e timeoutError) Error() string {
+	return fmt.Sprintf("%s: %s", ErrTimeout, e.err)
+}
+
+func (e timeoutError) Is(target error) bool {
+	return target == ErrTimeout
+}
+
+func (e timeoutError) Unwrap() error {
+	return e.err
+}
+
+// Retryable reports that timeouts are transient
+func (e timeoutError) Retryable() bool {
+	return true
+}
+
+// transportError wraps the error of a request that got no response, e.g.
+// because the connection failed
+type transportError struct {
+	err error
+}
+
+func (e transportError) Error() string {
+	return e.err.Error()
+}
+
+func (e transportError) Unwrap() error {
+	return e.err
+}
+
+// Retryable reports that network errors are transient, unless the request
+// was canceled by the caller
+func (e transportError) Retryable() bool {
+	return !errors.Is(e.err, context.Canceled)
+}
+
+// Doer sends HTTP requests. *http.Client implements it; see WithDoer to
+// use other implementations.
+type Doer interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+// bodyOpener opens the body of a request, once for each attempt, so
+// requests that stream their body may be retried
+type bodyOpener func() (io.ReadCloser, error)
+
+// lazyBody is a request body opened on the first read, so nothing is opened
+// for requests that are never sent
+type lazyBody struct {
+	open bodyOpener
+	rc   io.ReadCloser
+}
+
+func (b *lazyBody) Read(p []byte) (int, error) {
+	if b.rc == nil {
+		rc, err := b.open()
+		if err != nil {
+			return 0, err
+		}
+		b.rc = rc
+	}
+	return b.rc.Read(p)
+}
+
+func (b *lazyBody) Close() error {
+	if b.rc == nil {
+		return nil
+	}
+	return b.rc.Close()
+}
+
+// request is a single API request being built by a service method
+type request struct {
+	client   *Client
+	ctx      context.Context
+	settings *callSettings
+	header   http.Header
+	query    url.Values
+	body     interface{}
+	result   interface{}
+	cacheKey string // Key of the response in the cache, set by revalidate
+}
+
+// response is the response to a request, with the body already read
+type response struct {
+	RawResponse *http.Response
+	request     *http.Request
+	body        []byte
+	result      interface{}
+	secrets     []string // Credentials redacted from errorBody
+}
+
+// StatusCode returns the HTTP status code of the response
+func (r *response) StatusCode() int {
+	return r.RawResponse.StatusCode
+}
+
+// Header returns the HTTP headers of the response
+func (r *response) Header() http.Header {
+	return r.RawResponse.Header
+}
+
+// Body returns the response body
+func (r *response) Body() []byte {
+	return r.body
+}
+
+// Result returns the value set with request.SetResult, decoded from the
+// body if the response was successful
+func (r *response) Result() interface{} {
+	return r.result
+}
+
+// errorBody returns the response body with credentials redacted, for errors
+func (r *response) errorBody() string {
+	return string(redactBody(r.body, r.secrets))
+}
+
+// decodeFailure returns the error for a successful response whose body
+// couldn't be decoded into its result
+func (r *response) decodeFailure(err error) *APIError {
+	return r.apiError(newErrDecodeFailure(r.errorBody(), err))
+}
+
+// apiError returns an *APIError for the response, wrapping err
+func (r *response) apiError(err error) *APIError {
+	return &APIError{
+		Method:     r.request.Method,
+		URL:        redactURL(r.request.URL).String(),
+		StatusCode: r.StatusCode(),
+		RequestID:  requestID(r.Header()),
+		Body:       r.errorBody(),
+		Err:        err,
+	}
+}
+
+// newRequest returns a request bound to ctx and configured with opts
+func (c *Client) newRequest(ctx context.Context, opts []CallOption) *request {
+	return &request{
+		client:   c,
+		ctx:      ctx,
+		settings: newCallSettings(ctx, opts),
+		header:   make(http.Header),
+		query:    make(url.Values),
+	}
+}
+
+// SetResult sets the value the response body is decoded into on success
+func (r *request) SetResult(v interface{}) *request {
+	r.result = v
+	return r
+}
+
+// SetBody sets the request body. A bodyOpener is called to open the body
+// again for each attempt. Values other than bodyOpener, io.Reader, string
+// and []byte are encoded as JSON.
+func (r *request) SetBody(v interface{}) *request {
+	r.body = v
+	return r
+}
+
+// SetHeader sets a request header
+func (r *request) SetHeader(key string, value string) *request {
+	r.header.Set(key, value)
+	return r
+}
+
+// SetQueryParam sets a query string parameter
+func (r *request) SetQueryParam(key string, value string) *request {
+	r.query.Set(key, value)
+	return r
+}
+
+// SetQueryParams sets several query string parameters
+func (r *request) SetQueryParams(params map[string]string) *request {
+	for k, v := range params {
+		r.query.Set(k, v)
+	}
+	return r
+}
+
+// Get sends the request with method GET to endpoint, a path relative to the host URL
+func (r *request) Get(endpoint string) (*response, error) {
+	return r.execute(http.MethodGet, endpoint)
+}
+
+// Post sends the request with method POST
+func (r *request) Post(endpoint string) (*response, error) {
+	return r.execute(http.MethodPost, endpoint)
+}
+
+// Put sends the request with method PUT
+func (r *request) Put(endpoint string) (*response, error) {
+	return r.execute(http.MethodPut, endpoint)
+}
+
+// Delete sends the request with method DELETE
+func (r *request) Delete(endpoint string) (*response, error) {
+	return r.execute(http.MethodDelete, endpoint)
+}
+
+// execute sends the request to endpoint, applying the timeout and host URL
+// of the call or the client, and reads the response. Requests that fail
+// with transient errors are retried according to the retry policy.
+func (r *request) execute(method string, endpoint string) (*response, error) {
+	rawURL, err := r.url(endpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	send := func() (*response, error) {
+		resp, err := r.send(method, rawURL)
+		// Only the request that was sent knows the credential of its cache key
+		if stale := r.stale(method, resp, err); stale != nil {
+			return stale, nil
+		}
+		return resp, err
+	}
+	if r.client.flights != nil && r.shareable(method) {
+		return r.client.flights.do(r, r.key(rawURL), send)
+	}
+	return send()
+}
+
+// url returns the URL of endpoint in the host URL of the call or the client
+func (r *request) url(endpoint string) (string, error) {
+	hostURL := r.client.hostURL()
+	if r.settings.hostURL != "" {
+		var err error
+		hostURL, err = parseBaseURL(r.settings.hostURL)
+		if err != nil {
+			return "", err
+		}
+	}
+	return hostURL.String() + endpoint, nil
+}
+
+// send sends the request to rawURL, retrying it after transient errors
+func (r *request) send(method string, rawURL string) (*response, error) {
+	maxRetries := r.maxRetries(method)
+	for attempt := 0; ; attempt++ {
+		resp, retry, err := r.attempt(method, rawURL, attempt)
+		if !retry || attempt >= maxRetries {
+			return resp, err
+		}
+		if budget := r.client.budget; budget != nil && !budget.withdraw() {
+			return resp, err
+		}
+		var hint time.Duration
+		if resp != nil {
+			hint = retryAfter(resp.Header())
+		}
+		if waitErr := r.client.retry.wait(r.ctx, attempt, hint); waitErr != nil {
+			if err == nil {
+				err = newErrFromResponse(resp)
+			}
+			return nil, fmt.Errorf("retries aborted: %w; last error: %w", wrapTransportError(waitErr), err)
+		}
+	}
+}
+
+// maxRetries returns how many times the request may be retried
+func (r *request) maxRetries(method string) int {
+	if _, streamed := r.body.(io.Reader); streamed {
+		// The body can't be sent again
+		return 0
+	}
+	if r.settings.retries != nil {
+		return *r.settings.retries
+	}
+	if !idempotent(method) {
+		return 0
+	}
+	return r.client.retry.max
+}
+
+// attempt sends the request once and reads the response. Retry reports
+// whether it failed with a transient error and may be retried.
+func (r *request) attempt(method string, rawURL string, attempt int) (resp *response, retry bool, err error) {
+	ctx := r.ctx
+	timeout := r.client.timeout
+	if r.settings.timeout != nil {
+		timeout = *r.settings.timeout
+	}
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	req, err := r.build(ctx, method, rawURL)
+	if err != nil {
+		return nil, false, err
+	}
+	cached := r.revalidate(req)
+
+	if limiter := r.client.limiter; limiter != nil {
+		if err := limiter.acquire(ctx); err != nil {
+			return nil, false, wrapTransportError(err)
+		}
+		defer limiter.release()
+	}
+
+	breaker := r.client.breaker
+	if breaker != nil {
+		if err := breaker.allow(); err != nil {
+			return nil, false, err
+		}
+	}
+
+	secrets := r.secrets(req)
+	debug := r.client.debug
+	if debug != nil {
+		debug.request(req, secrets)
+	}
+	r.client.runRequestHooks(req)
+	if budget := r.client.budget; budget != nil && attempt == 0 {
+		budget.deposit()
+	}
+	start := time.Now()
+	raw, err := r.do(req)
+	if err != nil {
+		err = wrapTransportError(err)
+		r.failed(req, err, time.Since(start), attempt)
+		return nil, r.ctx.Err() == nil, err
+	}
+	defer raw.Body.Close()
+
+	body, err := readBody(raw)
+	if err != nil {
+		err = wrapTransportError(err)
+		r.failed(req, err, time.Since(start), attempt)
+		return nil, r.ctx.Err() == nil, err
+	}
+	if breaker != nil {
+		breaker.record(breakerFailure(raw.StatusCode))
+	}
+	elapsed := time.Since(start)
+	if debug != nil {
+		debug.response(req, raw, body, elapsed, secrets)
+	}
+	r.client.logResponse(ctx, req, raw, elapsed, attempt)
+	if len(r.client.responseHooks) > 0 {
+		r.client.runResponseHooks(raw, redactBody(body, secrets), elapsed)
+	}
+
+	if cached != nil && raw.StatusCode == http.StatusNotModified {
+		raw, body = cached.response(raw), cached.Body
+	} else {
+		r.store(req, raw, body)
+	}
+
+	if r.settings.response != nil {
+		captured := *raw
+		captured.Body = ioutil.NopCloser(bytes.NewReader(body))
+		*r.settings.response = &captured
+	}
+	if r.settings.metadata != nil {
+		*r.settings.metadata = ResponseMetadata{
+			StatusCode: raw.StatusCode,
+			RequestID:  requestID(raw.Header),
+			Retries:    attempt,
+		}
+	}
+
+	resp = &response{RawResponse: raw, request: req, body: body, result: r.result, secrets: secrets}
+	if r.result != nil && raw.StatusCode >= 200 && raw.StatusCode < 300 && raw.StatusCode != http.StatusNoContent {
+		if len(body) == 0 {
+			return resp, false, resp.decodeFailure(errEmptyBody)
+		}
+		if contentType := raw.Header.Get("Content-Type"); !jsonContentType(contentType) && !json.Valid(body) {
+			return resp, false, resp.apiError(newErrNonJSONResponse(contentType, resp.errorBody()))
+		}
+		if err := json.Unmarshal(body, r.result); err != nil {
+			return resp, false, resp.decodeFailure(err)
+		}
+	}
+	return resp, retryableStatus(raw.StatusCode), nil
+}
+
+// key identifies the requests to rawURL that may share a response, for
+// WithDeduplication
+func (r *request) key(rawURL string) string {
+	r.client.mu.RLock()
+	token := r.client.token
+	r.client.mu.RUnlock()
+	return token + " " + rawURL + "?" + r.query.Encode()
+}
+
+// secrets returns the credentials that may be used in req, to be redacted
+// from what reaches callers. They include the token sent in req, which may
+// come from a TokenProvider.
+func (r *request) secrets(req *http.Request) []string {
+	r.client.mu.RLock()
+	secrets := []string{r.client.token}
+	r.client.mu.RUnlock()
+	sent := strings.TrimPrefix(req.Header.Get("Authorization"), "token ")
+	secrets = append(secrets, r.settings.token, r.client.repoToken, sent)
+
+	nonEmpty := secrets[:0]
+	for _, secret := range secrets {
+		if secret != "" {
+			nonEmpty = append(nonEmpty, secret)
+		}
+	}
+	return nonEmpty
+}
+
+// failed reports a request that got no response to the circuit breaker,
+// debug writer and logger
+func (r *request) failed(req *http.Request, err error, elapsed time.Duration, attempt int) {
+	if breaker := r.client.breaker; breaker != nil {
+		if r.ctx.Err() != nil {
+			// Requests canceled by the caller say nothing about the API
+			breaker.release()
+		} else {
+			breaker.record(true)
+		}
+	}
+	if r.client.debug != nil {
+		r.client.debug.failure(req, err, elapsed)
+	}
+	r.client.logFailure(req.Context(), req, err, elapsed, attempt)
+}
+
+// build creates the HTTP request, with headers from the client and the token
+func (r *request) build(ctx context.Context, method string, rawURL string) (*http.Request, error) {
+	var body io.Reader
+	var payload []byte
+	contentType := ""
+	switch b := r.body.(type) {
+	case nil:
+	case io.Reader:
+		body = b
+	case bodyOpener:
+		body = &lazyBody{open: b}
+	case []byte:
+		payload = b
+	case string:
+		payload = []byte(b)
+	default:
+		encoded, err := json.Marshal(b)
+		if err != nil {
+			return nil, fmt.Errorf("encoding request body: %w", err)
+		}
+		payload = encoded
+		contentType = "application/json"
+	}
+
+	contentEncoding := ""
+	if payload != nil {
+		if r.client.gzipMinSize > 0 && len(payload) >= r.client.gzipMinSize {
+			compressed, err := gzipBytes(payload)
+			if err != nil {
+				return nil, fmt.Errorf("compressing request body: %w", err)
+			}
+			payload = compressed
+			contentEncoding = "gzip"
+		}
+		body = bytes.NewReader(payload)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, rawURL, body)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(r.query) > 0 {
+		query := req.URL.Query()
+		for k, v := range r.query {
+			query[k] = v
+		}
+		req.URL.RawQuery = query.Encode()
+	}
+
+	req.Header = r.client.headers()
+	req.Header.Set("Accept-Encoding", "gzip")
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	if contentEncoding != "" {
+		req.Header.Set("Content-Encoding", contentEncoding)
+	}
+
+	switch {
+	case r.settings.token != "":
+		req.Header.Set("Authorization", fmt.Sprintf("token %s", r.settings.token))
+	case r.client.tokenProvider != nil:
+		token, err := r.client.tokenProvider.Token(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("getting API token: %w", err)
+		}
+		req.Header.Set("Authorization", fmt.Sprintf("token %s", token))
+	}
+
+	for k, v := range r.header {
+		req.Header[k] = v
+	}
+	return req, nil
+}
+
+// wrapTransportError marks errors caused by timeouts with ErrTimeout, and
+// other errors as transport errors
+func wrapTransportError(err error) error {
+	var netErr net.Error
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		// The URL may include credentials, e.g. the repo token sent by BuildsService.Close
+		if u, parseErr := url.Parse(urlErr.URL); parseErr == nil {
+			redactedErr := *urlErr
+			redactedErr.URL = redactURL(u).String()
+			err = &redactedErr
+		}
+	}
+	if errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout()) {
+		return timeoutError{err: err}
+	}
+	return transportError{err: err}
+}
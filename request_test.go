@@ -0,0 +1,153 @@
+/*
+Copyright (c) 2020 Loadsmart, Inc.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package coveralls
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// newHangingServer returns a server whose handlers block until the test ends
+func newHangingServer(t *testing.T) *httptest.Server {
+	done := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		select {
+		case <-done:
+		case <-req.Context().Done():
+		}
+	}))
+	t.Cleanup(func() {
+		close(done)
+		server.Close()
+	})
+	return server
+}
+
+func TestWithTimeout(t *testing.T) {
+	server := newHangingServer(t)
+
+	client := NewClient("fake token", WithTimeout(20*time.Millisecond))
+	client.HostURL, _ = url.Parse(server.URL)
+
+	start := time.Now()
+	_, err := client.Repositories.Get(context.Background(), "github", "user/fakerepo")
+
+	assert.True(t, errors.Is(err, ErrTimeout), "expected ErrTimeout, got %v", err)
+	assert.True(t, errors.Is(err, context.DeadlineExceeded))
+	assert.Less(t, int64(time.Since(start)), int64(time.Second))
+}
+
+func TestContextDeadlineShorterThanTimeout(t *testing.T) {
+	server := newHangingServer(t)
+
+	client := NewClient("fake token", WithTimeout(time.Minute))
+	client.HostURL, _ = url.Parse(server.URL)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err := client.Repositories.Get(ctx, "github", "user/fakerepo")
+
+	assert.True(t, errors.Is(err, ErrTimeout), "expected ErrTimeout, got %v", err)
+	assert.Less(t, int64(time.Since(start)), int64(time.Second))
+}
+
+func TestContextCanceledIsNotTimeout(t *testing.T) {
+	server := newHangingServer(t)
+
+	client := NewClient("fake token")
+	client.HostURL, _ = url.Parse(server.URL)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(20*time.Millisecond, cancel)
+
+	_, err := client.Repositories.Get(ctx, "github", "user/fakerepo")
+
+	assert.True(t, errors.Is(err, context.Canceled), "expected context.Canceled, got %v", err)
+	assert.False(t, errors.Is(err, ErrTimeout))
+}
+
+func TestRequestDecodeError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte("not json"))
+	}))
+	defer server.Close()
+
+	client := NewClient("fake token")
+	client.HostURL, _ = url.Parse(server.URL)
+
+	_, err := client.Repositories.Get(context.Background(), "github", "user/fakerepo")
+
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "decoding response body")
+	var decodeErr ErrDecodeFailure
+	assert.True(t, errors.As(err, &decodeErr))
+	assert.Equal(t, "not json", decodeErr.Body)
+}
+
+func TestRequestEmptyBody(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	client, _ := NewEnterpriseClient(server.URL, "fake token")
+	ctx := context.Background()
+
+	var testCases = []struct {
+		name string
+		call func() (interface{}, error)
+	}{
+		{name: "Repositories.Get", call: func() (interface{}, error) { return client.Repositories.Get(ctx, "github", "user/fakerepo") }},
+		{name: "Repositories.Add", call: func() (interface{}, error) {
+			return client.Repositories.Add(ctx, &RepositoryConfig{Service: "github", Name: "user/fakerepo"})
+		}},
+		{name: "Repositories.List", call: func() (interface{}, error) { return client.Repositories.List(ctx, nil) }},
+		{name: "Builds.Get", call: func() (interface{}, error) { return client.Builds.Get(ctx, "github", "user/fakerepo", "abc") }},
+		{name: "Jobs.Submit", call: func() (interface{}, error) { return client.Jobs.Submit(ctx, &Job{}) }},
+		{name: "Jobs.Get", call: func() (interface{}, error) { return client.Jobs.Get(ctx, 42) }},
+		{name: "SourceFiles.Get", call: func() (interface{}, error) {
+			return client.SourceFiles.Get(ctx, "github", "user/fakerepo", "abc", "main.go")
+		}},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.call()
+
+			var decodeErr ErrDecodeFailure
+			assert.True(t, errors.As(err, &decodeErr), "unexpected error %v", err)
+			assert.Equal(t, "", decodeErr.Body)
+			assert.True(t, errors.Is(err, errEmptyBody))
+		})
+	}
+}
@@ -0,0 +1,23 @@
+module github.com/stone-payments/go-coveralls-api/restyadapter
+
+go 1.21
+
+require (
+	github.com/go-resty/resty/v2 v2.1.0
+	github.com/stone-payments/go-coveralls-api v0.0.0-00010101000000-000000000000
+	github.com/stretchr/testify v1.4.0
+)
+
+require (
+	github.com/davecgh/go-spew v1.1.0 // indirect
+	github.com/jstemmer/go-junit-report v1.0.0 // indirect
+	github.com/mattn/goveralls v0.0.11 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+	golang.org/x/mod v0.4.2 // indirect
+	golang.org/x/net v0.0.0-20210405180319-a5a99cb37ef4 // indirect
+	golang.org/x/tools v0.1.1 // indirect
+	golang.org/x/xerrors v0.0.0-20200804184101-5ec99f83aff1 // indirect
+	gopkg.in/yaml.v2 v2.2.2 // indirect
+)
+
+replace github.com/stone-payments/go-coveralls-api => ../
@@ -0,0 +1,65 @@
+/*
+Copyright (c) 2020 Loadsmart, Inc.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+// Package restyadapter lets a coveralls.Client send requests through a resty client
+package restyadapter
+
+import (
+	"net/http"
+
+	"github.com/go-resty/resty/v2"
+	coveralls "github.com/stone-payments/go-coveralls-api"
+)
+
+// doer sends requests through a resty client
+type doer struct {
+	client *resty.Client
+}
+
+// New returns a coveralls.Doer that sends requests through c, so its
+// middlewares, retries and settings apply to Coveralls API calls.
+// Use it with coveralls.WithDoer.
+//
+// Headers set in c are sent unless the Coveralls client sets the same header.
+func New(c *resty.Client) coveralls.Doer {
+	return &doer{client: c}
+}
+
+// Do sends req through the resty client. The response body is left unread
+func (d *doer) Do(req *http.Request) (*http.Response, error) {
+	r := d.client.R().
+		SetContext(req.Context()).
+		SetDoNotParseResponse(true)
+
+	for k, v := range req.Header {
+		r.Header[k] = v
+	}
+	if req.Body != nil {
+		r.SetBody(req.Body)
+	}
+
+	resp, err := r.Execute(req.Method, req.URL.String())
+	if err != nil {
+		return nil, err
+	}
+	return resp.RawResponse, nil
+}
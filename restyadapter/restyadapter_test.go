@@ -0,0 +1,75 @@
+/*
+Copyright (c) 2020 Loadsmart, Inc.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package restyadapter
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/go-resty/resty/v2"
+	coveralls "github.com/stone-payments/go-coveralls-api"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNew(t *testing.T) {
+	var body string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		assert.Equal(t, http.MethodPut, req.Method)
+		assert.Equal(t, "/api/repos/github/user/fakerepo", req.URL.Path)
+		assert.Equal(t, "token fake token", req.Header.Get("Authorization"))
+		assert.Equal(t, "from-resty", req.Header.Get("X-Extra"))
+		b, _ := ioutil.ReadAll(req.Body)
+		body = string(b)
+
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"name": "user/fakerepo", "service": "github"}`))
+	}))
+	defer server.Close()
+
+	rc := resty.New().SetHeader("X-Extra", "from-resty")
+	client := coveralls.NewClient("fake token", coveralls.WithDoer(New(rc)))
+	client.HostURL, _ = url.Parse(server.URL)
+
+	repo, err := client.Repositories.Update(context.Background(), "github", "user/fakerepo", &coveralls.RepositoryConfig{Service: "github", Name: "user/fakerepo"})
+
+	assert.Nil(t, err)
+	assert.Equal(t, "user/fakerepo", repo.Name)
+	assert.JSONEq(t, `{"repo": {"service": "github", "name": "user/fakerepo"}}`, body)
+}
+
+func TestNewNotFound(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	defer server.Close()
+
+	client := coveralls.NewClient("fake token", coveralls.WithDoer(New(resty.New())))
+	client.HostURL, _ = url.Parse(server.URL)
+
+	_, err := client.Repositories.Get(context.Background(), "github", "user/fakerepo")
+
+	assert.True(t, errors.Is(err, coveralls.ErrRepoNotFound))
+}
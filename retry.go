@@ -0,0 +1,143 @@
+/*
+Copyright (c) 2020 Loadsmart, Inc.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package coveralls
+
+import (
+	"context"
+	"math"
+	"math/rand"
+	"net/http"
+	"time"
+)
+
+// retryPolicy tells how requests that fail with transient errors are retried
+type retryPolicy struct {
+	max       int           // Maximum number of retries. Zero disables retries
+	baseDelay time.Duration // Delay before the first retry, doubled for each of the next ones
+	maxDelay  time.Duration // Maximum delay between retries
+}
+
+// WithRetry makes the Client retry requests that fail with transient errors:
+// network errors, timeouts and responses with status codes 429 Too Many
+// Requests, 500, 502, 503 and 504. Requests are retried up to max times,
+// waiting baseDelay before the first retry and doubling it for the next
+// ones, up to maxDelay (zero means no limit). A random jitter is applied to each delay so many
+// clients don't retry in lockstep. When a response has a Retry-After header,
+// as with ErrRateLimited, the Client waits at least as long as it asks.
+//
+// Retries stop as soon as the context of the call is done, or when its
+// deadline is closer than the next delay. The call then fails with an error
+// that matches both the context error, e.g. context.DeadlineExceeded, and
+// the error of the last attempt.
+//
+// Only idempotent requests (GET, PUT and DELETE) are retried by default, as
+// retrying others may e.g. submit the same job twice; use WithRetries to
+// retry a specific call anyway. Requests are not retried by default.
+func WithRetry(max int, baseDelay time.Duration, maxDelay time.Duration) Option {
+	return func(o *options) {
+		o.retry = retryPolicy{max: max, baseDelay: baseDelay, maxDelay: maxDelay}
+	}
+}
+
+// WithRetries sets how many times a single call may be retried after
+// transient errors, overriding the maximum set with WithRetry. Zero disables
+// retries. Unlike WithRetry, it applies to non-idempotent calls too.
+//
+// Calls that stream their body, such as SubmitMultipart, are never retried,
+// except for SubmitFile, which reads its file again for each retry.
+func WithRetries(n int) CallOption {
+	return func(s *callSettings) {
+		s.retries = &n
+	}
+}
+
+// delay returns how long to wait before the given retry, counted from zero
+func (p retryPolicy) delay(retry int) time.Duration {
+	limit := p.maxDelay
+	if limit <= 0 {
+		limit = math.MaxInt64 / 2
+	}
+	d := p.baseDelay
+	for i := 0; i < retry && d < limit; i++ {
+		d *= 2
+	}
+	if d > limit {
+		d = limit
+	}
+	if d <= 0 {
+		return 0
+	}
+	// Equal jitter: wait at least half of the delay
+	return d/2 + time.Duration(rand.Int63n(int64(d/2)+1))
+}
+
+// wait sleeps before the given retry, or for at least the delay the API
+// asked for in hint, returning early with an error if ctx is done. It
+// returns context.DeadlineExceeded right away if the deadline of ctx is
+// closer than the delay, as the retry would time out anyway.
+func (p retryPolicy) wait(ctx context.Context, retry int, hint time.Duration) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	d := p.delay(retry)
+	if hint > d {
+		d = hint
+	}
+	if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < d {
+		return context.DeadlineExceeded
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// idempotent reports whether requests with method can be safely retried
+func idempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPut, http.MethodDelete, http.MethodOptions:
+		return true
+	default:
+		return false
+	}
+}
+
+// retryableStatus reports whether a response with status code is a transient error
+func retryableStatus(code int) bool {
+	switch code {
+	case http.StatusTooManyRequests,
+		http.StatusInternalServerError,
+		http.StatusBadGateway,
+		http.StatusServiceUnavailable,
+		http.StatusGatewayTimeout:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,186 @@
+/*
+Copyright (c) 2020 Loadsmart, Inc.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package coveralls
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// newFlakyServer returns a server that answers with the given status codes
+// in order, and then with 204 No Content. It counts the requests received.
+func newFlakyServer(t *testing.T, codes ...int) (*httptest.Server, *int) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		requests++
+		if requests <= len(codes) {
+			w.WriteHeader(codes[requests-1])
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	t.Cleanup(server.Close)
+	return server, &requests
+}
+
+func TestWithRetry(t *testing.T) {
+	var testCases = []struct {
+		name         string
+		codes        []int
+		opts         []CallOption
+		wantErr      bool
+		wantRequests int
+	}{
+		{name: "no errors", codes: nil, wantRequests: 1},
+		{name: "transient errors", codes: []int{502, 503, 429}, wantRequests: 4},
+		{name: "too many errors", codes: []int{500, 502, 503, 504}, wantErr: true, wantRequests: 4},
+		{name: "permanent error", codes: []int{400}, wantErr: true, wantRequests: 1},
+		{name: "call retries", codes: []int{502, 502}, opts: []CallOption{WithRetries(1)}, wantErr: true, wantRequests: 2},
+		{name: "call retries disabled", codes: []int{502}, opts: []CallOption{WithRetries(0)}, wantErr: true, wantRequests: 1},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			server, requests := newFlakyServer(t, tt.codes...)
+			client, _ := NewEnterpriseClient(server.URL, "fake token", WithRetry(3, time.Millisecond, 5*time.Millisecond))
+
+			err := client.Repositories.Delete(context.Background(), "github", "user/fakerepo", tt.opts...)
+
+			assert.Equal(t, tt.wantErr, err != nil, "unexpected error: %v", err)
+			assert.Equal(t, tt.wantRequests, *requests)
+		})
+	}
+}
+
+func TestWithRetryNonIdempotent(t *testing.T) {
+	server, requests := newFlakyServer(t, 502, 502)
+	client, _ := NewEnterpriseClient(server.URL, "fake token", WithRetry(3, time.Millisecond, time.Millisecond))
+
+	_, err := client.Jobs.Submit(context.Background(), &Job{})
+	assert.NotNil(t, err)
+	assert.Equal(t, 1, *requests)
+
+	_, err = client.Jobs.Submit(context.Background(), &Job{}, WithRetries(3))
+	assert.NotNil(t, err) // 204 No Content is not a successful submission
+	assert.Equal(t, 3, *requests)
+}
+
+func TestWithRetryTransportError(t *testing.T) {
+	attempts := 0
+	transport := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		attempts++
+		if attempts < 3 {
+			return nil, assert.AnError
+		}
+		return &http.Response{StatusCode: http.StatusNoContent, Body: http.NoBody, Header: http.Header{}}, nil
+	})
+	client := NewClient("fake token", WithTransport(transport), WithRetry(5, time.Millisecond, time.Millisecond))
+
+	err := client.Repositories.Delete(context.Background(), "github", "user/fakerepo")
+
+	assert.Nil(t, err)
+	assert.Equal(t, 3, attempts)
+}
+
+func TestWithRetryContextCanceled(t *testing.T) {
+	server, requests := newFlakyServer(t, 502, 502, 502)
+	client, _ := NewEnterpriseClient(server.URL, "fake token", WithRetry(3, time.Hour, time.Hour))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	err := client.Repositories.Delete(ctx, "github", "user/fakerepo")
+
+	assert.NotNil(t, err)
+	assert.Equal(t, 1, *requests)
+}
+
+func TestRetryDelay(t *testing.T) {
+	policy := retryPolicy{max: 10, baseDelay: 100 * time.Millisecond, maxDelay: time.Second}
+
+	for retry, want := range []time.Duration{100, 200, 400, 800, 1000, 1000} {
+		want *= time.Millisecond
+		for i := 0; i < 10; i++ {
+			d := policy.delay(retry)
+			assert.True(t, d >= want/2 && d <= want, "delay %s of retry %d out of range", d, retry)
+		}
+	}
+}
+
+func TestRetryDeadline(t *testing.T) {
+	server, requests := newFlakyServer(t, 503, 503, 503)
+	client, _ := NewEnterpriseClient(server.URL, "fake token", WithRetry(3, time.Second, time.Second))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	err := client.Repositories.Delete(ctx, "github", "user/fakerepo")
+
+	assert.True(t, time.Since(start) < 100*time.Millisecond, "waited for a retry past the deadline")
+	assert.Equal(t, 1, *requests)
+	assert.True(t, errors.Is(err, context.DeadlineExceeded))
+	var unavailable ErrServiceUnavailable
+	assert.True(t, errors.As(err, &unavailable))
+	code, ok := StatusCode(err)
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.True(t, ok)
+}
+
+func TestRetryCanceled(t *testing.T) {
+	server, requests := newFlakyServer(t, 500, 500, 500)
+	ctx, cancel := context.WithCancel(context.Background())
+	client, _ := NewEnterpriseClient(server.URL, "fake token",
+		WithRetry(3, time.Hour, time.Hour),
+		WithResponseHook(func(resp *http.Response, elapsed time.Duration) { cancel() }),
+	)
+
+	err := client.Repositories.Delete(ctx, "github", "user/fakerepo")
+
+	assert.Equal(t, 1, *requests)
+	assert.True(t, errors.Is(err, context.Canceled))
+	var unexpected ErrUnexpectedStatusCode
+	assert.True(t, errors.As(err, &unexpected))
+	assert.Equal(t, http.StatusInternalServerError, unexpected.StatusCode)
+}
+
+func TestRetryWait(t *testing.T) {
+	policy := retryPolicy{max: 1, baseDelay: time.Millisecond, maxDelay: time.Millisecond}
+
+	assert.Nil(t, policy.wait(context.Background(), 0, 0))
+
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.Equal(t, context.Canceled, policy.wait(canceled, 0, 0))
+
+	short, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	start := time.Now()
+	assert.Equal(t, context.DeadlineExceeded, policy.wait(short, 0, time.Hour))
+	assert.True(t, time.Since(start) < time.Second)
+}
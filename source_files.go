@@ -0,0 +1,90 @@
+/*
+Copyright (c) 2020 Loadsmart, Inc.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package coveralls
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+)
+
+var (
+	// ErrSourceFileNotFound is returned when we receive a 404 Not Found status code while
+	// querying a source file. Either the build or the file in the build does not exist.
+	ErrSourceFileNotFound = fmt.Errorf("source file was not found (status code %d)", http.StatusNotFound)
+)
+
+// SourceFilesService holds information to access source file coverage endpoints
+type SourceFilesService interface {
+	Get(ctx context.Context, svc string, repo string, sha string, path string, opts ...CallOption) (*SourceFile, error)
+}
+
+// SourceFilesServiceImpl holds information to access source file coverage endpoints
+type SourceFilesServiceImpl service
+
+// Get per-line coverage of a file in the build of a given commit.
+//
+// Svc, repo and sha have the same meaning as in BuildsService.Get. Path
+// is the file path relative to the repository root, as sent in the job.
+//
+// It may return errors ErrSourceFileNotFound or ErrUnexpectedStatusCode
+func (s SourceFilesServiceImpl) Get(ctx context.Context, svc string, repo string, sha string, path string, opts ...CallOption) (*SourceFile, error) {
+	endpoint := fmt.Sprintf("/builds/%s/source.json", sha)
+
+	resp, err := s.client.newRequest(ctx, opts).
+		SetQueryParams(map[string]string{
+			"service":   svc,
+			"repo_name": repo,
+			"filename":  path,
+		}).
+		SetResult(&SourceFile{}).
+		Get(endpoint)
+
+	if err != nil {
+		return nil, err
+	}
+
+	switch resp.StatusCode() {
+	case http.StatusOK:
+		file, ok := resp.Result().(*SourceFile)
+		if !ok {
+			return nil, resp.decodeFailure(errNoResult)
+		}
+		return file, nil
+	case http.StatusNotFound:
+		return nil, resp.apiError(ErrSourceFileNotFound)
+	default:
+		return nil, newErrFromResponse(resp)
+	}
+}
+
+// UncoveredLines returns the numbers (starting at 1) of relevant lines without hits
+func (f *SourceFile) UncoveredLines() []int {
+	var lines []int
+	for i, hits := range f.Coverage {
+		if hits != nil && *hits == 0 {
+			lines = append(lines, i+1)
+		}
+	}
+	return lines
+}
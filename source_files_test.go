@@ -0,0 +1,92 @@
+/*
+Copyright (c) 2020 Loadsmart, Inc.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package coveralls
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSourceFilesServiceGet(t *testing.T) {
+	var testCases = []struct {
+		name string
+		code int
+		file *SourceFile
+		err  error
+	}{
+		{
+			name: "existing",
+			code: http.StatusOK,
+			file: &SourceFile{Name: "pkg/main.go", Coverage: []*int{nil, pint(1), pint(0)}},
+			err:  nil,
+		},
+		{
+			name: "notfound",
+			code: http.StatusNotFound,
+			file: nil,
+			err:  ErrSourceFileNotFound,
+		},
+		{
+			name: "unexpected",
+			code: http.StatusUseProxy,
+			file: nil,
+			err: ErrUnexpectedStatusCode{
+				StatusCode: http.StatusUseProxy,
+				ErrorBody:  "null",
+			},
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			fakeUrl := "https://coveralls.io/builds/abc123/source.json"
+			httpmock.RegisterResponder("GET", fakeUrl, func(req *http.Request) (*http.Response, error) {
+				assert.Equal(t, "pkg/main.go", req.URL.Query().Get("filename"))
+				assert.Equal(t, "user/fakerepo", req.URL.Query().Get("repo_name"))
+				return httpmock.NewJsonResponse(tt.code, tt.file)
+			})
+
+			client := NewClient("fake token")
+			httpmock.ActivateNonDefault(client.client)
+			defer httpmock.DeactivateAndReset()
+
+			result, err := client.SourceFiles.Get(context.Background(), "github", "user/fakerepo", "abc123", "pkg/main.go")
+
+			if !errors.Is(err, tt.err) {
+				t.Errorf("Errors do not match.\n\texpected: '%v'\n\tgot: '%v'", tt.err, err)
+			}
+			assert.Equal(t, tt.file, result)
+		})
+	}
+}
+
+func TestSourceFileUncoveredLines(t *testing.T) {
+	file := &SourceFile{Coverage: []*int{nil, pint(1), pint(0), nil, pint(0)}}
+
+	assert.Equal(t, []int{3, 5}, file.UncoveredLines())
+}